@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/app"                      //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/bootstrap"                //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/client/memory"            //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/config"                   //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/logger"                   //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/scheduler"                //nolint:depguard
+	internalgrpc "github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/server/grpc" //nolint:depguard
+	internalhttp "github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/server/http" //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storer"                   //nolint:depguard
+)
+
+// топик уведомлений, если broker.topic не задан.
+const defaultTopic = "events"
+
+// runAll запускает API, планировщик и хранителя с общими приложением, хранилищем и брокером в памяти процесса.
+// broker.type не используется: уведомления не покидают процесс.
+func runAll(ctx context.Context, config config.Config, logg *logger.Logger, storage app.Storage) error {
+	topic := config.Broker.Topic
+	if topic == "" {
+		topic = defaultTopic
+	}
+	broker := memory.New(bootstrap.RetryPolicy(config.Broker.Retry), logg)
+	calendar := app.New(logg, storage, broker)
+
+	storer := storer.New(calendar, bootstrap.NewDispatcher(storage, config.Delivery))
+	broker.Subscribe(topic, storer.Handler())
+	scheduler := scheduler.New(calendar, topic, bootstrap.SchedulerIntervals(config.Timer))
+	server := internalhttp.NewServer(calendar, config.Server.HTTP.Host, config.Server.HTTP.Port,
+		bootstrap.NewHealthChecker(storage, broker))
+	grpcServer := internalgrpc.NewServer(calendar, config.Server.GRPC.Host, config.Server.GRPC.Port)
+
+	logg.Info("calendar is running in all-in-one mode...")
+	// серверы останавливаются первыми и перестают принимать запросы, брокер завершает обработку
+	// полученных сообщений, хранилище закрывается после остановки всех компонентов
+	err := runComponents(ctx, logg,
+		component{name: "http server", run: server.Start, stop: server.Stop},
+		component{name: "grpc server", run: grpcServer.Start, stop: grpcServer.Stop},
+		component{name: "scheduler", run: func(ctx context.Context) error {
+			scheduler.Run(ctx)
+			return nil
+		}},
+		component{name: "broker", run: broker.Connect},
+		component{name: "config reloader", run: bootstrap.NewReloader(loadConfig, config, logg, scheduler, os.Stdout).Run},
+	)
+	if err := broker.Disconnect(); err != nil {
+		logg.Error("failed to disconnect broker: " + err.Error())
+	}
+	return err
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/app" //nolint:depguard
+)
+
+// время на остановку всех компонентов.
+const shutdownTimeout = time.Second * 3
+
+// component часть процесса, работает до отмены контекста.
+type component struct {
+	name string
+	run  func(ctx context.Context) error
+	// останавливает run, nil - run завершается по отмене контекста
+	stop func(ctx context.Context) error
+}
+
+// runComponents запускает компоненты и ждет их завершения. Ошибка одного компонента останавливает все.
+// Компоненты останавливаются в порядке перечисления, возвращается первая ошибка.
+func runComponents(ctx context.Context, logg app.Logger, components ...component) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make(chan error, len(components))
+	wg := sync.WaitGroup{}
+	for _, c := range components {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.run(ctx); err != nil {
+				errs <- fmt.Errorf("%v: %w", c.name, err)
+				cancel()
+			}
+		}()
+	}
+	<-ctx.Done()
+
+	stopCtx, stopCancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer stopCancel()
+	for _, c := range components {
+		if c.stop == nil {
+			continue
+		}
+		if err := c.stop(stopCtx); err != nil {
+			logg.Error(fmt.Sprintf("failed to stop %v: %v", c.name, err))
+		}
+	}
+	wg.Wait()
+	close(errs)
+	return <-errs
+}
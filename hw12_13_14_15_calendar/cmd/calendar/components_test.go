@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/logger" //nolint:depguard
+	"github.com/stretchr/testify/require"                             //nolint:depguard
+)
+
+var errListen = errors.New("address already in use")
+
+func TestRunComponents(t *testing.T) {
+	logg := logger.New("INFO", "/tmp/calendar-components-test.log")
+
+	// server работает до вызова stop
+	type server struct {
+		stopped chan struct{}
+	}
+	newServer := func() *server { return &server{stopped: make(chan struct{})} }
+	mu := sync.Mutex{}
+	stops := make([]string, 0)
+	serverComponent := func(name string, s *server) component {
+		return component{
+			name: name,
+			run: func(context.Context) error {
+				<-s.stopped
+				return nil
+			},
+			stop: func(context.Context) error {
+				mu.Lock()
+				defer mu.Unlock()
+				stops = append(stops, name)
+				close(s.stopped)
+				return nil
+			},
+		}
+	}
+
+	t.Run("cancel", func(t *testing.T) {
+		stops = stops[:0]
+		ctx, cancel := context.WithCancel(context.Background())
+		workerDone := false
+		go func() {
+			time.Sleep(time.Millisecond * 10)
+			cancel()
+		}()
+		err := runComponents(ctx, logg,
+			serverComponent("http", newServer()),
+			serverComponent("grpc", newServer()),
+			component{name: "worker", run: func(ctx context.Context) error {
+				<-ctx.Done()
+				workerDone = true
+				return nil
+			}},
+		)
+		require.NoError(t, err)
+		require.Equal(t, []string{"http", "grpc"}, stops)
+		require.True(t, workerDone)
+	})
+
+	t.Run("failed component stops others", func(t *testing.T) {
+		stops = stops[:0]
+		err := runComponents(context.Background(), logg,
+			serverComponent("http", newServer()),
+			component{name: "grpc", run: func(context.Context) error { return errListen }},
+		)
+		require.ErrorIs(t, err, errListen)
+		require.ErrorContains(t, err, "grpc")
+		require.Equal(t, []string{"http"}, stops)
+	})
+}
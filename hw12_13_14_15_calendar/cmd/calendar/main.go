@@ -3,23 +3,35 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
-	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/app"                          //nolint:depguard
-	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/config"                       //nolint:depguard
-	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/logger"                       //nolint:depguard
-	internalhttp "github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/server/http"     //nolint:depguard
-	memorystorage "github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage/memory" //nolint:depguard
-	sqlstorage "github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage/sql"       //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/app"                      //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/bootstrap"                //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/config"                   //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/logger"                   //nolint:depguard
+	internalgrpc "github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/server/grpc" //nolint:depguard
+	internalhttp "github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/server/http" //nolint:depguard
 )
 
-var configFile string
+var (
+	configFile string
+	// параметры конфигурации из флагов вида -db.dsn.
+	overrides config.Overrides
+)
 
 func init() {
-	flag.StringVar(&configFile, "config", "configs/calendar_config.yml", "Path to configuration file")
+	flag.StringVar(&configFile, "config", "configs/calendar_config.yml",
+		"Path to configuration file, empty - only defaults, CALENDAR_* environment and flags")
+	overrides = config.RegisterFlags(flag.CommandLine)
+}
+
+// loadConfig читает конфигурацию из файла -config, переменных окружения и флагов.
+func loadConfig() (config.Config, error) {
+	return config.Load(configFile, os.Environ(), overrides)
 }
 
 func main() {
@@ -30,55 +42,66 @@ func main() {
 		return
 	}
 
-	config := config.NewConfig(configFile)
+	config, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 	logg := logger.New(config.Logger.Level, config.Logger.File)
 	defer logg.Close()
-
-	var storage app.Storage
-	if config.Storage == "sql" {
-		logg.Info("create sql storage, connecting to server...")
-		dbStorage := sqlstorage.New(config.DB.Driver, config.DB.Dsn)
-		err := dbStorage.Connect(context.Background())
-		if err != nil {
-			logg.Error("failed to connect to db: " + err.Error())
-			os.Exit(1) //nolint:gocritic
-		}
-		storage = dbStorage
-	} else {
-		logg.Info("create memory storage")
-		storage = memorystorage.New()
+	shutdownTracing, err := bootstrap.SetupTracing(context.Background(), config.Tracing, "calendar", logg)
+	if err != nil {
+		logg.Error(err.Error())
+		os.Exit(1) //nolint:gocritic
 	}
-	calendar := app.New(logg, storage, nil)
+	defer shutdownTracing()
 
-	server := internalhttp.NewServer(calendar, config.Server.HTTP.Host, config.Server.HTTP.Port)
+	if flag.Arg(0) == "migrate" {
+		// миграциями управляет сама команда
+		config.DB.AutoMigrate = false
+	}
+	storage, err := bootstrap.NewStorage(context.Background(), config, logg)
+	if err != nil {
+		logg.Error(err.Error())
+		os.Exit(1) //nolint:gocritic
+	}
 
-	ctx, cancel := signal.NotifyContext(context.Background(),
-		syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	// SIGHUP перечитывает конфигурацию, см. bootstrap.Reloader
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
-	go func() {
-		<-ctx.Done()
+	// calendar all - API, планировщик и хранитель в одном процессе
+	run := runServer
+	switch flag.Arg(0) {
+	case "all":
+		run = runAll
+	case "migrate":
+		run = migrateCommand(flag.Arg(1))
+	}
+	err = run(ctx, config, logg, storage)
 
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
-		defer cancel()
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	if err := storage.Close(ctx); err != nil {
+		logg.Error("failed to close database: " + err.Error())
+	}
+	if err != nil {
+		logg.Error(err.Error())
+		os.Exit(1)
+	}
+}
 
-		if err := server.Stop(ctx); err != nil {
-			logg.Error("failed to stop http server: " + err.Error())
-		}
+func runServer(ctx context.Context, config config.Config, logg *logger.Logger, storage app.Storage) error {
+	calendar := app.New(logg, storage, nil)
 
-		dbStorage, ok := storage.(*sqlstorage.Storage)
-		if ok {
-			if err := dbStorage.Close(ctx); err != nil {
-				logg.Error("failed to close database: " + err.Error())
-			}
-		}
-	}()
+	server := internalhttp.NewServer(calendar, config.Server.HTTP.Host, config.Server.HTTP.Port,
+		bootstrap.NewHealthChecker(storage, nil))
+	grpcServer := internalgrpc.NewServer(calendar, config.Server.GRPC.Host, config.Server.GRPC.Port)
 
 	logg.Info("calendar is running...")
-
-	if err := server.Start(ctx); err != nil {
-		logg.Error("failed to start http server: " + err.Error())
-		cancel()
-		os.Exit(1)
-	}
+	return runComponents(ctx, logg,
+		component{name: "http server", run: server.Start, stop: server.Stop},
+		component{name: "grpc server", run: grpcServer.Start, stop: grpcServer.Stop},
+		component{name: "config reloader", run: bootstrap.NewReloader(loadConfig, config, logg, nil, os.Stdout).Run},
+	)
 }
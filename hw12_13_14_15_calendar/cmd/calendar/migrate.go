@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/app"     //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/config"  //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/logger"  //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage" //nolint:depguard
+)
+
+var (
+	ErrUnknownMigrateCommand = errors.New("unknown migrate command, expected up, down or status")
+	ErrNoMigrations          = errors.New("storage has no migrations")
+)
+
+// migrateCommand запускает runMigrate вместо сервера.
+func migrateCommand(command string) func(context.Context, config.Config, *logger.Logger, app.Storage) error {
+	return func(ctx context.Context, _ config.Config, _ *logger.Logger, st app.Storage) error {
+		return runMigrate(ctx, st, command, os.Stdout)
+	}
+}
+
+// runMigrate выполняет calendar migrate up|down|status и выводит затронутые миграции в w.
+func runMigrate(ctx context.Context, st app.Storage, command string, w io.Writer) error {
+	if wrapped, ok := st.(interface{ Unwrap() storage.Storage }); ok {
+		st = wrapped.Unwrap()
+	}
+	migrator, ok := st.(storage.Migrator)
+	if !ok {
+		return ErrNoMigrations
+	}
+	switch command {
+	case "up":
+		migrations, err := migrator.MigrateUp(ctx)
+		if err != nil {
+			return err
+		}
+		if len(migrations) == 0 {
+			fmt.Fprintln(w, "no new migrations")
+		}
+		for _, m := range migrations {
+			fmt.Fprintf(w, "applied %v\n", m.Source)
+		}
+	case "down":
+		m, err := migrator.MigrateDown(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "rolled back %v\n", m.Source)
+	case "status":
+		migrations, err := migrator.MigrationStatus(ctx)
+		if err != nil {
+			return err
+		}
+		for _, m := range migrations {
+			state := "pending"
+			if m.Applied {
+				state = "applied " + m.AppliedAt.Format(time.DateTime)
+			}
+			fmt.Fprintf(w, "%-30v %v\n", m.Source, state)
+		}
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownMigrateCommand, command)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage"                      //nolint:depguard
+	memorystorage "github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage/memory" //nolint:depguard
+	sqlitestorage "github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage/sqlite" //nolint:depguard
+	"github.com/stretchr/testify/require"                                                   //nolint:depguard
+)
+
+func TestRunMigrate(t *testing.T) {
+	ctx := context.Background()
+	st := sqlitestorage.New(filepath.Join(t.TempDir(), "calendar.db"), false)
+	require.NoError(t, st.Connect(ctx))
+	defer st.Close(ctx)
+
+	migrate := func(command string) (string, error) {
+		var out bytes.Buffer
+		err := runMigrate(ctx, st, command, &out)
+		return out.String(), err
+	}
+
+	// Connect уже применил все миграции
+	out, err := migrate("up")
+	require.NoError(t, err)
+	require.Equal(t, "no new migrations\n", out)
+
+	out, err = migrate("status")
+	require.NoError(t, err)
+	require.Contains(t, out, "00001_init.sql")
+	require.Contains(t, out, "applied")
+
+	migrations := []string{
+		"00001_init.sql", "00002_tracecontext.sql", "00003_attendee.sql", "00004_timezone.sql", "00005_reminder.sql",
+		"00006_audit.sql",
+	}
+	for i := len(migrations) - 1; i >= 0; i-- {
+		out, err = migrate("down")
+		require.NoError(t, err)
+		require.Equal(t, "rolled back "+migrations[i]+"\n", out)
+	}
+
+	out, err = migrate("status")
+	require.NoError(t, err)
+	require.True(t, strings.HasSuffix(out, "pending\n"), out)
+
+	_, err = migrate("down")
+	require.ErrorIs(t, err, storage.ErrMigration)
+
+	out, err = migrate("up")
+	require.NoError(t, err)
+	require.Equal(t, "applied "+strings.Join(migrations, "\napplied ")+"\n", out)
+
+	_, err = migrate("redo")
+	require.ErrorIs(t, err, ErrUnknownMigrateCommand)
+
+	err = runMigrate(ctx, memorystorage.New(false), "up", &bytes.Buffer{})
+	require.ErrorIs(t, err, ErrNoMigrations)
+}
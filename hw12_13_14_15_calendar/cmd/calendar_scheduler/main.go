@@ -3,25 +3,35 @@ package main
 import (
 	"context"
 	"flag"
-	"net"
+	"fmt"
 	"os"
 	"os/signal"
-	"strconv"
 	"syscall"
 	"time"
 
-	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/app"                          //nolint:depguard
-	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/client/kafka"                 //nolint:depguard
-	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/config"                       //nolint:depguard
-	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/logger"                       //nolint:depguard
-	memorystorage "github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage/memory" //nolint:depguard
-	sqlstorage "github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage/sql"       //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/app"       //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/bootstrap" //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/client"    //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/config"    //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/logger"    //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/scheduler" //nolint:depguard
 )
 
-var configFile string
+var (
+	configFile string
+	// параметры конфигурации из флагов вида -db.dsn.
+	overrides config.Overrides
+)
 
 func init() {
-	flag.StringVar(&configFile, "config", "configs/scheduler_config.yml", "Path to configuration file")
+	flag.StringVar(&configFile, "config", "configs/scheduler_config.yml",
+		"Path to configuration file, empty - only defaults, CALENDAR_* environment and flags")
+	overrides = config.RegisterFlags(flag.CommandLine)
+}
+
+// loadConfig читает конфигурацию из файла -config, переменных окружения и флагов.
+func loadConfig() (config.Config, error) {
+	return config.Load(configFile, os.Environ(), overrides)
 }
 
 func main() {
@@ -32,52 +42,58 @@ func main() {
 		return
 	}
 
-	config := config.NewConfig(configFile)
+	config, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 	logg := logger.New(config.Logger.Level, config.Logger.File)
 	defer logg.Close()
+	shutdownTracing, err := bootstrap.SetupTracing(context.Background(), config.Tracing, "calendar_scheduler", logg)
+	if err != nil {
+		logg.Error(err.Error())
+		os.Exit(1) //nolint:gocritic
+	}
+	defer shutdownTracing()
 
-	var storage app.Storage
-	if config.Storage == "sql" {
-		logg.Info("create sql storage, connecting to server...")
-		dbStorage := sqlstorage.New(config.DB.Driver, config.DB.Dsn)
-		err := dbStorage.Connect(context.Background())
-		if err != nil {
-			logg.Error("failed to connect to db: " + err.Error())
-			os.Exit(1) //nolint:gocritic
-		}
-		storage = dbStorage
-	} else {
-		logg.Info("create memory storage")
-		storage = memorystorage.New()
+	storage, err := bootstrap.NewStorage(context.Background(), config, logg)
+	if err != nil {
+		logg.Error(err.Error())
+		os.Exit(1) //nolint:gocritic
 	}
 
-	kafka := kafka.New([]string{net.JoinHostPort(config.Kafka.Host, strconv.Itoa(config.Kafka.Port))}, logg)
-	calendar := app.New(logg, storage, kafka)
+	// планировщик только публикует уведомления, повторы обработки ему не нужны
+	broker, err := bootstrap.NewBroker(config, client.RetryPolicy{}, logg)
+	if err != nil {
+		logg.Error("failed to create broker: " + err.Error())
+		os.Exit(1)
+	}
+	calendar := app.New(logg, storage, broker)
 
-	ctx, cancel := signal.NotifyContext(context.Background(),
-		syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	// SIGHUP перечитывает конфигурацию, см. bootstrap.Reloader
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
-	go worker(ctx, calendar, &config)
+	scheduler := scheduler.New(calendar, config.Broker.Topic, bootstrap.SchedulerIntervals(config.Timer))
+	go scheduler.Run(ctx)
+	bootstrap.StartAdminServer(ctx, config.Admin, bootstrap.NewHealthChecker(storage, broker), logg)
+	go bootstrap.NewReloader(loadConfig, config, logg, scheduler, os.Stdout).Run(ctx) //nolint:errcheck
 	go func() {
 		<-ctx.Done()
 
 		ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
 		defer cancel()
 
-		dbStorage, ok := storage.(*sqlstorage.Storage)
-		if ok {
-			if err := dbStorage.Close(ctx); err != nil {
-				logg.Error("failed to close database: " + err.Error())
-			}
+		if err := storage.Close(ctx); err != nil {
+			logg.Error("failed to close database: " + err.Error())
 		}
 	}()
 
 	logg.Info("calendar scheduler is starting...")
-	logg.Info(config.Kafka.Host + ":" + strconv.Itoa(config.Kafka.Port) + "/" + config.Kafka.Topic)
+	logg.Info(config.Broker.Type + "/" + config.Broker.Topic)
 
-	if err := kafka.Connect(ctx); err != nil {
-		logg.Error("failed to connect to kafka: " + err.Error())
+	if err := broker.Connect(ctx); err != nil {
+		logg.Error("failed to connect to broker: " + err.Error())
 		return
 	}
 }
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/client" //nolint:depguard
+)
+
+var (
+	errDeadLettersCommand     = errors.New("usage: calendar_storer dlq list|replay")
+	errDeadLettersUnsupported = errors.New("broker does not support dead letters")
+)
+
+type deadLetter struct {
+	ID     string `json:"id"`
+	Topic  string `json:"topic"`
+	Reason string `json:"reason"`
+	// уведомление, если сообщение содержит корректный JSON
+	Notification json.RawMessage `json:"notification,omitempty"`
+	Payload      string          `json:"payload,omitempty"`
+}
+
+// runDeadLetters выводит необработанные уведомления JSON строками, replay отправляет их в исходный топик.
+func runDeadLetters(broker client.Broker, topic, command string, idle time.Duration, w io.Writer) error {
+	dlq, ok := broker.(client.DeadLetterQueue)
+	if !ok {
+		return errDeadLettersUnsupported
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	var letters []client.DeadLetter
+	var err error
+	switch command {
+	case "list":
+		letters, err = dlq.ListDeadLetters(ctx, topic, idle)
+	case "replay":
+		letters, err = dlq.ReplayDeadLetters(ctx, topic, idle)
+	default:
+		return errDeadLettersCommand
+	}
+
+	encoder := json.NewEncoder(w)
+	for _, letter := range letters {
+		line := deadLetter{ID: letter.ID, Topic: letter.Topic, Reason: letter.Reason}
+		if json.Valid(letter.Payload) {
+			line.Notification = letter.Payload
+		} else {
+			line.Payload = string(letter.Payload)
+		}
+		if err := encoder.Encode(line); err != nil {
+			return err
+		}
+	}
+	return err
+}
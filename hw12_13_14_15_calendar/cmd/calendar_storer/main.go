@@ -3,25 +3,36 @@ package main
 import (
 	"context"
 	"flag"
-	"net"
+	"fmt"
 	"os"
 	"os/signal"
-	"strconv"
 	"syscall"
 	"time"
 
-	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/app"                          //nolint:depguard
-	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/client/kafka"                 //nolint:depguard
-	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/config"                       //nolint:depguard
-	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/logger"                       //nolint:depguard
-	memorystorage "github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage/memory" //nolint:depguard
-	sqlstorage "github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage/sql"       //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/app"       //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/bootstrap" //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/config"    //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/logger"    //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storer"    //nolint:depguard
 )
 
-var configFile string
+var (
+	configFile string
+	dlqIdle    time.Duration
+	// параметры конфигурации из флагов вида -db.dsn.
+	overrides config.Overrides
+)
 
 func init() {
-	flag.StringVar(&configFile, "config", "configs/storer_config.yml", "Path to configuration file")
+	flag.StringVar(&configFile, "config", "configs/storer_config.yml",
+		"Path to configuration file, empty - only defaults, CALENDAR_* environment and flags")
+	overrides = config.RegisterFlags(flag.CommandLine)
+	flag.DurationVar(&dlqIdle, "dlq-idle", time.Second*10, "Stop reading dead letters after this idle time")
+}
+
+// loadConfig читает конфигурацию из файла -config, переменных окружения и флагов.
+func loadConfig() (config.Config, error) {
+	return config.Load(configFile, os.Environ(), overrides)
 }
 
 func main() {
@@ -32,33 +43,50 @@ func main() {
 		return
 	}
 
-	config := config.NewConfig(configFile)
+	config, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 	logg := logger.New(config.Logger.Level, config.Logger.File)
 	defer logg.Close()
+	shutdownTracing, err := bootstrap.SetupTracing(context.Background(), config.Tracing, "calendar_storer", logg)
+	if err != nil {
+		logg.Error(err.Error())
+		os.Exit(1) //nolint:gocritic
+	}
+	defer shutdownTracing()
 
-	var storage app.Storage
-	if config.Storage == "sql" {
-		logg.Info("create sql storage, connecting to server...")
-		dbStorage := sqlstorage.New(config.DB.Driver, config.DB.Dsn)
-		err := dbStorage.Connect(context.Background())
-		if err != nil {
-			logg.Error("failed to connect to db: " + err.Error())
-			os.Exit(1) //nolint:gocritic
+	broker, err := bootstrap.NewBroker(config, bootstrap.RetryPolicy(config.Broker.Retry), logg)
+	if err != nil {
+		logg.Error("failed to create broker: " + err.Error())
+		os.Exit(1) //nolint:gocritic
+	}
+
+	// calendar_storer dlq list|replay - просмотр и повторная отправка необработанных уведомлений
+	if flag.Arg(0) == "dlq" {
+		if err := runDeadLetters(broker, config.Broker.Topic, flag.Arg(1), dlqIdle, os.Stdout); err != nil {
+			logg.Error("dlq: " + err.Error())
+			os.Exit(1)
 		}
-		storage = dbStorage
-	} else {
-		logg.Info("create memory storage")
-		storage = memorystorage.New()
+		return
 	}
 
-	kafka := kafka.New([]string{net.JoinHostPort(config.Kafka.Host, strconv.Itoa(config.Kafka.Port))}, logg)
-	kafka.Subscribe(config.Kafka.Topic, processNotification)
-	calendar = app.New(logg, storage, kafka)
+	storage, err := bootstrap.NewStorage(context.Background(), config, logg)
+	if err != nil {
+		logg.Error(err.Error())
+		os.Exit(1)
+	}
 
-	ctx, cancel := signal.NotifyContext(context.Background(),
-		syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	// SIGHUP перечитывает конфигурацию, см. bootstrap.Reloader
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
-	workerCtx = ctx
+
+	calendar := app.New(logg, storage, broker)
+	storer := storer.New(calendar, bootstrap.NewDispatcher(storage, config.Delivery))
+	broker.Subscribe(config.Broker.Topic, storer.Handler())
+	bootstrap.StartAdminServer(ctx, config.Admin, bootstrap.NewHealthChecker(storage, broker), logg)
+	go bootstrap.NewReloader(loadConfig, config, logg, nil, os.Stdout).Run(ctx) //nolint:errcheck
 
 	go func() {
 		<-ctx.Done()
@@ -66,19 +94,16 @@ func main() {
 		ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
 		defer cancel()
 
-		dbStorage, ok := storage.(*sqlstorage.Storage)
-		if ok {
-			if err := dbStorage.Close(ctx); err != nil {
-				logg.Error("failed to close database: " + err.Error())
-			}
+		if err := storage.Close(ctx); err != nil {
+			logg.Error("failed to close database: " + err.Error())
 		}
 	}()
 
 	logg.Info("calendar storer is starting...")
-	logg.Info(config.Kafka.Host + ":" + strconv.Itoa(config.Kafka.Port) + "/" + config.Kafka.Topic)
+	logg.Info(config.Broker.Type + "/" + config.Broker.Topic)
 
-	if err := kafka.Connect(ctx); err != nil {
-		logg.Error("failed to connect to kafka: " + err.Error())
+	if err := broker.Connect(ctx); err != nil {
+		logg.Error("failed to connect to broker: " + err.Error())
 		return
 	}
 }
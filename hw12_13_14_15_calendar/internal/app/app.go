@@ -2,10 +2,13 @@ package app
 
 import (
 	"context"
-	"time"
+	"errors"
+	"fmt"
 
+	"github.com/google/uuid"                                           //nolint:depguard
 	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/client"  //nolint:depguard
 	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage" //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/tracing" //nolint:depguard
 )
 
 type App struct {
@@ -21,20 +24,168 @@ type Logger interface {
 	Error(msg string)
 }
 
-type Storage interface {
-	CreateEvent(ctx context.Context, event storage.Event) (string, error)
-	UpdateEvent(ctx context.Context, id string, event storage.Event) error
-	DeleteEvent(ctx context.Context, id string) error
-	GetEvent(ctx context.Context, id string) (*storage.Event, error)
-	ListEventsDay(ctx context.Context, startTime time.Time) ([]*storage.Event, error)
-	ListEventsWeek(ctx context.Context, startTime time.Time) ([]*storage.Event, error)
-	ListEventsMonth(ctx context.Context, startTime time.Time) ([]*storage.Event, error)
-	ListEventsReminder(ctx context.Context) ([]*storage.Event, error)
-	ClearReminderTime(ctx context.Context, id string) error
-	DeleteEventsBeforeDate(ctx context.Context, time time.Time) error
-	SaveNotification(ctx context.Context, notification storage.Notification) error
-}
+type Storage = storage.Storage
 
 func New(logger Logger, storage Storage, broker client.Broker) *App {
 	return &App{Logger: logger, Storage: storage, Broker: broker}
 }
+
+// События доступны владельцу и приглашенным, остальным пользователям они не видны.
+// Менять событие и его участников может только владелец.
+// Изменения событий записываются в журнал от имени вызвавшего пользователя.
+
+func (a *App) CreateUserEvent(ctx context.Context, userID int64, event storage.Event) (string, error) {
+	if event.UserID != userID {
+		return "", fmt.Errorf("%w: event user id %v differs from calling user %v",
+			storage.ErrInvalidArgiments, event.UserID, userID)
+	}
+	event.TraceContext = tracing.TraceParent(ctx)
+	event.NormalizeAllDay()
+	return a.Storage.CreateEvent(storage.WithActor(ctx, userID), event)
+}
+
+func (a *App) GetUserEvent(ctx context.Context, userID int64, id string) (*storage.Event, error) {
+	event, err := a.Storage.GetEvent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !event.Visible(userID) {
+		return nil, storage.ErrEventNotFound
+	}
+	return event, nil
+}
+
+// getOwnEvent возвращает событие, которое пользователь может изменять.
+func (a *App) getOwnEvent(ctx context.Context, userID int64, id string) (*storage.Event, error) {
+	event, err := a.GetUserEvent(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if event.UserID != userID {
+		return nil, storage.ErrNotEventOwner
+	}
+	return event, nil
+}
+
+func (a *App) UpdateUserEvent(ctx context.Context, userID int64, id string, event storage.Event) error {
+	if _, err := a.getOwnEvent(ctx, userID, id); err != nil {
+		return err
+	}
+	if event.UserID != userID {
+		return storage.ErrUpdateUserID
+	}
+	event.TraceContext = tracing.TraceParent(ctx)
+	event.NormalizeAllDay()
+	return a.Storage.UpdateEvent(storage.WithActor(ctx, userID), id, event)
+}
+
+func (a *App) DeleteUserEvent(ctx context.Context, userID int64, id string) error {
+	if _, err := a.getOwnEvent(ctx, userID, id); err != nil {
+		return err
+	}
+	return a.Storage.DeleteEvent(storage.WithActor(ctx, userID), id)
+}
+
+// RestoreUserEvent восстанавливает удаленное событие, восстановить его может только владелец.
+func (a *App) RestoreUserEvent(ctx context.Context, userID int64, id string) error {
+	history, err := a.Storage.ListEventHistory(ctx, id)
+	if err != nil {
+		return err
+	}
+	if len(history) == 0 {
+		return storage.ErrEventNotFound
+	}
+	last := history[len(history)-1]
+	if last.Action != storage.AuditDelete {
+		// событие не удалено, ошибка зависит от его видимости пользователю
+		if _, err := a.getOwnEvent(ctx, userID, id); err != nil {
+			return err
+		}
+		return storage.ErrEventNotDeleted
+	}
+	if !last.Before.Visible(userID) {
+		return storage.ErrEventNotFound
+	}
+	if last.Before.UserID != userID {
+		return storage.ErrNotEventOwner
+	}
+	return a.Storage.RestoreEvent(storage.WithActor(ctx, userID), id)
+}
+
+// EventHistory возвращает журнал изменений события пользователю, которому событие видно,
+// удаленное событие проверяется по его последнему состоянию.
+func (a *App) EventHistory(ctx context.Context, userID int64, id string) ([]*storage.AuditRecord, error) {
+	_, err := a.GetUserEvent(ctx, userID, id)
+	if err != nil && !errors.Is(err, storage.ErrEventNotFound) {
+		return nil, err
+	}
+	history, errHistory := a.Storage.ListEventHistory(ctx, id)
+	if errHistory != nil {
+		return nil, errHistory
+	}
+	if err != nil {
+		if len(history) == 0 {
+			return nil, err
+		}
+		last := history[len(history)-1]
+		if last.Action != storage.AuditDelete || !last.Before.Visible(userID) {
+			return nil, err
+		}
+	}
+	return history, nil
+}
+
+// InviteAttendee приглашает на событие пользователя attendeeID, приглашенный получает уведомление.
+func (a *App) InviteAttendee(ctx context.Context, userID int64, id string, attendeeID int64) error {
+	event, err := a.getOwnEvent(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if attendeeID == userID {
+		return fmt.Errorf("%w: owner can't be invited to own event", storage.ErrInvalidArgiments)
+	}
+	attendee := storage.Attendee{UserID: attendeeID, Status: storage.RSVPNeedsAction}
+	notification := attendeeNotification(ctx, event, storage.NotificationInvitation, attendeeID, attendee)
+	return a.Storage.InviteAttendee(ctx, id, attendeeID, notification)
+}
+
+// RemoveAttendee отменяет приглашение пользователя attendeeID, приглашенный получает уведомление.
+func (a *App) RemoveAttendee(ctx context.Context, userID int64, id string, attendeeID int64) error {
+	event, err := a.getOwnEvent(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	attendee, ok := event.Attendee(attendeeID)
+	if !ok {
+		return storage.ErrAttendeeNotFound
+	}
+	notification := attendeeNotification(ctx, event, storage.NotificationUninvited, attendeeID, attendee)
+	return a.Storage.RemoveAttendee(ctx, id, attendeeID, notification)
+}
+
+// RespondInvitation сохраняет ответ приглашенного пользователя, владелец события получает уведомление.
+func (a *App) RespondInvitation(ctx context.Context, userID int64, id string, status storage.RSVP) error {
+	if err := status.Validate(); err != nil {
+		return err
+	}
+	event, err := a.GetUserEvent(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if _, ok := event.Attendee(userID); !ok {
+		return storage.ErrAttendeeNotFound
+	}
+	attendee := storage.Attendee{UserID: userID, Status: status}
+	notification := attendeeNotification(ctx, event, storage.NotificationRSVP, event.UserID, attendee)
+	return a.Storage.UpdateAttendee(ctx, id, attendee, notification)
+}
+
+// attendeeNotification уведомление пользователю recipient об изменении участника события.
+func attendeeNotification(ctx context.Context, event *storage.Event, kind storage.NotificationKind,
+	recipient int64, attendee storage.Attendee,
+) storage.Notification {
+	return storage.Notification{
+		ID: uuid.New().String(), Kind: kind, Attendee: &attendee, Title: event.Title, StartTime: event.StartTime,
+		UserID: recipient, TraceContext: tracing.TraceParent(ctx),
+	}
+}
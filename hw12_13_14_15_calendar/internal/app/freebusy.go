@@ -0,0 +1,71 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/freebusy" //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage"  //nolint:depguard
+)
+
+const (
+	// максимальный интервал запроса занятости: повторяющиеся события разворачиваются в повторения.
+	maxFreeBusyRange = 92 * 24 * time.Hour
+	maxFreeBusyUsers = 50
+)
+
+// UserBusy занятое время пользователя.
+type UserBusy struct {
+	UserID int64
+	Busy   []freebusy.Interval
+}
+
+// FreeBusy возвращает занятое время пользователей userIDs внутри [from, to) в порядке запроса.
+// Подробности событий не раскрываются, поэтому занятость доступна любому пользователю.
+func (a *App) FreeBusy(ctx context.Context, userIDs []int64, from, to time.Time) ([]UserBusy, error) {
+	if len(userIDs) == 0 || len(userIDs) > maxFreeBusyUsers {
+		return nil, fmt.Errorf("%w: from 1 to %v users expected, got %v",
+			storage.ErrInvalidArgiments, maxFreeBusyUsers, len(userIDs))
+	}
+	if !from.Before(to) || to.Sub(from) > maxFreeBusyRange {
+		return nil, fmt.Errorf("%w: range [%v, %v) must be non-empty and not longer than %v",
+			storage.ErrInvalidArgiments, from, to, maxFreeBusyRange)
+	}
+	result := make([]UserBusy, 0, len(userIDs))
+	seen := make(map[int64]struct{}, len(userIDs))
+	for _, userID := range userIDs {
+		if _, ok := seen[userID]; ok {
+			continue
+		}
+		seen[userID] = struct{}{}
+		events, _, err := a.Storage.ListEvents(ctx, storage.ListParams{UserID: userID, StartTime: from, StopTime: to})
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, UserBusy{UserID: userID, Busy: freebusy.Busy(events, userID, from, to)})
+	}
+	return result, nil
+}
+
+// SuggestSlots подбирает до limit интервалов длительностью duration в рабочее время внутри [from, to),
+// когда все пользователи userIDs свободны.
+func (a *App) SuggestSlots(ctx context.Context, userIDs []int64, from, to time.Time, duration time.Duration,
+	hours freebusy.WorkingHours, limit int,
+) ([]freebusy.Interval, error) {
+	if duration <= 0 || limit <= 0 {
+		return nil, fmt.Errorf("%w: duration=%v limit=%v must be positive", storage.ErrInvalidArgiments, duration, limit)
+	}
+	if err := hours.Validate(); err != nil {
+		return nil, err
+	}
+	usersBusy, err := a.FreeBusy(ctx, userIDs, from, to)
+	if err != nil {
+		return nil, err
+	}
+	busy := make([]freebusy.Interval, 0)
+	for _, userBusy := range usersBusy {
+		busy = append(busy, userBusy.Busy...)
+	}
+	return freebusy.Slots(busy, hours.Windows(from, to), duration, limit), nil
+}
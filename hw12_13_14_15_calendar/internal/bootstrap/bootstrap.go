@@ -0,0 +1,160 @@
+// Package bootstrap создает компоненты календаря по конфигурации. Общая часть процессов cmd/,
+// сами компоненты конфигурацию не знают и получают только нужные параметры.
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/app"              //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/client"           //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/client/amqp"      //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/client/kafka"     //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/client/memory"    //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/config"           //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/delivery"         //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/health"           //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/metrics"          //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/scheduler"        //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/server/admin"     //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage"          //nolint:depguard
+	_ "github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage/memory" //nolint:depguard
+	_ "github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage/sql"    //nolint:depguard
+	_ "github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage/sqlite" //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/tracing"          //nolint:depguard
+)
+
+// брокеры broker.type.
+const (
+	BrokerKafka  = "kafka"
+	BrokerAMQP   = "amqp"
+	BrokerMemory = "memory"
+)
+
+// хранилище по умолчанию.
+const StorageMemory = "memory"
+
+var ErrUnknownBroker = errors.New("unknown broker type")
+
+// NewStorage создает хранилище storage из зарегистрированных реализаций, по умолчанию - в памяти.
+// Операции хранилища попадают в метрики, исходное хранилище возвращает Unwrap.
+// Реализации регистрируются при импорте своих пакетов.
+func NewStorage(ctx context.Context, conf config.Config, logg app.Logger) (app.Storage, error) {
+	name := conf.Storage
+	if name == "" {
+		name = StorageMemory
+	}
+	logg.Info("create " + name + " storage")
+	st, err := storage.Open(ctx, name, storage.Params{
+		Driver: conf.DB.Driver, DSN: conf.DB.Dsn, AllowOverlap: conf.Events.AllowOverlap,
+		AutoMigrate: conf.DB.AutoMigrate,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return metrics.NewStorage(st), nil
+}
+
+// NewBroker создает брокер broker.type. Брокер memory передает сообщения только внутри процесса.
+func NewBroker(conf config.Config, retry client.RetryPolicy, logg app.Logger) (client.Broker, error) {
+	switch conf.Broker.Type {
+	case "", BrokerKafka:
+		return kafka.New([]string{net.JoinHostPort(conf.Kafka.Host, strconv.Itoa(conf.Kafka.Port))}, retry, logg), nil
+	case BrokerAMQP:
+		return amqp.New(conf.AMQP.URL, retry, logg), nil
+	case BrokerMemory:
+		return memory.New(retry, logg), nil
+	}
+	return nil, fmt.Errorf("%w: %v", ErrUnknownBroker, conf.Broker.Type)
+}
+
+func RetryPolicy(conf config.RetryConf) client.RetryPolicy {
+	return client.RetryPolicy{
+		MaxRetries:      conf.MaxRetries,
+		InitialInterval: time.Duration(conf.InitialInterval) * time.Millisecond,
+		MaxInterval:     time.Duration(conf.MaxInterval) * time.Millisecond,
+		Multiplier:      conf.Multiplier,
+	}
+}
+
+func NewDispatcher(store delivery.Store, conf config.DeliveryConf) *delivery.Dispatcher {
+	recipients := make(map[int64]delivery.Recipient, len(conf.Users))
+	for userID, user := range conf.Users {
+		recipients[userID] = delivery.Recipient{Channel: user.Channel, Address: user.Address}
+	}
+	channel := conf.Channel
+	if channel == "" {
+		channel = delivery.ChannelStdout
+	}
+	dispatcher := delivery.NewDispatcher(store, channel, recipients, conf.MaxAttempts)
+	dispatcher.Register(delivery.ChannelStdout, delivery.NewStdoutSender(os.Stdout))
+	dispatcher.Register(delivery.ChannelEmail, delivery.NewSMTPSender(conf.SMTP.Host, conf.SMTP.Port, conf.SMTP.From,
+		conf.SMTP.Username, conf.SMTP.Password))
+	dispatcher.Register(delivery.ChannelWebhook, delivery.NewWebhookSender(conf.Webhook.URL, conf.Webhook.Secret,
+		time.Duration(conf.Webhook.Timeout)*time.Second))
+	return dispatcher
+}
+
+func SchedulerIntervals(conf config.TimerConf) scheduler.Intervals {
+	return scheduler.Intervals{
+		ReminderEvents: time.Second * time.Duration(conf.ReminderEvents),
+		OldEvents:      time.Second * time.Duration(conf.OldEvents),
+		Outbox:         time.Second * time.Duration(conf.Outbox),
+	}
+}
+
+// время на проверку всех зависимостей /healthz и /readyz.
+const healthTimeout = time.Second * 2
+
+// NewHealthChecker создает проверки хранилища и брокера, broker nil - процесс без брокера.
+func NewHealthChecker(storage app.Storage, broker client.Broker) *health.Checker {
+	checker := health.New(healthTimeout)
+	checker.Add("db", storage.Ping)
+	if broker != nil {
+		checker.Add("broker", broker.Ping)
+	}
+	return checker
+}
+
+// StartAdminServer запускает служебный сервер с метриками и проверками, если задан порт,
+// и останавливает его по отмене ctx.
+func StartAdminServer(ctx context.Context, conf config.AddrConf, checker *health.Checker, logg app.Logger) {
+	if conf.Port == 0 {
+		return
+	}
+	server := admin.NewServer(conf.Host, conf.Port, checker, logg)
+	go func() {
+		if err := server.Start(ctx); err != nil {
+			logg.Error("admin server: " + err.Error())
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+		defer cancel()
+		if err := server.Stop(ctx); err != nil {
+			logg.Error("failed to stop admin server: " + err.Error())
+		}
+	}()
+}
+
+// SetupTracing настраивает экспорт спанов процесса service. Возвращает функцию, которая выгружает
+// накопленные спаны, ее вызывают при завершении процесса.
+func SetupTracing(ctx context.Context, conf config.TracingConf, service string, logg app.Logger) (func(), error) {
+	shutdown, err := tracing.Setup(ctx, service, conf.Exporter, conf.File)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			logg.Error("failed to flush traces: " + err.Error())
+		}
+	}, nil
+}
@@ -0,0 +1,109 @@
+package bootstrap
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/config"    //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/logger"    //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/scheduler" //nolint:depguard
+)
+
+// Reloader перечитывает конфигурацию по SIGHUP и применяет параметры, которые меняются без перезапуска:
+// уровень и файл журнала, интервалы планировщика. Остальные изменения вступят в силу после перезапуска.
+type Reloader struct {
+	load func() (config.Config, error)
+	// конфигурация, с которой работает процесс
+	config    config.Config
+	logg      *logger.Logger
+	scheduler *scheduler.Scheduler
+	out       io.Writer
+}
+
+// ReloadReport строка JSON, которую Reloader выводит после каждой перезагрузки.
+type ReloadReport struct {
+	Time    time.Time      `json:"time"`
+	Msg     string         `json:"msg"`
+	Error   string         `json:"error,omitempty"`
+	Changes []ReloadChange `json:"changes,omitempty"`
+}
+
+// ReloadChange изменение параметра, Applied - применено без перезапуска.
+type ReloadChange struct {
+	config.Change
+	Applied bool `json:"applied"`
+}
+
+// NewReloader создает Reloader процесса с конфигурацией current. load читает конфигурацию заново,
+// scheduler - планировщик процесса или nil, отчеты выводятся в out.
+func NewReloader(load func() (config.Config, error), current config.Config, logg *logger.Logger,
+	scheduler *scheduler.Scheduler, out io.Writer,
+) *Reloader {
+	return &Reloader{load: load, config: current, logg: logg, scheduler: scheduler, out: out}
+}
+
+// Run перезагружает конфигурацию по каждому SIGHUP до отмены контекста.
+func (r *Reloader) Run(ctx context.Context) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			r.Reload()
+		}
+	}
+}
+
+// Reload перечитывает конфигурацию, применяет ее и возвращает отчет. При ошибке чтения
+// процесс продолжает работать с прежней конфигурацией.
+func (r *Reloader) Reload() ReloadReport {
+	report := r.reload()
+	report.Time = time.Now()
+	if err := json.NewEncoder(r.out).Encode(report); err != nil {
+		r.logg.Error("failed to write reload report: " + err.Error())
+	}
+	return report
+}
+
+func (r *Reloader) reload() ReloadReport {
+	next, err := r.load()
+	if err != nil {
+		r.logg.Error("failed to reload config: " + err.Error())
+		return ReloadReport{Msg: "config reload failed", Error: err.Error()}
+	}
+	prev := r.config
+
+	// файл журнала переоткрывается всегда, в том числе с прежним именем после ротации
+	if err := r.logg.Reopen(next.Logger.File); err != nil {
+		r.logg.Error("failed to reopen log file: " + err.Error())
+		return ReloadReport{Msg: "config reload failed", Error: err.Error()}
+	}
+	// уровень уже проверен config.Validate
+	_ = r.logg.SetLevel(next.Logger.Level)
+	r.config.Logger = next.Logger
+	if r.scheduler != nil {
+		r.scheduler.SetIntervals(SchedulerIntervals(next.Timer))
+		r.config.Timer = next.Timer
+	}
+
+	// непримененные изменения остаются в r.config прежними и попадают в отчет каждой перезагрузки
+	report := ReloadReport{Msg: "config reloaded"}
+	for _, change := range config.Diff(prev, next) {
+		report.Changes = append(report.Changes, ReloadChange{Change: change, Applied: r.applied(change.Field)})
+	}
+	r.logg.Info("config reloaded")
+	return report
+}
+
+func (r *Reloader) applied(field string) bool {
+	return strings.HasPrefix(field, "log.") || r.scheduler != nil && strings.HasPrefix(field, "timer.")
+}
@@ -0,0 +1,75 @@
+package bootstrap
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/app"                          //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/config"                       //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/logger"                       //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/scheduler"                    //nolint:depguard
+	memorystorage "github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage/memory" //nolint:depguard
+	"github.com/stretchr/testify/require"                                                   //nolint:depguard
+)
+
+func TestReloader(t *testing.T) {
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "calendar.log")
+	configFile := filepath.Join(dir, "config.yml")
+	writeConfig := func(data string) {
+		require.NoError(t, os.WriteFile(configFile, []byte("log:\n  file: "+logFile+"\n"+data), 0o600))
+	}
+	load := func() (config.Config, error) { return config.Load(configFile, nil, nil) }
+
+	writeConfig("db:\n  dsn: postgres://old\n")
+	current, err := load()
+	require.NoError(t, err)
+	logg := logger.New(current.Logger.Level, current.Logger.File)
+	defer logg.Close()
+	sched := scheduler.New(app.New(logg, memorystorage.New(false), nil), "events", SchedulerIntervals(current.Timer))
+	var out bytes.Buffer
+	reloader := NewReloader(load, current, logg, sched, &out)
+
+	writeConfig(`
+  level: DEBUG
+db:
+  dsn: postgres://new
+server:
+  http:
+    port: 9090
+timer:
+  outbox: 2
+`)
+	report := reloader.Reload()
+	require.Empty(t, report.Error)
+	require.Equal(t, []ReloadChange{
+		{Change: config.Change{Field: "log.level", Old: "INFO", New: "DEBUG"}, Applied: true},
+		{Change: config.Change{Field: "server.http.port", Old: "8080", New: "9090"}},
+		{Change: config.Change{Field: "db.dsn", Old: "***", New: "***"}},
+		{Change: config.Change{Field: "timer.outbox", Old: "1", New: "2"}, Applied: true},
+	}, report.Changes)
+	require.Equal(t, time.Second*2, sched.Intervals().Outbox)
+
+	var line ReloadReport
+	require.NoError(t, json.Unmarshal(out.Bytes(), &line))
+	require.Equal(t, "config reloaded", line.Msg)
+	require.Len(t, line.Changes, 4)
+
+	// непримененные изменения сообщаются снова, примененные - нет
+	report = reloader.Reload()
+	require.Equal(t, []ReloadChange{
+		{Change: config.Change{Field: "server.http.port", Old: "8080", New: "9090"}},
+		{Change: config.Change{Field: "db.dsn", Old: "***", New: "***"}},
+	}, report.Changes)
+
+	// с ошибочной конфигурацией процесс работает с прежней
+	writeConfig("timer:\n  outbox: 0\n")
+	report = reloader.Reload()
+	require.Equal(t, "config reload failed", report.Msg)
+	require.Contains(t, report.Error, "timer.outbox")
+	require.Equal(t, time.Second*2, sched.Intervals().Outbox)
+}
@@ -0,0 +1,343 @@
+// Package amqp брокер RabbitMQ: топик - очередь с тем же именем, публикация через exchange по умолчанию.
+package amqp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"                                           //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/app"     //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/client"  //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/tracing" //nolint:depguard
+	amqp091 "github.com/rabbitmq/amqp091-go"                           //nolint:depguard
+)
+
+// заголовки сообщения в <topic>.dlq, совпадают с watermill.
+const (
+	reasonHeader = "reason_poisoned"
+	topicHeader  = "topic_poisoned"
+)
+
+// пауза между попытками подключения.
+const reconnectDelay = time.Second
+
+var (
+	ErrPublisherNotReady = errors.New("publisher is not ready")
+	ErrHandlerPanic      = errors.New("handler panic")
+)
+
+// amqpChannel операции канала *amqp091.Channel, через которые клиент объявляет очереди и публикует сообщения.
+type amqpChannel interface {
+	QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp091.Table) (amqp091.Queue, error)
+	PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool,
+		msg amqp091.Publishing) error
+}
+
+type Client struct {
+	url       string
+	retry     client.RetryPolicy
+	handlers  map[string]client.SubscriberHandlerFunc
+	appLogger app.Logger
+
+	mu       sync.Mutex
+	conn     *amqp091.Connection
+	channel  amqpChannel
+	declared map[string]struct{}
+}
+
+var (
+	_ client.Broker          = (*Client)(nil)
+	_ client.DeadLetterQueue = (*Client)(nil)
+)
+
+func New(url string, retry client.RetryPolicy, appLogger app.Logger) *Client {
+	return &Client{
+		url: url, retry: retry, handlers: make(map[string]client.SubscriberHandlerFunc, 0), appLogger: appLogger,
+	}
+}
+
+// Connect подключается к брокеру и обрабатывает сообщения подписок до отмены контекста.
+// Если брокер недоступен или соединение потеряно, подключение повторяется.
+func (c *Client) Connect(ctx context.Context) error {
+	for {
+		err := c.serve(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+		c.appLogger.Error("amqp: " + err.Error())
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+func (c *Client) serve(ctx context.Context) error {
+	conn, err := amqp091.Dial(c.url)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	channel, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+
+	// при закрытии соединения каналы получения сообщений закрываются и обработчики завершаются
+	ctx, cancel := context.WithCancel(ctx)
+	wg := sync.WaitGroup{}
+	defer func() {
+		cancel()
+		conn.Close()
+		wg.Wait()
+	}()
+	for topic, handler := range c.handlers {
+		deliveries, err := c.subscribe(conn, topic)
+		if err != nil {
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for delivery := range deliveries {
+				c.handle(ctx, channel, topic, handler, delivery)
+			}
+		}()
+	}
+
+	c.mu.Lock()
+	c.conn, c.channel, c.declared = conn, channel, make(map[string]struct{})
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.conn, c.channel = nil, nil
+		c.mu.Unlock()
+	}()
+
+	closed := conn.NotifyClose(make(chan *amqp091.Error, 1))
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-closed:
+		return fmt.Errorf("connection closed: %w", err)
+	}
+}
+
+// subscribe объявляет очереди топика и начинает получение сообщений. Сообщение подтверждается
+// после обработки, поэтому канал получает не больше одного сообщения за раз.
+func (c *Client) subscribe(conn *amqp091.Connection, topic string) (<-chan amqp091.Delivery, error) {
+	channel, err := conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	for _, queue := range []string{topic, client.DeadLetterTopic(topic)} {
+		if err := declare(channel, queue); err != nil {
+			return nil, err
+		}
+	}
+	if err := channel.Qos(1, 0, false); err != nil {
+		return nil, err
+	}
+	return channel.Consume(topic, "", false, false, false, false, nil)
+}
+
+// handle обрабатывает сообщение с повторами. Если повторы не помогли, сообщение уходит в <topic>.dlq.
+func (c *Client) handle(ctx context.Context, channel amqpChannel, topic string,
+	handler client.SubscriberHandlerFunc, delivery amqp091.Delivery,
+) {
+	err := c.call(ctx, topic, handler, delivery)
+	for retry := 1; err != nil && retry <= c.retry.MaxRetries; retry++ {
+		select {
+		case <-ctx.Done():
+			// сообщение будет получено заново после переподключения
+			_ = delivery.Nack(false, true)
+			return
+		case <-time.After(c.retry.Delay(retry)):
+		}
+		err = c.call(ctx, topic, handler, delivery)
+	}
+	if err == nil {
+		_ = delivery.Ack(false)
+		return
+	}
+
+	c.appLogger.Error("handler " + err.Error())
+	deadLetter := amqp091.Publishing{
+		MessageId:    delivery.MessageId,
+		ContentType:  delivery.ContentType,
+		DeliveryMode: amqp091.Persistent,
+		Headers:      amqp091.Table{reasonHeader: err.Error(), topicHeader: topic},
+		Body:         delivery.Body,
+	}
+	c.mu.Lock()
+	err = channel.PublishWithContext(ctx, "", client.DeadLetterTopic(topic), false, false, deadLetter)
+	c.mu.Unlock()
+	if err != nil {
+		c.appLogger.Error("amqp: failed to publish dead letter: " + err.Error())
+		_ = delivery.Nack(false, true)
+		return
+	}
+	_ = delivery.Ack(false)
+}
+
+func (c *Client) call(ctx context.Context, topic string, handler client.SubscriberHandlerFunc,
+	delivery amqp091.Delivery,
+) (err error) {
+	ctx, span := tracing.StartProcess(ctx, topic, metadata(delivery.Headers))
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrHandlerPanic, r)
+		}
+		tracing.End(span, err)
+	}()
+	raw := append([]byte(nil), delivery.Body...)
+	return handler(ctx, &raw)
+}
+
+// metadata строковые заголовки сообщения, среди них контекст трассировки.
+func metadata(headers amqp091.Table) map[string]string {
+	result := make(map[string]string, len(headers))
+	for key, value := range headers {
+		if s, ok := value.(string); ok {
+			result[key] = s
+		}
+	}
+	return result
+}
+
+func (c *Client) Disconnect() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
+
+// Ping проверяет, что соединение с брокером установлено и не закрыто.
+func (c *Client) Ping(_ context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil || c.conn.IsClosed() {
+		return ErrPublisherNotReady
+	}
+	return nil
+}
+
+func (c *Client) Publish(ctx context.Context, topic string, payload []byte) (err error) {
+	headers := make(map[string]string)
+	ctx, span := tracing.StartPublish(ctx, topic, headers)
+	defer func() { tracing.End(span, err) }()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.channel == nil {
+		return ErrPublisherNotReady
+	}
+	if _, ok := c.declared[topic]; !ok {
+		if err := declare(c.channel, topic); err != nil {
+			return err
+		}
+		c.declared[topic] = struct{}{}
+	}
+	table := make(amqp091.Table, len(headers))
+	for key, value := range headers {
+		table[key] = value
+	}
+	return publish(ctx, c.channel, topic, payload, table)
+}
+
+func (c *Client) Subscribe(topic string, handler client.SubscriberHandlerFunc) {
+	c.handlers[topic] = handler
+}
+
+// ListDeadLetters читает сообщения <topic>.dlq без подтверждения, после просмотра они возвращаются в очередь.
+func (c *Client) ListDeadLetters(_ context.Context, topic string, _ time.Duration) ([]client.DeadLetter, error) {
+	return c.readDeadLetters(topic, nil)
+}
+
+func (c *Client) ReplayDeadLetters(ctx context.Context, topic string, _ time.Duration) ([]client.DeadLetter, error) {
+	return c.readDeadLetters(topic, func(channel amqpChannel, letter client.DeadLetter) error {
+		if err := declare(channel, letter.Topic); err != nil {
+			return err
+		}
+		return publish(ctx, channel, letter.Topic, letter.Payload, nil)
+	})
+}
+
+// readDeadLetters читает все сообщения <topic>.dlq. Если задан replay, сообщение подтверждается после replay,
+// иначе остается в очереди.
+func (c *Client) readDeadLetters(topic string,
+	replay func(channel amqpChannel, letter client.DeadLetter) error,
+) ([]client.DeadLetter, error) {
+	conn, err := amqp091.Dial(c.url)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	channel, err := conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	// неподтвержденные сообщения возвращаются в очередь при закрытии канала
+	defer channel.Close()
+	queue := client.DeadLetterTopic(topic)
+	if err := declare(channel, queue); err != nil {
+		return nil, err
+	}
+
+	result := make([]client.DeadLetter, 0)
+	for {
+		delivery, ok, err := channel.Get(queue, false)
+		if err != nil {
+			return result, err
+		}
+		if !ok {
+			return result, nil
+		}
+		letter := deadLetter(delivery, topic)
+		if replay != nil {
+			if err := replay(channel, letter); err != nil {
+				return result, err
+			}
+			if err := delivery.Ack(false); err != nil {
+				return result, err
+			}
+		}
+		result = append(result, letter)
+	}
+}
+
+func deadLetter(delivery amqp091.Delivery, topic string) client.DeadLetter {
+	letter := client.DeadLetter{ID: delivery.MessageId, Topic: topic, Payload: delivery.Body}
+	if reason, ok := delivery.Headers[reasonHeader].(string); ok {
+		letter.Reason = reason
+	}
+	if source, ok := delivery.Headers[topicHeader].(string); ok && source != "" {
+		letter.Topic = source
+	}
+	return letter
+}
+
+func declare(channel amqpChannel, queue string) error {
+	_, err := channel.QueueDeclare(queue, true, false, false, false, nil)
+	return err
+}
+
+func publish(ctx context.Context, channel amqpChannel, topic string, payload []byte,
+	headers amqp091.Table,
+) error {
+	return channel.PublishWithContext(ctx, "", topic, false, false, amqp091.Publishing{
+		MessageId:    uuid.New().String(),
+		ContentType:  "application/json",
+		DeliveryMode: amqp091.Persistent,
+		Headers:      headers,
+		Body:         payload,
+	})
+}
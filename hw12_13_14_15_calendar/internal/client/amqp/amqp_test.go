@@ -0,0 +1,193 @@
+package amqp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/client" //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/logger" //nolint:depguard
+	amqp091 "github.com/rabbitmq/amqp091-go"                          //nolint:depguard
+	"github.com/stretchr/testify/require"                             //nolint:depguard
+)
+
+var (
+	errHandler = errors.New("handler error")
+	errPublish = errors.New("publish error")
+	// errPanic обработчик паникует вместо возврата ошибки.
+	errPanic = errors.New("panic")
+)
+
+// fakeChannel запоминает объявленные очереди и опубликованные сообщения вместо брокера.
+type fakeChannel struct {
+	queues     []string
+	published  map[string][]amqp091.Publishing
+	publishErr error
+}
+
+func newFakeChannel() *fakeChannel {
+	return &fakeChannel{published: make(map[string][]amqp091.Publishing)}
+}
+
+func (f *fakeChannel) QueueDeclare(name string, _, _, _, _ bool, _ amqp091.Table) (amqp091.Queue, error) {
+	f.queues = append(f.queues, name)
+	return amqp091.Queue{Name: name}, nil
+}
+
+func (f *fakeChannel) PublishWithContext(_ context.Context, _, key string, _, _ bool, msg amqp091.Publishing) error {
+	if f.publishErr != nil {
+		return f.publishErr
+	}
+	f.published[key] = append(f.published[key], msg)
+	return nil
+}
+
+// acknowledger запоминает подтверждение сообщения клиентом.
+type acknowledger struct {
+	acked    bool
+	requeued bool
+}
+
+func (a *acknowledger) Ack(_ uint64, _ bool) error {
+	a.acked = true
+	return nil
+}
+
+func (a *acknowledger) Nack(_ uint64, _ bool, requeue bool) error {
+	a.requeued = requeue
+	return nil
+}
+
+func (a *acknowledger) Reject(_ uint64, requeue bool) error {
+	a.requeued = requeue
+	return nil
+}
+
+func newTestClient(url string) *Client {
+	return New(url, client.RetryPolicy{MaxRetries: 1, InitialInterval: time.Millisecond, Multiplier: 2},
+		logger.New("INFO", "/tmp/calendar-amqp-test.log"))
+}
+
+func TestClientHandle(t *testing.T) {
+	tests := []struct {
+		name       string
+		results    []error
+		canceled   bool
+		publishErr error
+		calls      int
+		acked      bool
+		requeued   bool
+		reason     string
+	}{
+		{name: "success", results: []error{nil}, calls: 1, acked: true},
+		{name: "retry", results: []error{errHandler, nil}, calls: 2, acked: true},
+		{name: "dead letter", results: []error{errHandler, errHandler}, calls: 2, acked: true, reason: errHandler.Error()},
+		{name: "panic", results: []error{errPanic, errPanic}, calls: 2, acked: true, reason: ErrHandlerPanic.Error()},
+		{
+			name: "dead letter failed", results: []error{errHandler, errHandler}, publishErr: errPublish,
+			calls: 2, requeued: true,
+		},
+		{name: "canceled", results: []error{errHandler}, canceled: true, calls: 1, requeued: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestClient("")
+			channel := newFakeChannel()
+			channel.publishErr = tc.publishErr
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tc.canceled {
+				cancel()
+			}
+			calls := 0
+			handler := func(_ context.Context, raw *[]byte) error {
+				require.Equal(t, "payload", string(*raw))
+				err := tc.results[calls]
+				calls++
+				if errors.Is(err, errPanic) {
+					panic("boom")
+				}
+				return err
+			}
+			ack := &acknowledger{}
+			delivery := amqp091.Delivery{
+				Acknowledger: ack, DeliveryTag: 1, MessageId: "id", ContentType: "application/json",
+				Body: []byte("payload"),
+			}
+
+			c.handle(ctx, channel, "events", handler, delivery)
+			require.Equal(t, tc.calls, calls)
+			require.Equal(t, tc.acked, ack.acked)
+			require.Equal(t, tc.requeued, ack.requeued)
+
+			letters := channel.published[client.DeadLetterTopic("events")]
+			if tc.reason == "" {
+				require.Empty(t, letters)
+				return
+			}
+			require.Len(t, letters, 1)
+			require.Equal(t, "id", letters[0].MessageId)
+			require.Equal(t, "payload", string(letters[0].Body))
+			require.Equal(t, "events", letters[0].Headers[topicHeader])
+			require.Contains(t, letters[0].Headers[reasonHeader], tc.reason)
+		})
+	}
+}
+
+func TestClientPublish(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient("")
+	require.ErrorIs(t, c.Publish(ctx, "events", []byte("early")), ErrPublisherNotReady)
+	require.ErrorIs(t, c.Ping(ctx), ErrPublisherNotReady)
+
+	// после подключения serve задает канал публикации
+	channel := newFakeChannel()
+	c.channel, c.declared = channel, make(map[string]struct{})
+	require.NoError(t, c.Publish(ctx, "events", []byte("1")))
+	require.NoError(t, c.Publish(ctx, "events", []byte("2")))
+	require.NoError(t, c.Publish(ctx, "other", []byte("3")))
+
+	// очередь объявляется при первой публикации в топик
+	require.Equal(t, []string{"events", "other"}, channel.queues)
+	published := channel.published["events"]
+	require.Len(t, published, 2)
+	for i, payload := range []string{"1", "2"} {
+		require.Equal(t, payload, string(published[i].Body))
+		require.Equal(t, amqp091.Persistent, published[i].DeliveryMode)
+		require.NotEmpty(t, published[i].MessageId)
+	}
+	require.NotEqual(t, published[0].MessageId, published[1].MessageId)
+
+	channel.publishErr = errPublish
+	require.ErrorIs(t, c.Publish(ctx, "events", []byte("4")), errPublish)
+}
+
+func TestDeadLetter(t *testing.T) {
+	tests := []struct {
+		name     string
+		headers  amqp091.Table
+		expected client.DeadLetter
+	}{
+		{
+			name:     "with headers",
+			headers:  amqp091.Table{reasonHeader: "handler error", topicHeader: "events"},
+			expected: client.DeadLetter{ID: "id", Topic: "events", Reason: "handler error", Payload: []byte("1")},
+		},
+		{
+			name:     "without headers",
+			expected: client.DeadLetter{ID: "id", Topic: "queue", Payload: []byte("1")},
+		},
+		{
+			name:     "empty topic",
+			headers:  amqp091.Table{topicHeader: ""},
+			expected: client.DeadLetter{ID: "id", Topic: "queue", Payload: []byte("1")},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			delivery := amqp091.Delivery{MessageId: "id", Headers: tc.headers, Body: []byte("1")}
+			require.Equal(t, tc.expected, deadLetter(delivery, "queue"))
+		})
+	}
+}
@@ -1,13 +1,62 @@
 package client
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
-type SubscriberHandlerFunc func(raw *[]byte) error
+// SubscriberHandlerFunc обрабатывает сообщение, ctx содержит контекст трассировки из метаданных сообщения.
+type SubscriberHandlerFunc func(ctx context.Context, raw *[]byte) error
 
 // интерфейс клиента кафки чтобы их можно было менять.
 type Broker interface {
 	Connect(ctx context.Context) error
 	Disconnect() error
-	Publish(topic string, message []byte) error
+	// Publish передает контекст трассировки ctx в метаданных сообщения.
+	Publish(ctx context.Context, topic string, message []byte) error
 	Subscribe(topic string, handler SubscriberHandlerFunc)
+	// Ping проверяет, что клиент подключен к брокеру и может публиковать сообщения.
+	Ping(ctx context.Context) error
+}
+
+// RetryPolicy повторная обработка сообщения при ошибке обработчика с экспоненциально растущей паузой.
+// Если обработчик не справился и после MaxRetries повторов, сообщение уходит в топик DeadLetterTopic.
+type RetryPolicy struct {
+	MaxRetries      int
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	Multiplier      float64
+}
+
+// DeadLetter сообщение, которое не удалось обработать.
+type DeadLetter struct {
+	ID string
+	// исходный топик сообщения
+	Topic   string
+	Reason  string
+	Payload []byte
+}
+
+// DeadLetterQueue просмотр и повторная отправка необработанных сообщений топика.
+// Чтение заканчивается, когда новых сообщений нет в течение idle.
+type DeadLetterQueue interface {
+	ListDeadLetters(ctx context.Context, topic string, idle time.Duration) ([]DeadLetter, error)
+	// ReplayDeadLetters отправляет сообщения в исходный топик, каждое сообщение отправляется один раз.
+	ReplayDeadLetters(ctx context.Context, topic string, idle time.Duration) ([]DeadLetter, error)
+}
+
+func DeadLetterTopic(topic string) string {
+	return topic + ".dlq"
+}
+
+// Delay пауза перед повтором с номером retry, начиная с 1.
+func (p RetryPolicy) Delay(retry int) time.Duration {
+	delay := p.InitialInterval
+	for i := 1; i < retry; i++ {
+		delay = time.Duration(float64(delay) * p.Multiplier)
+		if p.MaxInterval > 0 && delay >= p.MaxInterval {
+			return p.MaxInterval
+		}
+	}
+	return delay
 }
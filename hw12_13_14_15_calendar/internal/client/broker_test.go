@@ -0,0 +1,16 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require" //nolint:depguard
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{InitialInterval: time.Second, MaxInterval: time.Second * 10, Multiplier: 2}
+	require.Equal(t, time.Second, policy.Delay(1))
+	require.Equal(t, time.Second*4, policy.Delay(3))
+	require.Equal(t, time.Second*10, policy.Delay(5))
+	require.Equal(t, time.Second*10, policy.Delay(100))
+}
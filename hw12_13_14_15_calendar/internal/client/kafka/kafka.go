@@ -2,38 +2,43 @@ package kafka
 
 import (
 	"context"
-	"errors"
+	"time"
 
-	"github.com/ThreeDotsLabs/watermill"                              //nolint:depguard
-	"github.com/ThreeDotsLabs/watermill-kafka/v3/pkg/kafka"           //nolint:depguard
-	"github.com/ThreeDotsLabs/watermill/message"                      //nolint:depguard
-	"github.com/ThreeDotsLabs/watermill/message/router/middleware"    //nolint:depguard
-	"github.com/ThreeDotsLabs/watermill/message/router/plugin"        //nolint:depguard
-	"github.com/google/uuid"                                          //nolint:depguard
-	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/app"    //nolint:depguard
-	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/client" //nolint:depguard
+	"github.com/IBM/sarama"                                                  //nolint:depguard
+	"github.com/ThreeDotsLabs/watermill"                                     //nolint:depguard
+	"github.com/ThreeDotsLabs/watermill-kafka/v3/pkg/kafka"                  //nolint:depguard
+	"github.com/ThreeDotsLabs/watermill/message"                             //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/app"           //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/client"        //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/client/pubsub" //nolint:depguard
 )
 
 type Client struct {
-	brokers    []string
-	marshaler  kafka.DefaultMarshaler
-	logger     watermill.LoggerAdapter
-	publisher  message.Publisher
-	subscriber message.Subscriber
-	handlers   map[string]client.SubscriberHandlerFunc
-	appLogger  app.Logger
+	*pubsub.Client
+	brokers   []string
+	marshaler kafka.DefaultMarshaler
+	logger    watermill.LoggerAdapter
 }
 
-var ErrPublisherNotReady = errors.New("publisher is not ready")
+var (
+	_ client.Broker          = (*Client)(nil)
+	_ client.DeadLetterQueue = (*Client)(nil)
+)
+
+// consumer group для повторной отправки необработанных сообщений.
+const replayConsumerGroup = "dlq_replay"
 
-func New(brokers []string, appLogger app.Logger) *Client {
+func New(brokers []string, retry client.RetryPolicy, appLogger app.Logger) *Client {
+	logger := watermill.NewStdLogger(true, false)
 	return &Client{
-		brokers: brokers, marshaler: kafka.DefaultMarshaler{}, logger: watermill.NewStdLogger(true, false),
-		handlers: make(map[string]client.SubscriberHandlerFunc, 0), appLogger: appLogger,
+		Client: pubsub.New(retry, logger, appLogger), brokers: brokers, marshaler: kafka.DefaultMarshaler{},
+		logger: logger,
 	}
 }
 
 func (c *Client) Connect(ctx context.Context) error {
+	var publisher message.Publisher
+	var subscriber message.Subscriber
 	for {
 		// ТЗ: при запуске процесс подключается к Kafka, если Kafka недоступна - ждёт
 		// но надо останавливаться по Ctrl+C
@@ -44,77 +49,74 @@ func (c *Client) Connect(ctx context.Context) error {
 		default:
 		}
 		var err error
-		c.publisher, err = c.createPublisher()
+		publisher, err = c.createPublisher()
 		if err != nil {
 			c.logger.Error("createPublisher", err, nil)
 			continue
 		}
 		// Subscriber is created with consumer group handler_1
-		c.subscriber, err = c.createSubscriber("handler_1")
+		subscriber, err = c.createSubscriber("handler_1", nil)
 		if err == nil {
 			break
 		}
 		c.logger.Error("createSubscriber", err, nil)
-		err = c.publisher.Close()
+		err = publisher.Close()
 		if err != nil {
 			c.logger.Error("closePublisher", err, nil)
 		}
 	}
 
-	router, err := message.NewRouter(message.RouterConfig{}, c.logger)
-	if err != nil {
-		// это не ошибка коннекта, работать нельзя
-		return err
-	}
-
-	router.AddPlugin(plugin.SignalsHandler)
-	router.AddMiddleware(middleware.Recoverer)
-
-	for topic, handler := range c.handlers {
-		router.AddNoPublisherHandler(
-			"handler_"+topic, // handler name, must be unique
-			topic,            // topic from which messages should be consumed
-			c.subscriber,
-			func(msg *message.Message) error {
-				raw := []byte(msg.Payload)
-				err := handler(&raw)
-				if err != nil {
-					// When a handler returns an error, the default behavior is to send a Nack (negative-acknowledgement).
-					// The message will be processed again.
-					//
-					// You can change the default behaviour by using middlewares, like Retry or PoisonQueue.
-					// You can also implement your own middleware.
-					c.appLogger.Error("handler" + err.Error())
-					return err
-				}
-				return nil
-			},
-		)
-	}
-
-	if err := router.Run(ctx); err != nil {
-		// это не ошибка коннекта, работать нельзя
-		return err
-	}
-	return nil
+	return c.Run(ctx, publisher, subscriber)
 }
 
 func (c *Client) Disconnect() error {
 	return nil
 }
 
-func (c *Client) Publish(topic string, payload []byte) error {
-	if c.publisher == nil {
-		return ErrPublisherNotReady
+func (c *Client) ListDeadLetters(ctx context.Context, topic string, idle time.Duration) ([]client.DeadLetter, error) {
+	// без consumer group смещения не сохраняются, сообщения читаются с начала при каждом просмотре
+	saramaConfig := kafka.DefaultSaramaSubscriberConfig()
+	saramaConfig.Consumer.Offsets.Initial = sarama.OffsetOldest
+	subscriber, err := c.createSubscriber("", saramaConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer subscriber.Close()
+	return pubsub.ReadDeadLetters(ctx, subscriber, topic, idle, func(client.DeadLetter) error { return nil })
+}
+
+func (c *Client) ReplayDeadLetters(ctx context.Context, topic string, idle time.Duration) ([]client.DeadLetter, error) {
+	publisher, err := c.createPublisher()
+	if err != nil {
+		return nil, err
+	}
+	defer publisher.Close()
+	saramaConfig := kafka.DefaultSaramaSubscriberConfig()
+	saramaConfig.Consumer.Offsets.Initial = sarama.OffsetOldest
+	subscriber, err := c.createSubscriber(replayConsumerGroup, saramaConfig)
+	if err != nil {
+		return nil, err
 	}
-	return c.publisher.Publish(topic, message.NewMessage(
-		uuid.New().String(),
-		payload,
-	))
+	defer subscriber.Close()
+	return pubsub.ReadDeadLetters(ctx, subscriber, topic, idle, pubsub.ReplayTo(publisher))
 }
 
-func (c *Client) Subscribe(topic string, handler client.SubscriberHandlerFunc) {
-	c.handlers[topic] = handler
+// Ping проверяет, что роутер запущен и хотя бы один брокер Kafka отвечает на запрос метаданных.
+func (c *Client) Ping(ctx context.Context) error {
+	if err := c.Client.Ping(ctx); err != nil {
+		return err
+	}
+	config := sarama.NewConfig()
+	if deadline, ok := ctx.Deadline(); ok {
+		config.Net.DialTimeout = time.Until(deadline)
+	}
+	config.Metadata.Retry.Max = 0
+	config.Metadata.Full = false
+	kafkaClient, err := sarama.NewClient(c.brokers, config)
+	if err != nil {
+		return err
+	}
+	return kafkaClient.Close()
 }
 
 func (c *Client) createPublisher() (message.Publisher, error) {
@@ -131,12 +133,13 @@ func (c *Client) createPublisher() (message.Publisher, error) {
 	return kafkaPublisher, nil
 }
 
-func (c *Client) createSubscriber(consumerGroup string) (message.Subscriber, error) {
+func (c *Client) createSubscriber(consumerGroup string, saramaConfig *sarama.Config) (message.Subscriber, error) {
 	kafkaSubscriber, err := kafka.NewSubscriber(
 		kafka.SubscriberConfig{
-			Brokers:       c.brokers,
-			Unmarshaler:   c.marshaler,
-			ConsumerGroup: consumerGroup, // every handler will use a separate consumer group
+			Brokers:               c.brokers,
+			Unmarshaler:           c.marshaler,
+			ConsumerGroup:         consumerGroup, // every handler will use a separate consumer group
+			OverwriteSaramaConfig: saramaConfig,
 		},
 		c.logger,
 	)
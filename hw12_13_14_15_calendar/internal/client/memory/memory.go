@@ -0,0 +1,94 @@
+// Package memory брокер в памяти процесса для тестов и запуска всех сервисов одним процессом.
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"                                     //nolint:depguard
+	"github.com/ThreeDotsLabs/watermill/message"                             //nolint:depguard
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"                    //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/app"           //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/client"        //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/client/pubsub" //nolint:depguard
+)
+
+// Broker передает сообщения подписчикам того же процесса. Сообщения не сохраняются, публикация
+// доступна после Connect, необработанные сообщения хранятся в памяти до повторной отправки.
+type Broker struct {
+	*pubsub.Client
+	pubSub      *gochannel.GoChannel
+	mu          sync.Mutex
+	deadLetters []client.DeadLetter
+}
+
+var (
+	_ client.Broker          = (*Broker)(nil)
+	_ client.DeadLetterQueue = (*Broker)(nil)
+)
+
+func New(retry client.RetryPolicy, appLogger app.Logger) *Broker {
+	logger := watermill.NopLogger{}
+	return &Broker{
+		Client: pubsub.New(retry, logger, appLogger),
+		pubSub: gochannel.NewGoChannel(gochannel.Config{}, logger),
+	}
+}
+
+func (b *Broker) Connect(ctx context.Context) error {
+	for _, topic := range b.Topics() {
+		messages, err := b.pubSub.Subscribe(ctx, client.DeadLetterTopic(topic))
+		if err != nil {
+			return err
+		}
+		go b.collectDeadLetters(messages, topic)
+	}
+	return b.Run(ctx, b.pubSub, b.pubSub)
+}
+
+func (b *Broker) Disconnect() error {
+	return b.pubSub.Close()
+}
+
+func (b *Broker) collectDeadLetters(messages <-chan *message.Message, topic string) {
+	for msg := range messages {
+		b.mu.Lock()
+		b.deadLetters = append(b.deadLetters, pubsub.DeadLetter(msg, topic))
+		b.mu.Unlock()
+		msg.Ack()
+	}
+}
+
+func (b *Broker) ListDeadLetters(_ context.Context, topic string, _ time.Duration) ([]client.DeadLetter, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	result := make([]client.DeadLetter, 0)
+	for _, letter := range b.deadLetters {
+		if letter.Topic == topic {
+			result = append(result, letter)
+		}
+	}
+	return result, nil
+}
+
+func (b *Broker) ReplayDeadLetters(ctx context.Context, topic string, _ time.Duration) ([]client.DeadLetter, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	result := make([]client.DeadLetter, 0)
+	rest := make([]client.DeadLetter, 0, len(b.deadLetters))
+	var err error
+	for _, letter := range b.deadLetters {
+		if letter.Topic != topic || err != nil {
+			rest = append(rest, letter)
+			continue
+		}
+		if err = b.Publish(ctx, letter.Topic, letter.Payload); err != nil {
+			rest = append(rest, letter)
+			continue
+		}
+		result = append(result, letter)
+	}
+	b.deadLetters = rest
+	return result, err
+}
@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/client"        //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/client/pubsub" //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/logger"        //nolint:depguard
+	"github.com/stretchr/testify/require"                                    //nolint:depguard
+)
+
+var errHandler = errors.New("handler error")
+
+func TestBroker(t *testing.T) {
+	broker := New(client.RetryPolicy{MaxRetries: 1, InitialInterval: time.Millisecond, Multiplier: 2},
+		logger.New("INFO", "/tmp/calendar-memory-broker-test.log"))
+	received := make(chan string, 10)
+	fail := make(chan bool, 10)
+	broker.Subscribe("events", func(_ context.Context, raw *[]byte) error {
+		if <-fail {
+			return errHandler
+		}
+		received <- string(*raw)
+		return nil
+	})
+	require.ErrorIs(t, broker.Publish(context.Background(), "events", []byte("early")), pubsub.ErrPublisherNotReady)
+	require.ErrorIs(t, broker.Ping(context.Background()), pubsub.ErrPublisherNotReady)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- broker.Connect(ctx) }()
+	defer func() {
+		cancel()
+		require.NoError(t, <-done)
+		require.NoError(t, broker.Disconnect())
+	}()
+	require.Eventually(t, func() bool { return broker.Ping(ctx) == nil }, time.Second, time.Millisecond*10)
+
+	fail <- false
+	require.NoError(t, broker.Publish(ctx, "events", []byte("1")))
+	require.Equal(t, "1", <-received)
+
+	// обе попытки обработки неудачны, сообщение уходит в очередь необработанных
+	fail <- true
+	fail <- true
+	require.NoError(t, broker.Publish(ctx, "events", []byte("2")))
+	var letters []client.DeadLetter
+	require.Eventually(t, func() bool {
+		letters, _ = broker.ListDeadLetters(ctx, "events", 0)
+		return len(letters) == 1
+	}, time.Second, time.Millisecond*10)
+	require.Equal(t, "events", letters[0].Topic)
+	require.Equal(t, errHandler.Error(), letters[0].Reason)
+
+	fail <- false
+	replayed, err := broker.ReplayDeadLetters(ctx, "events", 0)
+	require.NoError(t, err)
+	require.Len(t, replayed, 1)
+	require.Equal(t, "2", <-received)
+	letters, err = broker.ListDeadLetters(ctx, "events", 0)
+	require.NoError(t, err)
+	require.Empty(t, letters)
+}
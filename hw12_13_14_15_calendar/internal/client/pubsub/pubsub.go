@@ -0,0 +1,199 @@
+// Package pubsub общая часть брокеров на watermill: подписки с повторами обработки и очередью
+// необработанных сообщений. Конкретный брокер создает publisher и subscriber и вызывает Run.
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"                               //nolint:depguard
+	"github.com/ThreeDotsLabs/watermill/message"                       //nolint:depguard
+	"github.com/ThreeDotsLabs/watermill/message/router/middleware"     //nolint:depguard
+	"github.com/ThreeDotsLabs/watermill/message/router/plugin"         //nolint:depguard
+	"github.com/google/uuid"                                           //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/app"     //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/client"  //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/tracing" //nolint:depguard
+)
+
+var ErrPublisherNotReady = errors.New("publisher is not ready")
+
+type Client struct {
+	mu        sync.RWMutex
+	publisher message.Publisher
+	handlers  map[string]client.SubscriberHandlerFunc
+	retry     client.RetryPolicy
+	logger    watermill.LoggerAdapter
+	appLogger app.Logger
+}
+
+func New(retry client.RetryPolicy, logger watermill.LoggerAdapter, appLogger app.Logger) *Client {
+	return &Client{
+		handlers: make(map[string]client.SubscriberHandlerFunc, 0), retry: retry, logger: logger, appLogger: appLogger,
+	}
+}
+
+func (c *Client) Publish(ctx context.Context, topic string, payload []byte) (err error) {
+	msg := message.NewMessage(uuid.New().String(), payload)
+	_, span := tracing.StartPublish(ctx, topic, msg.Metadata)
+	defer func() { tracing.End(span, err) }()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.publisher == nil {
+		return ErrPublisherNotReady
+	}
+	return c.publisher.Publish(topic, msg)
+}
+
+// Ping возвращает ErrPublisherNotReady, пока роутер не запущен.
+func (c *Client) Ping(_ context.Context) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.publisher == nil {
+		return ErrPublisherNotReady
+	}
+	return nil
+}
+
+func (c *Client) Subscribe(topic string, handler client.SubscriberHandlerFunc) {
+	c.handlers[topic] = handler
+}
+
+// Topics возвращает топики подписок.
+func (c *Client) Topics() []string {
+	topics := make([]string, 0, len(c.handlers))
+	for topic := range c.handlers {
+		topics = append(topics, topic)
+	}
+	return topics
+}
+
+// Run обрабатывает сообщения подписок до отмены контекста. Публикация доступна, пока роутер запущен.
+func (c *Client) Run(ctx context.Context, publisher message.Publisher, subscriber message.Subscriber) error {
+	router, err := message.NewRouter(message.RouterConfig{}, c.logger)
+	if err != nil {
+		// это не ошибка коннекта, работать нельзя
+		return err
+	}
+
+	router.AddPlugin(plugin.SignalsHandler)
+
+	for topic, handler := range c.handlers {
+		// сообщение, которое не удалось обработать с повторами, уходит в <topic>.dlq и подтверждается,
+		// иначе оно получалось бы заново бесконечно
+		poisonQueue, err := middleware.PoisonQueue(publisher, client.DeadLetterTopic(topic))
+		if err != nil {
+			return err
+		}
+		h := router.AddNoPublisherHandler(
+			"handler_"+topic, // handler name, must be unique
+			topic,            // topic from which messages should be consumed
+			subscriber,
+			func(msg *message.Message) error {
+				// контекст обработчика отменяется вместе с Run и продолжает трассу отправителя
+				ctx, span := tracing.StartProcess(ctx, topic, msg.Metadata)
+				raw := []byte(msg.Payload)
+				err := handler(ctx, &raw)
+				tracing.End(span, err)
+				if err != nil {
+					c.appLogger.Error("handler " + err.Error())
+					return err
+				}
+				return nil
+			},
+		)
+		h.AddMiddleware(poisonQueue)
+		if c.retry.MaxRetries > 0 {
+			h.AddMiddleware(middleware.Retry{
+				MaxRetries:      c.retry.MaxRetries,
+				InitialInterval: c.retry.InitialInterval,
+				MaxInterval:     c.retry.MaxInterval,
+				Multiplier:      c.retry.Multiplier,
+				Logger:          c.logger,
+			}.Middleware)
+		}
+		h.AddMiddleware(middleware.Recoverer)
+	}
+
+	go func() {
+		select {
+		case <-router.Running():
+			c.setPublisher(publisher)
+		case <-ctx.Done():
+		}
+	}()
+	defer c.setPublisher(nil)
+
+	if err := router.Run(ctx); err != nil {
+		// это не ошибка коннекта, работать нельзя
+		return err
+	}
+	return nil
+}
+
+func (c *Client) setPublisher(publisher message.Publisher) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.publisher = publisher
+}
+
+// ReplayTo возвращает функцию отправки необработанного сообщения в исходный топик.
+func ReplayTo(publisher message.Publisher) func(client.DeadLetter) error {
+	return func(letter client.DeadLetter) error {
+		return publisher.Publish(letter.Topic, message.NewMessage(uuid.New().String(), letter.Payload))
+	}
+}
+
+// ReadDeadLetters передает fn сообщения из <topic>.dlq и подтверждает их. Чтение заканчивается,
+// когда новых сообщений нет в течение idle.
+func ReadDeadLetters(ctx context.Context, subscriber message.Subscriber, topic string, idle time.Duration,
+	fn func(client.DeadLetter) error,
+) ([]client.DeadLetter, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	messages, err := subscriber.Subscribe(ctx, client.DeadLetterTopic(topic))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]client.DeadLetter, 0)
+	timer := time.NewTimer(idle)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return result, ctx.Err()
+		case <-timer.C:
+			return result, nil
+		case msg, ok := <-messages:
+			if !ok {
+				return result, nil
+			}
+			letter := DeadLetter(msg, topic)
+			if err := fn(letter); err != nil {
+				msg.Nack()
+				return result, err
+			}
+			msg.Ack()
+			result = append(result, letter)
+			timer.Reset(idle)
+		}
+	}
+}
+
+// DeadLetter разбирает сообщение из <topic>.dlq.
+func DeadLetter(msg *message.Message, topic string) client.DeadLetter {
+	letter := client.DeadLetter{
+		ID:      msg.UUID,
+		Topic:   msg.Metadata.Get(middleware.PoisonedTopicKey),
+		Reason:  msg.Metadata.Get(middleware.ReasonForPoisonedKey),
+		Payload: msg.Payload,
+	}
+	if letter.Topic == "" {
+		letter.Topic = topic
+	}
+	return letter
+}
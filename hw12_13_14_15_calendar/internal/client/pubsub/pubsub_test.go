@@ -0,0 +1,108 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"                              //nolint:depguard
+	"github.com/ThreeDotsLabs/watermill/message"                      //nolint:depguard
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"             //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/client" //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/logger" //nolint:depguard
+	"github.com/stretchr/testify/require"                             //nolint:depguard
+)
+
+var errHandler = errors.New("handler error")
+
+func newTestClient(retry client.RetryPolicy) (*Client, *gochannel.GoChannel) {
+	pubSub := gochannel.NewGoChannel(gochannel.Config{Persistent: true}, watermill.NopLogger{})
+	return New(retry, watermill.NopLogger{}, logger.New("INFO", "/tmp/calendar-pubsub-test.log")), pubSub
+}
+
+// run запускает обработку подписок и ждет готовности к публикации.
+func run(t *testing.T, c *Client, pubSub *gochannel.GoChannel) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = c.Run(ctx, pubSub, pubSub)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	require.Eventually(t, func() bool {
+		return !errors.Is(c.Publish(context.Background(), "ping", nil), ErrPublisherNotReady)
+	}, time.Second, time.Millisecond*10)
+}
+
+func TestRetry(t *testing.T) {
+	retry := client.RetryPolicy{
+		MaxRetries: 3, InitialInterval: time.Millisecond, MaxInterval: time.Millisecond * 4, Multiplier: 2,
+	}
+
+	t.Run("success after retries", func(t *testing.T) {
+		c, pubSub := newTestClient(retry)
+		handled := make(chan string, 10)
+		calls := 0
+		c.Subscribe("events", func(_ context.Context, raw *[]byte) error {
+			calls++
+			if calls < 3 {
+				return errHandler
+			}
+			handled <- string(*raw)
+			return nil
+		})
+		require.ErrorIs(t, c.Publish(context.Background(), "events", []byte("payload")), ErrPublisherNotReady)
+		run(t, c, pubSub)
+
+		require.NoError(t, c.Publish(context.Background(), "events", []byte("payload")))
+		require.Equal(t, "payload", <-handled)
+		require.Equal(t, 3, calls)
+	})
+
+	t.Run("dead letter", func(t *testing.T) {
+		c, pubSub := newTestClient(retry)
+		calls := 0
+		c.Subscribe("events", func(_ context.Context, _ *[]byte) error {
+			calls++
+			return errHandler
+		})
+		run(t, c, pubSub)
+
+		require.NoError(t, pubSub.Publish("events", message.NewMessage("1", []byte("payload"))))
+		letters, err := ReadDeadLetters(context.Background(), pubSub, "events", time.Millisecond*500,
+			func(client.DeadLetter) error { return nil })
+		require.NoError(t, err)
+		require.Len(t, letters, 1)
+		require.Equal(t, client.DeadLetter{ID: "1", Topic: "events", Reason: errHandler.Error(), Payload: []byte("payload")},
+			letters[0])
+		require.Equal(t, retry.MaxRetries+1, calls)
+	})
+}
+
+func TestReplayDeadLetters(t *testing.T) {
+	pubSub := gochannel.NewGoChannel(gochannel.Config{Persistent: true}, watermill.NopLogger{})
+	for _, id := range []string{"1", "2"} {
+		msg := message.NewMessage(id, []byte("payload "+id))
+		msg.Metadata.Set("topic_poisoned", "events")
+		require.NoError(t, pubSub.Publish(client.DeadLetterTopic("events"), msg))
+	}
+
+	letters, err := ReadDeadLetters(context.Background(), pubSub, "events", time.Millisecond*100, ReplayTo(pubSub))
+	require.NoError(t, err)
+	require.Len(t, letters, 2)
+
+	messages, err := pubSub.Subscribe(context.Background(), "events")
+	require.NoError(t, err)
+	payloads := make([]string, 0, 2)
+	for range letters {
+		msg := <-messages
+		payloads = append(payloads, string(msg.Payload))
+		msg.Ack()
+	}
+	require.ElementsMatch(t, []string{"payload 1", "payload 2"}, payloads)
+}
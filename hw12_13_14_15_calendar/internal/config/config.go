@@ -1,22 +1,24 @@
 package config
 
-import (
-	"log"
-	"os"
-
-	"gopkg.in/yaml.v3"
-)
-
 // При желании конфигурацию можно вынести в internal/config.
 // Организация конфига в main принуждает нас сужать API компонентов, использовать
 // при их конструировании только необходимые параметры, а также уменьшает вероятность циклической зависимости.
 type Config struct {
-	Logger  LoggerConf `yaml:"log"`
-	Server  ServerConf `yaml:"server"`
-	Kafka   KafkaConf  `yaml:"kafka"`
+	Logger LoggerConf `yaml:"log"`
+	Server ServerConf `yaml:"server"`
+	// служебный HTTP-сервер планировщика и хранителя с /metrics, /healthz и /readyz, порт 0 - не запускать
+	Admin  AddrConf   `yaml:"admin"`
+	Broker BrokerConf `yaml:"broker"`
+	Kafka  KafkaConf  `yaml:"kafka"`
+	AMQP   AMQPConf   `yaml:"amqp"`
+	// реализация хранилища: memory, sql (Postgres) или sqlite (db.dsn - путь к файлу БД)
 	Storage string
 	DB      StorageConf
+	Events  EventsConf
 	Timer   TimerConf
+	// доставка уведомлений хранителем
+	Delivery DeliveryConf
+	Tracing  TracingConf `yaml:"tracing"`
 }
 
 type LoggerConf struct {
@@ -26,12 +28,31 @@ type LoggerConf struct {
 
 type ServerConf struct {
 	HTTP AddrConf `yaml:"http"`
+	GRPC AddrConf `yaml:"grpc"`
 }
 
-type KafkaConf struct {
-	Host  string
-	Port  int
+type BrokerConf struct {
+	// kafka (по умолчанию), amqp или memory - брокер в памяти процесса
+	Type  string
 	Topic string
+	Retry RetryConf
+}
+
+type KafkaConf struct {
+	Host string
+	Port int
+}
+
+type AMQPConf struct {
+	URL string `yaml:"url" secret:"true"`
+}
+
+// RetryConf повторы обработки полученного сообщения, интервалы в миллисекундах.
+type RetryConf struct {
+	MaxRetries      int     `yaml:"max_retries"`
+	InitialInterval int     `yaml:"initial_interval"`
+	MaxInterval     int     `yaml:"max_interval"`
+	Multiplier      float64 `yaml:"multiplier"`
 }
 
 type AddrConf struct {
@@ -41,24 +62,58 @@ type AddrConf struct {
 
 type StorageConf struct {
 	Driver string
-	Dsn    string
+	Dsn    string `secret:"true"`
+	// применять новые миграции при подключении к БД, по умолчанию схему обновляет calendar migrate up
+	AutoMigrate bool `yaml:"auto_migrate"`
+}
+
+type EventsConf struct {
+	// разрешить пользователю пересекающиеся по времени события
+	AllowOverlap bool `yaml:"allow_overlap"`
 }
 
 type TimerConf struct {
 	ReminderEvents int `yaml:"reminder_events"`
 	OldEvents      int `yaml:"old_events"`
+	// интервал отправки уведомлений из outbox, по умолчанию 1 секунда
+	Outbox int `yaml:"outbox"`
+}
+
+type DeliveryConf struct {
+	// канал по умолчанию: stdout, email или webhook
+	Channel string
+	// количество попыток доставки, после которого уведомление получает статус failed, 0 - без ограничения
+	MaxAttempts int         `yaml:"max_attempts"`
+	SMTP        SMTPConf    `yaml:"smtp"`
+	Webhook     WebhookConf `yaml:"webhook"`
+	// каналы и адреса отдельных пользователей
+	Users map[int64]RecipientConf
 }
 
-func NewConfig(configFile string) Config {
-	config := Config{Logger: LoggerConf{}}
-	confFile, err := os.ReadFile(configFile)
-	if err != nil {
-		log.Fatal(err.Error())
-	}
+type SMTPConf struct {
+	Host     string
+	Port     int
+	From     string
+	Username string
+	Password string `secret:"true"`
+}
+
+type WebhookConf struct {
+	URL    string `yaml:"url"`
+	Secret string `secret:"true"`
+	// таймаут запроса в секундах
+	Timeout int
+}
+
+// TracingConf экспорт спанов OpenTelemetry.
+type TracingConf struct {
+	// none (по умолчанию), stdout - спаны в JSON или otlp-file - строки JSON в формате OTLP
+	Exporter string
+	// файл для спанов, пустой - stdout
+	File string
+}
 
-	err = yaml.Unmarshal(confFile, &config) //nolint:typecheck
-	if err != nil {
-		log.Fatal(err.Error())
-	}
-	return config
+type RecipientConf struct {
+	Channel string
+	Address string
 }
@@ -0,0 +1,264 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3" //nolint:depguard
+)
+
+// EnvPrefix префикс переменных окружения параметров, например CALENDAR_DB_DSN для db.dsn.
+const EnvPrefix = "CALENDAR_"
+
+var ErrInvalidConfig = errors.New("invalid config")
+
+// FieldError ошибка значения одного параметра.
+type FieldError struct {
+	// путь параметра в файле конфигурации, например db.dsn
+	Field  string
+	Reason string
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Reason
+}
+
+// ValidationError ошибки всех неверных параметров, errors.Is(err, ErrInvalidConfig) для нее истинно.
+type ValidationError struct {
+	Fields []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	reasons := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		reasons = append(reasons, field.Error())
+	}
+	return ErrInvalidConfig.Error() + ": " + strings.Join(reasons, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidConfig //nolint:errorlint
+}
+
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		errs = append(errs, field)
+	}
+	return errs
+}
+
+// Default значения параметров, не заданных ни в одном источнике.
+func Default() Config {
+	return Config{
+		Logger: LoggerConf{Level: "INFO", File: os.DevNull},
+		Server: ServerConf{
+			HTTP: AddrConf{Host: "0.0.0.0", Port: 8080},
+			GRPC: AddrConf{Host: "0.0.0.0", Port: 50051},
+		},
+		Admin:    AddrConf{Host: "0.0.0.0"},
+		Broker:   BrokerConf{Type: "kafka", Topic: "events"},
+		Kafka:    KafkaConf{Host: "localhost", Port: 9092},
+		Storage:  "memory",
+		Timer:    TimerConf{ReminderEvents: 5, OldEvents: 300, Outbox: 1},
+		Delivery: DeliveryConf{Channel: "stdout"},
+		Tracing:  TracingConf{Exporter: "none"},
+	}
+}
+
+// Overrides значения параметров из командной строки по их путям.
+type Overrides map[string]string
+
+// RegisterFlags регистрирует в fs флаг для каждого параметра, имя флага - путь параметра, например -db.dsn.
+func RegisterFlags(fs *flag.FlagSet) Overrides {
+	overrides := make(Overrides)
+	config := Default()
+	walk(reflect.ValueOf(&config).Elem(), "", func(path string, _ reflect.Value) {
+		fs.Func(path, "overrides "+path+" (env "+envName(path)+")", func(value string) error {
+			overrides[path] = value
+			return nil
+		})
+	})
+	return overrides
+}
+
+// Load собирает конфигурацию по слоям: значения по умолчанию, файл configFile (если задан),
+// переменные окружения CALENDAR_* из environ, флаги командной строки. Результат проверяется Validate.
+func Load(configFile string, environ []string, overrides Overrides) (Config, error) {
+	config := Default()
+	if configFile != "" {
+		data, err := os.ReadFile(configFile)
+		if err != nil {
+			return Config{}, fmt.Errorf("read config: %w", err)
+		}
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return Config{}, fmt.Errorf("parse config %v: %w", configFile, err)
+		}
+	}
+
+	env := make(map[string]string)
+	for _, kv := range environ {
+		if name, value, ok := strings.Cut(kv, "="); ok && strings.HasPrefix(name, EnvPrefix) {
+			env[name] = value
+		}
+	}
+	var fieldErrs []*FieldError
+	walk(reflect.ValueOf(&config).Elem(), "", func(path string, field reflect.Value) {
+		if value, ok := env[envName(path)]; ok {
+			if err := setValue(field, value); err != nil {
+				fieldErrs = append(fieldErrs, &FieldError{Field: path, Reason: envName(path) + ": " + err.Error()})
+			}
+		}
+		if value, ok := overrides[path]; ok {
+			if err := setValue(field, value); err != nil {
+				fieldErrs = append(fieldErrs, &FieldError{Field: path, Reason: "-" + path + ": " + err.Error()})
+			}
+		}
+	})
+	if len(fieldErrs) > 0 {
+		return Config{}, &ValidationError{Fields: fieldErrs}
+	}
+	if err := config.Validate(); err != nil {
+		return Config{}, err
+	}
+	return config, nil
+}
+
+// Validate проверяет значения параметров и возвращает *ValidationError со всеми ошибками.
+func (c Config) Validate() error {
+	var fieldErrs []*FieldError
+	invalid := func(field, reason string) {
+		fieldErrs = append(fieldErrs, &FieldError{Field: field, Reason: reason})
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Logger.Level)); err != nil {
+		invalid("log.level", fmt.Sprintf("unknown level %q, expected DEBUG, INFO, WARN or ERROR", c.Logger.Level))
+	}
+	if c.Logger.File == "" {
+		invalid("log.file", "is required")
+	}
+	if (c.Storage == "sql" || c.Storage == "sqlite") && c.DB.Dsn == "" {
+		invalid("db.dsn", "is required for storage "+c.Storage)
+	}
+	intervals := []struct {
+		field string
+		value int
+	}{
+		{"timer.reminder_events", c.Timer.ReminderEvents},
+		{"timer.old_events", c.Timer.OldEvents},
+		{"timer.outbox", c.Timer.Outbox},
+	}
+	for _, interval := range intervals {
+		if interval.value <= 0 {
+			invalid(interval.field, "interval must be positive, got "+strconv.Itoa(interval.value))
+		}
+	}
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout", "otlp-file":
+	default:
+		invalid("tracing.exporter", fmt.Sprintf("unknown exporter %q, expected none, stdout or otlp-file",
+			c.Tracing.Exporter))
+	}
+
+	if len(fieldErrs) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: fieldErrs}
+}
+
+// Change параметр, значение которого изменилось.
+type Change struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// значение параметров с тегом secret:"true" в Diff.
+const maskedValue = "***"
+
+// Diff возвращает параметры, значения которых в prev и next различаются, в порядке полей Config.
+// Значения секретов скрываются, словари вроде delivery.users не сравниваются.
+func Diff(prev, next Config) []Change {
+	prevValues := make(map[string]string)
+	walk(reflect.ValueOf(&prev).Elem(), "", func(path string, field reflect.Value) {
+		prevValues[path] = fmt.Sprint(field.Interface())
+	})
+	var changes []Change
+	walkFields(reflect.ValueOf(&next).Elem(), "", func(path string, field reflect.Value, sf reflect.StructField) {
+		change := Change{Field: path, Old: prevValues[path], New: fmt.Sprint(field.Interface())}
+		if change.Old == change.New {
+			return
+		}
+		if sf.Tag.Get("secret") == "true" {
+			change.Old, change.New = maskedValue, maskedValue
+		}
+		changes = append(changes, change)
+	})
+	return changes
+}
+
+// walk вызывает fn для каждого простого параметра структуры v, путь составляется из ключей yaml.
+// Параметры-словари, например delivery.users, задаются только в файле.
+func walk(v reflect.Value, prefix string, fn func(path string, field reflect.Value)) {
+	walkFields(v, prefix, func(path string, field reflect.Value, _ reflect.StructField) { fn(path, field) })
+}
+
+func walkFields(v reflect.Value, prefix string, fn func(path string, field reflect.Value, sf reflect.StructField)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		path := prefix + fieldKey(t.Field(i))
+		field := v.Field(i)
+		switch field.Kind() { //nolint:exhaustive
+		case reflect.Struct:
+			walkFields(field, path+".", fn)
+		case reflect.String, reflect.Bool, reflect.Int, reflect.Int64, reflect.Float64:
+			fn(path, field, t.Field(i))
+		}
+	}
+}
+
+// fieldKey ключ поля в yaml: имя из тега или имя поля в нижнем регистре, как в gopkg.in/yaml.v3.
+func fieldKey(field reflect.StructField) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("yaml"), ","); name != "" {
+		return name
+	}
+	return strings.ToLower(field.Name)
+}
+
+func envName(path string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+}
+
+func setValue(field reflect.Value, value string) error {
+	switch field.Kind() { //nolint:exhaustive
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid bool %q", value)
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		field.SetFloat(f)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require" //nolint:depguard
+)
+
+const testConfig = `
+log:
+  level: DEBUG
+  file: /tmp/calendar.log
+storage: sql
+db:
+  dsn: postgres://file
+timer:
+  reminder_events: 10
+broker:
+  retry:
+    multiplier: 1.5
+`
+
+func writeConfig(t *testing.T, data string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(file, []byte(data), 0o600))
+	return file
+}
+
+func TestLoadLayers(t *testing.T) {
+	file := writeConfig(t, testConfig)
+
+	t.Run("defaults and file", func(t *testing.T) {
+		config, err := Load(file, nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, "DEBUG", config.Logger.Level)
+		require.Equal(t, "postgres://file", config.DB.Dsn)
+		require.Equal(t, 10, config.Timer.ReminderEvents)
+		require.Equal(t, 300, config.Timer.OldEvents)
+		require.Equal(t, 1.5, config.Broker.Retry.Multiplier)
+		require.Equal(t, 8080, config.Server.HTTP.Port)
+		require.Equal(t, "kafka", config.Broker.Type)
+	})
+
+	t.Run("env overrides file", func(t *testing.T) {
+		config, err := Load(file, []string{
+			"CALENDAR_DB_DSN=postgres://env",
+			"CALENDAR_DB_AUTO_MIGRATE=true",
+			"CALENDAR_SERVER_HTTP_PORT=9090",
+			"CALENDAR_BROKER_RETRY_MAX_RETRIES=7",
+			"DB_DSN=postgres://ignored",
+		}, nil)
+		require.NoError(t, err)
+		require.Equal(t, "postgres://env", config.DB.Dsn)
+		require.True(t, config.DB.AutoMigrate)
+		require.Equal(t, 9090, config.Server.HTTP.Port)
+		require.Equal(t, 7, config.Broker.Retry.MaxRetries)
+		require.Equal(t, "DEBUG", config.Logger.Level)
+	})
+
+	t.Run("flags override env", func(t *testing.T) {
+		fs := flag.NewFlagSet("calendar", flag.ContinueOnError)
+		overrides := RegisterFlags(fs)
+		require.NoError(t, fs.Parse([]string{"-db.dsn", "postgres://flag", "-timer.outbox=3", "all"}))
+		require.Equal(t, []string{"all"}, fs.Args())
+
+		config, err := Load(file, []string{"CALENDAR_DB_DSN=postgres://env", "CALENDAR_TIMER_OUTBOX=2"}, overrides)
+		require.NoError(t, err)
+		require.Equal(t, "postgres://flag", config.DB.Dsn)
+		require.Equal(t, 3, config.Timer.Outbox)
+	})
+
+	t.Run("without file", func(t *testing.T) {
+		config, err := Load("", []string{"CALENDAR_STORAGE=sqlite", "CALENDAR_DB_DSN=calendar.db"}, nil)
+		require.NoError(t, err)
+		require.Equal(t, "sqlite", config.Storage)
+		require.Equal(t, "INFO", config.Logger.Level)
+	})
+}
+
+func TestLoadErrors(t *testing.T) {
+	t.Run("missing file", func(t *testing.T) {
+		_, err := Load(filepath.Join(t.TempDir(), "missing.yml"), nil, nil)
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("bad yaml", func(t *testing.T) {
+		_, err := Load(writeConfig(t, "log: ["), nil, nil)
+		require.Error(t, err)
+		require.NotErrorIs(t, err, ErrInvalidConfig)
+	})
+
+	tests := []struct {
+		name    string
+		environ []string
+		fields  []string
+	}{
+		{
+			name:    "missing dsn",
+			environ: []string{"CALENDAR_STORAGE=sql"},
+			fields:  []string{"db.dsn"},
+		},
+		{
+			name:    "zero intervals",
+			environ: []string{"CALENDAR_TIMER_REMINDER_EVENTS=0", "CALENDAR_TIMER_OUTBOX=-1"},
+			fields:  []string{"timer.reminder_events", "timer.outbox"},
+		},
+		{
+			name:    "bad log level",
+			environ: []string{"CALENDAR_LOG_LEVEL=LOUD"},
+			fields:  []string{"log.level"},
+		},
+		{
+			name:    "unknown trace exporter",
+			environ: []string{"CALENDAR_TRACING_EXPORTER=jaeger"},
+			fields:  []string{"tracing.exporter"},
+		},
+		{
+			name:    "bad env values",
+			environ: []string{"CALENDAR_SERVER_GRPC_PORT=grpc", "CALENDAR_EVENTS_ALLOW_OVERLAP=maybe"},
+			fields:  []string{"server.grpc.port", "events.allow_overlap"},
+		},
+		{
+			name:    "all errors at once",
+			environ: []string{"CALENDAR_STORAGE=sqlite", "CALENDAR_LOG_LEVEL=", "CALENDAR_TIMER_OLD_EVENTS=0"},
+			fields:  []string{"log.level", "db.dsn", "timer.old_events"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Load("", tc.environ, nil)
+			require.ErrorIs(t, err, ErrInvalidConfig)
+
+			var validationErr *ValidationError
+			require.True(t, errors.As(err, &validationErr))
+			fields := make([]string, 0, len(validationErr.Fields))
+			for _, field := range validationErr.Fields {
+				fields = append(fields, field.Field)
+			}
+			require.Equal(t, tc.fields, fields)
+
+			var fieldErr *FieldError
+			require.True(t, errors.As(err, &fieldErr))
+			require.Equal(t, tc.fields[0], fieldErr.Field)
+		})
+	}
+}
+
+func TestConfigFiles(t *testing.T) {
+	for _, name := range []string{"calendar_config.yml", "scheduler_config.yml", "storer_config.yml"} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Load(filepath.Join("..", "..", "configs", name), nil, nil)
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// Package delivery доставляет уведомления о событиях пользователям по разным каналам.
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage" //nolint:depguard
+)
+
+// каналы доставки.
+const (
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+	ChannelStdout  = "stdout"
+)
+
+var (
+	ErrUnknownChannel = errors.New("unknown delivery channel")
+	ErrDelivery       = errors.New("delivery failed")
+	ErrNoAddress      = errors.New("recipient address is not set")
+)
+
+// Sender отправляет уведомление по одному каналу. address - адрес получателя в канале,
+// пустой адрес - адрес канала по умолчанию.
+type Sender interface {
+	Send(ctx context.Context, address string, notification storage.Notification) error
+}
+
+// Recipient настройки доставки конкретному пользователю.
+type Recipient struct {
+	Channel string
+	Address string
+}
+
+// Store хранилище состояния доставки уведомлений.
+type Store interface {
+	GetNotificationDelivery(ctx context.Context, id string) (*storage.NotificationDelivery, error)
+	UpdateNotificationDelivery(ctx context.Context, id string, status storage.DeliveryStatus,
+		channel string, lastError string) error
+}
+
+// Dispatcher выбирает канал доставки по пользователю и ведет состояние доставки в хранилище.
+type Dispatcher struct {
+	store          Store
+	senders        map[string]Sender
+	defaultChannel string
+	recipients     map[int64]Recipient
+	maxAttempts    int
+}
+
+func NewDispatcher(store Store, defaultChannel string, recipients map[int64]Recipient, maxAttempts int) *Dispatcher {
+	return &Dispatcher{
+		store: store, senders: make(map[string]Sender), defaultChannel: defaultChannel, recipients: recipients,
+		maxAttempts: maxAttempts,
+	}
+}
+
+func (d *Dispatcher) Register(channel string, sender Sender) {
+	d.senders[channel] = sender
+}
+
+// Deliver доставляет сохраненное уведомление, если оно еще не доставлено. Ошибка возвращается, только если
+// доставку нужно повторить; после maxAttempts неудачных попыток уведомление получает статус failed.
+func (d *Dispatcher) Deliver(ctx context.Context, notification storage.Notification) error {
+	delivery, err := d.store.GetNotificationDelivery(ctx, notification.ID)
+	if err != nil {
+		return err
+	}
+	if delivery.Status != storage.DeliveryPending {
+		// повторное получение уже доставленного уведомления
+		return nil
+	}
+
+	recipient := d.recipient(notification)
+	status, lastError := storage.DeliverySent, ""
+	sendErr := d.send(ctx, recipient, notification)
+	if sendErr != nil {
+		status, lastError = storage.DeliveryPending, sendErr.Error()
+		if d.maxAttempts > 0 && delivery.Attempts+1 >= d.maxAttempts {
+			status = storage.DeliveryFailed
+		}
+	}
+	err = d.store.UpdateNotificationDelivery(ctx, notification.ID, status, recipient.Channel, lastError)
+	if err != nil {
+		return err
+	}
+	if status == storage.DeliveryPending {
+		return sendErr
+	}
+	return nil
+}
+
+// recipient выбирает канал доставки: канал напоминания, иначе канал пользователя, иначе канал по умолчанию.
+// Адрес пользователя используется, только если он задан для выбранного канала.
+func (d *Dispatcher) recipient(notification storage.Notification) Recipient {
+	recipient, ok := d.recipients[notification.UserID]
+	if notification.Channel != "" {
+		if recipient.Channel != notification.Channel {
+			recipient.Address = ""
+		}
+		recipient.Channel = notification.Channel
+		return recipient
+	}
+	if !ok || recipient.Channel == "" {
+		recipient.Channel = d.defaultChannel
+	}
+	return recipient
+}
+
+func (d *Dispatcher) send(ctx context.Context, recipient Recipient, notification storage.Notification) error {
+	sender, ok := d.senders[recipient.Channel]
+	if !ok {
+		return fmt.Errorf("%w: %v", ErrUnknownChannel, recipient.Channel)
+	}
+	if err := sender.Send(ctx, recipient.Address, notification); err != nil {
+		return fmt.Errorf("%w: %v %v", ErrDelivery, recipient.Channel, err) //nolint:errorlint
+	}
+	return nil
+}
+
+// subject тема уведомления по его поводу.
+func subject(notification storage.Notification) string {
+	switch notification.Kind {
+	case storage.NotificationInvitation:
+		return "Invitation: " + notification.Title
+	case storage.NotificationRSVP:
+		return "RSVP: " + notification.Title
+	case storage.NotificationUninvited:
+		return "Invitation cancelled: " + notification.Title
+	case storage.NotificationReminder:
+	}
+	return "Reminder: " + notification.Title
+}
+
+// change описание изменения участника события, для напоминания пустое.
+func change(notification storage.Notification) string {
+	switch notification.Kind {
+	case storage.NotificationInvitation:
+		return "you are invited"
+	case storage.NotificationRSVP:
+		if notification.Attendee != nil {
+			return fmt.Sprintf("user %v responded %v", notification.Attendee.UserID, notification.Attendee.Status)
+		}
+	case storage.NotificationUninvited:
+		return "invitation is cancelled"
+	case storage.NotificationReminder:
+	}
+	return ""
+}
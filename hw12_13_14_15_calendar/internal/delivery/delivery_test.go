@@ -0,0 +1,174 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage"                      //nolint:depguard
+	memorystorage "github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage/memory" //nolint:depguard
+	"github.com/stretchr/testify/require"                                                   //nolint:depguard
+)
+
+var errTest = errors.New("test error")
+
+type testSender struct {
+	addresses []string
+	err       error
+}
+
+func (s *testSender) Send(_ context.Context, address string, _ storage.Notification) error {
+	s.addresses = append(s.addresses, address)
+	return s.err
+}
+
+func newNotification(t *testing.T, repo *memorystorage.Storage, id string, userID int64) storage.Notification {
+	t.Helper()
+	n := storage.Notification{
+		ID: id, Title: "event " + id, StartTime: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), UserID: userID,
+	}
+	require.NoError(t, repo.SaveNotification(context.Background(), n))
+	return n
+}
+
+func TestDispatcher(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("sent", func(t *testing.T) {
+		repo := memorystorage.New(false)
+		out := bytes.Buffer{}
+		d := NewDispatcher(repo, ChannelStdout, nil, 3)
+		d.Register(ChannelStdout, NewStdoutSender(&out))
+
+		n := newNotification(t, repo, "1", 1)
+		require.NoError(t, d.Deliver(ctx, n))
+		require.Contains(t, out.String(), `notification 1 for user 1: "event 1"`)
+		delivery, err := repo.GetNotificationDelivery(ctx, n.ID)
+		require.NoError(t, err)
+		require.Equal(t, storage.NotificationDelivery{Status: storage.DeliverySent, Channel: ChannelStdout, Attempts: 1},
+			*delivery)
+
+		// повторно полученное уведомление не отправляется
+		out.Reset()
+		require.NoError(t, d.Deliver(ctx, n))
+		require.Empty(t, out.String())
+	})
+
+	t.Run("per user channel", func(t *testing.T) {
+		repo := memorystorage.New(false)
+		stdout, webhook := &testSender{}, &testSender{}
+		d := NewDispatcher(repo, ChannelStdout, map[int64]Recipient{2: {Channel: ChannelWebhook, Address: "http://u2"}}, 3)
+		d.Register(ChannelStdout, stdout)
+		d.Register(ChannelWebhook, webhook)
+
+		require.NoError(t, d.Deliver(ctx, newNotification(t, repo, "1", 1)))
+		require.NoError(t, d.Deliver(ctx, newNotification(t, repo, "2", 2)))
+		require.Equal(t, []string{""}, stdout.addresses)
+		require.Equal(t, []string{"http://u2"}, webhook.addresses)
+		delivery, err := repo.GetNotificationDelivery(ctx, "2")
+		require.NoError(t, err)
+		require.Equal(t, ChannelWebhook, delivery.Channel)
+	})
+
+	t.Run("reminder channel", func(t *testing.T) {
+		repo := memorystorage.New(false)
+		stdout, webhook := &testSender{}, &testSender{}
+		d := NewDispatcher(repo, ChannelStdout, map[int64]Recipient{2: {Channel: ChannelWebhook, Address: "http://u2"}}, 3)
+		d.Register(ChannelStdout, stdout)
+		d.Register(ChannelWebhook, webhook)
+
+		// канал напоминания важнее канала пользователя, адрес пользователя берется только для своего канала
+		n := newNotification(t, repo, "1", 2)
+		n.Channel = ChannelStdout
+		require.NoError(t, d.Deliver(ctx, n))
+		n = newNotification(t, repo, "2", 2)
+		n.Channel = ChannelWebhook
+		require.NoError(t, d.Deliver(ctx, n))
+		n = newNotification(t, repo, "3", 1)
+		n.Channel = ChannelWebhook
+		require.NoError(t, d.Deliver(ctx, n))
+		require.Equal(t, []string{""}, stdout.addresses)
+		require.Equal(t, []string{"http://u2", ""}, webhook.addresses)
+		delivery, err := repo.GetNotificationDelivery(ctx, "1")
+		require.NoError(t, err)
+		require.Equal(t, ChannelStdout, delivery.Channel)
+	})
+
+	t.Run("retry then failed", func(t *testing.T) {
+		repo := memorystorage.New(false)
+		sender := &testSender{err: errTest}
+		d := NewDispatcher(repo, ChannelEmail, nil, 2)
+		d.Register(ChannelEmail, sender)
+		n := newNotification(t, repo, "1", 1)
+
+		err := d.Deliver(ctx, n)
+		require.ErrorIs(t, err, ErrDelivery)
+		delivery, err := repo.GetNotificationDelivery(ctx, n.ID)
+		require.NoError(t, err)
+		require.Equal(t, storage.DeliveryPending, delivery.Status)
+		require.Equal(t, 1, delivery.Attempts)
+		require.Contains(t, delivery.LastError, errTest.Error())
+
+		// последняя попытка: ошибка больше не возвращается, повторять нечего
+		require.NoError(t, d.Deliver(ctx, n))
+		delivery, err = repo.GetNotificationDelivery(ctx, n.ID)
+		require.NoError(t, err)
+		require.Equal(t, storage.DeliveryFailed, delivery.Status)
+		require.Equal(t, 2, delivery.Attempts)
+
+		require.NoError(t, d.Deliver(ctx, n))
+		require.Len(t, sender.addresses, 2)
+	})
+
+	t.Run("unknown channel", func(t *testing.T) {
+		repo := memorystorage.New(false)
+		d := NewDispatcher(repo, "sms", nil, 0)
+		err := d.Deliver(ctx, newNotification(t, repo, "1", 1))
+		require.ErrorIs(t, err, ErrUnknownChannel)
+	})
+
+	t.Run("not saved", func(t *testing.T) {
+		d := NewDispatcher(memorystorage.New(false), ChannelStdout, nil, 0)
+		err := d.Deliver(ctx, storage.Notification{ID: "1"})
+		require.ErrorIs(t, err, storage.ErrNotificationNotFound)
+	})
+}
+
+func TestStdoutSender(t *testing.T) {
+	startTime := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	attendee := &storage.Attendee{UserID: 2, Status: storage.RSVPDeclined}
+	tests := []struct {
+		kind     storage.NotificationKind
+		expected string
+	}{
+		{kind: "", expected: `notification 1 for user 1: "event" starts at 2024-05-01T10:00:00Z` + "\n"},
+		{
+			kind:     storage.NotificationReminder,
+			expected: `notification 1 for user 1: "event" starts at 2024-05-01T10:00:00Z` + "\n",
+		},
+		{
+			kind:     storage.NotificationInvitation,
+			expected: `notification 1 for user 1: "event" starts at 2024-05-01T10:00:00Z: you are invited` + "\n",
+		},
+		{
+			kind:     storage.NotificationRSVP,
+			expected: `notification 1 for user 1: "event" starts at 2024-05-01T10:00:00Z: user 2 responded declined` + "\n",
+		},
+		{
+			kind:     storage.NotificationUninvited,
+			expected: `notification 1 for user 1: "event" starts at 2024-05-01T10:00:00Z: invitation is cancelled` + "\n",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(string(tc.kind), func(t *testing.T) {
+			var out bytes.Buffer
+			err := NewStdoutSender(&out).Send(context.Background(), "", storage.Notification{
+				ID: "1", Kind: tc.kind, Attendee: attendee, Title: "event", StartTime: startTime, UserID: 1,
+			})
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, out.String())
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage" //nolint:depguard
+)
+
+// SMTPSender отправляет уведомления письмом. Адрес получателя - email.
+type SMTPSender struct {
+	addr     string
+	host     string
+	from     string
+	username string
+	password string
+}
+
+func NewSMTPSender(host string, port int, from, username, password string) *SMTPSender {
+	return &SMTPSender{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)), host: host, from: from, username: username, password: password,
+	}
+}
+
+func (s *SMTPSender) Send(ctx context.Context, address string, notification storage.Notification) error {
+	if address == "" {
+		return ErrNoAddress
+	}
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	// как в smtp.SendMail: шифрование, если сервер его поддерживает, и авторизация, если она задана
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.host, MinVersion: tls.VersionTLS12}); err != nil {
+			return err
+		}
+	}
+	if s.username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(s.from); err != nil {
+		return err
+	}
+	if err := c.Rcpt(address); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(s.message(address, notification)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func (s *SMTPSender) message(address string, notification storage.Notification) []byte {
+	buf := bytes.Buffer{}
+	fmt.Fprintf(&buf, "From: %v\r\n", s.from)
+	fmt.Fprintf(&buf, "To: %v\r\n", address)
+	fmt.Fprintf(&buf, "Subject: %v\r\n", mime.QEncoding.Encode("utf-8", subject(notification)))
+	fmt.Fprintf(&buf, "Date: %v\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%v@calendar>\r\n", notification.ID)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	fmt.Fprintf(&buf, "%v starts at %v.\r\n", notification.Title, notification.StartTime.Format(time.RFC1123Z))
+	if change := change(notification); change != "" {
+		fmt.Fprintf(&buf, "%v: %v.\r\n", notification.Title, change)
+	}
+	return buf.Bytes()
+}
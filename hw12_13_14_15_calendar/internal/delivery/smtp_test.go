@@ -0,0 +1,95 @@
+package delivery
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage" //nolint:depguard
+	"github.com/stretchr/testify/require"                              //nolint:depguard
+)
+
+// mail письмо, принятое тестовым SMTP сервером.
+type mail struct {
+	from string
+	to   []string
+	data string
+}
+
+// fakeSMTPServer принимает одно соединение и отвечает на минимальный набор команд SMTP.
+func fakeSMTPServer(t *testing.T) (string, int, <-chan mail) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	mails := make(chan mail, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tp := textproto.NewConn(conn)
+		received := mail{}
+		_ = tp.PrintfLine("220 localhost fake SMTP")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch command {
+			case "EHLO", "HELO":
+				_ = tp.PrintfLine("250 localhost")
+			case "MAIL":
+				received.from = line
+				_ = tp.PrintfLine("250 OK")
+			case "RCPT":
+				received.to = append(received.to, line)
+				_ = tp.PrintfLine("250 OK")
+			case "DATA":
+				_ = tp.PrintfLine("354 end data with <CR><LF>.<CR><LF>")
+				data, err := tp.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				received.data = string(data)
+				_ = tp.PrintfLine("250 OK")
+			case "QUIT":
+				_ = tp.PrintfLine("221 bye")
+				mails <- received
+				return
+			default:
+				_ = tp.PrintfLine("502 not implemented")
+			}
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, mails
+}
+
+func TestSMTPSender(t *testing.T) {
+	host, port, mails := fakeSMTPServer(t)
+	n := storage.Notification{
+		ID: "1", Title: "Встреча", StartTime: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), UserID: 1,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sender := NewSMTPSender(host, port, "calendar@example.com", "", "")
+	require.NoError(t, sender.Send(ctx, "user@example.com", n))
+
+	received := <-mails
+	require.Equal(t, "MAIL FROM:<calendar@example.com>", received.from)
+	require.Equal(t, []string{"RCPT TO:<user@example.com>"}, received.to)
+	require.Contains(t, received.data, "To: user@example.com\n")
+	require.Contains(t, received.data, "Subject: =?utf-8?q?Reminder:_=D0=92=D1=81=D1=82=D1=80=D0=B5=D1=87=D0=B0?=\n")
+	require.Contains(t, received.data, "Встреча starts at Wed, 01 May 2024 10:00:00 +0000.")
+
+	require.ErrorIs(t, sender.Send(ctx, "", n), ErrNoAddress)
+}
@@ -0,0 +1,33 @@
+package delivery
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage" //nolint:depguard
+)
+
+// StdoutSender выводит уведомления строками в writer, обычно os.Stdout.
+type StdoutSender struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewStdoutSender(w io.Writer) *StdoutSender {
+	return &StdoutSender{w: w}
+}
+
+func (s *StdoutSender) Send(_ context.Context, _ string, notification storage.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	line := fmt.Sprintf("notification %v for user %v: %q starts at %v", notification.ID,
+		notification.UserID, notification.Title, notification.StartTime.Format(time.RFC3339))
+	if change := change(notification); change != "" {
+		line += ": " + change
+	}
+	_, err := fmt.Fprintln(s.w, line)
+	return err
+}
@@ -0,0 +1,83 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage" //nolint:depguard
+)
+
+// заголовки запроса webhook.
+const (
+	SignatureHeader = "X-Calendar-Signature"
+	TimestampHeader = "X-Calendar-Timestamp"
+)
+
+// WebhookSender отправляет уведомление POST запросом с JSON телом. Адрес получателя - URL,
+// пустой адрес - URL по умолчанию. Запрос подписывается HMAC-SHA256 от "<timestamp>.<тело>"
+// с общим секретом, получатель проверяет подпись и свежесть timestamp.
+type WebhookSender struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+func NewWebhookSender(url, secret string, timeout time.Duration) *WebhookSender {
+	return &WebhookSender{url: url, secret: []byte(secret), client: &http.Client{Timeout: timeout}}
+}
+
+func (s *WebhookSender) Send(ctx context.Context, address string, notification storage.Notification) error {
+	url := address
+	if url == "" {
+		url = s.url
+	}
+	if url == "" {
+		return ErrNoAddress
+	}
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(s.secret, timestamp, body))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %v responded with status %v", url, resp.Status)
+	}
+	return nil
+}
+
+// Sign возвращает значение заголовка подписи в виде sha256=<hex>.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify проверяет подпись запроса на стороне получателя.
+func Verify(secret []byte, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
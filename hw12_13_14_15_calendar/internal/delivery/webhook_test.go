@@ -0,0 +1,45 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage" //nolint:depguard
+	"github.com/stretchr/testify/require"                              //nolint:depguard
+)
+
+func TestWebhookSender(t *testing.T) {
+	secret := []byte("secret")
+	n := storage.Notification{ID: "1", Title: "event", StartTime: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), UserID: 1}
+
+	var received storage.Notification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil || !Verify(secret, r.Header.Get(TimestampHeader), body, r.Header.Get(SignatureHeader)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if err := json.Unmarshal(body, &received); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	require.NoError(t, NewWebhookSender(server.URL, string(secret), time.Second).Send(ctx, "", n))
+	require.Equal(t, n, received)
+
+	// подпись другим секретом не принимается
+	err := NewWebhookSender(server.URL, "wrong", time.Second).Send(ctx, "", n)
+	require.ErrorContains(t, err, "401")
+
+	err = NewWebhookSender("", "", time.Second).Send(ctx, "", n)
+	require.ErrorIs(t, err, ErrNoAddress)
+}
@@ -0,0 +1,150 @@
+// Package freebusy вычисляет занятое и свободное время пользователей по их событиям
+// и подбирает общее свободное время для встречи.
+package freebusy
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage" //nolint:depguard
+)
+
+// Interval полуинтервал времени [Start, Stop).
+type Interval struct {
+	Start time.Time
+	Stop  time.Time
+}
+
+func (i Interval) empty() bool {
+	return !i.Start.Before(i.Stop)
+}
+
+// Merge упорядочивает интервалы и объединяет пересекающиеся и смежные, пустые интервалы отбрасываются.
+func Merge(intervals []Interval) []Interval {
+	sorted := make([]Interval, 0, len(intervals))
+	for _, interval := range intervals {
+		if !interval.empty() {
+			sorted = append(sorted, interval)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start.Before(sorted[j].Start) })
+
+	result := make([]Interval, 0, len(sorted))
+	for _, interval := range sorted {
+		if last := len(result) - 1; last >= 0 && !interval.Start.After(result[last].Stop) {
+			if interval.Stop.After(result[last].Stop) {
+				result[last].Stop = interval.Stop
+			}
+			continue
+		}
+		result = append(result, interval)
+	}
+	return result
+}
+
+// Busy занятое время пользователя userID внутри [from, to) по его событиям и событиям, на которые он приглашен.
+// События, от приглашения на которые пользователь отказался, время не занимают.
+func Busy(events []*storage.Event, userID int64, from, to time.Time) []Interval {
+	intervals := make([]Interval, 0, len(events))
+	for _, event := range events {
+		if attendee, ok := event.Attendee(userID); ok && attendee.Status == storage.RSVPDeclined {
+			continue
+		}
+		intervals = append(intervals, clip(Interval{Start: event.StartTime, Stop: event.StopTime}, from, to))
+	}
+	return Merge(intervals)
+}
+
+// Free свободное время внутри [from, to) при занятых интервалах busy, результат Merge.
+func Free(busy []Interval, from, to time.Time) []Interval {
+	result := make([]Interval, 0, len(busy)+1)
+	start := from
+	for _, interval := range busy {
+		interval = clip(interval, from, to)
+		if interval.empty() {
+			continue
+		}
+		if start.Before(interval.Start) {
+			result = append(result, Interval{Start: start, Stop: interval.Start})
+		}
+		if interval.Stop.After(start) {
+			start = interval.Stop
+		}
+	}
+	if start.Before(to) {
+		result = append(result, Interval{Start: start, Stop: to})
+	}
+	return result
+}
+
+func clip(interval Interval, from, to time.Time) Interval {
+	if interval.Start.Before(from) {
+		interval.Start = from
+	}
+	if interval.Stop.After(to) {
+		interval.Stop = to
+	}
+	return interval
+}
+
+// WorkingHours рабочее время: ежедневный интервал от полуночи в часовом поясе Location.
+type WorkingHours struct {
+	Start time.Duration
+	Stop  time.Duration
+	// рабочие ли суббота и воскресенье
+	Weekends bool
+	Location *time.Location
+}
+
+func (h WorkingHours) Validate() error {
+	if h.Start < 0 || h.Stop > 24*time.Hour || h.Start >= h.Stop {
+		return fmt.Errorf("%w: working hours %v-%v", storage.ErrInvalidArgiments, h.Start, h.Stop)
+	}
+	return nil
+}
+
+// Windows интервалы рабочего времени внутри [from, to) по дням.
+func (h WorkingHours) Windows(from, to time.Time) []Interval {
+	location := h.Location
+	if location == nil {
+		location = time.UTC
+	}
+	result := make([]Interval, 0)
+	year, month, day := from.In(location).Date()
+	for ; ; day++ {
+		// time.Date нормализует день и смещение от полуночи по местному времени, в том числе в дни перевода часов
+		start := time.Date(year, month, day, 0, 0, 0, int(h.Start), location)
+		if !start.Before(to) {
+			return result
+		}
+		if weekday := start.Weekday(); !h.Weekends && (weekday == time.Saturday || weekday == time.Sunday) {
+			continue
+		}
+		window := clip(Interval{Start: start, Stop: time.Date(year, month, day, 0, 0, 0, int(h.Stop), location)}, from, to)
+		if !window.empty() {
+			result = append(result, window)
+		}
+	}
+}
+
+// Slots подбирает до limit идущих подряд интервалов длительностью duration внутри окон windows,
+// не пересекающихся с занятым временем busy.
+func Slots(busy []Interval, windows []Interval, duration time.Duration, limit int) []Interval {
+	result := make([]Interval, 0)
+	if duration <= 0 {
+		return result
+	}
+	busy = Merge(busy)
+	for _, window := range windows {
+		for _, free := range Free(busy, window.Start, window.Stop) {
+			for start := free.Start; !start.Add(duration).After(free.Stop); start = start.Add(duration) {
+				if len(result) == limit {
+					return result
+				}
+				result = append(result, Interval{Start: start, Stop: start.Add(duration)})
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,140 @@
+package freebusy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage" //nolint:depguard
+	"github.com/stretchr/testify/require"                              //nolint:depguard
+)
+
+// at время 2 января 2025 года, четверг.
+func at(hour, minute int) time.Time {
+	return time.Date(2025, 1, 2, hour, minute, 0, 0, time.UTC)
+}
+
+func interval(startHour, startMinute, stopHour, stopMinute int) Interval {
+	return Interval{Start: at(startHour, startMinute), Stop: at(stopHour, stopMinute)}
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name      string
+		intervals []Interval
+		expected  []Interval
+	}{
+		{name: "empty", intervals: nil, expected: []Interval{}},
+		{
+			name:      "disjoint unordered",
+			intervals: []Interval{interval(12, 0, 13, 0), interval(10, 0, 11, 0)},
+			expected:  []Interval{interval(10, 0, 11, 0), interval(12, 0, 13, 0)},
+		},
+		{
+			name:      "overlapping",
+			intervals: []Interval{interval(10, 0, 11, 0), interval(10, 30, 12, 0), interval(11, 30, 11, 45)},
+			expected:  []Interval{interval(10, 0, 12, 0)},
+		},
+		{
+			name:      "adjacent",
+			intervals: []Interval{interval(10, 0, 11, 0), interval(11, 0, 12, 0)},
+			expected:  []Interval{interval(10, 0, 12, 0)},
+		},
+		{
+			name:      "nested",
+			intervals: []Interval{interval(10, 0, 14, 0), interval(11, 0, 12, 0), interval(13, 0, 15, 0)},
+			expected:  []Interval{interval(10, 0, 15, 0)},
+		},
+		{
+			name:      "empty intervals are dropped",
+			intervals: []Interval{interval(10, 0, 10, 0), interval(12, 0, 11, 0), interval(13, 0, 14, 0)},
+			expected:  []Interval{interval(13, 0, 14, 0)},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, Merge(tc.intervals))
+		})
+	}
+}
+
+func TestFree(t *testing.T) {
+	tests := []struct {
+		name     string
+		busy     []Interval
+		expected []Interval
+	}{
+		{name: "all free", busy: nil, expected: []Interval{interval(9, 0, 18, 0)}},
+		{
+			name:     "gaps",
+			busy:     []Interval{interval(10, 0, 11, 0), interval(12, 0, 13, 0)},
+			expected: []Interval{interval(9, 0, 10, 0), interval(11, 0, 12, 0), interval(13, 0, 18, 0)},
+		},
+		{
+			name:     "busy outside range is clipped",
+			busy:     []Interval{interval(8, 0, 9, 30), interval(17, 0, 19, 0)},
+			expected: []Interval{interval(9, 30, 17, 0)},
+		},
+		{name: "all busy", busy: []Interval{interval(8, 0, 19, 0)}, expected: []Interval{}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, Free(tc.busy, at(9, 0), at(18, 0)))
+		})
+	}
+}
+
+func TestBusy(t *testing.T) {
+	events := []*storage.Event{
+		{StartTime: at(10, 0), StopTime: at(11, 0), UserID: 1},
+		{StartTime: at(10, 30), StopTime: at(12, 0), UserID: 2, Attendees: []storage.Attendee{
+			{UserID: 1, Status: storage.RSVPAccepted},
+		}},
+		{StartTime: at(14, 0), StopTime: at(15, 0), UserID: 2, Attendees: []storage.Attendee{
+			{UserID: 1, Status: storage.RSVPDeclined},
+		}},
+		{StartTime: at(17, 0), StopTime: at(20, 0), UserID: 1},
+		{StartTime: at(16, 0), StopTime: at(16, 0), UserID: 1},
+	}
+	require.Equal(t, []Interval{interval(10, 0, 12, 0), interval(17, 0, 18, 0)}, Busy(events, 1, at(9, 0), at(18, 0)))
+}
+
+func TestWindows(t *testing.T) {
+	hours := WorkingHours{Start: 9 * time.Hour, Stop: 18 * time.Hour}
+	// с четверга 12:00 по вторник 10:00, выходные пропускаются
+	windows := hours.Windows(at(12, 0), time.Date(2025, 1, 7, 10, 0, 0, 0, time.UTC))
+	require.Equal(t, []Interval{
+		interval(12, 0, 18, 0),
+		{Start: time.Date(2025, 1, 3, 9, 0, 0, 0, time.UTC), Stop: time.Date(2025, 1, 3, 18, 0, 0, 0, time.UTC)},
+		{Start: time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC), Stop: time.Date(2025, 1, 6, 18, 0, 0, 0, time.UTC)},
+		{Start: time.Date(2025, 1, 7, 9, 0, 0, 0, time.UTC), Stop: time.Date(2025, 1, 7, 10, 0, 0, 0, time.UTC)},
+	}, windows)
+
+	hours.Weekends = true
+	require.Len(t, hours.Windows(at(0, 0), time.Date(2025, 1, 9, 0, 0, 0, 0, time.UTC)), 7)
+
+	// рабочий день задается по местному времени
+	moscow := time.FixedZone("MSK", 3*60*60)
+	hours.Location = moscow
+	windows = hours.Windows(at(0, 0), at(23, 0))
+	require.Len(t, windows, 1)
+	require.Equal(t, interval(6, 0, 15, 0), Interval{Start: windows[0].Start.UTC(), Stop: windows[0].Stop.UTC()})
+
+	require.NoError(t, WorkingHours{Start: 0, Stop: 24 * time.Hour}.Validate())
+	for _, invalid := range []WorkingHours{
+		{Start: 18 * time.Hour, Stop: 9 * time.Hour}, {Start: 9 * time.Hour, Stop: 25 * time.Hour}, {Start: -time.Hour},
+	} {
+		require.ErrorIs(t, invalid.Validate(), storage.ErrInvalidArgiments)
+	}
+}
+
+func TestSlots(t *testing.T) {
+	windows := []Interval{interval(9, 0, 12, 0), interval(14, 0, 16, 0)}
+	busy := []Interval{interval(9, 30, 10, 30), interval(10, 0, 11, 0), interval(14, 0, 15, 15)}
+	require.Equal(t,
+		[]Interval{interval(9, 0, 9, 30), interval(11, 0, 11, 30), interval(11, 30, 12, 0), interval(15, 15, 15, 45)},
+		Slots(busy, windows, 30*time.Minute, 10))
+	require.Equal(t, []Interval{interval(9, 0, 9, 30)}, Slots(busy, windows, 30*time.Minute, 1))
+	require.Equal(t, []Interval{interval(11, 0, 12, 0)}, Slots(busy, windows, time.Hour, 10))
+	require.Empty(t, Slots(busy, windows, 2*time.Hour, 10))
+	require.Empty(t, Slots(busy, windows, 0, 10))
+}
@@ -0,0 +1,63 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require" //nolint:depguard
+)
+
+var errBrokerDown = errors.New("broker is down")
+
+func TestChecker(t *testing.T) {
+	brokerErr := error(nil)
+	checker := New(time.Millisecond * 100)
+	checker.Add("db", func(context.Context) error { return nil })
+	checker.Add("broker", func(context.Context) error { return brokerErr })
+	checker.Add("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	mux := http.NewServeMux()
+	checker.Register(mux)
+
+	get := func(target string) (int, Report) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		var report Report
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+		return rec.Code, report
+	}
+
+	code, report := get("/readyz")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, StatusDown, report.Status)
+	require.Equal(t, StatusUp, report.Checks["db"].Status)
+	require.Equal(t, StatusUp, report.Checks["broker"].Status)
+	// проверка, не уложившаяся в timeout, прерывается
+	require.Equal(t, StatusDown, report.Checks["slow"].Status)
+	require.Equal(t, context.DeadlineExceeded.Error(), report.Checks["slow"].Error)
+	require.GreaterOrEqual(t, report.Checks["slow"].LatencyMs, float64(100))
+
+	checker.Add("slow", func(context.Context) error { return nil })
+	code, report = get("/readyz")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, StatusUp, report.Status)
+
+	brokerErr = errBrokerDown
+	code, report = get("/readyz")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, errBrokerDown.Error(), report.Checks["broker"].Error)
+
+	// процесс жив, даже если зависимость недоступна
+	code, report = get("/healthz")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, StatusDown, report.Status)
+	require.Len(t, report.Checks, 3)
+}
@@ -0,0 +1,478 @@
+// Package ical преобразует события календаря в формат iCalendar (RFC 5545) и обратно.
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage" //nolint:depguard
+)
+
+const (
+	ContentType = "text/calendar; charset=utf-8"
+
+	dateTimeLayout = "20060102T150405Z"
+	localLayout    = "20060102T150405"
+	dateLayout     = "20060102"
+	// максимальная длина строки в октетах, более длинные строки переносятся.
+	maxLineLength = 75
+	prodID        = "-//otus-hw//calendar//RU"
+
+	beginProperty = "BEGIN"
+	endProperty   = "END"
+	// канал доставки напоминания в VALARM, в стандарте iCalendar его нет.
+	channelProperty = "X-CALENDAR-CHANNEL"
+)
+
+var (
+	ErrInvalidCalendar = errors.New("invalid iCalendar data")
+	ErrInvalidEvent    = errors.New("invalid VEVENT")
+)
+
+// Component - разобранный VEVENT: событие либо ошибка разбора.
+type Component struct {
+	// порядковый номер VEVENT в файле, начиная с 1
+	Index int
+	UID   string
+	Event storage.Event
+	Err   error
+}
+
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Encode записывает события в формате VCALENDAR.
+func Encode(w io.Writer, events []*storage.Event) error {
+	bw := bufio.NewWriter(w)
+	write := func(line string) {
+		writeFolded(bw, line)
+	}
+	write("BEGIN:VCALENDAR")
+	write("VERSION:2.0")
+	write("PRODID:" + prodID)
+	write("CALSCALE:GREGORIAN")
+	stamp := time.Now().UTC().Format(dateTimeLayout)
+	for _, event := range events {
+		write("BEGIN:VEVENT")
+		write("UID:" + event.ID)
+		write("DTSTAMP:" + stamp)
+		write("DTSTART" + timeParams(event) + ":" + formatTime(event, event.StartTime))
+		write("DTEND" + timeParams(event) + ":" + formatTime(event, event.StopTime))
+		write("SUMMARY:" + escapeText(event.Title))
+		if event.Description != "" {
+			write("DESCRIPTION:" + escapeText(event.Description))
+		}
+		if event.IsRecurring() {
+			write("RRULE:" + event.RRule)
+			if len(event.ExDates) > 0 {
+				exDates := make([]string, 0, len(event.ExDates))
+				for _, t := range event.ExDates {
+					exDates = append(exDates, formatTime(event, t))
+				}
+				write("EXDATE" + timeParams(event) + ":" + strings.Join(exDates, ","))
+			}
+		}
+		for _, reminder := range event.Reminders {
+			write("BEGIN:VALARM")
+			write("ACTION:DISPLAY")
+			write("DESCRIPTION:" + escapeText(event.Title))
+			write("TRIGGER:" + FormatDuration(-reminder.Before))
+			if reminder.Channel != "" {
+				write(channelProperty + ":" + escapeText(reminder.Channel))
+			}
+			write("END:VALARM")
+		}
+		write("END:VEVENT")
+	}
+	write("END:VCALENDAR")
+	return bw.Flush()
+}
+
+// timeParams параметры свойств времени события: дата для события на весь день, местное время
+// с TZID для события с часовым поясом, иначе время в UTC. VTIMEZONE не выгружается,
+// клиенты понимают имена часовых поясов IANA.
+func timeParams(event *storage.Event) string {
+	switch {
+	case event.AllDay:
+		return ";VALUE=DATE"
+	case event.TimeZone != "":
+		return ";TZID=" + event.TimeZone
+	}
+	return ""
+}
+
+func formatTime(event *storage.Event, t time.Time) string {
+	switch {
+	case event.AllDay:
+		return t.In(event.Location()).Format(dateLayout)
+	case event.TimeZone != "":
+		return t.In(event.Location()).Format(localLayout)
+	}
+	return t.UTC().Format(dateTimeLayout)
+}
+
+// Decode разбирает VCALENDAR. Ошибки отдельных VEVENT не прерывают разбор и возвращаются в Component.Err.
+func Decode(r io.Reader) ([]Component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCalendar, err) //nolint:errorlint
+	}
+
+	result := make([]Component, 0)
+	inCalendar := false
+	var current []property
+	var alarm []property
+	inEvent, inAlarm := false, false
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		prop, err := parseProperty(line)
+		if err != nil {
+			if inEvent {
+				// ошибку запоминаем в компоненте, сам VEVENT будет отброшен
+				current = append(current, property{name: "X-PARSE-ERROR", value: err.Error()})
+				continue
+			}
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCalendar, err) //nolint:errorlint
+		}
+		switch {
+		case prop.name == beginProperty && strings.EqualFold(prop.value, "VCALENDAR"):
+			inCalendar = true
+		case prop.name == endProperty && strings.EqualFold(prop.value, "VCALENDAR"):
+			inCalendar = false
+		case !inCalendar:
+			return nil, fmt.Errorf("%w: content outside of VCALENDAR", ErrInvalidCalendar)
+		case prop.name == beginProperty && strings.EqualFold(prop.value, "VEVENT"):
+			inEvent = true
+			current = make([]property, 0)
+		case prop.name == endProperty && strings.EqualFold(prop.value, "VEVENT"):
+			inEvent = false
+			component := decodeEvent(current)
+			component.Index = len(result) + 1
+			result = append(result, component)
+		case inEvent && prop.name == beginProperty && strings.EqualFold(prop.value, "VALARM"):
+			inAlarm = true
+			alarm = make([]property, 0)
+		case inEvent && prop.name == endProperty && strings.EqualFold(prop.value, "VALARM"):
+			inAlarm = false
+			if trigger := findProperty(alarm, "TRIGGER"); trigger != nil {
+				// канал доставки переносится в параметры триггера, чтобы не потерять связь с ним
+				if channel := findProperty(alarm, channelProperty); channel != nil {
+					trigger.params[channelProperty] = unescapeText(channel.value)
+				}
+				current = append(current, property{name: "X-ALARM-TRIGGER", params: trigger.params, value: trigger.value})
+			}
+		case inAlarm:
+			alarm = append(alarm, prop)
+		case inEvent:
+			current = append(current, prop)
+		}
+	}
+	if inEvent {
+		return nil, fmt.Errorf("%w: unterminated VEVENT", ErrInvalidCalendar)
+	}
+	return result, nil
+}
+
+func decodeEvent(props []property) Component {
+	component := Component{}
+	fail := func(format string, args ...any) Component {
+		component.Err = fmt.Errorf("%w: "+format, append([]any{ErrInvalidEvent}, args...)...)
+		return component
+	}
+	if p := findProperty(props, "UID"); p != nil {
+		component.UID = p.value
+	}
+	if p := findProperty(props, "X-PARSE-ERROR"); p != nil {
+		return fail("%v", p.value)
+	}
+
+	event := storage.Event{}
+	if p := findProperty(props, "SUMMARY"); p != nil {
+		event.Title = unescapeText(p.value)
+	}
+	if p := findProperty(props, "DESCRIPTION"); p != nil {
+		event.Description = unescapeText(p.value)
+	}
+
+	dtStart := findProperty(props, "DTSTART")
+	if dtStart == nil {
+		return fail("DTSTART is required")
+	}
+	var err error
+	var allDay bool
+	event.StartTime, allDay, err = parseDateTime(dtStart)
+	if err != nil {
+		return fail("DTSTART %v", err)
+	}
+	event.AllDay = allDay
+	if !allDay {
+		event.TimeZone = dtStart.params["TZID"]
+	}
+	switch dtEnd, duration := findProperty(props, "DTEND"), findProperty(props, "DURATION"); {
+	case dtEnd != nil:
+		event.StopTime, _, err = parseDateTime(dtEnd)
+		if err != nil {
+			return fail("DTEND %v", err)
+		}
+	case duration != nil:
+		d, err := ParseDuration(duration.value)
+		if err != nil {
+			return fail("DURATION %v", err)
+		}
+		event.StopTime = event.StartTime.Add(d)
+	case allDay:
+		event.StopTime = event.StartTime.AddDate(0, 0, 1)
+	default:
+		event.StopTime = event.StartTime
+	}
+
+	if p := findProperty(props, "RRULE"); p != nil {
+		event.RRule = p.value
+	}
+	for _, p := range props {
+		if p.name != "EXDATE" {
+			continue
+		}
+		for _, value := range strings.Split(p.value, ",") {
+			exDate, _, err := parseDateTime(&property{params: p.params, value: value})
+			if err != nil {
+				return fail("EXDATE %v", err)
+			}
+			event.ExDates = append(event.ExDates, exDate)
+		}
+	}
+
+	for i := range props {
+		if props[i].name != "X-ALARM-TRIGGER" {
+			continue
+		}
+		before, err := parseTrigger(&props[i], event.StartTime)
+		if err != nil {
+			return fail("TRIGGER %v", err)
+		}
+		// одинаковые напоминания с разными ACTION сводятся к одному
+		reminder := storage.Reminder{Before: before, Channel: props[i].params[channelProperty]}
+		if !slices.Contains(event.Reminders, reminder) {
+			event.Reminders = append(event.Reminders, reminder)
+		}
+	}
+	storage.SortReminders(event.Reminders)
+	component.Event = event
+	return component
+}
+
+// parseTrigger возвращает за сколько до начала события сработает напоминание.
+func parseTrigger(p *property, startTime time.Time) (time.Duration, error) {
+	if strings.EqualFold(p.params["VALUE"], "DATE-TIME") {
+		t, _, err := parseDateTime(&property{value: p.value})
+		if err != nil {
+			return 0, err
+		}
+		return startTime.Sub(t), nil
+	}
+	if strings.EqualFold(p.params["RELATED"], "END") {
+		return 0, fmt.Errorf("RELATED=END is not supported")
+	}
+	d, err := ParseDuration(p.value)
+	if err != nil {
+		return 0, err
+	}
+	return -d, nil
+}
+
+func parseDateTime(p *property) (time.Time, bool, error) {
+	if strings.EqualFold(p.params["VALUE"], "DATE") || len(p.value) == len(dateLayout) {
+		t, err := time.Parse(dateLayout, p.value)
+		return t, true, err
+	}
+	if strings.HasSuffix(p.value, "Z") {
+		t, err := time.Parse(dateTimeLayout, p.value)
+		return t, false, err
+	}
+	location := time.UTC
+	if tzid := p.params["TZID"]; tzid != "" {
+		var err error
+		location, err = time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+	}
+	t, err := time.ParseInLocation(localLayout, p.value, location)
+	return t, false, err
+}
+
+func findProperty(props []property, name string) *property {
+	for i := range props {
+		if props[i].name == name {
+			return &props[i]
+		}
+	}
+	return nil
+}
+
+// parseProperty разбирает строку вида NAME;PARAM=VALUE;PARAM2="VALUE":value.
+func parseProperty(line string) (property, error) {
+	result := property{params: make(map[string]string)}
+	quoted := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		}
+		if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return result, fmt.Errorf("bad content line %q", line)
+	}
+	result.value = line[colon+1:]
+	parts := strings.Split(line[:colon], ";")
+	result.name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		name, value, ok := strings.Cut(param, "=")
+		if !ok {
+			return result, fmt.Errorf("bad parameter %q", param)
+		}
+		result.params[strings.ToUpper(name)] = strings.Trim(value, `"`)
+	}
+	return result, nil
+}
+
+// unfold склеивает перенесенные строки (продолжение начинается с пробела или табуляции).
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	result := make([]string, 0)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(result) > 0 {
+			result[len(result)-1] += line[1:]
+			continue
+		}
+		result = append(result, line)
+	}
+	return result, scanner.Err()
+}
+
+func writeFolded(w *bufio.Writer, line string) {
+	limit := maxLineLength
+	for len(line) > limit {
+		cut := limit
+		// не разрываем многобайтовые символы UTF-8
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+		line = line[cut:]
+		// строка продолжения начинается с пробела, он входит в длину
+		limit = maxLineLength - 1
+	}
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
+
+var (
+	textEscaper   = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	textUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+)
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+func unescapeText(s string) string {
+	return textUnescaper.Replace(s)
+}
+
+// FormatDuration возвращает длительность в формате RFC 5545, например -PT15M или P1DT2H.
+func FormatDuration(d time.Duration) string {
+	sb := strings.Builder{}
+	if d < 0 {
+		sb.WriteRune('-')
+		d = -d
+	}
+	sb.WriteRune('P')
+	days := int64(d / (24 * time.Hour))
+	d -= time.Duration(days) * 24 * time.Hour
+	if days > 0 {
+		sb.WriteString(strconv.FormatInt(days, 10) + "D")
+	}
+	if d > 0 || days == 0 {
+		sb.WriteRune('T')
+		hours, minutes, seconds := d/time.Hour, (d%time.Hour)/time.Minute, (d%time.Minute)/time.Second
+		if hours > 0 {
+			sb.WriteString(strconv.FormatInt(int64(hours), 10) + "H")
+		}
+		if minutes > 0 {
+			sb.WriteString(strconv.FormatInt(int64(minutes), 10) + "M")
+		}
+		if seconds > 0 || (hours == 0 && minutes == 0) {
+			sb.WriteString(strconv.FormatInt(int64(seconds), 10) + "S")
+		}
+	}
+	return sb.String()
+}
+
+// ParseDuration разбирает длительность в формате RFC 5545: [+-]P[nW][nD][T[nH][nM][nS]].
+func ParseDuration(value string) (time.Duration, error) {
+	s := value
+	negative := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		negative = true
+		s = s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	if !strings.HasPrefix(s, "P") || len(s) < 3 {
+		return 0, fmt.Errorf("bad duration %q", value)
+	}
+	s = s[1:]
+	var result time.Duration
+	inTime := false
+	number := ""
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			number += string(r)
+			continue
+		case r == 'T' && !inTime && number == "":
+			inTime = true
+			continue
+		}
+		n, err := strconv.Atoi(number)
+		if err != nil {
+			return 0, fmt.Errorf("bad duration %q", value)
+		}
+		number = ""
+		unit := map[bool]map[rune]time.Duration{
+			false: {'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour},
+			true:  {'H': time.Hour, 'M': time.Minute, 'S': time.Second},
+		}[inTime][r]
+		if unit == 0 {
+			return 0, fmt.Errorf("bad duration %q", value)
+		}
+		result += time.Duration(n) * unit
+	}
+	if number != "" {
+		return 0, fmt.Errorf("bad duration %q", value)
+	}
+	if negative {
+		result = -result
+	}
+	return result, nil
+}
@@ -0,0 +1,169 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage" //nolint:depguard
+	"github.com/stretchr/testify/require"                              //nolint:depguard
+)
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		value    string
+		duration time.Duration
+	}{
+		{"-PT15M", -15 * time.Minute},
+		{"PT1H30M", 90 * time.Minute},
+		{"P1D", 24 * time.Hour},
+		{"-P1DT2H", -26 * time.Hour},
+		{"PT0S", 0},
+	}
+	for _, tc := range tests {
+		d, err := ParseDuration(tc.value)
+		require.NoError(t, err, tc.value)
+		require.Equal(t, tc.duration, d, tc.value)
+		require.Equal(t, tc.value, FormatDuration(tc.duration))
+	}
+
+	d, err := ParseDuration("P2W")
+	require.NoError(t, err)
+	require.Equal(t, 14*24*time.Hour, d)
+
+	for _, bad := range []string{"", "15M", "PT", "PT15", "P1H", "PTXM"} {
+		_, err := ParseDuration(bad)
+		require.Error(t, err, bad)
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	events := []*storage.Event{
+		{
+			ID:          "123e4567-e89b-12d3-a456-426655440000",
+			Title:       "meeting; room 1, floor 2",
+			StartTime:   time.Date(2025, 1, 2, 15, 0, 0, 0, time.UTC),
+			StopTime:    time.Date(2025, 1, 2, 16, 0, 0, 0, time.UTC),
+			Description: strings.Repeat("очень длинное описание\n", 10),
+			Reminders: []storage.Reminder{
+				{Before: 24 * time.Hour, Channel: "email"}, {Before: 15 * time.Minute},
+			},
+			RRule:   "FREQ=WEEKLY;COUNT=3",
+			ExDates: []time.Time{time.Date(2025, 1, 9, 15, 0, 0, 0, time.UTC)},
+		},
+		{
+			ID:        "123e4567-e89b-12d3-a456-426655440001",
+			Title:     "no reminder",
+			StartTime: time.Date(2025, 1, 3, 15, 0, 0, 0, time.UTC),
+			StopTime:  time.Date(2025, 1, 3, 16, 0, 0, 0, time.UTC),
+		},
+		{
+			ID:        "123e4567-e89b-12d3-a456-426655440002",
+			Title:     "berlin",
+			StartTime: time.Date(2025, 3, 28, 8, 0, 0, 0, time.UTC),
+			StopTime:  time.Date(2025, 3, 28, 9, 0, 0, 0, time.UTC),
+			TimeZone:  "Europe/Berlin",
+			RRule:     "FREQ=DAILY;COUNT=5",
+			ExDates:   []time.Time{time.Date(2025, 3, 31, 7, 0, 0, 0, time.UTC)},
+		},
+		{
+			ID:        "123e4567-e89b-12d3-a456-426655440003",
+			Title:     "all day",
+			StartTime: time.Date(2025, 1, 4, 0, 0, 0, 0, time.UTC),
+			StopTime:  time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC),
+			AllDay:    true,
+		},
+	}
+
+	buf := bytes.Buffer{}
+	require.NoError(t, Encode(&buf, events))
+	require.Contains(t, buf.String(), "DTSTART;TZID=Europe/Berlin:20250328T090000\r\n")
+	require.Contains(t, buf.String(), "EXDATE;TZID=Europe/Berlin:20250331T090000\r\n")
+	require.Contains(t, buf.String(), "DTEND;VALUE=DATE:20250106\r\n")
+	for _, line := range strings.Split(buf.String(), "\r\n") {
+		require.LessOrEqual(t, len(line), maxLineLength, "lines must be folded")
+	}
+
+	components, err := Decode(&buf)
+	require.NoError(t, err)
+	require.Len(t, components, len(events))
+	for i, component := range components {
+		require.NoError(t, component.Err)
+		require.Equal(t, i+1, component.Index)
+		require.Equal(t, events[i].ID, component.UID)
+		event := component.Event
+		require.Equal(t, events[i].Title, event.Title)
+		require.Equal(t, events[i].Description, event.Description)
+		require.True(t, events[i].StartTime.Equal(event.StartTime))
+		require.True(t, events[i].StopTime.Equal(event.StopTime))
+		require.Equal(t, events[i].Reminders, event.Reminders)
+		require.Equal(t, events[i].RRule, event.RRule)
+		require.Equal(t, len(events[i].ExDates), len(event.ExDates))
+		for j := range event.ExDates {
+			require.True(t, events[i].ExDates[j].Equal(event.ExDates[j]))
+		}
+		require.Equal(t, events[i].TimeZone, event.TimeZone)
+		require.Equal(t, events[i].AllDay, event.AllDay)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	data := "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:1\r\n" +
+		"SUMMARY:Moscow\r\n" +
+		"DTSTART;TZID=Europe/Moscow:20250102T150000\r\n" +
+		"DURATION:PT30M\r\n" +
+		"BEGIN:VALARM\r\n" +
+		"TRIGGER;VALUE=DATE-TIME:20250102T110000Z\r\n" +
+		"END:VALARM\r\n" +
+		"BEGIN:VALARM\r\n" +
+		"ACTION:AUDIO\r\n" +
+		"TRIGGER:-PT1H\r\n" +
+		"END:VALARM\r\n" +
+		"BEGIN:VALARM\r\n" +
+		"TRIGGER:-P1D\r\n" +
+		"X-CALENDAR-CHANNEL:webhook\r\n" +
+		"END:VALARM\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:2\r\n" +
+		"SUMMARY:all day\r\n" +
+		"DTSTART;VALUE=DATE:20250103\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:3\r\n" +
+		"SUMMARY:bad\r\n" +
+		"DTSTART:tomorrow\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:4\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	components, err := Decode(strings.NewReader(data))
+	require.NoError(t, err)
+	require.Len(t, components, 4)
+
+	require.NoError(t, components[0].Err)
+	require.Equal(t, time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC), components[0].Event.StartTime.UTC())
+	require.Equal(t, 30*time.Minute, components[0].Event.StopTime.Sub(components[0].Event.StartTime))
+	require.Equal(t, []storage.Reminder{{Before: 24 * time.Hour, Channel: "webhook"}, {Before: time.Hour}},
+		components[0].Event.Reminders, "same alarm with another action is imported once")
+	require.Equal(t, "Europe/Moscow", components[0].Event.TimeZone)
+
+	require.NoError(t, components[1].Err)
+	require.True(t, components[1].Event.AllDay)
+	require.Equal(t, 24*time.Hour, components[1].Event.StopTime.Sub(components[1].Event.StartTime))
+
+	require.ErrorIs(t, components[2].Err, ErrInvalidEvent)
+	require.Equal(t, "3", components[2].UID)
+	require.ErrorIs(t, components[3].Err, ErrInvalidEvent)
+
+	_, err = Decode(strings.NewReader("BEGIN:VEVENT\r\n"))
+	require.ErrorIs(t, err, ErrInvalidCalendar)
+	_, err = Decode(strings.NewReader("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\n"))
+	require.ErrorIs(t, err, ErrInvalidCalendar)
+}
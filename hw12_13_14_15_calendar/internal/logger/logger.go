@@ -5,13 +5,27 @@ import (
 	"log"
 	"log/slog"
 	"os"
+	"sync"
 )
 
 type Logger struct {
-	file   *os.File
+	file   *fileWriter
+	level  *slog.LevelVar
 	logger *slog.Logger
 }
 
+// fileWriter файл журнала, который можно переоткрыть во время работы, например после ротации logrotate.
+type fileWriter struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func (w *fileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Write(p)
+}
+
 func New(level string, fileName string) *Logger {
 	// setup:
 	file, err := os.Create(fileName)
@@ -29,11 +43,30 @@ func New(level string, fileName string) *Logger {
 		Level:       programLevel,
 		ReplaceAttr: nil,
 	}
-	writer := io.MultiWriter(file, os.Stderr)
+	fileWriter := &fileWriter{file: file}
+	writer := io.MultiWriter(fileWriter, os.Stderr)
 	slogger := slog.New(slog.NewTextHandler(writer, logConfig))
 	slog.SetDefault(slogger)
 
-	return &Logger{file: file, logger: slogger}
+	return &Logger{file: fileWriter, level: programLevel, logger: slogger}
+}
+
+// SetLevel меняет уровень журнала работающего процесса.
+func (l Logger) SetLevel(level string) error {
+	return l.level.UnmarshalText([]byte(level))
+}
+
+// Reopen открывает файл журнала fileName заново и дописывает в него, прежний файл закрывается.
+func (l Logger) Reopen(fileName string) error {
+	file, err := os.OpenFile(fileName, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644) //nolint:gosec
+	if err != nil {
+		return err
+	}
+	l.file.mu.Lock()
+	defer l.file.mu.Unlock()
+	old := l.file.file
+	l.file.file = file
+	return old.Close()
 }
 
 func (l Logger) Debug(msg string) {
@@ -53,5 +86,7 @@ func (l Logger) Error(msg string) {
 }
 
 func (l Logger) Close() error {
-	return l.file.Close()
+	l.file.mu.Lock()
+	defer l.file.mu.Unlock()
+	return l.file.file.Close()
 }
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"io/fs"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require" //nolint:depguard
@@ -34,3 +35,32 @@ func checkLevel(t *testing.T, level string) {
 		require.NoError(t, logg.Close())
 	})
 }
+
+func TestLoggerReload(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "calendar.log")
+	logg := New("INFO", name)
+	defer logg.Close()
+
+	logg.Debug("hidden")
+	require.NoError(t, logg.SetLevel("DEBUG"))
+	logg.Debug("shown")
+	require.Error(t, logg.SetLevel("LOUD"))
+
+	// logrotate переименовывает файл, после Reopen запись идет в новый файл с тем же именем
+	rotated := filepath.Join(dir, "calendar.log.1")
+	require.NoError(t, os.Rename(name, rotated))
+	logg.Info("before reopen")
+	require.NoError(t, logg.Reopen(name))
+	logg.Info("after reopen")
+
+	data, err := os.ReadFile(rotated)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "hidden")
+	require.Contains(t, string(data), "shown")
+	require.Contains(t, string(data), "before reopen")
+	data, err = os.ReadFile(name)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "before reopen")
+	require.Contains(t, string(data), "after reopen")
+}
@@ -0,0 +1,83 @@
+// Package metrics метрики Prometheus процессов календаря, отдаются обработчиком Handler на /metrics.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"            //nolint:depguard
+	"github.com/prometheus/client_golang/prometheus/collectors" //nolint:depguard
+	"github.com/prometheus/client_golang/prometheus/promhttp"   //nolint:depguard
+)
+
+const namespace = "calendar"
+
+// Registry реестр метрик календаря, кроме них содержит метрики среды выполнения Go и процесса.
+var Registry = prometheus.NewRegistry()
+
+// HTTP API.
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "http", Name: "requests_total",
+		Help: "HTTP requests by method, route pattern and status code.",
+	}, []string{"method", "route", "code"})
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace, Subsystem: "http", Name: "request_duration_seconds",
+		Help:    "HTTP request latency by method and route pattern.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+// Хранилище.
+var (
+	StorageDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace, Subsystem: "storage", Name: "operation_duration_seconds",
+		Help:    "Storage operation duration by method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+	StorageErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "storage", Name: "errors_total",
+		Help: "Failed storage operations by method, rejected requests (busy time, not found, invalid data) are not counted.",
+	}, []string{"method"})
+)
+
+// Планировщик.
+var (
+	SchedulerReminderBatch = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace, Subsystem: "scheduler", Name: "reminder_batch_size",
+		Help:    "Events with due reminders found by one scheduler pass.",
+		Buckets: []float64{0, 1, 5, 10, 50, 100, 500, 1000},
+	})
+	SchedulerPublishFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "scheduler", Name: "publish_failures_total",
+		Help: "Notifications that failed to publish to the broker and stay in the outbox.",
+	})
+)
+
+// Хранитель.
+var (
+	StorerConsumeLag = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace, Subsystem: "storer", Name: "consume_lag_seconds",
+		Help:    "Time from publishing a notification to its handling by the storer.",
+		Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300, 900},
+	})
+	StorerHandlerErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "storer", Name: "handler_errors_total",
+		Help: "Storer handler errors by stage: unmarshal, save or deliver.",
+	}, []string{"stage"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests, HTTPRequestDuration,
+		StorageDuration, StorageErrors,
+		SchedulerReminderBatch, SchedulerPublishFailures,
+		StorerConsumeLag, StorerHandlerErrors,
+	)
+}
+
+// Handler отдает метрики Registry в текстовом формате Prometheus.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
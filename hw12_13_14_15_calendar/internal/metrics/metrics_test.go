@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage"                      //nolint:depguard
+	memorystorage "github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage/memory" //nolint:depguard
+	"github.com/prometheus/client_golang/prometheus/testutil"                               //nolint:depguard
+	"github.com/stretchr/testify/require"                                                   //nolint:depguard
+)
+
+func TestStorage(t *testing.T) {
+	ctx := context.Background()
+	repo := NewStorage(memorystorage.New(false))
+	require.IsType(t, &memorystorage.Storage{}, repo.Unwrap())
+
+	createErrors := testutil.ToFloat64(StorageErrors.WithLabelValues("CreateEvent"))
+	getErrors := testutil.ToFloat64(StorageErrors.WithLabelValues("GetEvent"))
+
+	start := time.Now().Add(time.Hour)
+	id, err := repo.CreateEvent(ctx, storage.Event{
+		Title: "event", UserID: 1, StartTime: start, StopTime: start.Add(time.Hour),
+	})
+	require.NoError(t, err)
+	_, err = repo.GetEvent(ctx, id)
+	require.NoError(t, err)
+	_, err = repo.GetEvent(ctx, "missing")
+	require.ErrorIs(t, err, storage.ErrEventNotFound)
+	_, err = repo.CreateEvent(ctx, storage.Event{
+		Title: "busy", UserID: 1, StartTime: start, StopTime: start.Add(time.Hour),
+	})
+	require.ErrorIs(t, err, storage.ErrDateBusy)
+
+	// отказы по бизнес-правилам не считаются ошибками хранилища
+	require.Equal(t, createErrors, testutil.ToFloat64(StorageErrors.WithLabelValues("CreateEvent")))
+	require.Equal(t, getErrors, testutil.ToFloat64(StorageErrors.WithLabelValues("GetEvent")))
+	require.Positive(t, testutil.CollectAndCount(StorageDuration, "calendar_storage_operation_duration_seconds"))
+}
+
+func TestIsFailure(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		failure bool
+	}{
+		{name: "no error"},
+		{name: "busy", err: fmt.Errorf("%w: event", storage.ErrDateBusy)},
+		{name: "not found", err: storage.ErrEventNotFound},
+		{name: "not owner", err: storage.ErrNotEventOwner},
+		{name: "invalid rrule", err: fmt.Errorf("%w: FREQ=YEARLY", storage.ErrInvalidRRule)},
+		{name: "read failed", err: fmt.Errorf("%w: connection refused", storage.ErrReadEvent), failure: true},
+		{name: "transaction failed", err: storage.ErrTransaction, failure: true},
+		{name: "unknown", err: errors.New("unknown"), failure: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.failure, isFailure(tc.err))
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	SchedulerPublishFailures.Inc()
+
+	server := httptest.NewServer(Handler())
+	defer server.Close()
+	resp, err := http.Get(server.URL) //nolint:noctx
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Contains(t, resp.Header.Get("Content-Type"), "text/plain")
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), "calendar_scheduler_publish_failures_total")
+	require.Contains(t, string(body), "go_goroutines")
+}
@@ -0,0 +1,204 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage" //nolint:depguard
+)
+
+// Storage измеряет длительность и считает ошибки операций хранилища.
+type Storage struct {
+	storage storage.Storage
+}
+
+// NewStorage оборачивает st метриками StorageDuration и StorageErrors.
+func NewStorage(st storage.Storage) *Storage {
+	return &Storage{storage: st}
+}
+
+// Unwrap возвращает обернутое хранилище, например для проверки storage.Migrator.
+func (s *Storage) Unwrap() storage.Storage {
+	return s.storage
+}
+
+func observe(method string, start time.Time, err error) {
+	StorageDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if isFailure(err) {
+		StorageErrors.WithLabelValues(method).Inc()
+	}
+}
+
+// isFailure отделяет сбои хранилища от ожидаемых ответов: занятое время, отсутствующее событие,
+// неверные данные и нарушение прав на событие - результат проверки запроса, а не ошибка хранилища.
+func isFailure(err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, storage.ErrDateBusy) ||
+		errors.Is(err, storage.ErrEventNotFound) ||
+		errors.Is(err, storage.ErrNotificationNotFound) ||
+		errors.Is(err, storage.ErrAttendeeNotFound) ||
+		errors.Is(err, storage.ErrNotEventOwner) ||
+		errors.Is(err, storage.ErrUpdateUserID) ||
+		errors.Is(err, storage.ErrEventNotDeleted) ||
+		errors.Is(err, storage.ErrInvalidArgiments) ||
+		errors.Is(err, storage.ErrInvalidStopTime) ||
+		errors.Is(err, storage.ErrInvalidRRule) ||
+		errors.Is(err, storage.ErrInvalidRSVP) ||
+		errors.Is(err, storage.ErrInvalidTimeZone) ||
+		errors.Is(err, storage.ErrInvalidAllDay) ||
+		errors.Is(err, storage.ErrInvalidReminder):
+		return false
+	default:
+		return true
+	}
+}
+
+func (s *Storage) CreateEvent(ctx context.Context, event storage.Event) (id string, err error) {
+	defer func(start time.Time) { observe("CreateEvent", start, err) }(time.Now())
+	return s.storage.CreateEvent(ctx, event)
+}
+
+func (s *Storage) UpdateEvent(ctx context.Context, id string, event storage.Event) (err error) {
+	defer func(start time.Time) { observe("UpdateEvent", start, err) }(time.Now())
+	return s.storage.UpdateEvent(ctx, id, event)
+}
+
+func (s *Storage) DeleteEvent(ctx context.Context, id string) (err error) {
+	defer func(start time.Time) { observe("DeleteEvent", start, err) }(time.Now())
+	return s.storage.DeleteEvent(ctx, id)
+}
+
+func (s *Storage) RestoreEvent(ctx context.Context, id string) (err error) {
+	defer func(start time.Time) { observe("RestoreEvent", start, err) }(time.Now())
+	return s.storage.RestoreEvent(ctx, id)
+}
+
+func (s *Storage) ListEventHistory(ctx context.Context, id string) (history []*storage.AuditRecord, err error) {
+	defer func(start time.Time) { observe("ListEventHistory", start, err) }(time.Now())
+	return s.storage.ListEventHistory(ctx, id)
+}
+
+func (s *Storage) GetEvent(ctx context.Context, id string) (event *storage.Event, err error) {
+	defer func(start time.Time) { observe("GetEvent", start, err) }(time.Now())
+	return s.storage.GetEvent(ctx, id)
+}
+
+func (s *Storage) ListEventsDay(ctx context.Context, userID int64, startTime time.Time) (
+	events []*storage.Event, err error,
+) {
+	defer func(start time.Time) { observe("ListEventsDay", start, err) }(time.Now())
+	return s.storage.ListEventsDay(ctx, userID, startTime)
+}
+
+func (s *Storage) ListEventsWeek(ctx context.Context, userID int64, startTime time.Time) (
+	events []*storage.Event, err error,
+) {
+	defer func(start time.Time) { observe("ListEventsWeek", start, err) }(time.Now())
+	return s.storage.ListEventsWeek(ctx, userID, startTime)
+}
+
+func (s *Storage) ListEventsMonth(ctx context.Context, userID int64, startTime time.Time) (
+	events []*storage.Event, err error,
+) {
+	defer func(start time.Time) { observe("ListEventsMonth", start, err) }(time.Now())
+	return s.storage.ListEventsMonth(ctx, userID, startTime)
+}
+
+func (s *Storage) ListEvents(ctx context.Context, params storage.ListParams) (
+	events []*storage.Event, cursor *storage.Cursor, err error,
+) {
+	defer func(start time.Time) { observe("ListEvents", start, err) }(time.Now())
+	return s.storage.ListEvents(ctx, params)
+}
+
+func (s *Storage) ListDueReminders(ctx context.Context) (reminders []*storage.DueReminder, err error) {
+	defer func(start time.Time) { observe("ListDueReminders", start, err) }(time.Now())
+	return s.storage.ListDueReminders(ctx)
+}
+
+func (s *Storage) ClearReminderTime(ctx context.Context, id string, reminder storage.Reminder) (err error) {
+	defer func(start time.Time) { observe("ClearReminderTime", start, err) }(time.Now())
+	return s.storage.ClearReminderTime(ctx, id, reminder)
+}
+
+func (s *Storage) DeleteEventsBeforeDate(ctx context.Context, before time.Time) (err error) {
+	defer func(start time.Time) { observe("DeleteEventsBeforeDate", start, err) }(time.Now())
+	return s.storage.DeleteEventsBeforeDate(ctx, before)
+}
+
+func (s *Storage) SaveNotification(ctx context.Context, notification storage.Notification) (err error) {
+	defer func(start time.Time) { observe("SaveNotification", start, err) }(time.Now())
+	return s.storage.SaveNotification(ctx, notification)
+}
+
+func (s *Storage) GetNotificationDelivery(ctx context.Context, id string) (
+	delivery *storage.NotificationDelivery, err error,
+) {
+	defer func(start time.Time) { observe("GetNotificationDelivery", start, err) }(time.Now())
+	return s.storage.GetNotificationDelivery(ctx, id)
+}
+
+func (s *Storage) UpdateNotificationDelivery(ctx context.Context, id string, status storage.DeliveryStatus,
+	channel string, lastError string,
+) (err error) {
+	defer func(start time.Time) { observe("UpdateNotificationDelivery", start, err) }(time.Now())
+	return s.storage.UpdateNotificationDelivery(ctx, id, status, channel, lastError)
+}
+
+func (s *Storage) EnqueueNotification(ctx context.Context, eventID string, reminder storage.Reminder,
+	notification storage.Notification,
+) (err error) {
+	defer func(start time.Time) { observe("EnqueueNotification", start, err) }(time.Now())
+	return s.storage.EnqueueNotification(ctx, eventID, reminder, notification)
+}
+
+func (s *Storage) InviteAttendee(ctx context.Context, eventID string, userID int64,
+	notification storage.Notification,
+) (err error) {
+	defer func(start time.Time) { observe("InviteAttendee", start, err) }(time.Now())
+	return s.storage.InviteAttendee(ctx, eventID, userID, notification)
+}
+
+func (s *Storage) UpdateAttendee(ctx context.Context, eventID string, attendee storage.Attendee,
+	notification storage.Notification,
+) (err error) {
+	defer func(start time.Time) { observe("UpdateAttendee", start, err) }(time.Now())
+	return s.storage.UpdateAttendee(ctx, eventID, attendee, notification)
+}
+
+func (s *Storage) RemoveAttendee(ctx context.Context, eventID string, userID int64,
+	notification storage.Notification,
+) (err error) {
+	defer func(start time.Time) { observe("RemoveAttendee", start, err) }(time.Now())
+	return s.storage.RemoveAttendee(ctx, eventID, userID, notification)
+}
+
+func (s *Storage) ListOutbox(ctx context.Context, limit int) (messages []*storage.OutboxMessage, err error) {
+	defer func(start time.Time) { observe("ListOutbox", start, err) }(time.Now())
+	return s.storage.ListOutbox(ctx, limit)
+}
+
+func (s *Storage) MarkOutboxSent(ctx context.Context, id string) (err error) {
+	defer func(start time.Time) { observe("MarkOutboxSent", start, err) }(time.Now())
+	return s.storage.MarkOutboxSent(ctx, id)
+}
+
+func (s *Storage) MarkOutboxFailed(ctx context.Context, id string, nextAttemptTime time.Time,
+	reason string,
+) (err error) {
+	defer func(start time.Time) { observe("MarkOutboxFailed", start, err) }(time.Now())
+	return s.storage.MarkOutboxFailed(ctx, id, nextAttemptTime, reason)
+}
+
+func (s *Storage) Ping(ctx context.Context) error {
+	return s.storage.Ping(ctx)
+}
+
+func (s *Storage) Close(ctx context.Context) error {
+	return s.storage.Close(ctx)
+}
+
+var _ storage.Storage = (*Storage)(nil)
@@ -0,0 +1,203 @@
+// Package scheduler планировщик: складывает уведомления о событиях в outbox, отправляет их в брокер
+// и удаляет старые события.
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/app"     //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/metrics" //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage" //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/tracing" //nolint:depguard
+)
+
+const (
+	// размер пачки уведомлений outbox за один проход.
+	outboxBatchSize = 100
+	// задержка повторной отправки растет вдвое после каждой неудачи, но не больше outboxMaxRetryDelay.
+	outboxRetryDelay    = time.Second
+	outboxMaxRetryDelay = time.Minute * 10
+)
+
+// интервалы по умолчанию.
+const (
+	defaultReminderEvents = time.Second * 5
+	defaultOldEvents      = time.Minute * 5
+	defaultOutbox         = time.Second
+)
+
+// Intervals периоды проходов планировщика, нулевой период - период по умолчанию.
+type Intervals struct {
+	ReminderEvents time.Duration
+	OldEvents      time.Duration
+	Outbox         time.Duration
+}
+
+type Scheduler struct {
+	app   *app.App
+	topic string
+
+	mu        sync.Mutex
+	intervals Intervals
+	// сигнал Run перезапустить таймеры с новыми интервалами
+	intervalsChanged chan struct{}
+}
+
+func New(app *app.App, topic string, intervals Intervals) *Scheduler {
+	return &Scheduler{
+		app:              app,
+		topic:            topic,
+		intervals:        intervals.withDefaults(),
+		intervalsChanged: make(chan struct{}, 1),
+	}
+}
+
+func (i Intervals) withDefaults() Intervals {
+	if i.ReminderEvents <= 0 {
+		i.ReminderEvents = defaultReminderEvents
+	}
+	if i.OldEvents <= 0 {
+		i.OldEvents = defaultOldEvents
+	}
+	if i.Outbox <= 0 {
+		i.Outbox = defaultOutbox
+	}
+	return i
+}
+
+// Intervals возвращает текущие периоды проходов.
+func (s *Scheduler) Intervals() Intervals {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.intervals
+}
+
+// SetIntervals меняет периоды проходов работающего планировщика, отсчет новых периодов начинается сразу.
+func (s *Scheduler) SetIntervals(intervals Intervals) {
+	s.mu.Lock()
+	s.intervals = intervals.withDefaults()
+	s.mu.Unlock()
+	select {
+	case s.intervalsChanged <- struct{}{}:
+	default:
+	}
+}
+
+// Run выполняет проходы планировщика до отмены контекста.
+func (s *Scheduler) Run(ctx context.Context) {
+	intervals := s.Intervals()
+	ticker := time.NewTicker(intervals.ReminderEvents)
+	defer ticker.Stop()
+	tickerClearOldEvents := time.NewTicker(intervals.OldEvents)
+	defer tickerClearOldEvents.Stop()
+	tickerOutbox := time.NewTicker(intervals.Outbox)
+	defer tickerOutbox.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.app.Logger.Info("worker closing")
+			return
+		case <-s.intervalsChanged:
+			intervals := s.Intervals()
+			ticker.Reset(intervals.ReminderEvents)
+			tickerClearOldEvents.Reset(intervals.OldEvents)
+			tickerOutbox.Reset(intervals.Outbox)
+		case <-ticker.C:
+			s.ProcessEvents(ctx)
+		case <-tickerOutbox.C:
+			s.RelayOutbox(ctx)
+		case <-tickerClearOldEvents.C:
+			s.ClearOldEvents(ctx)
+		}
+	}
+}
+
+// ProcessEvents складывает уведомления в outbox, отправкой в брокер занимается RelayOutbox.
+func (s *Scheduler) ProcessEvents(ctx context.Context) {
+	s.app.Logger.Debug("worker processing events")
+
+	reminders, err := s.app.Storage.ListDueReminders(ctx)
+	if err != nil {
+		s.app.Logger.Error("failed to get reminders: " + err.Error())
+		return
+	}
+	metrics.SchedulerReminderBatch.Observe(float64(len(reminders)))
+	for _, due := range reminders {
+		if err := s.enqueue(ctx, due); err != nil {
+			s.app.Logger.Error("failed to enqueue notification: " + err.Error())
+			continue
+		}
+		s.app.Logger.Info("enqueued " + due.Event.Title)
+	}
+}
+
+// enqueue складывает уведомление о событии в outbox в спане, продолжающем трейс запроса, изменившего событие.
+func (s *Scheduler) enqueue(ctx context.Context, due *storage.DueReminder) (err error) {
+	event := due.Event
+	ctx, span := tracing.Tracer().Start(tracing.WithTraceParent(ctx, event.TraceContext), "scheduler enqueue")
+	defer func() { tracing.End(span, err) }()
+
+	notification := storage.Notification{
+		ID:           event.NotificationID(due.Reminder),
+		Title:        event.Title,
+		StartTime:    event.StartTime,
+		UserID:       event.UserID,
+		Channel:      due.Channel,
+		TraceContext: tracing.TraceParent(ctx),
+	}
+	return s.app.Storage.EnqueueNotification(ctx, event.ID, due.Reminder, notification)
+}
+
+// RelayOutbox отправляет уведомления из outbox в брокер. Неотправленные уведомления остаются в outbox
+// и отправляются повторно, хранитель сохраняет уведомление по id один раз.
+func (s *Scheduler) RelayOutbox(ctx context.Context) {
+	messages, err := s.app.Storage.ListOutbox(ctx, outboxBatchSize)
+	if err != nil {
+		s.app.Logger.Error("failed to get outbox: " + err.Error())
+		return
+	}
+	for _, message := range messages {
+		notification := message.Notification
+		notification.PublishedAt = time.Now()
+		payload, err := json.Marshal(notification)
+		if err != nil {
+			s.app.Logger.Error("failed to marshal notification: " + err.Error())
+			continue
+		}
+		err = s.app.Broker.Publish(tracing.WithTraceParent(ctx, notification.TraceContext), s.topic, payload)
+		if err != nil {
+			s.app.Logger.Error("failed to publish notification: " + err.Error())
+			metrics.SchedulerPublishFailures.Inc()
+			nextAttemptTime := time.Now().Add(retryDelay(message.Attempts))
+			if err := s.app.Storage.MarkOutboxFailed(ctx, message.ID, nextAttemptTime, err.Error()); err != nil {
+				s.app.Logger.Error("failed to update outbox: " + err.Error())
+			}
+			continue
+		}
+		if err := s.app.Storage.MarkOutboxSent(ctx, message.ID); err != nil {
+			s.app.Logger.Error("failed to mark notification sent: " + err.Error())
+			continue
+		}
+		s.app.Logger.Info("published " + message.Title)
+	}
+}
+
+func retryDelay(attempts int) time.Duration {
+	delay := outboxRetryDelay
+	for i := 0; i < attempts && delay < outboxMaxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, outboxMaxRetryDelay)
+}
+
+func (s *Scheduler) ClearOldEvents(ctx context.Context) {
+	// ТЗ: процесс должен очищать старые (произошедшие более 1 года назад) события.
+	s.app.Logger.Debug("clear old events")
+	err := s.app.Storage.DeleteEventsBeforeDate(ctx, time.Now().Add(-time.Hour*24*365))
+	if err != nil {
+		s.app.Logger.Error("failed to clear old events" + err.Error())
+	}
+}
@@ -0,0 +1,164 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/app"                          //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/client"                       //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/logger"                       //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/metrics"                      //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage"                      //nolint:depguard
+	memorystorage "github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage/memory" //nolint:depguard
+	"github.com/prometheus/client_golang/prometheus/testutil"                               //nolint:depguard
+	"github.com/stretchr/testify/require"                                                   //nolint:depguard
+)
+
+var errBrokerDown = errors.New("broker is down")
+
+type testBroker struct {
+	mu        sync.Mutex
+	fail      bool
+	published [][]byte
+}
+
+func (b *testBroker) Connect(_ context.Context) error { return nil }
+
+func (b *testBroker) Disconnect() error { return nil }
+
+func (b *testBroker) Ping(_ context.Context) error { return nil }
+
+func (b *testBroker) Publish(_ context.Context, _ string, message []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.fail {
+		return errBrokerDown
+	}
+	b.published = append(b.published, message)
+	return nil
+}
+
+func (b *testBroker) publishedCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.published)
+}
+
+func (b *testBroker) Subscribe(_ string, _ client.SubscriberHandlerFunc) {}
+
+func TestOutbox(t *testing.T) {
+	ctx := context.Background()
+	broker := &testBroker{fail: true}
+	repo := memorystorage.New(false)
+	scheduler := New(app.New(logger.New("INFO", "/tmp/calendar-scheduler-test.log"), repo, broker), "events",
+		Intervals{})
+
+	reminder := storage.Reminder{Before: time.Hour}
+	startTime := time.Now().Add(time.Minute * 30)
+	eventID, err := repo.CreateEvent(ctx, storage.Event{
+		Title: "event", UserID: 1, StartTime: startTime, StopTime: startTime.Add(time.Hour),
+		Reminders: []storage.Reminder{reminder},
+	})
+	require.NoError(t, err)
+	event, err := repo.GetEvent(ctx, eventID)
+	require.NoError(t, err)
+	id := event.NotificationID(reminder)
+
+	publishFailures := testutil.ToFloat64(metrics.SchedulerPublishFailures)
+	scheduler.ProcessEvents(ctx)
+	scheduler.ProcessEvents(ctx)
+	scheduler.RelayOutbox(ctx)
+	require.Empty(t, broker.published)
+	require.Equal(t, publishFailures+1, testutil.ToFloat64(metrics.SchedulerPublishFailures))
+
+	// следующая попытка отложена, брокер снова доступен
+	messages, err := repo.ListOutbox(ctx, outboxBatchSize)
+	require.NoError(t, err)
+	require.Empty(t, messages)
+	broker.fail = false
+	require.NoError(t, repo.MarkOutboxFailed(ctx, id, time.Now(), errBrokerDown.Error()))
+
+	scheduler.RelayOutbox(ctx)
+	scheduler.RelayOutbox(ctx)
+	require.Len(t, broker.published, 1, "notification is published once")
+	notification := storage.Notification{}
+	require.NoError(t, json.Unmarshal(broker.published[0], &notification))
+	require.Equal(t, id, notification.ID)
+	require.WithinDuration(t, time.Now(), notification.PublishedAt, time.Minute)
+}
+
+func TestProcessEventsReminders(t *testing.T) {
+	ctx := context.Background()
+	broker := &testBroker{}
+	repo := memorystorage.New(false)
+	scheduler := New(app.New(logger.New("INFO", "/tmp/calendar-scheduler-test.log"), repo, broker), "events",
+		Intervals{})
+
+	startTime := time.Now().Add(time.Minute * 30)
+	_, err := repo.CreateEvent(ctx, storage.Event{
+		Title: "event", UserID: 1, StartTime: startTime, StopTime: startTime.Add(time.Hour),
+		Reminders: []storage.Reminder{
+			{Before: time.Hour, Channel: "email"}, {Before: time.Hour, Channel: "webhook"}, {Before: time.Minute},
+		},
+	})
+	require.NoError(t, err)
+
+	// каждое наступившее напоминание отправляется отдельным уведомлением в свой канал
+	scheduler.ProcessEvents(ctx)
+	scheduler.RelayOutbox(ctx)
+	require.Len(t, broker.published, 2)
+	channels := make([]string, 0, len(broker.published))
+	ids := make(map[string]bool)
+	for _, payload := range broker.published {
+		notification := storage.Notification{}
+		require.NoError(t, json.Unmarshal(payload, &notification))
+		channels = append(channels, notification.Channel)
+		ids[notification.ID] = true
+	}
+	require.ElementsMatch(t, []string{"email", "webhook"}, channels)
+	require.Len(t, ids, 2, "notification id differs per reminder")
+
+	scheduler.ProcessEvents(ctx)
+	scheduler.RelayOutbox(ctx)
+	require.Len(t, broker.published, 2, "later reminder is not due yet")
+}
+
+func TestSetIntervals(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	broker := &testBroker{}
+	repo := memorystorage.New(false)
+	scheduler := New(app.New(logger.New("INFO", "/tmp/calendar-scheduler-test.log"), repo, broker), "events",
+		Intervals{ReminderEvents: time.Hour, OldEvents: time.Hour, Outbox: time.Hour})
+
+	startTime := time.Now().Add(time.Minute * 30)
+	_, err := repo.CreateEvent(ctx, storage.Event{
+		Title: "event", UserID: 1, StartTime: startTime, StopTime: startTime.Add(time.Hour),
+		Reminders: []storage.Reminder{{Before: time.Hour}},
+	})
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scheduler.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// с часовыми интервалами уведомление не отправится до конца теста
+	scheduler.SetIntervals(Intervals{ReminderEvents: time.Millisecond * 10, Outbox: time.Millisecond * 10})
+	require.Equal(t, defaultOldEvents, scheduler.Intervals().OldEvents)
+	require.Eventually(t, func() bool { return broker.publishedCount() == 1 }, time.Second*5, time.Millisecond*10)
+}
+
+func TestRetryDelay(t *testing.T) {
+	require.Equal(t, time.Second, retryDelay(0))
+	require.Equal(t, time.Second*8, retryDelay(3))
+	require.Equal(t, outboxMaxRetryDelay, retryDelay(100))
+}
@@ -0,0 +1,51 @@
+// Package admin служебный HTTP-сервер процессов без API: планировщика и хранителя.
+// Отдает метрики и проверки зависимостей.
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/app"     //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/health"  //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/metrics" //nolint:depguard
+)
+
+type Server struct {
+	server *http.Server
+	logger app.Logger
+}
+
+// NewServer создает сервер с метриками на /metrics и проверками checker на /healthz и /readyz.
+func NewServer(host string, port int, checker *health.Checker, logger app.Logger) *Server {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics.Handler())
+	checker.Register(mux)
+	return &Server{
+		server: &http.Server{
+			Addr:              net.JoinHostPort(host, strconv.Itoa(port)),
+			ReadHeaderTimeout: 10 * time.Second,
+			Handler:           mux,
+		},
+		logger: logger,
+	}
+}
+
+func (s *Server) Start(ctx context.Context) error {
+	s.logger.Info(fmt.Sprintf("starting admin server at %s", s.server.Addr))
+	if err := s.server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	<-ctx.Done()
+	return nil
+}
+
+func (s *Server) Stop(ctx context.Context) error {
+	s.logger.Info("shutting down admin server")
+	return s.server.Shutdown(ctx)
+}
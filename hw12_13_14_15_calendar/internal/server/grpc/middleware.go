@@ -0,0 +1,44 @@
+package internalgrpc
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/app" //nolint:depguard
+	"google.golang.org/grpc"                                       //nolint:depguard
+	"google.golang.org/grpc/metadata"                              //nolint:depguard
+	"google.golang.org/grpc/peer"                                  //nolint:depguard
+	"google.golang.org/grpc/status"                                //nolint:depguard
+)
+
+// логирование запросов по аналогии с HTTP API.
+func loggingInterceptor(logger app.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		sb := strings.Builder{}
+		if p, ok := peer.FromContext(ctx); ok {
+			sb.WriteString(p.Addr.String())
+			sb.WriteRune(' ')
+		}
+		sb.WriteString(info.FullMethod)
+		sb.WriteRune(' ')
+
+		startTime := time.Now()
+		resp, err := handler(ctx, req)
+		duration := time.Since(startTime).Milliseconds()
+
+		sb.WriteString(status.Code(err).String())
+		sb.WriteRune(' ')
+		sb.WriteString(strconv.FormatInt(duration, 10))
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if ua := md.Get("user-agent"); len(ua) > 0 {
+				sb.WriteRune(' ')
+				sb.WriteString(ua[0])
+			}
+		}
+
+		logger.Info(sb.String())
+		return resp, err
+	}
+}
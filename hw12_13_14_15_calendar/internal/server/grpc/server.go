@@ -0,0 +1,61 @@
+//go:generate protoc -I ../../../api --go_out=pb --go_opt=paths=source_relative --go-grpc_out=pb --go-grpc_opt=paths=source_relative EventService.proto
+
+package internalgrpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/app"            //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/server/grpc/pb" //nolint:depguard
+	"google.golang.org/grpc"                                                  //nolint:depguard
+)
+
+type Server struct {
+	server *grpc.Server
+	app    *app.App
+	addr   string
+}
+
+func NewServer(app *app.App, host string, port int) *Server {
+	server := grpc.NewServer(grpc.UnaryInterceptor(loggingInterceptor(app.Logger)))
+	pb.RegisterEventServiceServer(server, NewService(app))
+	return &Server{server: server, app: app, addr: net.JoinHostPort(host, strconv.Itoa(port))}
+}
+
+func (s *Server) Start(ctx context.Context) error {
+	s.app.Logger.Info(fmt.Sprintf("starting grpc server at %s", s.addr))
+
+	lsn, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	if err := s.server.Serve(lsn); err != nil {
+		return err
+	}
+	<-ctx.Done()
+	return nil
+}
+
+func (s *Server) Stop(ctx context.Context) error {
+	if s.server == nil {
+		return nil
+	}
+	s.app.Logger.Info("shutting down grpc server")
+
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		// не дождались завершения активных запросов, закрываем соединения принудительно
+		s.server.Stop()
+		return ctx.Err()
+	}
+}
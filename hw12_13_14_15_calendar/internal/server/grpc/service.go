@@ -0,0 +1,239 @@
+package internalgrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/app"            //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/delivery"       //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/server/grpc/pb" //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage"        //nolint:depguard
+	"google.golang.org/grpc/codes"                                            //nolint:depguard
+	"google.golang.org/grpc/metadata"                                         //nolint:depguard
+	"google.golang.org/grpc/status"                                           //nolint:depguard
+	"google.golang.org/protobuf/types/known/durationpb"                       //nolint:depguard
+	"google.golang.org/protobuf/types/known/timestamppb"                      //nolint:depguard
+)
+
+// ключ метаданных с ID вызывающего пользователя.
+const UserIDMetadataKey = "x-user-id"
+
+// ensure that we've conformed to the `EventServiceServer` with a compile-time check.
+var _ pb.EventServiceServer = (*Service)(nil)
+
+type Service struct {
+	pb.UnimplementedEventServiceServer
+	app *app.App
+}
+
+func NewService(app *app.App) *Service {
+	return &Service{app: app}
+}
+
+func (s *Service) CreateEvent(ctx context.Context, req *pb.CreateEventRequest) (*pb.CreateEventResponse, error) {
+	if req.GetEvent() == nil {
+		return nil, status.Error(codes.InvalidArgument, "event is required")
+	}
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	event, err := eventFromPB(req.GetEvent())
+	if err != nil {
+		return nil, storageErrorToStatus(err)
+	}
+	id, err := s.app.CreateUserEvent(ctx, userID, event)
+	if err != nil {
+		return nil, storageErrorToStatus(err)
+	}
+	return &pb.CreateEventResponse{Id: id}, nil
+}
+
+func (s *Service) UpdateEvent(ctx context.Context, req *pb.UpdateEventRequest) (*pb.UpdateEventResponse, error) {
+	if req.GetEvent() == nil {
+		return nil, status.Error(codes.InvalidArgument, "event is required")
+	}
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	event, err := eventFromPB(req.GetEvent())
+	if err != nil {
+		return nil, storageErrorToStatus(err)
+	}
+	err = s.app.UpdateUserEvent(ctx, userID, req.GetId(), event)
+	if err != nil {
+		return nil, storageErrorToStatus(err)
+	}
+	return &pb.UpdateEventResponse{}, nil
+}
+
+func (s *Service) DeleteEvent(ctx context.Context, req *pb.DeleteEventRequest) (*pb.DeleteEventResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = s.app.DeleteUserEvent(ctx, userID, req.GetId())
+	if err != nil {
+		return nil, storageErrorToStatus(err)
+	}
+	return &pb.DeleteEventResponse{}, nil
+}
+
+func (s *Service) GetEvent(ctx context.Context, req *pb.GetEventRequest) (*pb.GetEventResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	event, err := s.app.GetUserEvent(ctx, userID, req.GetId())
+	if err != nil {
+		return nil, storageErrorToStatus(err)
+	}
+	return &pb.GetEventResponse{Event: eventToPB(event)}, nil
+}
+
+func (s *Service) ListDay(ctx context.Context, req *pb.ListEventsRequest) (*pb.ListEventsResponse, error) {
+	return listEvents(ctx, req, s.app.Storage.ListEventsDay)
+}
+
+func (s *Service) ListWeek(ctx context.Context, req *pb.ListEventsRequest) (*pb.ListEventsResponse, error) {
+	return listEvents(ctx, req, s.app.Storage.ListEventsWeek)
+}
+
+func (s *Service) ListMonth(ctx context.Context, req *pb.ListEventsRequest) (*pb.ListEventsResponse, error) {
+	return listEvents(ctx, req, s.app.Storage.ListEventsMonth)
+}
+
+type listFunc func(ctx context.Context, userID int64, startTime time.Time) ([]*storage.Event, error)
+
+func listEvents(ctx context.Context, req *pb.ListEventsRequest, list listFunc) (*pb.ListEventsResponse, error) {
+	if req.GetStartTime() == nil {
+		return nil, status.Error(codes.InvalidArgument, "start time is required")
+	}
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// границы дня, недели и месяца считаются от startTime в часовом поясе запроса
+	location, err := storage.LoadLocation(req.GetTz())
+	if err != nil {
+		return nil, storageErrorToStatus(err)
+	}
+	events, err := list(ctx, userID, req.GetStartTime().AsTime().In(location))
+	if err != nil {
+		return nil, storageErrorToStatus(err)
+	}
+	resp := &pb.ListEventsResponse{Events: make([]*pb.Event, 0, len(events))}
+	for _, event := range events {
+		resp.Events = append(resp.Events, eventToPB(event))
+	}
+	return resp, nil
+}
+
+// userIDFromContext возвращает ID вызывающего пользователя из метаданных запроса, аналог заголовка X-User-ID.
+func userIDFromContext(ctx context.Context) (int64, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(UserIDMetadataKey)
+	if len(values) == 0 {
+		return 0, status.Error(codes.InvalidArgument, UserIDMetadataKey+" metadata is required")
+	}
+	userID, err := strconv.ParseInt(values[0], 10, 64)
+	if err != nil {
+		return 0, status.Error(codes.InvalidArgument, "invalid "+UserIDMetadataKey+": "+values[0])
+	}
+	return userID, nil
+}
+
+func eventFromPB(event *pb.Event) (storage.Event, error) {
+	result := storage.Event{
+		ID:          event.GetId(),
+		Title:       event.GetTitle(),
+		StartTime:   event.GetStartTime().AsTime(),
+		StopTime:    event.GetStopTime().AsTime(),
+		Description: event.GetDescription(),
+		UserID:      event.GetUserId(),
+		RRule:       event.GetRrule(),
+		TimeZone:    event.GetTimeZone(),
+		AllDay:      event.GetAllDay(),
+	}
+	for _, exDate := range event.GetExdates() {
+		result.ExDates = append(result.ExDates, exDate.AsTime())
+	}
+	// Timestamp не хранит часовой пояс, дни события на весь день берутся в часовом поясе события
+	if result.AllDay {
+		if location, err := storage.LoadLocation(result.TimeZone); err == nil {
+			result.StartTime, result.StopTime = result.StartTime.In(location), result.StopTime.In(location)
+		}
+	}
+	for _, reminder := range event.GetReminders() {
+		if reminder.GetBefore() == nil {
+			return storage.Event{}, fmt.Errorf("%w: before is required", storage.ErrInvalidReminder)
+		}
+		switch reminder.GetChannel() {
+		case "", delivery.ChannelEmail, delivery.ChannelWebhook, delivery.ChannelStdout:
+		default:
+			return storage.Event{}, fmt.Errorf("%w: channel %q", storage.ErrInvalidReminder, reminder.GetChannel())
+		}
+		result.Reminders = append(result.Reminders,
+			storage.Reminder{Before: reminder.GetBefore().AsDuration(), Channel: reminder.GetChannel()})
+	}
+	// устаревшее поле - напоминание в канал по умолчанию
+	if event.GetReminder() != nil { //nolint:staticcheck
+		reminder := storage.Reminder{Before: event.GetReminder().AsDuration()} //nolint:staticcheck
+		if !slices.Contains(result.Reminders, reminder) {
+			result.Reminders = append(result.Reminders, reminder)
+		}
+	}
+	return result, nil
+}
+
+func eventToPB(event *storage.Event) *pb.Event {
+	result := &pb.Event{
+		Id:          event.ID,
+		Title:       event.Title,
+		StartTime:   timestamppb.New(event.StartTime),
+		StopTime:    timestamppb.New(event.StopTime),
+		Description: event.Description,
+		UserId:      event.UserID,
+		Rrule:       event.RRule,
+		TimeZone:    event.TimeZone,
+		AllDay:      event.AllDay,
+	}
+	for _, exDate := range event.ExDates {
+		result.Exdates = append(result.Exdates, timestamppb.New(exDate))
+	}
+	for _, reminder := range event.Reminders {
+		result.Reminders = append(result.Reminders,
+			&pb.Reminder{Before: durationpb.New(reminder.Before), Channel: reminder.Channel})
+		// устаревшее поле - первое напоминание в канал по умолчанию
+		if reminder.Channel == "" && result.Reminder == nil { //nolint:staticcheck
+			result.Reminder = durationpb.New(reminder.Before) //nolint:staticcheck
+		}
+	}
+	return result
+}
+
+func storageErrorToStatus(err error) error {
+	switch {
+	case errors.Is(err, storage.ErrDateBusy):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, storage.ErrInvalidArgiments) ||
+		errors.Is(err, storage.ErrInvalidRRule) ||
+		errors.Is(err, storage.ErrInvalidTimeZone) ||
+		errors.Is(err, storage.ErrInvalidAllDay) ||
+		errors.Is(err, storage.ErrInvalidReminder) ||
+		errors.Is(err, storage.ErrUpdateUserID) ||
+		errors.Is(err, storage.ErrInvalidStopTime):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, storage.ErrEventNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, storage.ErrNotEventOwner):
+		return status.Error(codes.PermissionDenied, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
@@ -0,0 +1,252 @@
+package internalgrpc
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/app"                          //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/logger"                       //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/server/grpc/pb"               //nolint:depguard
+	memorystorage "github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage/memory" //nolint:depguard
+	"github.com/stretchr/testify/require"                                                   //nolint:depguard
+	"google.golang.org/grpc"                                                                //nolint:depguard
+	"google.golang.org/grpc/codes"                                                          //nolint:depguard
+	"google.golang.org/grpc/credentials/insecure"                                           //nolint:depguard
+	"google.golang.org/grpc/metadata"                                                       //nolint:depguard
+	"google.golang.org/grpc/status"                                                         //nolint:depguard
+	"google.golang.org/grpc/test/bufconn"                                                   //nolint:depguard
+	"google.golang.org/protobuf/types/known/durationpb"                                     //nolint:depguard
+	"google.golang.org/protobuf/types/known/timestamppb"                                    //nolint:depguard
+)
+
+func newTestClient(t *testing.T) pb.EventServiceClient {
+	t.Helper()
+	testApp := &app.App{Logger: logger.New("INFO", "/tmp/calendar-grpc-test.log"), Storage: memorystorage.New(false)}
+	server := NewServer(testApp, "", 0)
+
+	lsn := bufconn.Listen(1024 * 1024)
+	go func() {
+		_ = server.server.Serve(lsn)
+	}()
+	t.Cleanup(server.server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lsn.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewEventServiceClient(conn)
+}
+
+func userContext(userID int64) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), UserIDMetadataKey, strconv.FormatInt(userID, 10))
+}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+	testStartTime := time.Date(2025, 1, 2, 15, 0, 0, 0, time.UTC)
+	testStopTime := time.Date(2025, 1, 2, 16, 0, 0, 0, time.UTC)
+	allEvents := make(map[string]int64)
+
+	t.Run("create events", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			resp, err := client.CreateEvent(userContext(int64(i)), &pb.CreateEventRequest{Event: &pb.Event{
+				Title:       "new event " + strconv.Itoa(i),
+				StartTime:   timestamppb.New(testStartTime),
+				StopTime:    timestamppb.New(testStopTime),
+				Description: "test description",
+				UserId:      int64(i),
+				Reminder:    durationpb.New(time.Hour),
+			}})
+			require.NoError(t, err)
+			require.Equal(t, 36, len(resp.GetId()), "event id should be 36 chars long")
+			allEvents[resp.GetId()] = int64(i)
+		}
+	})
+
+	t.Run("create event ErrDateBusy", func(t *testing.T) {
+		_, err := client.CreateEvent(userContext(0), &pb.CreateEventRequest{Event: &pb.Event{
+			Title:     "busy",
+			StartTime: timestamppb.New(testStartTime),
+			StopTime:  timestamppb.New(testStopTime),
+			UserId:    0,
+		}})
+		require.Equal(t, codes.AlreadyExists, status.Code(err))
+	})
+
+	t.Run("create event without event", func(t *testing.T) {
+		_, err := client.CreateEvent(userContext(0), &pb.CreateEventRequest{})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("get event", func(t *testing.T) {
+		for eventID, userID := range allEvents {
+			resp, err := client.GetEvent(userContext(userID), &pb.GetEventRequest{Id: eventID})
+			require.NoError(t, err)
+			event := resp.GetEvent()
+			require.Equal(t, eventID, event.GetId())
+			require.Equal(t, "new event "+strconv.Itoa(int(userID)), event.GetTitle())
+			require.Equal(t, "test description", event.GetDescription())
+			require.Equal(t, testStartTime, event.GetStartTime().AsTime())
+			require.Equal(t, testStopTime, event.GetStopTime().AsTime())
+			require.Equal(t, userID, event.GetUserId())
+			require.Equal(t, time.Hour, event.GetReminder().AsDuration()) //nolint:staticcheck
+		}
+	})
+
+	t.Run("get event ErrEventNotFound", func(t *testing.T) {
+		_, err := client.GetEvent(userContext(0), &pb.GetEventRequest{Id: "bad_event_id"})
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("update event", func(t *testing.T) {
+		for eventID, userID := range allEvents {
+			_, err := client.UpdateEvent(userContext(userID), &pb.UpdateEventRequest{Id: eventID, Event: &pb.Event{
+				Id:        eventID,
+				Title:     "event title updated",
+				StartTime: timestamppb.New(testStartTime),
+				StopTime:  timestamppb.New(testStopTime),
+				UserId:    userID,
+			}})
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("update event ErrInvalidStopTime", func(t *testing.T) {
+		for eventID, userID := range allEvents {
+			_, err := client.UpdateEvent(userContext(userID), &pb.UpdateEventRequest{Id: eventID, Event: &pb.Event{
+				Id:        eventID,
+				StartTime: timestamppb.New(testStopTime),
+				StopTime:  timestamppb.New(testStartTime),
+				UserId:    userID,
+			}})
+			require.Equal(t, codes.InvalidArgument, status.Code(err))
+			break
+		}
+	})
+
+	t.Run("list events", func(t *testing.T) {
+		for _, userID := range allEvents {
+			day, err := client.ListDay(userContext(userID), &pb.ListEventsRequest{StartTime: timestamppb.New(testStartTime)})
+			require.NoError(t, err)
+			require.Equal(t, 1, len(day.GetEvents()), "user should see only own events")
+			for _, event := range day.GetEvents() {
+				require.Equal(t, allEvents[event.GetId()], event.GetUserId())
+				require.Equal(t, "event title updated", event.GetTitle())
+				require.Nil(t, event.GetReminder()) //nolint:staticcheck
+			}
+
+			week, err := client.ListWeek(userContext(userID), &pb.ListEventsRequest{StartTime: timestamppb.New(testStartTime)})
+			require.NoError(t, err)
+			require.Equal(t, 1, len(week.GetEvents()))
+
+			month, err := client.ListMonth(userContext(userID), &pb.ListEventsRequest{StartTime: timestamppb.New(testStopTime)})
+			require.NoError(t, err)
+			require.Equal(t, 0, len(month.GetEvents()))
+		}
+	})
+
+	t.Run("list events without start time", func(t *testing.T) {
+		_, err := client.ListDay(userContext(0), &pb.ListEventsRequest{})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("events of another user", func(t *testing.T) {
+		for eventID, userID := range allEvents {
+			_, err := client.GetEvent(userContext(userID+100), &pb.GetEventRequest{Id: eventID})
+			require.Equal(t, codes.NotFound, status.Code(err))
+			_, err = client.DeleteEvent(userContext(userID+100), &pb.DeleteEventRequest{Id: eventID})
+			require.Equal(t, codes.NotFound, status.Code(err))
+		}
+		_, err := client.GetEvent(ctx, &pb.GetEventRequest{Id: "bad_event_id"})
+		require.Equal(t, codes.InvalidArgument, status.Code(err), "x-user-id metadata is required")
+	})
+
+	t.Run("delete event", func(t *testing.T) {
+		for eventID, userID := range allEvents {
+			_, err := client.DeleteEvent(userContext(userID), &pb.DeleteEventRequest{Id: eventID})
+			require.NoError(t, err)
+		}
+		_, err := client.DeleteEvent(userContext(0), &pb.DeleteEventRequest{Id: "bad_event_id"})
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
+
+func TestServiceTimeZone(t *testing.T) {
+	client := newTestClient(t)
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	t.Run("all-day event with reminders", func(t *testing.T) {
+		event := &pb.Event{
+			Title:     "all day",
+			StartTime: timestamppb.New(time.Date(2025, 3, 10, 0, 0, 0, 0, moscow)),
+			StopTime:  timestamppb.New(time.Date(2025, 3, 11, 0, 0, 0, 0, moscow)),
+			UserId:    1,
+			TimeZone:  "Europe/Moscow",
+			AllDay:    true,
+			Reminders: []*pb.Reminder{
+				{Before: durationpb.New(time.Hour)},
+				{Before: durationpb.New(time.Hour * 24), Channel: "email"},
+			},
+		}
+		created, err := client.CreateEvent(userContext(1), &pb.CreateEventRequest{Event: event})
+		require.NoError(t, err)
+		resp, err := client.GetEvent(userContext(1), &pb.GetEventRequest{Id: created.GetId()})
+		require.NoError(t, err)
+		require.Equal(t, "Europe/Moscow", resp.GetEvent().GetTimeZone())
+		require.True(t, resp.GetEvent().GetAllDay())
+		require.Equal(t, time.Date(2025, 3, 10, 0, 0, 0, 0, moscow), resp.GetEvent().GetStartTime().AsTime().In(moscow))
+		require.Equal(t, time.Date(2025, 3, 11, 0, 0, 0, 0, moscow), resp.GetEvent().GetStopTime().AsTime().In(moscow))
+		reminders := make(map[time.Duration]string)
+		for _, reminder := range resp.GetEvent().GetReminders() {
+			reminders[reminder.GetBefore().AsDuration()] = reminder.GetChannel()
+		}
+		require.Equal(t, map[time.Duration]string{time.Hour: "", time.Hour * 24: "email"}, reminders)
+		require.Equal(t, time.Hour, resp.GetEvent().GetReminder().AsDuration()) //nolint:staticcheck
+	})
+
+	t.Run("list day in time zone", func(t *testing.T) {
+		// 10 марта 00:30 по Нью-Йорку уже после перехода на летнее время, сутки 9 марта короче 24 часов
+		_, err := client.CreateEvent(userContext(2), &pb.CreateEventRequest{Event: &pb.Event{
+			Title:     "after midnight",
+			StartTime: timestamppb.New(time.Date(2025, 3, 10, 0, 30, 0, 0, newYork)),
+			StopTime:  timestamppb.New(time.Date(2025, 3, 10, 1, 0, 0, 0, newYork)),
+			UserId:    2,
+		}})
+		require.NoError(t, err)
+		startTime := timestamppb.New(time.Date(2025, 3, 9, 0, 0, 0, 0, newYork))
+		day, err := client.ListDay(userContext(2), &pb.ListEventsRequest{StartTime: startTime})
+		require.NoError(t, err)
+		require.Len(t, day.GetEvents(), 1, "24 hours in UTC")
+		day, err = client.ListDay(userContext(2), &pb.ListEventsRequest{StartTime: startTime, Tz: "America/New_York"})
+		require.NoError(t, err)
+		require.Empty(t, day.GetEvents())
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, err := client.ListDay(userContext(2), &pb.ListEventsRequest{StartTime: timestamppb.Now(), Tz: "Mars/Olympus"})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+		for _, event := range []*pb.Event{
+			{TimeZone: "Mars/Olympus"},
+			{TimeZone: "Local"},
+			{Reminders: []*pb.Reminder{{Before: durationpb.New(time.Hour), Channel: "sms"}}},
+			{Reminders: []*pb.Reminder{{Channel: "email"}}},
+		} {
+			event.Title = "invalid"
+			event.StartTime = timestamppb.New(time.Date(2025, 4, 1, 10, 0, 0, 0, time.UTC))
+			event.StopTime = timestamppb.New(time.Date(2025, 4, 1, 11, 0, 0, 0, time.UTC))
+			event.UserId = 3
+			_, err := client.CreateEvent(userContext(3), &pb.CreateEventRequest{Event: event})
+			require.Equal(t, codes.InvalidArgument, status.Code(err), event.String())
+		}
+	})
+}
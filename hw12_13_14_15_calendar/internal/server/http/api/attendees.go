@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage" //nolint:depguard
+)
+
+func (s *Server) FindEventAttendees(w http.ResponseWriter, r *http.Request, id ID,
+	params FindEventAttendeesParams,
+) {
+	stEvent, err := s.app.GetUserEvent(r.Context(), params.XUserID, id)
+	if err != nil {
+		sendAPIError(w, storageErrorToAPIErrorCode(err), err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(makeAPIAttendees(stEvent.Attendees))
+}
+
+func (s *Server) InviteAttendee(w http.ResponseWriter, r *http.Request, id ID, params InviteAttendeeParams) {
+	var invitation Invitation
+	if err := json.NewDecoder(r.Body).Decode(&invitation); err != nil {
+		sendAPIError(w, http.StatusBadRequest, "Invalid format for Invitation")
+		return
+	}
+	err := s.app.InviteAttendee(r.Context(), params.XUserID, id, invitation.UserID)
+	if err != nil {
+		sendAPIError(w, storageErrorToAPIErrorCode(err), err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) RemoveAttendee(w http.ResponseWriter, r *http.Request, id ID, userID int64,
+	params RemoveAttendeeParams,
+) {
+	err := s.app.RemoveAttendee(r.Context(), params.XUserID, id, userID)
+	if err != nil {
+		sendAPIError(w, storageErrorToAPIErrorCode(err), err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) RespondInvitation(w http.ResponseWriter, r *http.Request, id ID, params RespondInvitationParams) {
+	var rsvp RSVP
+	if err := json.NewDecoder(r.Body).Decode(&rsvp); err != nil {
+		sendAPIError(w, http.StatusBadRequest, "Invalid format for RSVP")
+		return
+	}
+	err := s.app.RespondInvitation(r.Context(), params.XUserID, id, storage.RSVP(rsvp.Status))
+	if err != nil {
+		sendAPIError(w, storageErrorToAPIErrorCode(err), err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func makeAPIAttendees(stAttendees []storage.Attendee) []Attendee {
+	result := make([]Attendee, 0, len(stAttendees))
+	for _, attendee := range stAttendees {
+		result = append(result, Attendee{UserID: attendee.UserID, Status: RSVPStatus(attendee.Status)})
+	}
+	return result
+}
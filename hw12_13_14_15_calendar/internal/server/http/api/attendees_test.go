@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage"                      //nolint:depguard
+	memorystorage "github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage/memory" //nolint:depguard
+	"github.com/oapi-codegen/testutil"                                                      //nolint:depguard
+	"github.com/stretchr/testify/require"                                                   //nolint:depguard
+)
+
+func TestAttendees(t *testing.T) {
+	repo := memorystorage.New(false)
+	m := newTestHandler(t, repo)
+	startTime := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+	id, err := repo.CreateEvent(context.Background(), storage.Event{
+		Title: "meeting", UserID: 1, StartTime: startTime, StopTime: startTime.Add(time.Hour),
+	})
+	require.NoError(t, err)
+	user := func(userID int64) string { return strconv.FormatInt(userID, 10) }
+	invite := func(userID, attendeeID int64) int {
+		return testutil.NewRequest().Post("/events/"+id+"/attendees").WithHeader(userIDHeader, user(userID)).
+			WithJsonBody(Invitation{UserID: attendeeID}).GoWithHTTPHandler(t, m).Recorder.Code
+	}
+	respond := func(userID int64, status RSVPStatus) int {
+		return testutil.NewRequest().Put("/events/"+id+"/rsvp").WithHeader(userIDHeader, user(userID)).
+			WithJsonBody(RSVP{Status: status}).GoWithHTTPHandler(t, m).Recorder.Code
+	}
+	remove := func(userID, attendeeID int64) int {
+		return testutil.NewRequest().Delete("/events/"+id+"/attendees/"+user(attendeeID)).
+			WithHeader(userIDHeader, user(userID)).GoWithHTTPHandler(t, m).Recorder.Code
+	}
+	attendees := func(userID int64) []Attendee {
+		rr := doGet(t, m, "/events/"+id+"/attendees", userID)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var result []Attendee
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&result))
+		return result
+	}
+
+	t.Run("invite", func(t *testing.T) {
+		require.Equal(t, http.StatusNoContent, invite(1, 2))
+		require.Equal(t, http.StatusNoContent, invite(1, 2), "repeated invitation")
+		require.Equal(t, http.StatusBadRequest, invite(1, 1), "owner can't be invited")
+		require.Equal(t, http.StatusNotFound, invite(3, 4), "event is not visible")
+		require.Equal(t, http.StatusForbidden, invite(2, 4), "attendee can't invite")
+
+		rr := doGet(t, m, "/events/"+id, 2)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var event Event
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&event))
+		require.Equal(t, &[]Attendee{{UserID: 2, Status: NeedsAction}}, event.Attendees)
+
+		rr = doGet(t, m, "/events?startTime="+startTime.Format(time.RFC3339), 2)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var events []Event
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&events))
+		require.Len(t, events, 1, "invited event is listed")
+	})
+
+	t.Run("attendee can't change event", func(t *testing.T) {
+		update := Event{Title: "changed", StartTime: startTime, StopTime: startTime.Add(time.Hour), UserID: 1, ID: id}
+		rr := testutil.NewRequest().Put("/events/"+id).WithHeader(userIDHeader, "2").WithJsonBody(update).
+			GoWithHTTPHandler(t, m).Recorder
+		require.Equal(t, http.StatusForbidden, rr.Code)
+		rr = testutil.NewRequest().Delete("/events/"+id).WithHeader(userIDHeader, "2").GoWithHTTPHandler(t, m).Recorder
+		require.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("respond", func(t *testing.T) {
+		require.Equal(t, http.StatusNoContent, respond(2, Accepted))
+		require.Equal(t, http.StatusNotFound, respond(1, Accepted), "owner is not an attendee")
+		require.Equal(t, http.StatusNotFound, respond(3, Accepted))
+		require.Equal(t, http.StatusBadRequest, respond(2, "maybe"))
+		require.Equal(t, []Attendee{{UserID: 2, Status: Accepted}}, attendees(1))
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		require.Equal(t, http.StatusForbidden, remove(2, 2))
+		require.Equal(t, http.StatusNoContent, remove(1, 2))
+		require.Equal(t, http.StatusNotFound, remove(1, 2))
+		require.Empty(t, attendees(1))
+		require.Equal(t, http.StatusNotFound, doGet(t, m, "/events/"+id, 2).Code)
+	})
+
+	t.Run("notifications", func(t *testing.T) {
+		messages, err := repo.ListOutbox(context.Background(), 10)
+		require.NoError(t, err)
+		kinds := make([]storage.NotificationKind, 0, len(messages))
+		recipients := make([]int64, 0, len(messages))
+		for _, message := range messages {
+			kinds = append(kinds, message.Kind)
+			recipients = append(recipients, message.UserID)
+		}
+		require.Equal(t, []storage.NotificationKind{
+			storage.NotificationInvitation, storage.NotificationRSVP, storage.NotificationUninvited,
+		}, kinds)
+		require.Equal(t, []int64{2, 1, 2}, recipients)
+	})
+}
@@ -20,6 +20,57 @@ import (
 	"github.com/oapi-codegen/runtime"
 )
 
+// Defines values for AuditRecordAction.
+const (
+	Create  AuditRecordAction = "create"
+	Delete  AuditRecordAction = "delete"
+	Restore AuditRecordAction = "restore"
+	Update  AuditRecordAction = "update"
+)
+
+// Defines values for RSVPStatus.
+const (
+	Accepted    RSVPStatus = "accepted"
+	Declined    RSVPStatus = "declined"
+	NeedsAction RSVPStatus = "needs-action"
+	Tentative   RSVPStatus = "tentative"
+)
+
+// Defines values for ReminderChannel.
+const (
+	Email   ReminderChannel = "email"
+	Stdout  ReminderChannel = "stdout"
+	Webhook ReminderChannel = "webhook"
+)
+
+// Defines values for FindEventsParamsSort.
+const (
+	Asc  FindEventsParamsSort = "asc"
+	Desc FindEventsParamsSort = "desc"
+)
+
+// Attendee defines model for Attendee.
+type Attendee struct {
+	// Status response to invitation
+	Status RSVPStatus `json:"Status"`
+	UserID int64      `json:"UserID"`
+}
+
+// AuditRecord defines model for AuditRecord.
+type AuditRecord struct {
+	// Action event change
+	Action AuditRecordAction `json:"Action"`
+
+	// Actor user who changed the event, 0 is the service itself
+	Actor  int64     `json:"Actor"`
+	After  *Event    `json:"After,omitempty"`
+	Before *Event    `json:"Before,omitempty"`
+	Time   time.Time `json:"Time"`
+}
+
+// AuditRecordAction event change
+type AuditRecordAction string
+
 // Error defines model for Error.
 type Error struct {
 	// Code error code
@@ -31,15 +82,41 @@ type Error struct {
 
 // Event defines model for Event.
 type Event struct {
-	Description *string `json:"Description,omitempty"`
+	// AllDay all-day event, only dates of StartTime and StopTime are used: the event lasts from midnight of the start date to midnight after the stop date in the event time zone
+	AllDay *bool `json:"AllDay,omitempty"`
+
+	// Attendees invited users, managed by /events/{id}/attendees and ignored on update
+	Attendees   *[]Attendee `json:"Attendees,omitempty"`
+	Description *string     `json:"Description,omitempty"`
+
+	// ExDates start times of excluded occurrences (EXDATE)
+	ExDates *[]time.Time `json:"ExDates,omitempty"`
 
 	// ID event id
-	ID        string    `json:"ID"`
-	Reminder  *string   `json:"Reminder,omitempty"`
-	StartTime time.Time `json:"StartTime"`
-	StopTime  time.Time `json:"StopTime"`
-	Title     string    `json:"Title"`
-	UserID    int64     `json:"UserID"`
+	ID string `json:"ID"`
+
+	// RRule recurrence rule RFC 5545 RRULE (FREQ=DAILY|WEEKLY|MONTHLY, INTERVAL, BYDAY, COUNT, UNTIL)
+	RRule *string `json:"RRule,omitempty"`
+
+	// Reminder reminder to the default channel, use Reminders
+	// Deprecated:
+	Reminder *string `json:"Reminder,omitempty"`
+
+	// Reminders reminders, each one is sent separately
+	Reminders *[]EventReminder `json:"Reminders,omitempty"`
+	StartTime time.Time        `json:"StartTime"`
+	StopTime  time.Time        `json:"StopTime"`
+
+	// TimeZone IANA time zone of recurrence and all-day event dates, empty is UTC
+	TimeZone *string `json:"TimeZone,omitempty"`
+	Title    string  `json:"Title"`
+	UserID   int64   `json:"UserID"`
+}
+
+// EventAttendees defines model for EventAttendees.
+type EventAttendees struct {
+	// Attendees invited users, managed by /events/{id}/attendees and ignored on update
+	Attendees *[]Attendee `json:"Attendees,omitempty"`
 }
 
 // EventID defines model for EventID.
@@ -48,23 +125,276 @@ type EventID struct {
 	ID string `json:"ID"`
 }
 
+// EventReminder defines model for EventReminder.
+type EventReminder struct {
+	// Before time before event start
+	Before string `json:"Before"`
+
+	// Channel delivery channel, the user channel if not set
+	Channel *ReminderChannel `json:"Channel,omitempty"`
+}
+
+// FreeBusyRequest defines model for FreeBusyRequest.
+type FreeBusyRequest struct {
+	From time.Time `json:"From"`
+
+	// To range end, exclusive, not later than 92 days after From
+	To      time.Time `json:"To"`
+	UserIDs []int64   `json:"UserIDs"`
+}
+
+// ImportResult defines model for ImportResult.
+type ImportResult struct {
+	// Created number of created events
+	Created int             `json:"Created"`
+	Events  []ImportedEvent `json:"Events"`
+}
+
+// ImportedEvent defines model for ImportedEvent.
+type ImportedEvent struct {
+	// Error reason why VEVENT was not imported
+	Error *string `json:"Error,omitempty"`
+
+	// ID created event id
+	ID *string `json:"ID,omitempty"`
+
+	// Index VEVENT number in the file, starting from 1
+	Index int `json:"Index"`
+
+	// UID VEVENT UID
+	UID *string `json:"UID,omitempty"`
+}
+
+// Interval defines model for Interval.
+type Interval struct {
+	Start time.Time `json:"Start"`
+
+	// Stop interval end, exclusive
+	Stop time.Time `json:"Stop"`
+}
+
+// Invitation defines model for Invitation.
+type Invitation struct {
+	// UserID invited user id
+	UserID int64 `json:"UserID"`
+}
+
 // NewEvent defines model for NewEvent.
 type NewEvent struct {
-	Description *string   `json:"Description,omitempty"`
-	Reminder    *string   `json:"Reminder,omitempty"`
-	StartTime   time.Time `json:"StartTime"`
-	StopTime    time.Time `json:"StopTime"`
-	Title       string    `json:"Title"`
-	UserID      int64     `json:"UserID"`
+	// AllDay all-day event, only dates of StartTime and StopTime are used: the event lasts from midnight of the start date to midnight after the stop date in the event time zone
+	AllDay      *bool   `json:"AllDay,omitempty"`
+	Description *string `json:"Description,omitempty"`
+
+	// ExDates start times of excluded occurrences (EXDATE)
+	ExDates *[]time.Time `json:"ExDates,omitempty"`
+
+	// RRule recurrence rule RFC 5545 RRULE (FREQ=DAILY|WEEKLY|MONTHLY, INTERVAL, BYDAY, COUNT, UNTIL)
+	RRule *string `json:"RRule,omitempty"`
+
+	// Reminder reminder to the default channel, use Reminders
+	// Deprecated:
+	Reminder *string `json:"Reminder,omitempty"`
+
+	// Reminders reminders, each one is sent separately
+	Reminders *[]EventReminder `json:"Reminders,omitempty"`
+	StartTime time.Time        `json:"StartTime"`
+	StopTime  time.Time        `json:"StopTime"`
+
+	// TimeZone IANA time zone of recurrence and all-day event dates, empty is UTC
+	TimeZone *string `json:"TimeZone,omitempty"`
+	Title    string  `json:"Title"`
+	UserID   int64   `json:"UserID"`
 }
 
+// RSVP defines model for RSVP.
+type RSVP struct {
+	// Status response to invitation
+	Status RSVPStatus `json:"Status"`
+}
+
+// RSVPStatus response to invitation
+type RSVPStatus string
+
+// ReminderChannel delivery channel, the user channel if not set
+type ReminderChannel string
+
+// SlotsRequest defines model for SlotsRequest.
+type SlotsRequest struct {
+	Duration string    `json:"Duration"`
+	From     time.Time `json:"From"`
+	Limit    *int      `json:"Limit,omitempty"`
+
+	// TimeZone IANA time zone of working hours, empty is UTC
+	TimeZone *string `json:"TimeZone,omitempty"`
+
+	// To range end, exclusive, not later than 92 days after From
+	To      time.Time `json:"To"`
+	UserIDs []int64   `json:"UserIDs"`
+
+	// Weekends saturday and sunday are working days
+	Weekends *bool `json:"Weekends,omitempty"`
+
+	// WorkDayEnd working day end, HH:MM in TimeZone, 24:00 is midnight
+	WorkDayEnd *string `json:"WorkDayEnd,omitempty"`
+
+	// WorkDayStart working day start, HH:MM in TimeZone
+	WorkDayStart *string `json:"WorkDayStart,omitempty"`
+}
+
+// UserBusy defines model for UserBusy.
+type UserBusy struct {
+	Busy   []Interval `json:"Busy"`
+	UserID int64      `json:"UserID"`
+}
+
+// ID defines model for ID.
+type ID = string
+
+// TZ defines model for TZ.
+type TZ = string
+
+// UserID defines model for UserID.
+type UserID = int64
+
 // FindEventsParams defines parameters for FindEvents.
 type FindEventsParams struct {
 	// StartTime events start time
-	StartTime time.Time `form:"startTime" json:"startTime"`
+	StartTime *time.Time `form:"startTime,omitempty" json:"startTime,omitempty"`
 
 	// Period period from startTime - day, week, month
 	Period *string `form:"period,omitempty" json:"period,omitempty"`
+
+	// From range start, inclusive
+	From *time.Time `form:"from,omitempty" json:"from,omitempty"`
+
+	// To range end, exclusive
+	To *time.Time `form:"to,omitempty" json:"to,omitempty"`
+
+	// Q words to search in title and description, all words must match
+	Q *string `form:"q,omitempty" json:"q,omitempty"`
+
+	// Limit maximum number of events in response
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+
+	// Cursor opaque cursor from X-Next-Cursor header of the previous page
+	Cursor *string `form:"cursor,omitempty" json:"cursor,omitempty"`
+
+	// Sort order by event start time
+	Sort *FindEventsParamsSort `form:"sort,omitempty" json:"sort,omitempty"`
+
+	// Tz IANA time zone of period boundaries and times in response, by default times are returned in the event time zone
+	Tz *TZ `form:"tz,omitempty" json:"tz,omitempty"`
+
+	// XUserID calling user ID, events of other users are visible only to invited users
+	XUserID UserID `json:"X-User-ID"`
+}
+
+// FindEventsParamsSort defines parameters for FindEvents.
+type FindEventsParamsSort string
+
+// CreateEventParams defines parameters for CreateEvent.
+type CreateEventParams struct {
+	// XUserID calling user ID, events of other users are visible only to invited users
+	XUserID UserID `json:"X-User-ID"`
+}
+
+// DeleteEventByIDParams defines parameters for DeleteEventByID.
+type DeleteEventByIDParams struct {
+	// XUserID calling user ID, events of other users are visible only to invited users
+	XUserID UserID `json:"X-User-ID"`
+}
+
+// FindEventByIDParams defines parameters for FindEventByID.
+type FindEventByIDParams struct {
+	// Tz IANA time zone of period boundaries and times in response, by default times are returned in the event time zone
+	Tz *TZ `form:"tz,omitempty" json:"tz,omitempty"`
+
+	// XUserID calling user ID, events of other users are visible only to invited users
+	XUserID UserID `json:"X-User-ID"`
+}
+
+// UpdateEventByIDParams defines parameters for UpdateEventByID.
+type UpdateEventByIDParams struct {
+	// XUserID calling user ID, events of other users are visible only to invited users
+	XUserID UserID `json:"X-User-ID"`
+}
+
+// FindEventAttendeesParams defines parameters for FindEventAttendees.
+type FindEventAttendeesParams struct {
+	// XUserID calling user ID, events of other users are visible only to invited users
+	XUserID UserID `json:"X-User-ID"`
+}
+
+// InviteAttendeeParams defines parameters for InviteAttendee.
+type InviteAttendeeParams struct {
+	// XUserID calling user ID, events of other users are visible only to invited users
+	XUserID UserID `json:"X-User-ID"`
+}
+
+// RemoveAttendeeParams defines parameters for RemoveAttendee.
+type RemoveAttendeeParams struct {
+	// XUserID calling user ID, events of other users are visible only to invited users
+	XUserID UserID `json:"X-User-ID"`
+}
+
+// FindEventHistoryParams defines parameters for FindEventHistory.
+type FindEventHistoryParams struct {
+	// Tz IANA time zone of period boundaries and times in response, by default times are returned in the event time zone
+	Tz *TZ `form:"tz,omitempty" json:"tz,omitempty"`
+
+	// XUserID calling user ID, events of other users are visible only to invited users
+	XUserID UserID `json:"X-User-ID"`
+}
+
+// RestoreEventByIDParams defines parameters for RestoreEventByID.
+type RestoreEventByIDParams struct {
+	// XUserID calling user ID, events of other users are visible only to invited users
+	XUserID UserID `json:"X-User-ID"`
+}
+
+// RespondInvitationParams defines parameters for RespondInvitation.
+type RespondInvitationParams struct {
+	// XUserID calling user ID, events of other users are visible only to invited users
+	XUserID UserID `json:"X-User-ID"`
+}
+
+// FreeBusyParams defines parameters for FreeBusy.
+type FreeBusyParams struct {
+	// XUserID calling user ID, events of other users are visible only to invited users
+	XUserID UserID `json:"X-User-ID"`
+}
+
+// ExportEventsParams defines parameters for ExportEvents.
+type ExportEventsParams struct {
+	// StartTime events start time
+	StartTime *time.Time `form:"startTime,omitempty" json:"startTime,omitempty"`
+
+	// Period period from startTime - day, week, month
+	Period *string `form:"period,omitempty" json:"period,omitempty"`
+
+	// From range start, inclusive
+	From *time.Time `form:"from,omitempty" json:"from,omitempty"`
+
+	// To range end, exclusive
+	To *time.Time `form:"to,omitempty" json:"to,omitempty"`
+
+	// Tz IANA time zone of period boundaries and times in response, by default times are returned in the event time zone
+	Tz *TZ `form:"tz,omitempty" json:"tz,omitempty"`
+
+	// XUserID calling user ID, events of other users are visible only to invited users
+	XUserID UserID `json:"X-User-ID"`
+}
+
+// ImportEventsParams defines parameters for ImportEvents.
+type ImportEventsParams struct {
+	// XUserID calling user ID, events of other users are visible only to invited users
+	XUserID UserID `json:"X-User-ID"`
+}
+
+// SuggestSlotsParams defines parameters for SuggestSlots.
+type SuggestSlotsParams struct {
+	// XUserID calling user ID, events of other users are visible only to invited users
+	XUserID UserID `json:"X-User-ID"`
 }
 
 // CreateEventJSONRequestBody defines body for CreateEvent for application/json ContentType.
@@ -73,6 +403,18 @@ type CreateEventJSONRequestBody = NewEvent
 // UpdateEventByIDJSONRequestBody defines body for UpdateEventByID for application/json ContentType.
 type UpdateEventByIDJSONRequestBody = Event
 
+// InviteAttendeeJSONRequestBody defines body for InviteAttendee for application/json ContentType.
+type InviteAttendeeJSONRequestBody = Invitation
+
+// RespondInvitationJSONRequestBody defines body for RespondInvitation for application/json ContentType.
+type RespondInvitationJSONRequestBody = RSVP
+
+// FreeBusyJSONRequestBody defines body for FreeBusy for application/json ContentType.
+type FreeBusyJSONRequestBody = FreeBusyRequest
+
+// SuggestSlotsJSONRequestBody defines body for SuggestSlots for application/json ContentType.
+type SuggestSlotsJSONRequestBody = SlotsRequest
+
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
 	// Get all events
@@ -80,16 +422,46 @@ type ServerInterface interface {
 	FindEvents(w http.ResponseWriter, r *http.Request, params FindEventsParams)
 	// Create new event
 	// (POST /events)
-	CreateEvent(w http.ResponseWriter, r *http.Request)
+	CreateEvent(w http.ResponseWriter, r *http.Request, params CreateEventParams)
 	// Delete event by ID
 	// (DELETE /events/{id})
-	DeleteEventByID(w http.ResponseWriter, r *http.Request, id string)
+	DeleteEventByID(w http.ResponseWriter, r *http.Request, id string, params DeleteEventByIDParams)
 	// Get event by ID
 	// (GET /events/{id})
-	FindEventByID(w http.ResponseWriter, r *http.Request, id string)
+	FindEventByID(w http.ResponseWriter, r *http.Request, id string, params FindEventByIDParams)
 	// Update event by ID
 	// (PUT /events/{id})
-	UpdateEventByID(w http.ResponseWriter, r *http.Request, id string)
+	UpdateEventByID(w http.ResponseWriter, r *http.Request, id string, params UpdateEventByIDParams)
+	// Get event attendees
+	// (GET /events/{id}/attendees)
+	FindEventAttendees(w http.ResponseWriter, r *http.Request, id ID, params FindEventAttendeesParams)
+	// Invite user to event
+	// (POST /events/{id}/attendees)
+	InviteAttendee(w http.ResponseWriter, r *http.Request, id ID, params InviteAttendeeParams)
+	// Cancel invitation
+	// (DELETE /events/{id}/attendees/{userId})
+	RemoveAttendee(w http.ResponseWriter, r *http.Request, id ID, userId int64, params RemoveAttendeeParams)
+	// Get event change history
+	// (GET /events/{id}/history)
+	FindEventHistory(w http.ResponseWriter, r *http.Request, id ID, params FindEventHistoryParams)
+	// Restore deleted event
+	// (POST /events/{id}/restore)
+	RestoreEventByID(w http.ResponseWriter, r *http.Request, id ID, params RestoreEventByIDParams)
+	// Respond to invitation
+	// (PUT /events/{id}/rsvp)
+	RespondInvitation(w http.ResponseWriter, r *http.Request, id ID, params RespondInvitationParams)
+	// Get busy time of users
+	// (POST /freebusy)
+	FreeBusy(w http.ResponseWriter, r *http.Request, params FreeBusyParams)
+	// Export user events in iCalendar format
+	// (GET /ical)
+	ExportEvents(w http.ResponseWriter, r *http.Request, params ExportEventsParams)
+	// Import events from iCalendar file
+	// (POST /ical)
+	ImportEvents(w http.ResponseWriter, r *http.Request, params ImportEventsParams)
+	// Find common free slots
+	// (POST /suggest-slots)
+	SuggestSlots(w http.ResponseWriter, r *http.Request, params SuggestSlotsParams)
 }
 
 // ServerInterfaceWrapper converts contexts to parameters.
@@ -109,16 +481,9 @@ func (siw *ServerInterfaceWrapper) FindEvents(w http.ResponseWriter, r *http.Req
 	// Parameter object where we will unmarshal all parameters from the context
 	var params FindEventsParams
 
-	// ------------- Required query parameter "startTime" -------------
-
-	if paramValue := r.URL.Query().Get("startTime"); paramValue != "" {
-
-	} else {
-		siw.ErrorHandlerFunc(w, r, &RequiredParamError{ParamName: "startTime"})
-		return
-	}
+	// ------------- Optional query parameter "startTime" -------------
 
-	err = runtime.BindQueryParameter("form", true, true, "startTime", r.URL.Query(), &params.StartTime)
+	err = runtime.BindQueryParameter("form", true, false, "startTime", r.URL.Query(), &params.StartTime)
 	if err != nil {
 		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "startTime", Err: err})
 		return
@@ -132,6 +497,87 @@ func (siw *ServerInterfaceWrapper) FindEvents(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// ------------- Optional query parameter "from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "from", r.URL.Query(), &params.From)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "from", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "to", r.URL.Query(), &params.To)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "to", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "q" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "q", r.URL.Query(), &params.Q)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "q", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "cursor" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "cursor", r.URL.Query(), &params.Cursor)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "cursor", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "sort" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "sort", r.URL.Query(), &params.Sort)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "sort", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "tz" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "tz", r.URL.Query(), &params.Tz)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "tz", Err: err})
+		return
+	}
+
+	headers := r.Header
+
+	// ------------- Required header parameter "X-User-ID" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("X-User-ID")]; found {
+		var XUserID UserID
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "X-User-ID", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "X-User-ID", valueList[0], &XUserID, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: true})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "X-User-ID", Err: err})
+			return
+		}
+
+		params.XUserID = XUserID
+
+	} else {
+		err := fmt.Errorf("Header parameter X-User-ID is required, but not found")
+		siw.ErrorHandlerFunc(w, r, &RequiredHeaderError{ParamName: "X-User-ID", Err: err})
+		return
+	}
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.FindEvents(w, r, params)
 	}))
@@ -146,8 +592,38 @@ func (siw *ServerInterfaceWrapper) FindEvents(w http.ResponseWriter, r *http.Req
 // CreateEvent operation middleware
 func (siw *ServerInterfaceWrapper) CreateEvent(w http.ResponseWriter, r *http.Request) {
 
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params CreateEventParams
+
+	headers := r.Header
+
+	// ------------- Required header parameter "X-User-ID" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("X-User-ID")]; found {
+		var XUserID UserID
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "X-User-ID", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "X-User-ID", valueList[0], &XUserID, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: true})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "X-User-ID", Err: err})
+			return
+		}
+
+		params.XUserID = XUserID
+
+	} else {
+		err := fmt.Errorf("Header parameter X-User-ID is required, but not found")
+		siw.ErrorHandlerFunc(w, r, &RequiredHeaderError{ParamName: "X-User-ID", Err: err})
+		return
+	}
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.CreateEvent(w, r)
+		siw.Handler.CreateEvent(w, r, params)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -171,8 +647,36 @@ func (siw *ServerInterfaceWrapper) DeleteEventByID(w http.ResponseWriter, r *htt
 		return
 	}
 
+	// Parameter object where we will unmarshal all parameters from the context
+	var params DeleteEventByIDParams
+
+	headers := r.Header
+
+	// ------------- Required header parameter "X-User-ID" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("X-User-ID")]; found {
+		var XUserID UserID
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "X-User-ID", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "X-User-ID", valueList[0], &XUserID, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: true})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "X-User-ID", Err: err})
+			return
+		}
+
+		params.XUserID = XUserID
+
+	} else {
+		err := fmt.Errorf("Header parameter X-User-ID is required, but not found")
+		siw.ErrorHandlerFunc(w, r, &RequiredHeaderError{ParamName: "X-User-ID", Err: err})
+		return
+	}
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.DeleteEventByID(w, r, id)
+		siw.Handler.DeleteEventByID(w, r, id, params)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -196,8 +700,44 @@ func (siw *ServerInterfaceWrapper) FindEventByID(w http.ResponseWriter, r *http.
 		return
 	}
 
+	// Parameter object where we will unmarshal all parameters from the context
+	var params FindEventByIDParams
+
+	// ------------- Optional query parameter "tz" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "tz", r.URL.Query(), &params.Tz)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "tz", Err: err})
+		return
+	}
+
+	headers := r.Header
+
+	// ------------- Required header parameter "X-User-ID" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("X-User-ID")]; found {
+		var XUserID UserID
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "X-User-ID", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "X-User-ID", valueList[0], &XUserID, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: true})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "X-User-ID", Err: err})
+			return
+		}
+
+		params.XUserID = XUserID
+
+	} else {
+		err := fmt.Errorf("Header parameter X-User-ID is required, but not found")
+		siw.ErrorHandlerFunc(w, r, &RequiredHeaderError{ParamName: "X-User-ID", Err: err})
+		return
+	}
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.FindEventByID(w, r, id)
+		siw.Handler.FindEventByID(w, r, id, params)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -221,8 +761,587 @@ func (siw *ServerInterfaceWrapper) UpdateEventByID(w http.ResponseWriter, r *htt
 		return
 	}
 
+	// Parameter object where we will unmarshal all parameters from the context
+	var params UpdateEventByIDParams
+
+	headers := r.Header
+
+	// ------------- Required header parameter "X-User-ID" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("X-User-ID")]; found {
+		var XUserID UserID
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "X-User-ID", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "X-User-ID", valueList[0], &XUserID, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: true})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "X-User-ID", Err: err})
+			return
+		}
+
+		params.XUserID = XUserID
+
+	} else {
+		err := fmt.Errorf("Header parameter X-User-ID is required, but not found")
+		siw.ErrorHandlerFunc(w, r, &RequiredHeaderError{ParamName: "X-User-ID", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.UpdateEventByID(w, r, id, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// FindEventAttendees operation middleware
+func (siw *ServerInterfaceWrapper) FindEventAttendees(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id ID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", r.PathValue("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params FindEventAttendeesParams
+
+	headers := r.Header
+
+	// ------------- Required header parameter "X-User-ID" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("X-User-ID")]; found {
+		var XUserID UserID
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "X-User-ID", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "X-User-ID", valueList[0], &XUserID, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: true})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "X-User-ID", Err: err})
+			return
+		}
+
+		params.XUserID = XUserID
+
+	} else {
+		err := fmt.Errorf("Header parameter X-User-ID is required, but not found")
+		siw.ErrorHandlerFunc(w, r, &RequiredHeaderError{ParamName: "X-User-ID", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.FindEventAttendees(w, r, id, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// InviteAttendee operation middleware
+func (siw *ServerInterfaceWrapper) InviteAttendee(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id ID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", r.PathValue("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params InviteAttendeeParams
+
+	headers := r.Header
+
+	// ------------- Required header parameter "X-User-ID" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("X-User-ID")]; found {
+		var XUserID UserID
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "X-User-ID", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "X-User-ID", valueList[0], &XUserID, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: true})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "X-User-ID", Err: err})
+			return
+		}
+
+		params.XUserID = XUserID
+
+	} else {
+		err := fmt.Errorf("Header parameter X-User-ID is required, but not found")
+		siw.ErrorHandlerFunc(w, r, &RequiredHeaderError{ParamName: "X-User-ID", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.InviteAttendee(w, r, id, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// RemoveAttendee operation middleware
+func (siw *ServerInterfaceWrapper) RemoveAttendee(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id ID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", r.PathValue("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "userId" -------------
+	var userId int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "userId", r.PathValue("userId"), &userId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "userId", Err: err})
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params RemoveAttendeeParams
+
+	headers := r.Header
+
+	// ------------- Required header parameter "X-User-ID" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("X-User-ID")]; found {
+		var XUserID UserID
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "X-User-ID", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "X-User-ID", valueList[0], &XUserID, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: true})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "X-User-ID", Err: err})
+			return
+		}
+
+		params.XUserID = XUserID
+
+	} else {
+		err := fmt.Errorf("Header parameter X-User-ID is required, but not found")
+		siw.ErrorHandlerFunc(w, r, &RequiredHeaderError{ParamName: "X-User-ID", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.RemoveAttendee(w, r, id, userId, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// FindEventHistory operation middleware
+func (siw *ServerInterfaceWrapper) FindEventHistory(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id ID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", r.PathValue("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params FindEventHistoryParams
+
+	// ------------- Optional query parameter "tz" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "tz", r.URL.Query(), &params.Tz)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "tz", Err: err})
+		return
+	}
+
+	headers := r.Header
+
+	// ------------- Required header parameter "X-User-ID" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("X-User-ID")]; found {
+		var XUserID UserID
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "X-User-ID", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "X-User-ID", valueList[0], &XUserID, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: true})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "X-User-ID", Err: err})
+			return
+		}
+
+		params.XUserID = XUserID
+
+	} else {
+		err := fmt.Errorf("Header parameter X-User-ID is required, but not found")
+		siw.ErrorHandlerFunc(w, r, &RequiredHeaderError{ParamName: "X-User-ID", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.FindEventHistory(w, r, id, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// RestoreEventByID operation middleware
+func (siw *ServerInterfaceWrapper) RestoreEventByID(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id ID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", r.PathValue("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params RestoreEventByIDParams
+
+	headers := r.Header
+
+	// ------------- Required header parameter "X-User-ID" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("X-User-ID")]; found {
+		var XUserID UserID
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "X-User-ID", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "X-User-ID", valueList[0], &XUserID, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: true})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "X-User-ID", Err: err})
+			return
+		}
+
+		params.XUserID = XUserID
+
+	} else {
+		err := fmt.Errorf("Header parameter X-User-ID is required, but not found")
+		siw.ErrorHandlerFunc(w, r, &RequiredHeaderError{ParamName: "X-User-ID", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.RestoreEventByID(w, r, id, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// RespondInvitation operation middleware
+func (siw *ServerInterfaceWrapper) RespondInvitation(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id ID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", r.PathValue("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params RespondInvitationParams
+
+	headers := r.Header
+
+	// ------------- Required header parameter "X-User-ID" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("X-User-ID")]; found {
+		var XUserID UserID
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "X-User-ID", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "X-User-ID", valueList[0], &XUserID, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: true})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "X-User-ID", Err: err})
+			return
+		}
+
+		params.XUserID = XUserID
+
+	} else {
+		err := fmt.Errorf("Header parameter X-User-ID is required, but not found")
+		siw.ErrorHandlerFunc(w, r, &RequiredHeaderError{ParamName: "X-User-ID", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.RespondInvitation(w, r, id, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// FreeBusy operation middleware
+func (siw *ServerInterfaceWrapper) FreeBusy(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params FreeBusyParams
+
+	headers := r.Header
+
+	// ------------- Required header parameter "X-User-ID" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("X-User-ID")]; found {
+		var XUserID UserID
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "X-User-ID", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "X-User-ID", valueList[0], &XUserID, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: true})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "X-User-ID", Err: err})
+			return
+		}
+
+		params.XUserID = XUserID
+
+	} else {
+		err := fmt.Errorf("Header parameter X-User-ID is required, but not found")
+		siw.ErrorHandlerFunc(w, r, &RequiredHeaderError{ParamName: "X-User-ID", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.FreeBusy(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// ExportEvents operation middleware
+func (siw *ServerInterfaceWrapper) ExportEvents(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ExportEventsParams
+
+	// ------------- Optional query parameter "startTime" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "startTime", r.URL.Query(), &params.StartTime)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "startTime", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "period" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "period", r.URL.Query(), &params.Period)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "period", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "from", r.URL.Query(), &params.From)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "from", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "to", r.URL.Query(), &params.To)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "to", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "tz" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "tz", r.URL.Query(), &params.Tz)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "tz", Err: err})
+		return
+	}
+
+	headers := r.Header
+
+	// ------------- Required header parameter "X-User-ID" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("X-User-ID")]; found {
+		var XUserID UserID
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "X-User-ID", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "X-User-ID", valueList[0], &XUserID, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: true})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "X-User-ID", Err: err})
+			return
+		}
+
+		params.XUserID = XUserID
+
+	} else {
+		err := fmt.Errorf("Header parameter X-User-ID is required, but not found")
+		siw.ErrorHandlerFunc(w, r, &RequiredHeaderError{ParamName: "X-User-ID", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ExportEvents(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// ImportEvents operation middleware
+func (siw *ServerInterfaceWrapper) ImportEvents(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ImportEventsParams
+
+	headers := r.Header
+
+	// ------------- Required header parameter "X-User-ID" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("X-User-ID")]; found {
+		var XUserID UserID
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "X-User-ID", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "X-User-ID", valueList[0], &XUserID, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: true})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "X-User-ID", Err: err})
+			return
+		}
+
+		params.XUserID = XUserID
+
+	} else {
+		err := fmt.Errorf("Header parameter X-User-ID is required, but not found")
+		siw.ErrorHandlerFunc(w, r, &RequiredHeaderError{ParamName: "X-User-ID", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ImportEvents(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// SuggestSlots operation middleware
+func (siw *ServerInterfaceWrapper) SuggestSlots(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params SuggestSlotsParams
+
+	headers := r.Header
+
+	// ------------- Required header parameter "X-User-ID" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("X-User-ID")]; found {
+		var XUserID UserID
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "X-User-ID", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "X-User-ID", valueList[0], &XUserID, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: true})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "X-User-ID", Err: err})
+			return
+		}
+
+		params.XUserID = XUserID
+
+	} else {
+		err := fmt.Errorf("Header parameter X-User-ID is required, but not found")
+		siw.ErrorHandlerFunc(w, r, &RequiredHeaderError{ParamName: "X-User-ID", Err: err})
+		return
+	}
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.UpdateEventByID(w, r, id)
+		siw.Handler.SuggestSlots(w, r, params)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -357,6 +1476,16 @@ func HandlerWithOptions(si ServerInterface, options StdHTTPServerOptions) http.H
 	m.HandleFunc("DELETE "+options.BaseURL+"/events/{id}", wrapper.DeleteEventByID)
 	m.HandleFunc("GET "+options.BaseURL+"/events/{id}", wrapper.FindEventByID)
 	m.HandleFunc("PUT "+options.BaseURL+"/events/{id}", wrapper.UpdateEventByID)
+	m.HandleFunc("GET "+options.BaseURL+"/events/{id}/attendees", wrapper.FindEventAttendees)
+	m.HandleFunc("POST "+options.BaseURL+"/events/{id}/attendees", wrapper.InviteAttendee)
+	m.HandleFunc("DELETE "+options.BaseURL+"/events/{id}/attendees/{userId}", wrapper.RemoveAttendee)
+	m.HandleFunc("GET "+options.BaseURL+"/events/{id}/history", wrapper.FindEventHistory)
+	m.HandleFunc("POST "+options.BaseURL+"/events/{id}/restore", wrapper.RestoreEventByID)
+	m.HandleFunc("PUT "+options.BaseURL+"/events/{id}/rsvp", wrapper.RespondInvitation)
+	m.HandleFunc("POST "+options.BaseURL+"/freebusy", wrapper.FreeBusy)
+	m.HandleFunc("GET "+options.BaseURL+"/ical", wrapper.ExportEvents)
+	m.HandleFunc("POST "+options.BaseURL+"/ical", wrapper.ImportEvents)
+	m.HandleFunc("POST "+options.BaseURL+"/suggest-slots", wrapper.SuggestSlots)
 
 	return m
 }
@@ -364,19 +1493,60 @@ func HandlerWithOptions(si ServerInterface, options StdHTTPServerOptions) http.H
 // Base64 encoded, gzipped, json marshaled Swagger object
 var swaggerSpec = []string{
 
-	"H4sIAAAAAAAC/9RVXXPaOhD9K5q991EEQ4B7r99uAu3wknba5CmTBwUtoNaWFEkk8TD+7x1JBvNhApmm",
-	"0/TJHmnXe86e3eMlTFSulUTpLKRLsJM55iy8joxRxr9oozQaJzAcTxRH/+RoJ0ZoJ5SEFNAHk3BHwRUa",
-	"IQUhHc7QQEkhR2vZ7GDe6nqdap0RcgZlScHgw0IY5JDeQvX9VfhdSWH0iNL577Is+zSF9HYJfxucQgp/",
-	"tWtq7YpX+wqfYkZJXw4MUeMhlOsi4+F+M+LZDiUfTAQHCvjMcp15Qp3uOfb6g39a+O9/961Ol5+3WK8/",
-	"aPW6g0G/3+slSZIcpT8eBsprDntwhptAlhvlr+fCEmEJIxKfSEC4X43CF8yF5BhUnyqTMwcpaDRC8abw",
-	"r44Zdy1y3IrnzGHL+dPGFKVfl3EtXIbbbK5e4nBj0URZ1gkdWtcS0g16DTO60+pYdZPiBvZ1kbvS5wk5",
-	"Vb6ei0jhkmUoOTPk/89jYtE8ogEKj2hsHJDOWXKWeKhKo2RaQArn4YiCZm4elGwHfuF1hkFprzPzyo45",
-	"pPBBSD6KIT7LsBwdGhvmv2EcLbGeCKmaLPzFwwJNARQky2MHa6Z1J5xZIK1c4VTN/GptY4gjRKZG5WRd",
-	"h7QIZwUlT4jfKcmVdPMD0NYTWOPYLXnnQVutpI2b0E2SaFbSrfxB60xMQgfb32xckPp7wmEeEo96gheu",
-	"qs6MYQWEGWjs+QoRhIApW2TuVaBexBLcuaH2jcRnjROHnGAVQ8Eu8pyZAlL4iI6wLCPVfJUUtLINA3Zp",
-	"kDkcVVvmBwKtu1C8eDMGtRVvL58fufIn5TzJ2Q/o9i5li3JsuLe/rkyivRS8jP+hDB3uazkM54H3RTEe",
-	"nuQY8QcWttGbUr2M4fywQRxfzN6hX2aEz99T22PnYsvJfUH82NAjjnx6i0Pcr2jxGy/Ln7Uq3uF2BNOL",
-	"BsFuNGe/dSve3lJf4acHt3AR2vKutjAqtS1qWZY/AgAA//9jvCHivAwAAA==",
+	"H4sIAAAAAAAC/+xb23PbNpf/VzDYPjQzkC27drZVpw+KpWw06zhdRU6aJtkZiDyS8IUEGACUrKb637/B",
+	"jaREyqYdN58605dEEkHg4Fx/5+IvOBJpJjhwrXDvC86opClokPbbaGD+jUFFkmWaCY57GJbANWIxJpiZ",
+	"7xnVC0wwpyngHra/S/icMwkx7mmZA8EqWkBKzVZ6nZlVSkvG53izIXjye/2IUf+qjzRLAf0hOCAxQxlI",
+	"JmI0FTmPqWSgEOWxXaIQ40iCygRXQNB0jWKY0TzR/imVgCToXHKIzVK9AOSuUBzwgYe7fM5BrsvL6D9w",
+	"lXi4oWmWmAfDXIoMjl8KFYkVJg3XulYgm7gX0SRhfI5yBRKNBsTRoswdhV6AtA8c1Uum2DQBJHiyRlog",
+	"xpdMQ+xWBIoXQGOQJcm/dczJndHgVjHMhEypNuLi+ulZeQHGNcxB4s1mE5ZbPehrDTwGMJ8zc3WpGdgn",
+	"rzXVuf30nYQZ7uH/Oi4V6thvcTx+/eZXv3KLNwVHT0kLmqo3eh92IYGEjxuC+3nM9BgiIeM6qf3IyaBZ",
+	"oaMF5XPABAPPU7N/JIFq80Oexe5DDAnYDxKUFhLwx5rkiTlFyPohVt6rhfDnxKUeEtRFTNnvCuSSRYCY",
+	"VpDMMCn5c9KCPwT3ZxrkXbIYmkPN6mcwM5dou3zCUtjSHcOVjjGiRgOoispzPjDH72UENpRSyLqoIhFD",
+	"g6DMYmSfNd0+BaXofO974fFdxPr9w3JLpeVB7wumSfJqhnvvb2fZFawC11rwdjRoty7YoMKbgqbyt7q2",
+	"Vx9t82PLkRCUUk6NRk7X6Nh5o+MvLN4c07CD9bVszoWEGAmOCoNgGtI7bT8Qgi2fafyKJ+vgkbwkqJR0",
+	"7ZxOYEntPneEotI3n5z+AGfnT/+7Az/+NO2cnMY/dOjZ+dPO2enTp+fnZ2fdbrd7pxKMBqXgx5AyHkOD",
+	"mpYWtE2XjSxT+9DHGqWp1Ntknqe4YtQuwOEGh3KxoJxDcqeL9VSG5bs38rSaWz2XAM9ytR7D5xyUrt/r",
+	"uRRpW1MneCLqHJDGySHgMUFwEyW5YksgiAuNEqpBIr2gHP10imK6Vogat4XsoaTlmc75W2ILJWzhIFN6",
+	"M3LLz7sEp4z7byc1VWyKNQoT7KmcCMvJUZoJqceg8qSBjRc2gsR17vA8nYI0Md8FmdijgEaah8uAzVqZ",
+	"myMJ4sJv33qxQGJxTHmrsEXtWoXP3hE5UCU4Wi3W6M3wzfBqglZUWZEzv1+TIBsxUpUpiDW/x2O4qb/q",
+	"T/YM9nBvxhIgzgQN9JpJkaKTRl5fjwZ797weDcp39rkNS5XlIdcglzRpRExSt7eu11pkTS7cbb9jYi3t",
+	"Z4fq19452aMc8UumaUBL2+TvA7bVoLLjkh8O7gwxRTSth7gkGdC1I8VCftyb0UQB2SGNJkknpusAtyye",
+	"NtyxqNte3uARG+UMC9wXCeYqca+SMCRUaeXUJ2UxZ/OFNjuYBVa57KYGqRdPnWdzC0Tmnt+Sg3imTIVI",
+	"gHIj/UH1ItUMZLJgysBGijis3GZN2jO8GZiL1sXlCHYpkpg5DYpNeI+iXErgESj0/fC3QX8yfFKN9O2U",
+	"dtvnEDwe5wk0uYxwGJJ5Amj8/AKdn5+do/H4+nKIvn8+Hv7fL4P+6PLdn2+Hw/+9fPfny1dXkxeX7wga",
+	"XU2G4zf9S4KevRv03xF08er6akLQ9dVkdPlkK9LaXdz7P9vFv7x8Rd4Of7Zv/HLSbbpANebHkEmInCd3",
+	"sGX3Fm6tkbyRbMg/IxeJidEjFDZU7YJ+ubyBaf4RQUCjBRIcjCIoCzPA5O8aknVbdLaNcBpEV9jH/VzW",
+	"/d4wq38XHNqUAipKYyx2y7idWRMEaabXhivXkwtM2ifuhhLtNLV85+o2A2vIZU/u7+7cqVVmV7hIqt7Q",
+	"ZNKPkYXXQ0DIoSurGnTP1VmKcgT1iV1ImjlArDo05Hs0iiBzkT+GKGHcftTAzYvL5gR6F8jWiIghYUuQ",
+	"69LAjNXZuON/QWxmgYcCXaENUsoSTPAKpgshPmGClY5FrhupeJ0IrSoAuV3mt4usN2RXUoNc0ro3/6Hb",
+	"Mhm4ZCnTWxHvpGthLUvNJU+6Dtb6b00Q5z6mthLyk0FMC5HLr7KqtwCfgMfq7litqM6lsWdj28pU+9Y2",
+	"FgdSTMLQGCnfCvlpQNdDHm8dgk9+7Nl8b/uYynYOQr140Xv50sTmwB+CTs96XVuaCeEcE5xRrUGaHf7/",
+	"w4f4w4e45/77rvHWjqQC8JVEdX+6iygbnxvIuh8NO1ZeKN9HW0IwbsVoa0Ni639tl3EEqNsQPUr3+ODy",
+	"nqXloy0OMD6zuaZ2Thpf0AR4TCXq/zqyxTOQmOAlSOUYenLUPeoaMkQGnGbMWJr9yTJxYW/mSx7m4xx0",
+	"3SrGtnasijrtEmRCs8zIybgday8u231vkCFBWjxBwoE+X7UuMg/qCwFGmEdoyGzBt/jFvDUVeuEQpq1v",
+	"C5TmSqMpIAX6CA3omqAVwCf7NBVcL6ol8ZlIErGyJ0eBMx6f6j9Kyz6yWNOI2yrDKDYYifF4GHLQag9g",
+	"j8Mrlxx7QW3ILuc8x0qkuafKripBr6FEfWses3um57jlYMnYDooD54hj2x5SCsd7S8eiudTh7ZXxMhNr",
+	"OmDmygdfe82m8sqeE7V4hPNWQsbKqKMCKqOFzWGMDVo9rCwlBo4ht9pqbkp1tI/Zn+/HZx/kUFk88QpW",
+	"6f/sOSmxUbN6WiV87sTPOwJonS6R0c85oCiXSkineb91ruBGdy7cT65FEywxk7BkIlcoo/N99Lq97sce",
+	"Ic0h03W14Hir1Qm5hyWYqqgCm9w3c1gDUNqQO/3D5He8+UgK6Gg97Wm3i22Zn+tQWc+yhEXWIR3/Szl8",
+	"VNLWPpVpqHhtSLNjqiiNE5E9YUt2DbUpJ1MvTA432gqSIDq1GZhw2b0pFQQJ7xeiI82z/R7suJULtjrX",
+	"cOtrDjcZRLaw5tcQrPI0pXKNe/h/QFvj9eFwQ3AmHPTdjhSuYjj0+dDDQsVHF+pB6WciXj/a1cu+yzaY",
+	"0DKHzVdqYKs2zh5VKzXtgOTt5FipHpnH1f6P033b8awpwcD+bu/9bG1R2mNihscaLqh7nbN9PSR30fiQ",
+	"BOR47N35dI0svwJG3YPe/gphjAaPIoxvEyhaxIe/k5Eap7yjAFneoADXtiP7t7DHx3f79/D5e+3ftbQP",
+	"yv6dTLfFv+OiyxZ9JX3d4xrKeYCvUI47Vhax/a+GetWZgrvQXsEjZCGyG3QIXaqDtPVSqhUUtr2XGaLw",
+	"q8WKg0QR5X5A7AhNFrA1LIbmoG2nSGg285dy9TXDlbIZxbjZDCVMmWqFOkJjyFwntqz1oliA7eouGJ83",
+	"VRJs7xAKAX0DZXt8j1Lpfz7UrTgFc0I4JC1z4nFqocUe6Ff6leMvZuVoFw22UMaI8shU4gtWVsr0Dfp4",
+	"VFOkMaRi+a0UqRb4Agcq/eyGAOiY85Wjlq1gasUCHWeTw9Kri11x15VqwZQWcr230trPMuBxx7bnEzEv",
+	"Kjx+ZFIRJJIYlEYzJpV2bs7vadbSAOL9W6Y5vqQsoWaG1rdm3XCtGcNUdFXxfH5Wy25glPG2CukLf42/",
+	"XCEPpa5SHaptEW+DSKR9YyvqOkG6qtRBRl5PYNDUmgqHwd/el/vEZf8WqSicLY5yoUM/wQ9/l8PgZqnd",
+	"ockx2u0eBe0/CNDtRdH+ogflmDy3tp1Dg2TV0o5Z+fRq+4iJ66iUg/uhK+MDPNkSeRPaWi2Ah0Ehnavg",
+	"0hqFmwkej6rN9b8hgrJjCg/FTmEFUnR5cMpkpLMz/mCVaSYBpqGZKtQtncQUpB23ztUahTk+5aOd9ZtW",
+	"HAG7r5iB2uj9c9tcnIgnxP4k8uCzYtCUJeoIuf4dCoMWxuEWiItKsO7GHmr8b2OI89MLh1bXrU1VPH55",
+	"t1UgLPrlLaLgjnT90J9r0tghpqqQ1aFFw0JNDK2eQqPkLKLJXvw2vMmE1I/fKSeI+qEv86QAd3DjpoqR",
+	"4BFYm0BMKzc6+IDmeoM9uAv90xb/py3+uCVsDTf6OExobBv07pE1431z0b8cXg36Y6fx1rmXLbtD8SHO",
+	"cqrUGQ/Iinkdz+69TcZR+giWd2s0up8Mvl0vcesvShoY7/6WAkm7wPDRowb3RwoHVWlylHrxW9dTUQCW",
+	"gAspKp/PQemOMgOXd4OnyHA9yjVbArKvhGSpjKixn3IL0GlnitFCcdPpLv/SdiahERC9drTZWdBDA0Vb",
+	"A6r/KUS0f/Svri6GyUFkZUmgEgYPSHdNsQdFIk0FRyXdZqPNvwcA11+jQjE/AAA=",
 }
 
 // GetSwagger returns the content of the embedded swagger specification file
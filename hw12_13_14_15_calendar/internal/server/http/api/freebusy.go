@@ -0,0 +1,115 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/freebusy" //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage"  //nolint:depguard
+)
+
+// значения по умолчанию параметров подбора времени встречи, как в api.yaml.
+const (
+	defaultWorkDayStart = "09:00"
+	defaultWorkDayEnd   = "18:00"
+	defaultSlotsLimit   = 10
+)
+
+func (s *Server) FreeBusy(w http.ResponseWriter, r *http.Request, _ FreeBusyParams) {
+	var request FreeBusyRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		sendAPIError(w, http.StatusBadRequest, "Invalid format for FreeBusyRequest")
+		return
+	}
+	usersBusy, err := s.app.FreeBusy(r.Context(), request.UserIDs, request.From, request.To)
+	if err != nil {
+		sendAPIError(w, storageErrorToAPIErrorCode(err), err.Error())
+		return
+	}
+	result := make([]UserBusy, 0, len(usersBusy))
+	for _, userBusy := range usersBusy {
+		result = append(result, UserBusy{UserID: userBusy.UserID, Busy: makeAPIIntervals(userBusy.Busy)})
+	}
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+func (s *Server) SuggestSlots(w http.ResponseWriter, r *http.Request, _ SuggestSlotsParams) {
+	var request SlotsRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		sendAPIError(w, http.StatusBadRequest, "Invalid format for SlotsRequest")
+		return
+	}
+	duration, err := time.ParseDuration(request.Duration)
+	if err != nil {
+		sendAPIError(w, http.StatusBadRequest, "Invalid format for Duration")
+		return
+	}
+	hours, err := makeWorkingHours(request)
+	if err != nil {
+		sendAPIError(w, storageErrorToAPIErrorCode(err), err.Error())
+		return
+	}
+	limit := defaultSlotsLimit
+	if request.Limit != nil {
+		limit = *request.Limit
+	}
+	slots, err := s.app.SuggestSlots(r.Context(), request.UserIDs, request.From, request.To, duration, hours, limit)
+	if err != nil {
+		sendAPIError(w, storageErrorToAPIErrorCode(err), err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(makeAPIIntervals(slots))
+}
+
+func makeWorkingHours(request SlotsRequest) (freebusy.WorkingHours, error) {
+	workDayStart, workDayEnd := defaultWorkDayStart, defaultWorkDayEnd
+	if request.WorkDayStart != nil {
+		workDayStart = *request.WorkDayStart
+	}
+	if request.WorkDayEnd != nil {
+		workDayEnd = *request.WorkDayEnd
+	}
+	start, err := parseClock(workDayStart)
+	if err != nil {
+		return freebusy.WorkingHours{}, err
+	}
+	stop, err := parseClock(workDayEnd)
+	if err != nil {
+		return freebusy.WorkingHours{}, err
+	}
+	var timeZone string
+	if request.TimeZone != nil {
+		timeZone = *request.TimeZone
+	}
+	location, err := storage.LoadLocation(timeZone)
+	if err != nil {
+		return freebusy.WorkingHours{}, err
+	}
+	return freebusy.WorkingHours{
+		Start: start, Stop: stop, Weekends: request.Weekends != nil && *request.Weekends, Location: location,
+	}, nil
+}
+
+// parseClock время суток HH:MM как смещение от полуночи, 24:00 - конец суток.
+func parseClock(value string) (time.Duration, error) {
+	if value == "24:00" {
+		return 24 * time.Hour, nil
+	}
+	clock, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: time of day %q", storage.ErrInvalidArgiments, value)
+	}
+	return time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute, nil
+}
+
+func makeAPIIntervals(intervals []freebusy.Interval) []Interval {
+	result := make([]Interval, 0, len(intervals))
+	for _, interval := range intervals {
+		result = append(result, Interval{Start: interval.Start, Stop: interval.Stop})
+	}
+	return result
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage"                      //nolint:depguard
+	memorystorage "github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage/memory" //nolint:depguard
+	"github.com/oapi-codegen/testutil"                                                      //nolint:depguard
+	"github.com/stretchr/testify/require"                                                   //nolint:depguard
+)
+
+func TestFreeBusy(t *testing.T) {
+	repo := memorystorage.New(false)
+	m := newTestHandler(t, repo)
+	// четверг 2 января 2025 года
+	at := func(hour, minute int) time.Time { return time.Date(2025, 1, 2, hour, minute, 0, 0, time.UTC) }
+	for _, event := range []storage.Event{
+		{Title: "standup", UserID: 1, StartTime: at(9, 0), StopTime: at(9, 30)},
+		{Title: "review", UserID: 1, StartTime: at(9, 30), StopTime: at(10, 30)},
+		{Title: "lunch", UserID: 2, StartTime: at(12, 0), StopTime: at(13, 0)},
+		{Title: "call", UserID: 2, StartTime: at(10, 0), StopTime: at(11, 0)},
+	} {
+		_, err := repo.CreateEvent(context.Background(), event)
+		require.NoError(t, err)
+	}
+	post := func(t *testing.T, url string, body any, result any) int {
+		t.Helper()
+		rr := testutil.NewRequest().Post(url).WithHeader(userIDHeader, "1").WithJsonBody(body).
+			GoWithHTTPHandler(t, m).Recorder
+		if rr.Code == http.StatusOK {
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(result))
+		}
+		return rr.Code
+	}
+
+	t.Run("freebusy", func(t *testing.T) {
+		var result []UserBusy
+		code := post(t, "/freebusy", FreeBusyRequest{UserIDs: []int64{2, 1, 3}, From: at(0, 0), To: at(12, 30)}, &result)
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, []UserBusy{
+			{UserID: 2, Busy: []Interval{{Start: at(10, 0), Stop: at(11, 0)}, {Start: at(12, 0), Stop: at(12, 30)}}},
+			{UserID: 1, Busy: []Interval{{Start: at(9, 0), Stop: at(10, 30)}}},
+			{UserID: 3, Busy: []Interval{}},
+		}, result)
+
+		for _, request := range []FreeBusyRequest{
+			{UserIDs: []int64{}, From: at(0, 0), To: at(12, 0)},
+			{UserIDs: []int64{1}, From: at(12, 0), To: at(0, 0)},
+			{UserIDs: []int64{1}, From: at(0, 0), To: at(0, 0).AddDate(1, 0, 0)},
+		} {
+			require.Equal(t, http.StatusBadRequest, post(t, "/freebusy", request, nil), request)
+		}
+	})
+
+	t.Run("suggest slots", func(t *testing.T) {
+		var result []Interval
+		workDayEnd, limit := "13:30", 3
+		request := SlotsRequest{
+			UserIDs: []int64{1, 2}, From: at(0, 0), To: at(0, 0).AddDate(0, 0, 7), Duration: "1h",
+			WorkDayEnd: &workDayEnd, Limit: &limit,
+		}
+		require.Equal(t, http.StatusOK, post(t, "/suggest-slots", request, &result))
+		require.Equal(t, []Interval{
+			{Start: at(11, 0), Stop: at(12, 0)},
+			// следующий рабочий день - пятница
+			{Start: at(9, 0).AddDate(0, 0, 1), Stop: at(10, 0).AddDate(0, 0, 1)},
+			{Start: at(10, 0).AddDate(0, 0, 1), Stop: at(11, 0).AddDate(0, 0, 1)},
+		}, result)
+
+		invalid := request
+		invalid.Duration = "hour"
+		require.Equal(t, http.StatusBadRequest, post(t, "/suggest-slots", invalid, nil))
+		invalid = request
+		workDayStart := "14:00"
+		invalid.WorkDayStart = &workDayStart
+		require.Equal(t, http.StatusBadRequest, post(t, "/suggest-slots", invalid, nil))
+		badClock := "25:00"
+		invalid.WorkDayStart = &badClock
+		require.Equal(t, http.StatusBadRequest, post(t, "/suggest-slots", invalid, nil))
+	})
+
+	t.Run("suggest slots in time zone", func(t *testing.T) {
+		var result []Interval
+		// рабочий день 12:00-16:30 по Москве совпадает с 9:00-13:30 UTC
+		workDayStart, workDayEnd, timeZone, limit := "12:00", "16:30", "Europe/Moscow", 2
+		request := SlotsRequest{
+			UserIDs: []int64{1, 2}, From: at(0, 0), To: at(0, 0).AddDate(0, 0, 7), Duration: "1h",
+			WorkDayStart: &workDayStart, WorkDayEnd: &workDayEnd, TimeZone: &timeZone, Limit: &limit,
+		}
+		require.Equal(t, http.StatusOK, post(t, "/suggest-slots", request, &result))
+		require.Len(t, result, 2)
+		require.True(t, at(11, 0).Equal(result[0].Start), result[0].Start)
+		require.True(t, at(9, 0).AddDate(0, 0, 1).Equal(result[1].Start), result[1].Start)
+
+		badZone := "Mars/Olympus"
+		request.TimeZone = &badZone
+		require.Equal(t, http.StatusBadRequest, post(t, "/suggest-slots", request, nil))
+	})
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage" //nolint:depguard
+)
+
+func (s *Server) FindEventHistory(w http.ResponseWriter, r *http.Request, id ID, params FindEventHistoryParams) {
+	location, err := requestLocation(params.Tz)
+	if err != nil {
+		sendAPIError(w, storageErrorToAPIErrorCode(err), err.Error())
+		return
+	}
+	history, err := s.app.EventHistory(r.Context(), params.XUserID, id)
+	if err != nil {
+		sendAPIError(w, storageErrorToAPIErrorCode(err), err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(makeAPIHistory(history, location))
+}
+
+func (s *Server) RestoreEventByID(w http.ResponseWriter, r *http.Request, id ID, params RestoreEventByIDParams) {
+	err := s.app.RestoreUserEvent(r.Context(), params.XUserID, id)
+	if err != nil {
+		sendAPIError(w, storageErrorToAPIErrorCode(err), err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func makeAPIHistory(history []*storage.AuditRecord, location *time.Location) []AuditRecord {
+	result := make([]AuditRecord, 0, len(history))
+	for _, record := range history {
+		apiRecord := AuditRecord{
+			Action: AuditRecordAction(record.Action),
+			Actor:  record.Actor,
+			Time:   inLocation(record.Time, location),
+		}
+		if record.Before != nil {
+			before := makeAPIEvent(record.Before, location)
+			apiRecord.Before = &before
+		}
+		if record.After != nil {
+			after := makeAPIEvent(record.After, location)
+			apiRecord.After = &after
+		}
+		result = append(result, apiRecord)
+	}
+	return result
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	memorystorage "github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage/memory" //nolint:depguard
+	"github.com/oapi-codegen/testutil"                                                      //nolint:depguard
+	"github.com/stretchr/testify/require"                                                   //nolint:depguard
+)
+
+func TestHistory(t *testing.T) {
+	m := newTestHandler(t, memorystorage.New(false))
+	startTime := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+	user := func(userID int64) string { return strconv.FormatInt(userID, 10) }
+
+	newEvent := NewEvent{Title: "meeting", UserID: 1, StartTime: startTime, StopTime: startTime.Add(time.Hour)}
+	rr := testutil.NewRequest().Post("/events").WithHeader(userIDHeader, user(1)).WithJsonBody(newEvent).
+		GoWithHTTPHandler(t, m).Recorder
+	require.Equal(t, http.StatusCreated, rr.Code)
+	var eventID EventID
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&eventID))
+	id := eventID.ID
+
+	rr = testutil.NewRequest().Post("/events/"+id+"/attendees").WithHeader(userIDHeader, user(1)).
+		WithJsonBody(Invitation{UserID: 2}).GoWithHTTPHandler(t, m).Recorder
+	require.Equal(t, http.StatusNoContent, rr.Code)
+	update := Event{Title: "changed", UserID: 1, StartTime: startTime, StopTime: startTime.Add(time.Hour), ID: id}
+	rr = testutil.NewRequest().Put("/events/"+id).WithHeader(userIDHeader, user(1)).WithJsonBody(update).
+		GoWithHTTPHandler(t, m).Recorder
+	require.Equal(t, http.StatusNoContent, rr.Code)
+	rr = testutil.NewRequest().Delete("/events/"+id).WithHeader(userIDHeader, user(1)).GoWithHTTPHandler(t, m).Recorder
+	require.Equal(t, http.StatusNoContent, rr.Code)
+
+	history := func(userID int64) []AuditRecord {
+		rr := doGet(t, m, "/events/"+id+"/history", userID)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var result []AuditRecord
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&result))
+		return result
+	}
+	restore := func(userID int64) int {
+		return testutil.NewRequest().Post("/events/"+id+"/restore").WithHeader(userIDHeader, user(userID)).
+			GoWithHTTPHandler(t, m).Recorder.Code
+	}
+	actions := func(history []AuditRecord) []AuditRecordAction {
+		result := make([]AuditRecordAction, 0, len(history))
+		for _, record := range history {
+			require.Equal(t, int64(1), record.Actor)
+			result = append(result, record.Action)
+		}
+		return result
+	}
+
+	t.Run("deleted event history", func(t *testing.T) {
+		require.Equal(t, http.StatusNotFound, doGet(t, m, "/events/"+id, 1).Code)
+		records := history(1)
+		require.Equal(t, []AuditRecordAction{Create, Update, Delete}, actions(records))
+		require.Nil(t, records[0].Before)
+		require.Equal(t, "meeting", records[0].After.Title)
+		require.Equal(t, "meeting", records[1].Before.Title)
+		require.Equal(t, "changed", records[1].After.Title)
+		require.Equal(t, "changed", records[2].Before.Title)
+		require.Nil(t, records[2].After)
+
+		require.Len(t, history(2), 3, "attendee sees history")
+		require.Equal(t, http.StatusNotFound, doGet(t, m, "/events/"+id+"/history", 3).Code)
+		require.Equal(t, http.StatusNotFound, doGet(t, m, "/events/unknown/history", 1).Code)
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		require.Equal(t, http.StatusNotFound, restore(3))
+		require.Equal(t, http.StatusForbidden, restore(2), "attendee can't restore")
+		require.Equal(t, http.StatusNoContent, restore(1))
+		require.Equal(t, http.StatusBadRequest, restore(1), "event is not deleted")
+
+		rr := doGet(t, m, "/events/"+id, 2)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var event Event
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&event))
+		require.Equal(t, "changed", event.Title)
+		require.Equal(t, []AuditRecordAction{Create, Update, Delete, Restore}, actions(history(2)))
+	})
+}
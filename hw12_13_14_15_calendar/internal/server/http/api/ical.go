@@ -0,0 +1,112 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3filter"                     //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/ical"    //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage" //nolint:depguard
+)
+
+// максимальный размер загружаемого файла .ics.
+const maxICalendarSize = 10 * 1024 * 1024
+
+func init() {
+	// валидатор запросов по спецификации должен принимать тело text/calendar как строку
+	openapi3filter.RegisterBodyDecoder("text/calendar", openapi3filter.FileBodyDecoder)
+}
+
+func (s *Server) ExportEvents(w http.ResponseWriter, r *http.Request, params ExportEventsParams) {
+	location, err := requestLocation(params.Tz)
+	if err != nil {
+		sendAPIError(w, storageErrorToAPIErrorCode(err), err.Error())
+		return
+	}
+	startTime, stopTime, err := requestRange(params.StartTime, params.Period, params.From, params.To, location)
+	if err != nil {
+		sendAPIError(w, storageErrorToAPIErrorCode(err), err.Error())
+		return
+	}
+	// выгружаются все события интервала, без ограничения количества
+	stEvents, _, err := s.app.Storage.ListEvents(r.Context(), storage.ListParams{
+		UserID: params.XUserID, StartTime: startTime, StopTime: stopTime,
+	})
+	if err == nil {
+		stEvents, err = s.seriesMasters(r.Context(), params.XUserID, stEvents)
+	}
+	if err != nil {
+		sendAPIError(w, storageErrorToAPIErrorCode(err), err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", ical.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="calendar-%d.ics"`, params.XUserID))
+	w.WriteHeader(http.StatusOK)
+	if err := ical.Encode(w, stEvents); err != nil {
+		s.app.Logger.Error("failed to export events: " + err.Error())
+	}
+}
+
+// seriesMasters заменяет повторения серий самими сериями: серия выгружается одним VEVENT со своими
+// DTSTART, RRULE и EXDATE, повторения разворачивает клиент.
+func (s *Server) seriesMasters(ctx context.Context, userID int64, events []*storage.Event) (
+	[]*storage.Event, error,
+) {
+	result := make([]*storage.Event, 0, len(events))
+	exported := make(map[string]bool)
+	for _, event := range events {
+		if !event.IsRecurring() {
+			result = append(result, event)
+			continue
+		}
+		if exported[event.ID] {
+			continue
+		}
+		exported[event.ID] = true
+		master, err := s.app.GetUserEvent(ctx, userID, event.ID)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, master)
+	}
+	return result, nil
+}
+
+func (s *Server) ImportEvents(w http.ResponseWriter, r *http.Request, params ImportEventsParams) {
+	components, err := ical.Decode(http.MaxBytesReader(w, r.Body, maxICalendarSize))
+	if err != nil {
+		s.app.Logger.Error(err.Error())
+		sendAPIError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// ошибка одного VEVENT не прерывает импорт остальных
+	result := ImportResult{Events: make([]ImportedEvent, 0, len(components))}
+	for _, component := range components {
+		imported := ImportedEvent{Index: component.Index}
+		if component.UID != "" {
+			imported.UID = &component.UID
+		}
+		err := component.Err
+		if err == nil {
+			stEvent := component.Event
+			stEvent.UserID = params.XUserID
+			var id string
+			id, err = s.app.CreateUserEvent(r.Context(), params.XUserID, stEvent)
+			if err == nil {
+				imported.ID = &id
+				result.Created++
+			}
+		}
+		if err != nil {
+			message := err.Error()
+			imported.Error = &message
+		}
+		result.Events = append(result.Events, imported)
+	}
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(result)
+}
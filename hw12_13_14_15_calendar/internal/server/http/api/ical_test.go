@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage"                      //nolint:depguard
+	memorystorage "github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage/memory" //nolint:depguard
+	"github.com/oapi-codegen/testutil"                                                      //nolint:depguard
+	"github.com/stretchr/testify/require"                                                   //nolint:depguard
+)
+
+const testCalendar = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:first\r\n" +
+	"SUMMARY:first event\r\n" +
+	"DESCRIPTION:imported\r\n" +
+	"DTSTART:20250102T150000Z\r\n" +
+	"DTEND:20250102T160000Z\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"ACTION:DISPLAY\r\n" +
+	"TRIGGER:-PT15M\r\n" +
+	"END:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:busy\r\n" +
+	"SUMMARY:same start time\r\n" +
+	"DTSTART:20250102T150000Z\r\n" +
+	"DTEND:20250102T153000Z\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:second\r\n" +
+	"SUMMARY:second event\r\n" +
+	"DTSTART:20250103T150000Z\r\n" +
+	"DTEND:20250103T160000Z\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestICalendar(t *testing.T) {
+	repo := memorystorage.New(false)
+	m := newTestHandler(t, repo)
+
+	t.Run("import", func(t *testing.T) {
+		rr := testutil.NewRequest().Post("/ical").WithHeader(userIDHeader, "1").WithContentType("text/calendar").
+			WithBody([]byte(testCalendar)).GoWithHTTPHandler(t, m).Recorder
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var result ImportResult
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&result))
+		require.Equal(t, 2, result.Created)
+		require.Len(t, result.Events, 3)
+		require.NotNil(t, result.Events[0].ID)
+		require.Nil(t, result.Events[0].Error)
+		require.Equal(t, "busy", *result.Events[1].UID)
+		require.Nil(t, result.Events[1].ID)
+		require.Contains(t, *result.Events[1].Error, storage.ErrDateBusy.Error())
+		require.NotNil(t, result.Events[2].ID)
+
+		event, err := repo.GetEvent(context.Background(), *result.Events[0].ID)
+		require.NoError(t, err)
+		require.Equal(t, "first event", event.Title)
+		require.Equal(t, "imported", event.Description)
+		require.Equal(t, int64(1), event.UserID)
+		require.Equal(t, []storage.Reminder{{Before: 15 * time.Minute}}, event.Reminders)
+	})
+
+	t.Run("import invalid file", func(t *testing.T) {
+		rr := testutil.NewRequest().Post("/ical").WithHeader(userIDHeader, "1").WithContentType("text/calendar").
+			WithBody([]byte("not a calendar")).GoWithHTTPHandler(t, m).Recorder
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("export", func(t *testing.T) {
+		_, err := repo.CreateEvent(context.Background(), storage.Event{
+			Title: "other user", UserID: 2,
+			StartTime: time.Date(2025, 1, 2, 17, 0, 0, 0, time.UTC), StopTime: time.Date(2025, 1, 2, 18, 0, 0, 0, time.UTC),
+		})
+		require.NoError(t, err)
+
+		rr := testutil.NewRequest().Get("/ical?period=week&startTime=2025-01-01T00:00:00Z").WithHeader(userIDHeader, "1").
+			GoWithHTTPHandler(t, m).Recorder
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.True(t, strings.HasPrefix(rr.Header().Get("Content-Type"), "text/calendar"))
+		body := rr.Body.String()
+		require.Equal(t, 2, strings.Count(body, "BEGIN:VEVENT"))
+		require.Contains(t, body, "SUMMARY:first event")
+		require.Contains(t, body, "TRIGGER:-PT15M")
+		require.NotContains(t, body, "other user")
+	})
+
+	t.Run("export range", func(t *testing.T) {
+		rr := testutil.NewRequest().Get("/ical?from=2025-01-03T00:00:00Z&to=2025-01-04T00:00:00Z").
+			WithHeader(userIDHeader, "1").GoWithHTTPHandler(t, m).Recorder
+		require.Equal(t, http.StatusOK, rr.Code)
+		body := rr.Body.String()
+		require.Equal(t, 1, strings.Count(body, "BEGIN:VEVENT"))
+		require.Contains(t, body, "SUMMARY:second event")
+	})
+
+	t.Run("export invalid range", func(t *testing.T) {
+		for _, query := range []string{
+			"",
+			"from=2025-01-03T00:00:00Z",
+			"from=2025-01-04T00:00:00Z&to=2025-01-03T00:00:00Z",
+			"startTime=2025-01-01T00:00:00Z&from=2025-01-03T00:00:00Z&to=2025-01-04T00:00:00Z",
+		} {
+			rr := testutil.NewRequest().Get("/ical?"+query).WithHeader(userIDHeader, "1").
+				GoWithHTTPHandler(t, m).Recorder
+			require.Equal(t, http.StatusBadRequest, rr.Code, query)
+		}
+	})
+}
+
+func TestICalendarRecurring(t *testing.T) {
+	repo := memorystorage.New(false)
+	m := newTestHandler(t, repo)
+	startTime := time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC)
+	series := storage.Event{
+		Title: "daily", UserID: 1, StartTime: startTime, StopTime: startTime.Add(time.Hour),
+		RRule: "FREQ=DAILY;COUNT=10", ExDates: []time.Time{startTime.AddDate(0, 0, 2)},
+	}
+	id, err := repo.CreateEvent(context.Background(), series)
+	require.NoError(t, err)
+
+	// неделя со второго дня серии: повторения выгружаются одним VEVENT с началом серии
+	rr := testutil.NewRequest().Get("/ical?period=week&startTime=2025-01-07T00:00:00Z").WithHeader(userIDHeader, "1").
+		GoWithHTTPHandler(t, m).Recorder
+	require.Equal(t, http.StatusOK, rr.Code)
+	body := rr.Body.String()
+	require.Equal(t, 1, strings.Count(body, "BEGIN:VEVENT"))
+	require.Equal(t, 1, strings.Count(body, "UID:"+id))
+	require.Equal(t, 1, strings.Count(body, "RRULE:"))
+	require.Contains(t, body, "DTSTART:20250106T100000Z")
+	require.Contains(t, body, "EXDATE:20250108T100000Z")
+
+	imported := memorystorage.New(false)
+	rr = testutil.NewRequest().Post("/ical").WithHeader(userIDHeader, "1").WithContentType("text/calendar").
+		WithBody([]byte(body)).GoWithHTTPHandler(t, newTestHandler(t, imported)).Recorder
+	require.Equal(t, http.StatusOK, rr.Code)
+	var result ImportResult
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&result))
+	require.Equal(t, 1, result.Created)
+
+	expected, err := repo.ListEventsMonth(context.Background(), 1, startTime)
+	require.NoError(t, err)
+	actual, err := imported.ListEventsMonth(context.Background(), 1, startTime)
+	require.NoError(t, err)
+	require.Len(t, expected, 9)
+	require.Len(t, actual, len(expected))
+	for i := range expected {
+		require.Equal(t, expected[i].StartTime, actual[i].StartTime.UTC())
+		require.Equal(t, series.RRule, actual[i].RRule)
+	}
+}
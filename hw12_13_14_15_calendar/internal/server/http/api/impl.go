@@ -5,7 +5,9 @@ package api
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"time"
 
 	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/app"     //nolint:depguard
@@ -15,6 +17,12 @@ import (
 // ensure that we've conformed to the `ServerInterface` with a compile-time check.
 var _ ServerInterface = (*Server)(nil)
 
+const (
+	nextCursorHeader = "X-Next-Cursor"
+	defaultLimit     = 100
+	maxLimit         = 1000
+)
+
 type Server struct {
 	app *app.App
 }
@@ -24,20 +32,17 @@ func NewAPIServer(app *app.App) *Server {
 }
 
 func (s *Server) FindEvents(w http.ResponseWriter, r *http.Request, params FindEventsParams) {
-	var stEvents []*storage.Event
-	var err error
-	period := "day"
-	if params.Period != nil {
-		period = *params.Period
+	location, err := requestLocation(params.Tz)
+	if err != nil {
+		sendAPIError(w, storageErrorToAPIErrorCode(err), err.Error())
+		return
 	}
-	switch period {
-	case "day":
-		stEvents, err = s.app.Storage.ListEventsDay(r.Context(), params.StartTime)
-	case "week":
-		stEvents, err = s.app.Storage.ListEventsWeek(r.Context(), params.StartTime)
-	case "month":
-		stEvents, err = s.app.Storage.ListEventsMonth(r.Context(), params.StartTime)
+	listParams, err := makeListParams(params, location)
+	if err != nil {
+		sendAPIError(w, storageErrorToAPIErrorCode(err), err.Error())
+		return
 	}
+	stEvents, next, err := s.app.Storage.ListEvents(r.Context(), listParams)
 	if err != nil {
 		sendAPIError(w, storageErrorToAPIErrorCode(err), err.Error())
 		return
@@ -45,23 +50,16 @@ func (s *Server) FindEvents(w http.ResponseWriter, r *http.Request, params FindE
 
 	result := make([]Event, 0, len(stEvents))
 	for _, stEvent := range stEvents {
-		reminder := stEvent.Reminder.String()
-		event := Event{
-			ID:          stEvent.ID,
-			Title:       stEvent.Title,
-			UserID:      stEvent.UserID,
-			StartTime:   stEvent.StartTime,
-			StopTime:    stEvent.StopTime,
-			Description: &stEvent.Description,
-			Reminder:    &reminder,
-		}
-		result = append(result, event)
+		result = append(result, makeAPIEvent(stEvent, location))
+	}
+	if next != nil {
+		w.Header().Set(nextCursorHeader, next.String())
 	}
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(result)
 }
 
-func (s *Server) CreateEvent(w http.ResponseWriter, r *http.Request) {
+func (s *Server) CreateEvent(w http.ResponseWriter, r *http.Request, params CreateEventParams) {
 	// We expect a NewEvent object in the request body.
 	var newEvent NewEvent
 	if err := json.NewDecoder(r.Body).Decode(&newEvent); err != nil {
@@ -76,20 +74,30 @@ func (s *Server) CreateEvent(w http.ResponseWriter, r *http.Request) {
 		StopTime:  newEvent.StopTime,
 		UserID:    newEvent.UserID,
 	}
-	if newEvent.Reminder != nil {
-		reminder, err := time.ParseDuration(*newEvent.Reminder)
-		if err != nil {
-			s.app.Logger.Error(err.Error())
-			sendAPIError(w, http.StatusBadRequest, "Invalid format for Reminder")
-			return
-		}
-		storageEvent.Reminder = &reminder
+	reminders, err := makeReminders(newEvent.Reminder, newEvent.Reminders)
+	if err != nil {
+		s.app.Logger.Error(err.Error())
+		sendAPIError(w, http.StatusBadRequest, "Invalid format for Reminders")
+		return
 	}
+	storageEvent.Reminders = reminders
 	if newEvent.Description != nil {
 		storageEvent.Description = *newEvent.Description
 	}
+	if newEvent.RRule != nil {
+		storageEvent.RRule = *newEvent.RRule
+	}
+	if newEvent.ExDates != nil {
+		storageEvent.ExDates = *newEvent.ExDates
+	}
+	if newEvent.TimeZone != nil {
+		storageEvent.TimeZone = *newEvent.TimeZone
+	}
+	if newEvent.AllDay != nil {
+		storageEvent.AllDay = *newEvent.AllDay
+	}
 
-	id, err := s.app.Storage.CreateEvent(r.Context(), storageEvent)
+	id, err := s.app.CreateUserEvent(r.Context(), params.XUserID, storageEvent)
 	if err != nil {
 		sendAPIError(w, storageErrorToAPIErrorCode(err), err.Error())
 		return
@@ -99,8 +107,8 @@ func (s *Server) CreateEvent(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(eventID)
 }
 
-func (s *Server) DeleteEventByID(w http.ResponseWriter, r *http.Request, id string) {
-	err := s.app.Storage.DeleteEvent(r.Context(), id)
+func (s *Server) DeleteEventByID(w http.ResponseWriter, r *http.Request, id string, params DeleteEventByIDParams) {
+	err := s.app.DeleteUserEvent(r.Context(), params.XUserID, id)
 	if err != nil {
 		sendAPIError(w, storageErrorToAPIErrorCode(err), err.Error())
 		return
@@ -108,28 +116,24 @@ func (s *Server) DeleteEventByID(w http.ResponseWriter, r *http.Request, id stri
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func (s *Server) FindEventByID(w http.ResponseWriter, r *http.Request, id string) {
-	stEvent, err := s.app.Storage.GetEvent(r.Context(), id)
+func (s *Server) FindEventByID(w http.ResponseWriter, r *http.Request, id string, params FindEventByIDParams) {
+	location, err := requestLocation(params.Tz)
 	if err != nil {
 		sendAPIError(w, storageErrorToAPIErrorCode(err), err.Error())
 		return
 	}
-
-	reminder := stEvent.Reminder.String()
-	resp := Event{
-		ID:          stEvent.ID,
-		Title:       stEvent.Title,
-		UserID:      stEvent.UserID,
-		StartTime:   stEvent.StartTime,
-		StopTime:    stEvent.StopTime,
-		Description: &stEvent.Description,
-		Reminder:    &reminder,
+	stEvent, err := s.app.GetUserEvent(r.Context(), params.XUserID, id)
+	if err != nil {
+		sendAPIError(w, storageErrorToAPIErrorCode(err), err.Error())
+		return
 	}
+
+	resp := makeAPIEvent(stEvent, location)
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(resp)
 }
 
-func (s *Server) UpdateEventByID(w http.ResponseWriter, r *http.Request, id string) {
+func (s *Server) UpdateEventByID(w http.ResponseWriter, r *http.Request, id string, params UpdateEventByIDParams) {
 	// We expect a Event object in the request body.
 	var event Event
 	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
@@ -145,19 +149,29 @@ func (s *Server) UpdateEventByID(w http.ResponseWriter, r *http.Request, id stri
 		UserID:    event.UserID,
 	}
 
-	if event.Reminder != nil {
-		reminder, err := time.ParseDuration(*event.Reminder)
-		if err != nil {
-			sendAPIError(w, http.StatusBadRequest, "Invalid format for Reminder")
-			return
-		}
-		stEvent.Reminder = &reminder
+	reminders, err := makeReminders(event.Reminder, event.Reminders)
+	if err != nil {
+		sendAPIError(w, http.StatusBadRequest, "Invalid format for Reminders")
+		return
 	}
+	stEvent.Reminders = reminders
 	if event.Description != nil {
 		stEvent.Description = *event.Description
 	}
+	if event.RRule != nil {
+		stEvent.RRule = *event.RRule
+	}
+	if event.ExDates != nil {
+		stEvent.ExDates = *event.ExDates
+	}
+	if event.TimeZone != nil {
+		stEvent.TimeZone = *event.TimeZone
+	}
+	if event.AllDay != nil {
+		stEvent.AllDay = *event.AllDay
+	}
 
-	err := s.app.Storage.UpdateEvent(r.Context(), id, stEvent)
+	err = s.app.UpdateUserEvent(r.Context(), params.XUserID, id, stEvent)
 	if err != nil {
 		sendAPIError(w, storageErrorToAPIErrorCode(err), err.Error())
 		return
@@ -165,6 +179,172 @@ func (s *Server) UpdateEventByID(w http.ResponseWriter, r *http.Request, id stri
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func makeListParams(params FindEventsParams, location *time.Location) (storage.ListParams, error) {
+	startTime, stopTime, err := requestRange(params.StartTime, params.Period, params.From, params.To, location)
+	if err != nil {
+		return storage.ListParams{}, err
+	}
+	listParams := storage.ListParams{
+		UserID:    params.XUserID,
+		StartTime: startTime,
+		StopTime:  stopTime,
+		Limit:     defaultLimit,
+		Desc:      params.Sort != nil && *params.Sort == Desc,
+	}
+	if params.Q != nil {
+		listParams.Query = *params.Q
+	}
+	if params.Limit != nil {
+		if *params.Limit < 1 || *params.Limit > maxLimit {
+			return storage.ListParams{}, fmt.Errorf("%w: limit=%v", storage.ErrInvalidArgiments, *params.Limit)
+		}
+		listParams.Limit = *params.Limit
+	}
+	if params.Cursor != nil {
+		listParams.Cursor, err = storage.ParseCursor(*params.Cursor)
+		if err != nil {
+			return storage.ListParams{}, err
+		}
+	}
+	return listParams, nil
+}
+
+// requestRange интервал выборки: явный [from, to) либо период от startTime, границы которого
+// считаются в часовом поясе location.
+func requestRange(startTime *time.Time, period *string, from, to *time.Time, location *time.Location) (
+	time.Time, time.Time, error,
+) {
+	switch {
+	case from == nil && to == nil && startTime != nil:
+		start := inLocation(*startTime, location)
+		stop, err := periodStopTime(start, period)
+		return start, stop, err
+	case from != nil && to != nil && startTime == nil && period == nil:
+		if !from.Before(*to) {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: from=%v must be before to=%v",
+				storage.ErrInvalidArgiments, *from, *to)
+		}
+		return *from, *to, nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("%w: either startTime or both from and to must be set",
+		storage.ErrInvalidArgiments)
+}
+
+// periodStopTime окончание периода, границы совпадают с ListEventsDay, ListEventsWeek и ListEventsMonth.
+func periodStopTime(startTime time.Time, period *string) (time.Time, error) {
+	if period == nil {
+		return startTime.AddDate(0, 0, 1), nil
+	}
+	switch *period {
+	case "day":
+		return startTime.AddDate(0, 0, 1), nil
+	case "week":
+		return startTime.AddDate(0, 0, 7), nil
+	case "month":
+		return startTime.AddDate(0, 1, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("%w: period=%v", storage.ErrInvalidArgiments, *period)
+}
+
+// requestLocation часовой пояс из параметра tz, nil - параметр не задан.
+func requestLocation(tz *TZ) (*time.Location, error) {
+	if tz == nil {
+		return nil, nil //nolint:nilnil
+	}
+	return storage.LoadLocation(*tz)
+}
+
+// inLocation переводит время в часовой пояс location, если он задан.
+func inLocation(t time.Time, location *time.Location) time.Time {
+	if location == nil {
+		return t
+	}
+	return t.In(location)
+}
+
+// makeAPIEvent возвращает событие со временем в часовом поясе location, без него - в часовом поясе события.
+func makeAPIEvent(stEvent *storage.Event, location *time.Location) Event {
+	if location == nil {
+		location = stEvent.Location()
+	}
+	event := Event{
+		ID:          stEvent.ID,
+		Title:       stEvent.Title,
+		UserID:      stEvent.UserID,
+		StartTime:   stEvent.StartTime.In(location),
+		StopTime:    stEvent.StopTime.In(location),
+		Description: &stEvent.Description,
+	}
+	if len(stEvent.Reminders) > 0 {
+		reminders := make([]EventReminder, 0, len(stEvent.Reminders))
+		for _, reminder := range stEvent.Reminders {
+			apiReminder := EventReminder{Before: reminder.Before.String()}
+			if reminder.Channel != "" {
+				channel := ReminderChannel(reminder.Channel)
+				apiReminder.Channel = &channel
+			} else if event.Reminder == nil {
+				// устаревшее поле - первое напоминание в канал по умолчанию
+				event.Reminder = &apiReminder.Before
+			}
+			reminders = append(reminders, apiReminder)
+		}
+		event.Reminders = &reminders
+	}
+	if stEvent.IsRecurring() {
+		exDates := make([]time.Time, 0, len(stEvent.ExDates))
+		for _, t := range stEvent.ExDates {
+			exDates = append(exDates, t.In(location))
+		}
+		event.RRule = &stEvent.RRule
+		event.ExDates = &exDates
+	}
+	if stEvent.TimeZone != "" {
+		event.TimeZone = &stEvent.TimeZone
+	}
+	if stEvent.AllDay {
+		event.AllDay = &stEvent.AllDay
+	}
+	if len(stEvent.Attendees) > 0 {
+		attendees := makeAPIAttendees(stEvent.Attendees)
+		event.Attendees = &attendees
+	}
+	return event
+}
+
+// makeReminders собирает напоминания события из Reminders и устаревшего Reminder,
+// который добавляется напоминанием без канала, если такого еще нет.
+func makeReminders(reminder *string, reminders *[]EventReminder) ([]storage.Reminder, error) {
+	var result []storage.Reminder
+	if reminders != nil {
+		for _, apiReminder := range *reminders {
+			before, err := time.ParseDuration(apiReminder.Before)
+			if err != nil {
+				return nil, err
+			}
+			stReminder := storage.Reminder{Before: before}
+			if apiReminder.Channel != nil {
+				switch *apiReminder.Channel {
+				case Email, Webhook, Stdout:
+				default:
+					return nil, fmt.Errorf("%w: channel %q", storage.ErrInvalidReminder, *apiReminder.Channel)
+				}
+				stReminder.Channel = string(*apiReminder.Channel)
+			}
+			result = append(result, stReminder)
+		}
+	}
+	if reminder != nil {
+		before, err := time.ParseDuration(*reminder)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(result, storage.Reminder{Before: before}) {
+			result = append(result, storage.Reminder{Before: before})
+		}
+	}
+	return result, nil
+}
+
 func sendAPIError(w http.ResponseWriter, code int, message string) {
 	apiErr := Error{
 		Code:    code,
@@ -180,16 +360,27 @@ func storageErrorToAPIErrorCode(err error) int {
 		return http.StatusOK
 	case errors.Is(err, storage.ErrDateBusy) ||
 		errors.Is(err, storage.ErrInvalidArgiments) ||
+		errors.Is(err, storage.ErrInvalidRRule) ||
+		errors.Is(err, storage.ErrInvalidTimeZone) ||
+		errors.Is(err, storage.ErrInvalidAllDay) ||
+		errors.Is(err, storage.ErrInvalidReminder) ||
 		errors.Is(err, storage.ErrUpdateUserID) ||
+		errors.Is(err, storage.ErrInvalidRSVP) ||
+		errors.Is(err, storage.ErrEventNotDeleted) ||
 		errors.Is(err, storage.ErrInvalidStopTime):
 		return http.StatusBadRequest
 	case errors.Is(err, storage.ErrCreateEvent) ||
 		errors.Is(err, storage.ErrUpdateEvent) ||
 		errors.Is(err, storage.ErrDeleteEvent) ||
-		errors.Is(err, storage.ErrReadEvent):
+		errors.Is(err, storage.ErrReadEvent) ||
+		errors.Is(err, storage.ErrWriteAudit) ||
+		errors.Is(err, storage.ErrReadAudit):
 		return http.StatusInternalServerError
-	case errors.Is(err, storage.ErrEventNotFound):
+	case errors.Is(err, storage.ErrEventNotFound) ||
+		errors.Is(err, storage.ErrAttendeeNotFound):
 		return http.StatusNotFound
+	case errors.Is(err, storage.ErrNotEventOwner):
+		return http.StatusForbidden
 	default:
 		return http.StatusInternalServerError
 	}
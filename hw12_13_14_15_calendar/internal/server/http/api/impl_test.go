@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/app"                          //nolint:depguard
 	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/logger"                       //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage"                      //nolint:depguard
 	memorystorage "github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage/memory" //nolint:depguard
 	middleware "github.com/oapi-codegen/nethttp-middleware"                                 //nolint:depguard
 	"github.com/oapi-codegen/testutil"                                                      //nolint:depguard
@@ -17,12 +19,33 @@ import (
 	"github.com/stretchr/testify/require"                                                   //nolint:depguard
 )
 
-func doGet(t *testing.T, mux *http.ServeMux, url string) *httptest.ResponseRecorder {
+func doGet(t *testing.T, mux *http.ServeMux, url string, userID int64) *httptest.ResponseRecorder {
 	t.Helper()
-	response := testutil.NewRequest().Get(url).WithAcceptJson().GoWithHTTPHandler(t, mux)
+	response := testutil.NewRequest().Get(url).WithAcceptJson().WithHeader(userIDHeader, strconv.FormatInt(userID, 10)).
+		GoWithHTTPHandler(t, mux)
 	return response.Recorder
 }
 
+const userIDHeader = "X-User-ID"
+
+func newTestHandler(t *testing.T, repo app.Storage) *http.ServeMux {
+	t.Helper()
+	swagger, err := GetSwagger()
+	require.NoError(t, err)
+	swagger.Servers = nil
+
+	m := http.NewServeMux()
+	opts := StdHTTPServerOptions{
+		BaseRouter: m,
+		Middlewares: []MiddlewareFunc{
+			middleware.OapiRequestValidator(swagger),
+		},
+	}
+	testApp := &app.App{Logger: logger.New("INFO", "/tmp/calendar-test.log"), Storage: repo}
+	HandlerWithOptions(NewAPIServer(testApp), opts)
+	return m
+}
+
 func TestCalendar(t *testing.T) {
 	testStartTime, _ := time.Parse(time.RFC3339, "2025-01-02T15:00:00Z")
 	testStopTime, _ := time.Parse(time.RFC3339, "2025-01-02T16:00:00Z")
@@ -52,7 +75,7 @@ func TestCalendar(t *testing.T) {
 		},
 	}
 
-	testApp := &app.App{Logger: logger.New("INFO", "/tmp/calendar-test.log"), Storage: memorystorage.New()}
+	testApp := &app.App{Logger: logger.New("INFO", "/tmp/calendar-test.log"), Storage: memorystorage.New(false)}
 	store := NewAPIServer(testApp)
 
 	HandlerWithOptions(store, opts)
@@ -68,7 +91,8 @@ func TestCalendar(t *testing.T) {
 				Reminder:    &testReminder,
 			}
 
-			rr := testutil.NewRequest().Post("/events").WithJsonBody(newEvent).GoWithHTTPHandler(t, m).Recorder
+			rr := testutil.NewRequest().Post("/events").WithHeader(userIDHeader, strconv.Itoa(i)).WithJsonBody(newEvent).
+				GoWithHTTPHandler(t, m).Recorder
 			assert.Equal(t, http.StatusCreated, rr.Code)
 
 			var resultEventID EventID
@@ -81,7 +105,7 @@ func TestCalendar(t *testing.T) {
 
 	t.Run("Get event", func(t *testing.T) {
 		for eventID, userID := range allEvents {
-			rr := doGet(t, m, "/events/"+eventID)
+			rr := doGet(t, m, "/events/"+eventID, userID)
 			assert.Equal(t, http.StatusOK, rr.Code)
 
 			var resultEvent Event
@@ -109,29 +133,254 @@ func TestCalendar(t *testing.T) {
 				ID:          eventID,
 			}
 
-			rr := testutil.NewRequest().Put("/events/"+eventID).WithJsonBody(newEvent).GoWithHTTPHandler(t, m).Recorder
+			rr := testutil.NewRequest().Put("/events/"+eventID).WithHeader(userIDHeader, strconv.FormatInt(userID, 10)).
+				WithJsonBody(newEvent).GoWithHTTPHandler(t, m).Recorder
 			assert.Equal(t, http.StatusNoContent, rr.Code)
 		}
 	})
 
 	t.Run("Find events", func(t *testing.T) {
-		rr := doGet(t, m, "/events?period=day&startTime="+testStartTime.Format(time.RFC3339))
-		assert.Equal(t, http.StatusOK, rr.Code)
+		for _, userID := range allEvents {
+			rr := doGet(t, m, "/events?period=day&startTime="+testStartTime.Format(time.RFC3339), userID)
+			assert.Equal(t, http.StatusOK, rr.Code)
 
-		var resultEvents []Event
-		err = json.NewDecoder(rr.Body).Decode(&resultEvents)
-		assert.NoError(t, err, "error unmarshaling response")
-		assert.Equal(t, len(allEvents), len(resultEvents), "event count should be the same as the number of events created")
+			var resultEvents []Event
+			err = json.NewDecoder(rr.Body).Decode(&resultEvents)
+			assert.NoError(t, err, "error unmarshaling response")
+			assert.Equal(t, 1, len(resultEvents), "user should see only own events")
 
-		for _, event := range resultEvents {
-			assert.Equal(t, event.UserID, allEvents[event.ID], "event user id should match")
+			for _, event := range resultEvents {
+				assert.Equal(t, event.UserID, allEvents[event.ID], "event user id should match")
+			}
 		}
 	})
 
+	t.Run("Events of another user", func(t *testing.T) {
+		for eventID, userID := range allEvents {
+			rr := doGet(t, m, "/events/"+eventID, userID+100)
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+
+			rr = testutil.NewRequest().Delete("/events/"+eventID).WithHeader(userIDHeader, strconv.FormatInt(userID+100, 10)).
+				GoWithHTTPHandler(t, m).Recorder
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+
+			newEvent := Event{
+				Title: "foreign update", StartTime: testStartTime, StopTime: testStopTime, UserID: userID + 100, ID: eventID,
+			}
+			rr = testutil.NewRequest().Put("/events/"+eventID).WithHeader(userIDHeader, strconv.FormatInt(userID+100, 10)).
+				WithJsonBody(newEvent).GoWithHTTPHandler(t, m).Recorder
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+		}
+
+		newEvent := NewEvent{Title: "foreign", StartTime: testStartTime, StopTime: testStopTime, UserID: 1}
+		rr := testutil.NewRequest().Post("/events").WithHeader(userIDHeader, "2").WithJsonBody(newEvent).
+			GoWithHTTPHandler(t, m).Recorder
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = testutil.NewRequest().Get("/events/"+testStartTime.Format(time.RFC3339)).GoWithHTTPHandler(t, m).Recorder
+		assert.Equal(t, http.StatusBadRequest, rr.Code, "X-User-ID header is required")
+	})
+
 	t.Run("Delete event", func(t *testing.T) {
-		for eventID := range allEvents {
-			rr := testutil.NewRequest().Delete("/events/"+eventID).GoWithHTTPHandler(t, m).Recorder
+		for eventID, userID := range allEvents {
+			rr := testutil.NewRequest().Delete("/events/"+eventID).WithHeader(userIDHeader, strconv.FormatInt(userID, 10)).
+				GoWithHTTPHandler(t, m).Recorder
 			assert.Equal(t, http.StatusNoContent, rr.Code)
 		}
 	})
 }
+
+func TestFindEventsPagination(t *testing.T) {
+	repo := memorystorage.New(false)
+	m := newTestHandler(t, repo)
+	startTime := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		eventStart := startTime.Add(time.Duration(i) * time.Hour)
+		_, err := repo.CreateEvent(context.Background(), storage.Event{
+			Title: "event " + strconv.Itoa(i), UserID: 1, StartTime: eventStart, StopTime: eventStart.Add(30 * time.Minute),
+		})
+		require.NoError(t, err)
+	}
+
+	readAll := func(t *testing.T, query string) []string {
+		t.Helper()
+		titles := make([]string, 0)
+		url := "/events?startTime=" + startTime.Format(time.RFC3339) + query
+		for pages := 0; ; pages++ {
+			require.Less(t, pages, 5, "too many pages")
+			rr := doGet(t, m, url, 1)
+			require.Equal(t, http.StatusOK, rr.Code)
+			var events []Event
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&events))
+			require.LessOrEqual(t, len(events), 2)
+			for _, event := range events {
+				titles = append(titles, event.Title)
+			}
+			next := rr.Header().Get(nextCursorHeader)
+			if next == "" {
+				return titles
+			}
+			url = "/events?startTime=" + startTime.Format(time.RFC3339) + query + "&cursor=" + next
+		}
+	}
+
+	t.Run("asc", func(t *testing.T) {
+		require.Equal(t, []string{"event 0", "event 1", "event 2", "event 3", "event 4"}, readAll(t, "&limit=2"))
+	})
+	t.Run("desc", func(t *testing.T) {
+		require.Equal(t, []string{"event 4", "event 3", "event 2", "event 1", "event 0"},
+			readAll(t, "&limit=2&sort=desc"))
+	})
+	t.Run("invalid parameters", func(t *testing.T) {
+		for _, query := range []string{"&limit=0", "&limit=1001", "&sort=random", "&cursor=bad"} {
+			rr := doGet(t, m, "/events?startTime="+startTime.Format(time.RFC3339)+query, 1)
+			require.Equal(t, http.StatusBadRequest, rr.Code, query)
+		}
+	})
+}
+
+func TestFindEventsRange(t *testing.T) {
+	repo := memorystorage.New(false)
+	m := newTestHandler(t, repo)
+	startTime := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+	for i, title := range []string{"Design review", "Lunch", "Design sync"} {
+		eventStart := startTime.Add(time.Duration(i) * 2 * time.Hour)
+		_, err := repo.CreateEvent(context.Background(), storage.Event{
+			Title: title, UserID: 1, StartTime: eventStart, StopTime: eventStart.Add(time.Hour),
+		})
+		require.NoError(t, err)
+	}
+	find := func(t *testing.T, query string) []string {
+		t.Helper()
+		rr := doGet(t, m, "/events?"+query, 1)
+		require.Equal(t, http.StatusOK, rr.Code, query)
+		var events []Event
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&events))
+		titles := make([]string, 0, len(events))
+		for _, event := range events {
+			titles = append(titles, event.Title)
+		}
+		return titles
+	}
+
+	require.Equal(t, []string{"Design review", "Lunch"},
+		find(t, "from=2025-01-02T10:30:00Z&to=2025-01-02T12:30:00Z"))
+	require.Equal(t, []string{"Design review", "Design sync"},
+		find(t, "from=2025-01-02T00:00:00Z&to=2025-01-03T00:00:00Z&q=design"))
+	require.Equal(t, []string{"Design sync"}, find(t, "startTime=2025-01-02T00:00:00Z&q=sync%20design"))
+
+	for _, query := range []string{
+		"", "from=2025-01-02T00:00:00Z", "from=2025-01-03T00:00:00Z&to=2025-01-02T00:00:00Z",
+		"startTime=2025-01-02T00:00:00Z&from=2025-01-02T00:00:00Z&to=2025-01-03T00:00:00Z",
+	} {
+		rr := doGet(t, m, "/events?"+query, 1)
+		require.Equal(t, http.StatusBadRequest, rr.Code, query)
+	}
+}
+
+func TestTimeZone(t *testing.T) {
+	repo := memorystorage.New(false)
+	m := newTestHandler(t, repo)
+	// день перехода на летнее время в Берлине, полночь 30 марта - 23:00 UTC 29 марта
+	berlin := time.FixedZone("CET", 60*60)
+	timeZone, allDay := "Europe/Berlin", true
+	rr := testutil.NewRequest().Post("/events").WithHeader(userIDHeader, "1").WithJsonBody(NewEvent{
+		Title: "all day", UserID: 1, TimeZone: &timeZone, AllDay: &allDay,
+		StartTime: time.Date(2025, 3, 30, 10, 0, 0, 0, berlin), StopTime: time.Date(2025, 3, 30, 11, 0, 0, 0, berlin),
+	}).GoWithHTTPHandler(t, m).Recorder
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var id EventID
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&id))
+	_, err := repo.CreateEvent(context.Background(), storage.Event{
+		Title: "next day", UserID: 1, TimeZone: timeZone,
+		StartTime: time.Date(2025, 3, 30, 22, 30, 0, 0, time.UTC), StopTime: time.Date(2025, 3, 30, 23, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+
+	get := func(t *testing.T, url string) []byte {
+		t.Helper()
+		rr := doGet(t, m, url, 1)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		return rr.Body.Bytes()
+	}
+
+	t.Run("event time zone", func(t *testing.T) {
+		var event map[string]any
+		require.NoError(t, json.Unmarshal(get(t, "/events/"+id.ID), &event))
+		require.Equal(t, "2025-03-30T00:00:00+01:00", event["StartTime"])
+		require.Equal(t, "2025-03-31T00:00:00+02:00", event["StopTime"])
+		require.Equal(t, timeZone, event["TimeZone"])
+		require.Equal(t, true, event["AllDay"])
+	})
+	t.Run("request time zone", func(t *testing.T) {
+		var event map[string]any
+		require.NoError(t, json.Unmarshal(get(t, "/events/"+id.ID+"?tz=UTC"), &event))
+		require.Equal(t, "2025-03-29T23:00:00Z", event["StartTime"])
+		require.Equal(t, "2025-03-30T22:00:00Z", event["StopTime"])
+	})
+	t.Run("day boundaries", func(t *testing.T) {
+		titles := func(body []byte) []string {
+			var events []Event
+			require.NoError(t, json.Unmarshal(body, &events))
+			result := make([]string, 0, len(events))
+			for _, event := range events {
+				result = append(result, event.Title)
+			}
+			return result
+		}
+		require.Equal(t, []string{"all day"}, titles(get(t, "/events?startTime=2025-03-29T23:00:00Z&tz=Europe/Berlin")))
+		require.Equal(t, []string{"all day", "next day"}, titles(get(t, "/events?startTime=2025-03-29T23:00:00Z")))
+	})
+	t.Run("invalid time zone", func(t *testing.T) {
+		for _, url := range []string{"/events?startTime=2025-03-29T23:00:00Z&tz=Mars", "/events/" + id.ID + "?tz=Local"} {
+			rr := doGet(t, m, url, 1)
+			require.Equal(t, http.StatusBadRequest, rr.Code, url)
+		}
+	})
+}
+
+func TestReminders(t *testing.T) {
+	repo := memorystorage.New(false)
+	m := newTestHandler(t, repo)
+	startTime := time.Date(2025, 1, 2, 15, 0, 0, 0, time.UTC)
+	post := func(t *testing.T, event map[string]any) *httptest.ResponseRecorder {
+		t.Helper()
+		event["Title"], event["UserID"] = "meeting", 1
+		event["StartTime"], event["StopTime"] = startTime, startTime.Add(time.Hour)
+		return testutil.NewRequest().Post("/events").WithHeader(userIDHeader, "1").WithJsonBody(event).
+			GoWithHTTPHandler(t, m).Recorder
+	}
+
+	rr := post(t, map[string]any{
+		"Reminder":  "15m",
+		"Reminders": []map[string]any{{"Before": "24h", "Channel": "email"}, {"Before": "15m"}, {"Before": "1h"}},
+	})
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var id EventID
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&id))
+
+	stEvent, err := repo.GetEvent(context.Background(), id.ID)
+	require.NoError(t, err)
+	require.Equal(t, []storage.Reminder{
+		{Before: 24 * time.Hour, Channel: "email"}, {Before: time.Hour}, {Before: 15 * time.Minute},
+	}, stEvent.Reminders, "deprecated Reminder is not duplicated")
+
+	rr = doGet(t, m, "/events/"+id.ID, 1)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var event Event
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&event))
+	require.Equal(t, "1h0m0s", *event.Reminder, "first reminder to default channel")
+	require.Len(t, *event.Reminders, 3)
+	require.Equal(t, Email, *(*event.Reminders)[0].Channel)
+	require.Nil(t, (*event.Reminders)[1].Channel)
+
+	for _, reminders := range [][]map[string]any{
+		{{"Before": "soon"}},
+		{{"Before": "-1h"}},
+		{{"Before": "1h"}, {"Before": "1h"}},
+		{{"Before": "1h", "Channel": "sms"}},
+	} {
+		rr := post(t, map[string]any{"Reminders": reminders})
+		require.Equal(t, http.StatusBadRequest, rr.Code, reminders)
+	}
+}
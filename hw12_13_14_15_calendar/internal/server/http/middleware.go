@@ -6,10 +6,19 @@ import (
 	"strings"
 	"time"
 
-	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/app" //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/app"     //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/metrics" //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/tracing" //nolint:depguard
+	"go.opentelemetry.io/otel/codes"                                   //nolint:depguard
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"                 //nolint:depguard
+	"go.opentelemetry.io/otel/trace"                                   //nolint:depguard
 )
 
-func loggingMiddleware(logger app.Logger, next http.Handler) http.Handler {
+// маршрут метрик запросов, не попавших ни в один шаблон mux.
+const unmatchedRoute = "unmatched"
+
+// loggingMiddleware пишет запросы в журнал и в метрики HTTP, маршрут метрик - шаблон mux, под который попал запрос.
+func loggingMiddleware(logger app.Logger, mux *http.ServeMux, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		wrw := &WrapResponseWriter{ResponseWriter: w}
 		sb := strings.Builder{}
@@ -22,11 +31,24 @@ func loggingMiddleware(logger app.Logger, next http.Handler) http.Handler {
 		sb.WriteString(r.Proto)
 		sb.WriteRune(' ')
 
+		_, route := mux.Handler(r)
+		if route == "" {
+			route = unmatchedRoute
+		}
 		startTime := time.Now()
 		next.ServeHTTP(wrw, r)
-		duration := time.Since(startTime).Milliseconds()
+		elapsed := time.Since(startTime)
+		duration := elapsed.Milliseconds()
+
+		// обработчик, не вызвавший WriteHeader, отвечает 200
+		status := wrw.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		metrics.HTTPRequests.WithLabelValues(r.Method, route, strconv.Itoa(status)).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(r.Method, route).Observe(elapsed.Seconds())
 
-		sb.WriteString(strconv.FormatInt(int64(wrw.status), 10))
+		sb.WriteString(strconv.FormatInt(int64(status), 10))
 		sb.WriteRune(' ')
 		sb.WriteString(strconv.FormatInt(duration, 10))
 		sb.WriteRune(' ')
@@ -36,6 +58,47 @@ func loggingMiddleware(logger app.Logger, next http.Handler) http.Handler {
 	})
 }
 
+// tracingMiddleware выполняет запрос в серверном спане "METHOD маршрут", продолжающем трейс
+// из заголовка traceparent запроса. Маршрут - путь шаблона mux без метода.
+func tracingMiddleware(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, route := mux.Handler(r)
+		if route == "" {
+			route = unmatchedRoute
+		}
+		if _, path, ok := strings.Cut(route, " "); ok {
+			route = path
+		}
+		ctx := tracing.Extract(r.Context(), headerCarrier(r.Header))
+		ctx, span := tracing.Tracer().Start(ctx, r.Method+" "+route, trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPRequestMethodKey.String(r.Method), semconv.HTTPRoute(route),
+				semconv.URLPath(r.URL.Path)))
+		defer span.End()
+
+		wrw := &WrapResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(wrw, r.WithContext(ctx))
+		status := wrw.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
+
+// headerCarrier первые значения заголовков запроса для извлечения контекста трассировки.
+func headerCarrier(header http.Header) map[string]string {
+	carrier := make(map[string]string, len(header))
+	for key, values := range header {
+		if len(values) > 0 {
+			carrier[strings.ToLower(key)] = values[0]
+		}
+	}
+	return carrier
+}
+
 type WrapResponseWriter struct {
 	http.ResponseWriter
 	status int
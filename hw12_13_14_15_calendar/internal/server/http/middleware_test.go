@@ -0,0 +1,76 @@
+package internalhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/logger"  //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/metrics" //nolint:depguard
+	"github.com/prometheus/client_golang/prometheus/testutil"          //nolint:depguard
+	"github.com/stretchr/testify/require"                              //nolint:depguard
+	"go.opentelemetry.io/otel"                                         //nolint:depguard
+	"go.opentelemetry.io/otel/attribute"                               //nolint:depguard
+	"go.opentelemetry.io/otel/codes"                                   //nolint:depguard
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"                      //nolint:depguard
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"                     //nolint:depguard
+	"go.opentelemetry.io/otel/trace"                                   //nolint:depguard
+	"go.opentelemetry.io/otel/trace/noop"                              //nolint:depguard
+)
+
+func TestLoggingMiddlewareMetrics(t *testing.T) {
+	logg := logger.New("INFO", "/tmp/calendar-http-test.log")
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /events/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("event"))
+	})
+	mux.HandleFunc("DELETE /events/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	handler := loggingMiddleware(logg, mux, mux)
+
+	tests := []struct {
+		method, target string
+		route, code    string
+	}{
+		{http.MethodGet, "/events/1", "GET /events/{id}", "200"},
+		{http.MethodGet, "/events/2", "GET /events/{id}", "200"},
+		{http.MethodDelete, "/events/1", "DELETE /events/{id}", "204"},
+		{http.MethodGet, "/unknown", unmatchedRoute, "404"},
+	}
+	for _, tc := range tests {
+		counter := metrics.HTTPRequests.WithLabelValues(tc.method, tc.route, tc.code)
+		before := testutil.ToFloat64(counter)
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tc.method, tc.target, nil))
+		require.Equal(t, before+1, testutil.ToFloat64(counter), tc.target)
+	}
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+	mux := http.NewServeMux()
+	var handlerCtx context.Context
+	mux.HandleFunc("GET /events/{id}", func(w http.ResponseWriter, r *http.Request) {
+		handlerCtx = r.Context()
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	handler := tracingMiddleware(mux, mux)
+
+	r := httptest.NewRequest(http.MethodGet, "/events/1", nil)
+	r.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	require.Equal(t, "GET /events/{id}", span.Name())
+	require.Equal(t, trace.SpanKindServer, span.SpanKind())
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	require.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+	require.Equal(t, span.SpanContext(), trace.SpanContextFromContext(handlerCtx), "handler continues the span")
+	require.Contains(t, span.Attributes(), attribute.Int("http.response.status_code", http.StatusInternalServerError))
+	require.Equal(t, codes.Error, span.Status().Code)
+}
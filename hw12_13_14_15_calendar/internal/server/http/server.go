@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/app"             //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/health"          //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/metrics"         //nolint:depguard
 	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/server/http/api" //nolint:depguard
 )
 
@@ -17,21 +19,24 @@ type Server struct {
 	app    *app.App
 }
 
-func NewServer(app *app.App, host string, port int) *Server {
+// NewServer создает сервер API, checker отвечает на /healthz и /readyz.
+func NewServer(app *app.App, host string, port int, checker *health.Checker) *Server {
 	// create a type that satisfies the `api.ServerInterface`, which contains an implementation
 	// of every operation from the generated code
 	apiServer := api.NewAPIServer(app)
 	mux := http.NewServeMux()
 	// get an `http.Handler` that we can use
-	h := loggingMiddleware(app.Logger, api.HandlerFromMux(apiServer, mux))
+	h := loggingMiddleware(app.Logger, mux, tracingMiddleware(mux, api.HandlerFromMux(apiServer, mux)))
 
 	server := &http.Server{
 		Addr:        net.JoinHostPort(host, strconv.Itoa(port)),
 		ReadTimeout: 30 * time.Second,
 		Handler:     h,
 	}
-	helloWorldHandler := http.HandlerFunc(helloWorld)
-	mux.Handle("/hello", loggingMiddleware(app.Logger, helloWorldHandler))
+	// маршруты mux уже обернуты loggingMiddleware и tracingMiddleware
+	mux.HandleFunc("/hello", helloWorld)
+	mux.Handle("GET /metrics", metrics.Handler())
+	checker.Register(mux)
 	return &Server{server: server, app: app}
 }
 
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RSVP ответ участника на приглашение, значения PARTSTAT RFC 5545.
+type RSVP string
+
+const (
+	// участник еще не ответил на приглашение.
+	RSVPNeedsAction RSVP = "needs-action"
+	RSVPAccepted    RSVP = "accepted"
+	RSVPDeclined    RSVP = "declined"
+	RSVPTentative   RSVP = "tentative"
+)
+
+func (r RSVP) Validate() error {
+	switch r {
+	case RSVPNeedsAction, RSVPAccepted, RSVPDeclined, RSVPTentative:
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrInvalidRSVP, r)
+}
+
+// Attendee приглашенный на событие пользователь. Владелец события в участники не входит.
+type Attendee struct {
+	UserID int64
+	Status RSVP
+}
+
+// Attendee возвращает участника события userID.
+func (e *Event) Attendee(userID int64) (Attendee, bool) {
+	for _, attendee := range e.Attendees {
+		if attendee.UserID == userID {
+			return attendee, true
+		}
+	}
+	return Attendee{}, false
+}
+
+// Visible проверяет, что событие видно пользователю: владельцу и приглашенным.
+func (e *Event) Visible(userID int64) bool {
+	_, ok := e.Attendee(userID)
+	return e.UserID == userID || ok
+}
+
+// SortAttendees упорядочивает участников по id пользователя.
+func SortAttendees(attendees []Attendee) {
+	sort.Slice(attendees, func(i, j int) bool { return attendees[i].UserID < attendees[j].UserID })
+}
+
+// FormatAttendees сериализует участников строкой вида "2:accepted,3:needs-action".
+func FormatAttendees(attendees []Attendee) string {
+	parts := make([]string, 0, len(attendees))
+	for _, attendee := range attendees {
+		parts = append(parts, strconv.FormatInt(attendee.UserID, 10)+":"+string(attendee.Status))
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseAttendees разбирает строку FormatAttendees, участники упорядочиваются по id пользователя.
+func ParseAttendees(value string) ([]Attendee, error) {
+	if value == "" {
+		return nil, nil
+	}
+	result := make([]Attendee, 0)
+	for _, part := range strings.Split(value, ",") {
+		userID, status, _ := strings.Cut(part, ":")
+		id, err := strconv.ParseInt(userID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: attendee %q", ErrInvalidRSVP, part)
+		}
+		attendee := Attendee{UserID: id, Status: RSVP(status)}
+		if err := attendee.Status.Validate(); err != nil {
+			return nil, err
+		}
+		result = append(result, attendee)
+	}
+	SortAttendees(result)
+	return result, nil
+}
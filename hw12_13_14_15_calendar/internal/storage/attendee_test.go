@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require" //nolint:depguard
+)
+
+func TestAttendees(t *testing.T) {
+	attendees := []Attendee{{UserID: 2, Status: RSVPAccepted}, {UserID: 10, Status: RSVPNeedsAction}}
+	value := FormatAttendees(attendees)
+	require.Equal(t, "2:accepted,10:needs-action", value)
+	parsed, err := ParseAttendees("10:needs-action,2:accepted")
+	require.NoError(t, err)
+	require.Equal(t, attendees, parsed, "attendees are sorted by user id")
+
+	parsed, err = ParseAttendees("")
+	require.NoError(t, err)
+	require.Empty(t, parsed)
+	for _, value := range []string{"bad", "x:accepted", "2:maybe", "2"} {
+		_, err = ParseAttendees(value)
+		require.ErrorIs(t, err, ErrInvalidRSVP, value)
+	}
+
+	event := &Event{UserID: 1, Attendees: attendees}
+	require.True(t, event.Visible(1))
+	require.True(t, event.Visible(10))
+	require.False(t, event.Visible(3))
+	attendee, ok := event.Attendee(2)
+	require.True(t, ok)
+	require.Equal(t, RSVPAccepted, attendee.Status)
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// AuditAction изменение события в журнале.
+type AuditAction string
+
+const (
+	AuditCreate  AuditAction = "create"
+	AuditUpdate  AuditAction = "update"
+	AuditDelete  AuditAction = "delete"
+	AuditRestore AuditAction = "restore"
+)
+
+// AuditRecord запись журнала изменений события. Журнал только дополняется, записи не меняются и не удаляются.
+type AuditRecord struct {
+	EventID string
+	Action  AuditAction
+	// пользователь, изменивший событие, 0 - сам сервис
+	Actor int64
+	Time  time.Time
+	// событие до и после изменения: Before нет у создания и восстановления, After - у удаления
+	Before *Event
+	After  *Event
+}
+
+// Snapshot возвращает состояние события, которое известно по записи: после изменения, для удаления - до него.
+func (r *AuditRecord) Snapshot() *Event {
+	if r.After != nil {
+		return r.After
+	}
+	return r.Before
+}
+
+type actorKey struct{}
+
+// WithActor возвращает контекст запроса пользователя userID, хранилище записывает его в журнал изменений.
+func WithActor(ctx context.Context, userID int64) context.Context {
+	return context.WithValue(ctx, actorKey{}, userID)
+}
+
+// Actor возвращает пользователя из контекста WithActor, без него - 0.
+func Actor(ctx context.Context) int64 {
+	userID, _ := ctx.Value(actorKey{}).(int64)
+	return userID
+}
+
+// NewAuditRecord создает запись журнала об изменении события пользователем из контекста.
+func NewAuditRecord(ctx context.Context, action AuditAction, before, after *Event) *AuditRecord {
+	record := &AuditRecord{Action: action, Actor: Actor(ctx), Time: time.Now(), Before: before, After: after}
+	record.EventID = record.Snapshot().ID
+	return record
+}
+
+// FormatSnapshot сериализует состояние события для журнала, nil - пустая строка.
+func FormatSnapshot(event *Event) (string, error) {
+	if event == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrWriteAudit, err) //nolint:errorlint
+	}
+	return string(data), nil
+}
+
+// ParseSnapshot разбирает состояние события FormatSnapshot.
+func ParseSnapshot(value string) (*Event, error) {
+	if value == "" {
+		return nil, nil //nolint:nilnil
+	}
+	event := &Event{}
+	if err := json.Unmarshal([]byte(value), event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
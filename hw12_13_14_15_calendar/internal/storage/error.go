@@ -5,14 +5,31 @@ import (
 )
 
 var (
-	ErrDateBusy           = errors.New("time reserved for another event")
-	ErrEventNotFound      = errors.New("event not found")
-	ErrInvalidArgiments   = errors.New("invalid arguments")
-	ErrUpdateUserID       = errors.New("can't change user id")
-	ErrInvalidStopTime    = errors.New("stop time must be greater than start time")
-	ErrCreateEvent        = errors.New("can't create event")
-	ErrUpdateEvent        = errors.New("can't update event")
-	ErrDeleteEvent        = errors.New("can't delete event")
-	ErrReadEvent          = errors.New("can't read event")
-	ErrCreateNotification = errors.New("can't create notification")
+	ErrDateBusy             = errors.New("time reserved for another event")
+	ErrEventNotFound        = errors.New("event not found")
+	ErrInvalidArgiments     = errors.New("invalid arguments")
+	ErrUpdateUserID         = errors.New("can't change user id")
+	ErrInvalidStopTime      = errors.New("stop time must be greater than start time")
+	ErrCreateEvent          = errors.New("can't create event")
+	ErrUpdateEvent          = errors.New("can't update event")
+	ErrDeleteEvent          = errors.New("can't delete event")
+	ErrReadEvent            = errors.New("can't read event")
+	ErrCreateNotification   = errors.New("can't create notification")
+	ErrInvalidRRule         = errors.New("invalid recurrence rule")
+	ErrReadNotification     = errors.New("can't read notification")
+	ErrTransaction          = errors.New("transaction failed")
+	ErrNotificationNotFound = errors.New("notification not found")
+	ErrUpdateNotification   = errors.New("can't update notification")
+	ErrUnknownStorage       = errors.New("unknown storage")
+	ErrMigration            = errors.New("migration failed")
+	ErrNotEventOwner        = errors.New("only event owner can change event")
+	ErrAttendeeNotFound     = errors.New("attendee not found")
+	ErrInvalidRSVP          = errors.New("invalid rsvp status")
+	ErrUpdateAttendee       = errors.New("can't update attendee")
+	ErrInvalidTimeZone      = errors.New("invalid time zone")
+	ErrInvalidAllDay        = errors.New("all-day event must start and stop at midnight")
+	ErrInvalidReminder      = errors.New("invalid reminder")
+	ErrEventNotDeleted      = errors.New("event is not deleted")
+	ErrWriteAudit           = errors.New("can't write event audit record")
+	ErrReadAudit            = errors.New("can't read event history")
 )
@@ -11,13 +11,72 @@ type Event struct {
 	StopTime    time.Time
 	Description string
 	UserID      int64
-	Reminder    *time.Duration
+	// напоминания, упорядоченные SortReminders. Каждое отправляется о каждом повторении серии
+	Reminders []Reminder
+	// правило повторения в формате RFC 5545 RRULE, пустое - событие не повторяется
+	RRule string
+	// исключенные из серии повторения (EXDATE), время начала повторения
+	ExDates []time.Time
+	// контекст трассировки W3C traceparent запроса, создавшего или изменившего событие,
+	// по нему напоминание продолжает трейс запроса
+	TraceContext string
+	// приглашенные пользователи, упорядоченные по id. CreateEvent и UpdateEvent их не меняют,
+	// участниками управляют InviteAttendee, UpdateAttendee и RemoveAttendee
+	Attendees []Attendee
+	// часовой пояс IANA, в котором задано событие, пустой - UTC. В нем считаются повторения
+	// и границы событий на весь день
+	TimeZone string
+	// событие на весь день: StartTime и StopTime - полночь первого и следующего за последним дня
+	// в часовом поясе события
+	AllDay bool
 }
 
+// NotificationKind повод уведомления.
+type NotificationKind string
+
+const (
+	// напоминание о начале события, пустой повод - тоже напоминание.
+	NotificationReminder NotificationKind = "reminder"
+	// приглашение участника.
+	NotificationInvitation NotificationKind = "invitation"
+	// ответ участника на приглашение, уведомление получает владелец события.
+	NotificationRSVP NotificationKind = "rsvp"
+	// приглашение отменено владельцем события.
+	NotificationUninvited NotificationKind = "uninvited"
+)
+
 // Уведомление - временная сущность, в БД не хранится, складывается в очередь для хранителя.
 type Notification struct {
-	ID        string
+	ID   string
+	Kind NotificationKind `json:",omitempty"`
+	// канал доставки напоминания, пустой - канал получателя по умолчанию
+	Channel string `json:",omitempty"`
+	// участник, к которому относится уведомление о приглашении или ответе
+	Attendee  *Attendee `json:",omitempty"`
 	Title     string
 	StartTime time.Time
 	UserID    int64
+	// время публикации в брокер, по нему хранитель измеряет задержку обработки, в хранилище не сохраняется
+	PublishedAt time.Time
+	// контекст трассировки события, хранится в outbox до публикации, в сообщение не попадает
+	TraceContext string `json:"-"`
+}
+
+type DeliveryStatus string
+
+const (
+	// уведомление еще не доставлено, попытки продолжаются.
+	DeliveryPending DeliveryStatus = "pending"
+	DeliverySent    DeliveryStatus = "sent"
+	// попытки доставки исчерпаны.
+	DeliveryFailed DeliveryStatus = "failed"
+)
+
+// Состояние доставки уведомления пользователю.
+type NotificationDelivery struct {
+	Status DeliveryStatus
+	// канал последней попытки доставки
+	Channel   string
+	Attempts  int
+	LastError string
 }
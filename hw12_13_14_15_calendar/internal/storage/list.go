@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid" //nolint:depguard
+)
+
+// Cursor позиция в упорядоченной выборке событий: время начала и id последнего отданного события.
+// Для повторений серии id совпадает, поэтому время начала входит в ключ.
+type Cursor struct {
+	StartTime time.Time
+	ID        string
+}
+
+// String возвращает непрозрачное для клиента представление курсора.
+func (c Cursor) String() string {
+	value := strconv.FormatInt(c.StartTime.UnixNano(), 10) + "/" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(value))
+}
+
+func ParseCursor(value string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: cursor %q", ErrInvalidArgiments, value)
+	}
+	nanos, id, ok := strings.Cut(string(data), "/")
+	if _, err := uuid.Parse(id); !ok || err != nil {
+		return nil, fmt.Errorf("%w: cursor %q", ErrInvalidArgiments, value)
+	}
+	unixNano, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: cursor %q", ErrInvalidArgiments, value)
+	}
+	return &Cursor{StartTime: time.Unix(0, unixNano).UTC(), ID: id}, nil
+}
+
+// ListParams параметры постраничной выборки событий пользователя.
+type ListParams struct {
+	UserID    int64
+	StartTime time.Time
+	StopTime  time.Time
+	// максимальное количество событий на странице, 0 - без ограничения
+	Limit int
+	// выборка продолжается после события курсора
+	Cursor *Cursor
+	// сортировка по убыванию времени начала
+	Desc bool
+	// поиск по названию и описанию, событие должно содержать все слова запроса
+	Query string
+}
+
+// less порядок событий в выборке: время начала, затем id.
+func (p ListParams) less(a, b Cursor) bool {
+	if !a.StartTime.Equal(b.StartTime) {
+		return a.StartTime.Before(b.StartTime) != p.Desc
+	}
+	return a.ID != b.ID && (a.ID < b.ID) != p.Desc
+}
+
+// After проверяет, что событие идет в выборке после курсора.
+func (p ListParams) After(event *Event) bool {
+	return p.Cursor == nil || p.less(*p.Cursor, event.Cursor())
+}
+
+// Page упорядочивает события и возвращает страницу после курсора и курсор следующей страницы.
+// Курсор следующей страницы nil, если страница последняя.
+func (p ListParams) Page(events []*Event) ([]*Event, *Cursor) {
+	result := make([]*Event, 0, len(events))
+	for _, event := range events {
+		if p.After(event) {
+			result = append(result, event)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return p.less(result[i].Cursor(), result[j].Cursor())
+	})
+	if p.Limit <= 0 || len(result) <= p.Limit {
+		return result, nil
+	}
+	result = result[:p.Limit]
+	next := result[p.Limit-1].Cursor()
+	return result, &next
+}
+
+func (e *Event) Cursor() Cursor {
+	return Cursor{StartTime: e.StartTime, ID: e.ID}
+}
+
+// Overlaps проверяет пересечение события с интервалом [from, to). Событие нулевой длительности
+// пересекается с интервалом, если начинается внутри него.
+func (e *Event) Overlaps(from, to time.Time) bool {
+	return e.StartTime.Before(to) && (e.StopTime.After(from) || !e.StartTime.Before(from))
+}
+
+// Overlapping возвращает повторения события, пересекающиеся с интервалом [from, to).
+func (e *Event) Overlapping(from, to time.Time) []*Event {
+	// повторение, начавшееся раньше from на длительность события, еще может идти
+	occurrences := e.Occurrences(from.Add(-e.StopTime.Sub(e.StartTime)), to)
+	result := make([]*Event, 0, len(occurrences))
+	for _, occurrence := range occurrences {
+		if occurrence.Overlaps(from, to) {
+			result = append(result, occurrence)
+		}
+	}
+	return result
+}
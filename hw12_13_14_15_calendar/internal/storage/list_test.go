@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require" //nolint:depguard
+)
+
+func TestCursor(t *testing.T) {
+	cursor := Cursor{StartTime: date(1, 2, 10), ID: "6f0c3f6e-3a35-4b8e-a9f3-9d1f0c4a7b11"}
+	parsed, err := ParseCursor(cursor.String())
+	require.NoError(t, err)
+	require.Equal(t, cursor, *parsed)
+
+	for _, bad := range []string{"", "!!!", Cursor{StartTime: date(1, 2, 10), ID: "id"}.String()} {
+		_, err := ParseCursor(bad)
+		require.ErrorIs(t, err, ErrInvalidArgiments, bad)
+	}
+}
+
+func TestPage(t *testing.T) {
+	series := &Event{
+		ID: "6f0c3f6e-3a35-4b8e-a9f3-9d1f0c4a7b11", StartTime: date(1, 1, 10), StopTime: date(1, 1, 11),
+		RRule: "FREQ=DAILY;COUNT=3",
+	}
+	events := series.Occurrences(date(1, 1, 0), date(2, 1, 0))
+	events = append(events,
+		&Event{ID: "00000000-0000-0000-0000-000000000001", StartTime: date(1, 2, 10)},
+		&Event{ID: "00000000-0000-0000-0000-000000000002", StartTime: date(1, 1, 12)})
+
+	readAll := func(params ListParams) []Cursor {
+		result := make([]Cursor, 0)
+		for {
+			page, next := params.Page(events)
+			require.LessOrEqual(t, len(page), params.Limit)
+			for _, event := range page {
+				result = append(result, event.Cursor())
+			}
+			if next == nil {
+				return result
+			}
+			params.Cursor = next
+		}
+	}
+
+	expected := []Cursor{
+		{StartTime: date(1, 1, 10), ID: series.ID},
+		{StartTime: date(1, 1, 12), ID: "00000000-0000-0000-0000-000000000002"},
+		{StartTime: date(1, 2, 10), ID: "00000000-0000-0000-0000-000000000001"},
+		{StartTime: date(1, 2, 10), ID: series.ID},
+		{StartTime: date(1, 3, 10), ID: series.ID},
+	}
+	require.Equal(t, expected, readAll(ListParams{Limit: 2}))
+
+	desc := readAll(ListParams{Limit: 3, Desc: true})
+	for i := range expected {
+		require.Equal(t, expected[len(expected)-1-i], desc[i])
+	}
+
+	page, next := ListParams{}.Page(events)
+	require.Len(t, page, len(expected))
+	require.Nil(t, next)
+}
+
+func TestOverlapping(t *testing.T) {
+	tests := []struct {
+		name        string
+		start, stop time.Time
+		from, to    time.Time
+		expected    bool
+	}{
+		{
+			name: "inside", start: date(1, 1, 10), stop: date(1, 1, 11),
+			from: date(1, 1, 0), to: date(1, 2, 0), expected: true,
+		},
+		{
+			name: "starts before", start: date(1, 1, 10), stop: date(1, 1, 12),
+			from: date(1, 1, 11), to: date(1, 2, 0), expected: true,
+		},
+		{
+			name: "ends after", start: date(1, 1, 23), stop: date(1, 2, 1),
+			from: date(1, 1, 0), to: date(1, 2, 0), expected: true,
+		},
+		{
+			name: "ends at from", start: date(1, 1, 10), stop: date(1, 1, 11),
+			from: date(1, 1, 11), to: date(1, 2, 0),
+		},
+		{
+			name: "starts at to", start: date(1, 2, 0), stop: date(1, 2, 1),
+			from: date(1, 1, 0), to: date(1, 2, 0),
+		},
+		{
+			name: "empty at from", start: date(1, 1, 0), stop: date(1, 1, 0),
+			from: date(1, 1, 0), to: date(1, 2, 0), expected: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			event := &Event{StartTime: tc.start, StopTime: tc.stop}
+			require.Equal(t, tc.expected, event.Overlaps(tc.from, tc.to))
+		})
+	}
+
+	// повторение, начавшееся до интервала, попадает в выборку
+	series := &Event{StartTime: date(1, 1, 22), StopTime: date(1, 2, 2), RRule: "FREQ=DAILY;COUNT=3"}
+	require.Equal(t, []time.Time{date(1, 1, 22), date(1, 2, 22)},
+		startTimes(series.Overlapping(date(1, 2, 0), date(1, 3, 0))))
+}
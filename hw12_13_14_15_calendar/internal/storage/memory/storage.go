@@ -3,6 +3,8 @@ package memorystorage
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sort"
 	"sync"
 	"time"
 
@@ -10,43 +12,136 @@ import (
 	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage" //nolint:depguard
 )
 
-type userEvents map[time.Time]*storage.Event
+// события пользователя, упорядоченные по времени начала.
+type userEvents []*storage.Event
+
+// индекс первого события, начинающегося не раньше startTime.
+func (ue userEvents) search(startTime time.Time) int {
+	return sort.Search(len(ue), func(i int) bool { return !ue[i].StartTime.Before(startTime) })
+}
+
+func (ue *userEvents) insert(event *storage.Event) {
+	i := ue.search(event.StartTime)
+	*ue = append(*ue, nil)
+	copy((*ue)[i+1:], (*ue)[i:])
+	(*ue)[i] = event
+}
+
+func (ue *userEvents) remove(event *storage.Event) {
+	for i := ue.search(event.StartTime); i < len(*ue) && (*ue)[i].StartTime.Equal(event.StartTime); i++ {
+		if (*ue)[i].ID == event.ID {
+			*ue = append((*ue)[:i], (*ue)[i+1:]...)
+			return
+		}
+	}
+}
+
+// overlaps проверяет пересечение события с другими событиями пользователя. Когда пересечения запрещены,
+// непустые интервалы первых повторений пользователя не пересекаются между собой, поэтому для обычного события
+// достаточно проверить последнее непустое событие, начавшееся раньше окончания нового, и более поздние
+// повторения серий. Пустые интервалы, как и пустой tstzrange в БД, ни с чем не пересекаются.
+func (ue userEvents) overlaps(event *storage.Event) bool {
+	if event.IsRecurring() {
+		for _, current := range ue {
+			if current.ID != event.ID && current.OverlapsEvent(event) {
+				return true
+			}
+		}
+		return false
+	}
+	if !event.StartTime.Before(event.StopTime) {
+		return false
+	}
+	last := ue.search(event.StopTime)
+	for i := last - 1; i >= 0; i-- {
+		current := ue[i]
+		if current.ID == event.ID || !current.StartTime.Before(current.StopTime) {
+			continue
+		}
+		if current.StopTime.After(event.StartTime) {
+			return true
+		}
+		break
+	}
+	for _, current := range ue[:last] {
+		if current.ID != event.ID && current.IsRecurring() && current.OverlapsEvent(event) {
+			return true
+		}
+	}
+	return false
+}
 
 type Storage struct {
 	mu sync.RWMutex
 	// все события
 	all map[string]*storage.Event
-	// события по пользователям и времени. Ограничение: для одного пользователя в один момент времени может начинаться
-	// только одно событие. другие пересечения событий по времени считаем допустимым
+	// события по пользователям, упорядоченные по времени начала
 	byUser map[int64]userEvents
+	// события, на которые приглашены пользователи
+	byAttendee map[int64]map[string]*storage.Event
+	// время отправки напоминаний по событиям, аналог поля reminderTime таблицы reminder в БД
+	reminderTime map[string]map[storage.Reminder]time.Time
+	// поисковый индекс: слово названия или описания - id событий
+	tokens map[string]map[string]struct{}
+	// помеченные удаленными события, в индексах их нет
+	deleted map[string]*storage.Event
+	// журнал изменений по событиям
+	history map[string][]*storage.AuditRecord
+	// сохраненные хранителем уведомления и состояние их доставки
+	notifications map[string]*notification
+	// outbox уведомлений планировщика: неотправленные по id и порядок добавления
+	outbox      map[string]*storage.OutboxMessage
+	outboxOrder []string
+	// разрешены ли пользователю пересекающиеся по времени события
+	allowOverlap bool
+}
+
+func init() {
+	storage.Register("memory", func(_ context.Context, params storage.Params) (storage.Storage, error) {
+		return New(params.AllowOverlap), nil
+	})
+}
+
+func New(allowOverlap bool) *Storage {
+	return &Storage{
+		mu: sync.RWMutex{}, all: make(map[string]*storage.Event), byUser: make(map[int64]userEvents),
+		byAttendee:   make(map[int64]map[string]*storage.Event),
+		reminderTime: make(map[string]map[storage.Reminder]time.Time), tokens: make(map[string]map[string]struct{}),
+		outbox: make(map[string]*storage.OutboxMessage), notifications: make(map[string]*notification),
+		deleted: make(map[string]*storage.Event), history: make(map[string][]*storage.AuditRecord),
+		allowOverlap: allowOverlap,
+	}
+}
+
+func (s *Storage) Ping(_ context.Context) error {
+	return nil
 }
 
-func New() *Storage {
-	return &Storage{mu: sync.RWMutex{}, all: make(map[string]*storage.Event), byUser: make(map[int64]userEvents)}
+// Close ничего не делает, данные в памяти не сохраняются.
+func (s *Storage) Close(_ context.Context) error {
+	return nil
 }
 
-func (s *Storage) CreateEvent(_ context.Context, event storage.Event) (string, error) {
-	if event.StopTime.Before(event.StartTime) {
-		return "", storage.ErrInvalidStopTime
+func (s *Storage) CreateEvent(ctx context.Context, event storage.Event) (string, error) {
+	if err := event.Validate(); err != nil {
+		return "", err
 	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	ue := s.byUser[event.UserID]
-	if ue == nil {
-		// такого пользователя нет
-		ue = make(userEvents)
-		s.byUser[event.UserID] = ue
-	} else if ue[event.StartTime] != nil {
+	event.ID = uuid.New().String()
+	// участниками управляют отдельные методы
+	event.Attendees = nil
+	event.Reminders = sortedReminders(event.Reminders)
+	if !s.allowOverlap && s.byUser[event.UserID].overlaps(&event) {
 		return "", storage.ErrDateBusy
 	}
-	event.ID = uuid.New().String()
-	ue[event.StartTime] = &event
-	s.all[event.ID] = &event
+	s.addEvent(&event)
+	s.audit(storage.NewAuditRecord(ctx, storage.AuditCreate, nil, copyEvent(&event)))
 	return event.ID, nil
 }
 
-func (s *Storage) UpdateEvent(_ context.Context, id string, event storage.Event) error {
+func (s *Storage) UpdateEvent(ctx context.Context, id string, event storage.Event) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	// проверки
@@ -60,28 +155,39 @@ func (s *Storage) UpdateEvent(_ context.Context, id string, event storage.Event)
 	if current.UserID != event.UserID {
 		return storage.ErrUpdateUserID
 	}
-	if event.StopTime.Before(event.StartTime) {
-		return storage.ErrInvalidStopTime
+	if err := event.Validate(); err != nil {
+		return err
 	}
 
 	ue := s.byUser[event.UserID]
-	if ce, ok := ue[event.StartTime]; ok && ce.ID != event.ID {
+	if !s.allowOverlap && ue.overlaps(&event) {
 		return storage.ErrDateBusy
 	}
 	// изменение
-	delete(ue, current.StartTime)
-	ue[event.StartTime] = current
+	before := copyEvent(current)
+	ue.remove(current)
+	s.unindexTokens(current)
 
 	current.Title = event.Title
 	current.Description = event.Description
 	current.StartTime = event.StartTime
 	current.StopTime = event.StopTime
-	current.Reminder = event.Reminder
+	current.Reminders = sortedReminders(event.Reminders)
+	current.RRule = event.RRule
+	current.ExDates = event.ExDates
+	current.TraceContext = event.TraceContext
+	current.TimeZone = event.TimeZone
+	current.AllDay = event.AllDay
+	ue.insert(current)
+	s.byUser[event.UserID] = ue
+	s.indexTokens(current)
+	s.setReminderTime(current)
+	s.audit(storage.NewAuditRecord(ctx, storage.AuditUpdate, before, copyEvent(current)))
 
 	return nil
 }
 
-func (s *Storage) DeleteEvent(_ context.Context, id string) error {
+func (s *Storage) DeleteEvent(ctx context.Context, id string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	// проверки
@@ -90,33 +196,154 @@ func (s *Storage) DeleteEvent(_ context.Context, id string) error {
 		return storage.ErrEventNotFound
 	}
 	// изменение
-	delete(s.byUser[current.UserID], current.StartTime)
-	delete(s.all, id)
+	s.removeEvent(current)
+	s.deleted[id] = current
+	s.audit(storage.NewAuditRecord(ctx, storage.AuditDelete, copyEvent(current), nil))
 	return nil
 }
 
-func (s *Storage) listEventsInt(startTime time.Time, stopTime time.Time) []*storage.Event {
+func (s *Storage) RestoreEvent(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// проверки
+	current := s.deleted[id]
+	if current == nil {
+		if s.all[id] != nil {
+			return storage.ErrEventNotDeleted
+		}
+		return storage.ErrEventNotFound
+	}
+	if !s.allowOverlap && s.byUser[current.UserID].overlaps(current) {
+		return storage.ErrDateBusy
+	}
+	// изменение
+	delete(s.deleted, id)
+	s.addEvent(current)
+	s.audit(storage.NewAuditRecord(ctx, storage.AuditRestore, nil, copyEvent(current)))
+	return nil
+}
+
+func (s *Storage) ListEventHistory(_ context.Context, id string) ([]*storage.AuditRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]*storage.AuditRecord, 0, len(s.history[id]))
+	for _, record := range s.history[id] {
+		record := *record
+		if record.Before != nil {
+			record.Before = copyEvent(record.Before)
+		}
+		if record.After != nil {
+			record.After = copyEvent(record.After)
+		}
+		result = append(result, &record)
+	}
+	return result, nil
+}
+
+func (s *Storage) audit(record *storage.AuditRecord) {
+	s.history[record.EventID] = append(s.history[record.EventID], record)
+}
+
+func (s *Storage) listEventsInt(userID int64, startTime time.Time, stopTime time.Time, query string) []*storage.Event {
 	result := make([]*storage.Event, 0)
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	for _, v := range s.all {
-		if v.StartTime.Compare(startTime) >= 0 && v.StartTime.Compare(stopTime) <= 0 {
-			result = append(result, v)
+	found := s.search(query)
+	// события пользователя берем из индекса по пользователям
+	for _, v := range s.byUser[userID] {
+		if !v.StartTime.Before(stopTime) {
+			// дальше события начинаются после окончания интервала
+			break
+		}
+		if _, ok := found[v.ID]; query != "" && !ok {
+			continue
+		}
+		for _, occurrence := range v.Overlapping(startTime, stopTime) {
+			result = append(result, copyEvent(occurrence))
+		}
+	}
+	// события, на которые пользователь приглашен
+	for _, v := range s.byAttendee[userID] {
+		if _, ok := found[v.ID]; query != "" && !ok {
+			continue
+		}
+		for _, occurrence := range v.Overlapping(startTime, stopTime) {
+			result = append(result, copyEvent(occurrence))
 		}
 	}
 	return result
 }
 
-func (s *Storage) ListEventsDay(_ context.Context, startTime time.Time) ([]*storage.Event, error) {
-	return s.listEventsInt(startTime, startTime.Add(time.Hour*24)), nil
+// copyEvent возвращает копию события: изменение результата не должно менять хранилище в обход UpdateEvent.
+func copyEvent(event *storage.Event) *storage.Event {
+	result := *event
+	result.Attendees = slices.Clone(event.Attendees)
+	result.Reminders = slices.Clone(event.Reminders)
+	return &result
+}
+
+func sortedReminders(reminders []storage.Reminder) []storage.Reminder {
+	result := slices.Clone(reminders)
+	storage.SortReminders(result)
+	return result
 }
 
-func (s *Storage) ListEventsWeek(_ context.Context, startTime time.Time) ([]*storage.Event, error) {
-	return s.listEventsInt(startTime, startTime.Add(time.Hour*24*7)), nil
+// search возвращает id событий, содержащих все слова запроса.
+func (s *Storage) search(query string) map[string]struct{} {
+	result := make(map[string]struct{})
+	tokens := storage.Tokenize(query)
+	if len(tokens) == 0 {
+		return result
+	}
+	for id := range s.tokens[tokens[0]] {
+		result[id] = struct{}{}
+	}
+	for _, token := range tokens[1:] {
+		ids := s.tokens[token]
+		for id := range result {
+			if _, ok := ids[id]; !ok {
+				delete(result, id)
+			}
+		}
+	}
+	return result
+}
+
+func (s *Storage) indexTokens(event *storage.Event) {
+	for _, token := range storage.Tokenize(event.SearchText()) {
+		ids := s.tokens[token]
+		if ids == nil {
+			ids = make(map[string]struct{})
+			s.tokens[token] = ids
+		}
+		ids[event.ID] = struct{}{}
+	}
+}
+
+func (s *Storage) unindexTokens(event *storage.Event) {
+	for _, token := range storage.Tokenize(event.SearchText()) {
+		delete(s.tokens[token], event.ID)
+		if len(s.tokens[token]) == 0 {
+			delete(s.tokens, token)
+		}
+	}
 }
 
-func (s *Storage) ListEventsMonth(_ context.Context, startTime time.Time) ([]*storage.Event, error) {
-	return s.listEventsInt(startTime, startTime.AddDate(0, 1, 0)), nil
+func (s *Storage) ListEventsDay(_ context.Context, userID int64, startTime time.Time) ([]*storage.Event, error) {
+	return s.listEventsInt(userID, startTime, startTime.AddDate(0, 0, 1), ""), nil
+}
+
+func (s *Storage) ListEventsWeek(_ context.Context, userID int64, startTime time.Time) ([]*storage.Event, error) {
+	return s.listEventsInt(userID, startTime, startTime.AddDate(0, 0, 7), ""), nil
+}
+
+func (s *Storage) ListEventsMonth(_ context.Context, userID int64, startTime time.Time) ([]*storage.Event, error) {
+	return s.listEventsInt(userID, startTime, startTime.AddDate(0, 1, 0), ""), nil
+}
+
+func (s *Storage) ListEvents(_ context.Context, params storage.ListParams) ([]*storage.Event, *storage.Cursor, error) {
+	events, next := params.Page(s.listEventsInt(params.UserID, params.StartTime, params.StopTime, params.Query))
+	return events, next, nil
 }
 
 func (s *Storage) GetEvent(_ context.Context, id string) (*storage.Event, error) {
@@ -126,39 +353,307 @@ func (s *Storage) GetEvent(_ context.Context, id string) (*storage.Event, error)
 	if current == nil {
 		return nil, storage.ErrEventNotFound
 	}
-	return current, nil
+	return copyEvent(current), nil
 }
 
-func (s *Storage) ListEventsReminder(_ context.Context) ([]*storage.Event, error) {
-	result := make([]*storage.Event, 0)
+func (s *Storage) ListDueReminders(_ context.Context) ([]*storage.DueReminder, error) {
+	result := make([]*storage.DueReminder, 0)
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	for _, v := range s.all {
-		if v.Reminder != nil && v.StartTime.Before(time.Now().Add(-*v.Reminder)) {
-			result = append(result, v)
+	now := time.Now()
+	for id, reminders := range s.reminderTime {
+		for reminder, reminderTime := range reminders {
+			if !reminderTime.Before(now) {
+				continue
+			}
+			// для серии - напоминание о конкретном повторении
+			event, ok := s.all[id].DueOccurrence(reminder, reminderTime)
+			if !ok {
+				continue
+			}
+			result = append(result, &storage.DueReminder{Reminder: reminder, Event: copyEvent(event)})
 		}
 	}
 	return result, nil
 }
 
-func (s *Storage) ClearReminderTime(_ context.Context, _ string) error {
-	// поле reminderTime есть только в БД, здесь ничего делать не надо
+func (s *Storage) ClearReminderTime(_ context.Context, id string, reminder storage.Reminder) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.clearReminderTime(id, reminder)
+}
+
+func (s *Storage) clearReminderTime(id string, reminder storage.Reminder) error {
+	current := s.all[id]
+	if current == nil {
+		return storage.ErrEventNotFound
+	}
+	reminderTime, ok := s.reminderTime[id][reminder]
+	if !ok {
+		return nil
+	}
+	// для серии переходим к следующему повторению
+	if next := current.FollowingReminderTime(reminder, reminderTime); next != nil {
+		s.reminderTime[id][reminder] = *next
+		return nil
+	}
+	delete(s.reminderTime[id], reminder)
+	if len(s.reminderTime[id]) == 0 {
+		delete(s.reminderTime, id)
+	}
 	return nil
 }
 
+// addEvent добавляет событие во все индексы.
+func (s *Storage) addEvent(event *storage.Event) {
+	ue := s.byUser[event.UserID]
+	ue.insert(event)
+	s.byUser[event.UserID] = ue
+	for _, attendee := range event.Attendees {
+		s.indexAttendee(event, attendee.UserID)
+	}
+	s.all[event.ID] = event
+	s.indexTokens(event)
+	s.setReminderTime(event)
+}
+
+// removeEvent удаляет событие из всех индексов.
+func (s *Storage) removeEvent(event *storage.Event) {
+	ue := s.byUser[event.UserID]
+	ue.remove(event)
+	s.byUser[event.UserID] = ue
+	for _, attendee := range event.Attendees {
+		s.unindexAttendee(event.ID, attendee.UserID)
+	}
+	s.unindexTokens(event)
+	delete(s.all, event.ID)
+	delete(s.reminderTime, event.ID)
+}
+
+func (s *Storage) indexAttendee(event *storage.Event, userID int64) {
+	events := s.byAttendee[userID]
+	if events == nil {
+		events = make(map[string]*storage.Event)
+		s.byAttendee[userID] = events
+	}
+	events[event.ID] = event
+}
+
+func (s *Storage) unindexAttendee(eventID string, userID int64) {
+	delete(s.byAttendee[userID], eventID)
+	if len(s.byAttendee[userID]) == 0 {
+		delete(s.byAttendee, userID)
+	}
+}
+
+func (s *Storage) setReminderTime(event *storage.Event) {
+	delete(s.reminderTime, event.ID)
+	now := time.Now()
+	for _, reminder := range event.Reminders {
+		reminderTime := event.NextReminderTime(reminder, now)
+		if reminderTime == nil {
+			continue
+		}
+		if s.reminderTime[event.ID] == nil {
+			s.reminderTime[event.ID] = make(map[storage.Reminder]time.Time)
+		}
+		s.reminderTime[event.ID][reminder] = *reminderTime
+	}
+}
+
 func (s *Storage) DeleteEventsBeforeDate(_ context.Context, time time.Time) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	for _, v := range s.all {
-		if v.StartTime.Before(time) {
-			delete(s.byUser[v.UserID], v.StartTime)
-			delete(s.all, v.ID)
+		if expired(v, time) {
+			s.removeEvent(v)
+		}
+	}
+	for id, v := range s.deleted {
+		if expired(v, time) {
+			delete(s.deleted, id)
+		}
+	}
+	return nil
+}
+
+// expired проверяет, что событие закончилось до before: серия - только после окончания последнего повторения.
+func expired(event *storage.Event, before time.Time) bool {
+	lastStopTime, ok := event.LastStopTime()
+	return ok && event.StartTime.Before(before) && (!event.IsRecurring() || lastStopTime.Before(before))
+}
+
+type notification struct {
+	storage.Notification
+	delivery storage.NotificationDelivery
+}
+
+func (s *Storage) SaveNotification(_ context.Context, n storage.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.notifications[n.ID]; !ok {
+		s.notifications[n.ID] = &notification{
+			Notification: n, delivery: storage.NotificationDelivery{Status: storage.DeliveryPending},
+		}
+	}
+	return nil
+}
+
+func (s *Storage) GetNotificationDelivery(_ context.Context, id string) (*storage.NotificationDelivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	current := s.notifications[id]
+	if current == nil {
+		return nil, storage.ErrNotificationNotFound
+	}
+	delivery := current.delivery
+	return &delivery, nil
+}
+
+func (s *Storage) UpdateNotificationDelivery(_ context.Context, id string, status storage.DeliveryStatus,
+	channel string, lastError string,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current := s.notifications[id]
+	if current == nil {
+		return storage.ErrNotificationNotFound
+	}
+	current.delivery.Status = status
+	current.delivery.Channel = channel
+	current.delivery.Attempts++
+	current.delivery.LastError = lastError
+	return nil
+}
+
+func (s *Storage) EnqueueNotification(_ context.Context, eventID string, reminder storage.Reminder,
+	notification storage.Notification,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.clearReminderTime(eventID, reminder); err != nil {
+		return err
+	}
+	s.enqueue(notification)
+	return nil
+}
+
+func (s *Storage) enqueue(notification storage.Notification) {
+	if _, ok := s.outbox[notification.ID]; ok {
+		return
+	}
+	now := time.Now()
+	s.outbox[notification.ID] = &storage.OutboxMessage{
+		Notification: notification, CreatedAt: now, NextAttemptTime: now,
+	}
+	s.outboxOrder = append(s.outboxOrder, notification.ID)
+}
+
+func (s *Storage) InviteAttendee(_ context.Context, eventID string, userID int64,
+	notification storage.Notification,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current := s.all[eventID]
+	if current == nil {
+		return storage.ErrEventNotFound
+	}
+	if _, ok := current.Attendee(userID); ok {
+		return nil
+	}
+	current.Attendees = append(current.Attendees, storage.Attendee{UserID: userID, Status: storage.RSVPNeedsAction})
+	storage.SortAttendees(current.Attendees)
+	s.indexAttendee(current, userID)
+	s.enqueue(notification)
+	return nil
+}
+
+func (s *Storage) UpdateAttendee(_ context.Context, eventID string, attendee storage.Attendee,
+	notification storage.Notification,
+) error {
+	if err := attendee.Status.Validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current := s.all[eventID]
+	if current == nil {
+		return storage.ErrEventNotFound
+	}
+	i := slices.IndexFunc(current.Attendees, func(a storage.Attendee) bool { return a.UserID == attendee.UserID })
+	if i < 0 {
+		return storage.ErrAttendeeNotFound
+	}
+	if current.Attendees[i].Status == attendee.Status {
+		return nil
+	}
+	current.Attendees[i].Status = attendee.Status
+	s.enqueue(notification)
+	return nil
+}
+
+func (s *Storage) RemoveAttendee(_ context.Context, eventID string, userID int64,
+	notification storage.Notification,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current := s.all[eventID]
+	if current == nil {
+		return storage.ErrEventNotFound
+	}
+	i := slices.IndexFunc(current.Attendees, func(a storage.Attendee) bool { return a.UserID == userID })
+	if i < 0 {
+		return storage.ErrAttendeeNotFound
+	}
+	current.Attendees = slices.Delete(current.Attendees, i, i+1)
+	s.unindexAttendee(eventID, userID)
+	s.enqueue(notification)
+	return nil
+}
+
+func (s *Storage) ListOutbox(_ context.Context, limit int) ([]*storage.OutboxMessage, error) {
+	result := make([]*storage.OutboxMessage, 0)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	now := time.Now()
+	for _, id := range s.outboxOrder {
+		if len(result) == limit {
+			break
+		}
+		if message := s.outbox[id]; !message.NextAttemptTime.After(now) {
+			copied := *message
+			result = append(result, &copied)
+		}
+	}
+	return result, nil
+}
+
+func (s *Storage) MarkOutboxSent(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.outbox[id]; !ok {
+		return storage.ErrNotificationNotFound
+	}
+	// отправленные уведомления в памяти не храним
+	delete(s.outbox, id)
+	for i, current := range s.outboxOrder {
+		if current == id {
+			s.outboxOrder = append(s.outboxOrder[:i], s.outboxOrder[i+1:]...)
+			break
 		}
 	}
 	return nil
 }
 
-func (s *Storage) SaveNotification(_ context.Context, _ storage.Notification) error {
-	// реализация только в БД
+func (s *Storage) MarkOutboxFailed(_ context.Context, id string, nextAttemptTime time.Time, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	message, ok := s.outbox[id]
+	if !ok {
+		return storage.ErrNotificationNotFound
+	}
+	message.Attempts++
+	message.NextAttemptTime = nextAttemptTime
+	message.LastError = reason
 	return nil
 }
@@ -7,8 +7,9 @@ import (
 	"testing"
 	"time"
 
-	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage" //nolint:depguard
-	"github.com/stretchr/testify/require"                              //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage"             //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage/storagetest" //nolint:depguard
+	"github.com/stretchr/testify/require"                                          //nolint:depguard
 )
 
 const (
@@ -16,9 +17,15 @@ const (
 	badUserID  = 777
 )
 
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(_ *testing.T, allowOverlap bool) storage.Storage {
+		return New(allowOverlap)
+	})
+}
+
 func TestStorage(t *testing.T) {
 	ctx := context.Background()
-	repo := New()
+	repo := New(false)
 	event1 := storage.Event{
 		Title:       "title 1",
 		StartTime:   time.Date(2025, 1, 1, 11, 0, 0, 0, time.UTC),
@@ -43,7 +50,7 @@ func TestStorage(t *testing.T) {
 		require.Equal(t, len(repo.all), 1)
 		require.Equal(t, len(repo.byUser), 1)
 		require.Equal(t, len(repo.byUser[event1.UserID]), 1)
-		require.Equal(t, repo.byUser[event1.UserID][event1.StartTime], &event1)
+		require.Equal(t, repo.byUser[event1.UserID][0], &event1)
 		require.Equal(t, repo.all[id], &event1)
 	})
 
@@ -68,7 +75,7 @@ func TestStorage(t *testing.T) {
 		require.Equal(t, len(repo.all), 2)
 		require.Equal(t, len(repo.byUser), 1)
 		require.Equal(t, len(repo.byUser[event2.UserID]), 2)
-		require.Equal(t, repo.byUser[event2.UserID][event2.StartTime], &event2)
+		require.Equal(t, repo.byUser[event2.UserID][1], &event2)
 		require.Equal(t, repo.all[id], &event2)
 	})
 
@@ -120,33 +127,37 @@ func TestStorage(t *testing.T) {
 		require.Equal(t, len(repo.all), 2)
 		require.Equal(t, len(repo.byUser), 1)
 		require.Equal(t, len(repo.byUser[event1.UserID]), 2)
-		require.Equal(t, repo.byUser[event1.UserID][event1.StartTime], &event1)
+		require.Equal(t, repo.byUser[event1.UserID][0], &event1)
 		require.Equal(t, repo.all[event1.ID], &event1)
 	})
 
 	t.Run("list events day", func(t *testing.T) {
-		events, err := repo.ListEventsDay(ctx, event1.StartTime)
+		events, err := repo.ListEventsDay(ctx, 1, event1.StartTime)
 		require.NoError(t, err)
 		require.Equal(t, len(events), 1)
 		require.Equal(t, *events[0], event1)
 	})
 	t.Run("list events week", func(t *testing.T) {
-		events, err := repo.ListEventsWeek(ctx, event1.StartTime)
+		events, err := repo.ListEventsWeek(ctx, 1, event1.StartTime)
 		require.NoError(t, err)
 		require.Equal(t, len(events), 2)
 	})
 	t.Run("list events month", func(t *testing.T) {
-		events, err := repo.ListEventsMonth(ctx, event1.StartTime)
+		events, err := repo.ListEventsMonth(ctx, 1, event1.StartTime)
 		require.NoError(t, err)
 		require.Equal(t, len(events), 2)
+		// чужие события не видны
+		events, err = repo.ListEventsMonth(ctx, badUserID, event1.StartTime)
+		require.NoError(t, err)
+		require.Equal(t, len(events), 0)
 	})
-	t.Run("list events reminder", func(t *testing.T) {
-		events, err := repo.ListEventsReminder(ctx)
+	t.Run("list due reminders", func(t *testing.T) {
+		reminders, err := repo.ListDueReminders(ctx)
 		require.NoError(t, err)
-		require.Equal(t, 0, len(events))
+		require.Equal(t, 0, len(reminders))
 	})
 	t.Run("clear reminder", func(t *testing.T) {
-		err := repo.ClearReminderTime(ctx, event1.ID)
+		err := repo.ClearReminderTime(ctx, event1.ID, storage.Reminder{Before: time.Hour})
 		require.NoError(t, err)
 	})
 
@@ -160,13 +171,250 @@ func TestStorage(t *testing.T) {
 		require.Equal(t, len(repo.all), 1)
 		require.Equal(t, len(repo.byUser), 1)
 		require.Equal(t, len(repo.byUser[event2.UserID]), 1)
-		require.Equal(t, repo.byUser[event2.UserID][event2.StartTime], &event2)
+		require.Equal(t, repo.byUser[event2.UserID][0], &event2)
 		require.Equal(t, repo.all[event2.ID], &event2)
 	})
 }
 
+func TestStorageOverlap(t *testing.T) {
+	ctx := context.Background()
+	at := func(hour, minute int) time.Time {
+		return time.Date(2025, 1, 1, hour, minute, 0, 0, time.UTC)
+	}
+	newEvent := func(start, stop time.Time) storage.Event {
+		return storage.Event{UserID: 1, StartTime: start, StopTime: stop}
+	}
+
+	t.Run("overlaps forbidden", func(t *testing.T) {
+		repo := New(false)
+		id, err := repo.CreateEvent(ctx, newEvent(at(10, 0), at(11, 0)))
+		require.NoError(t, err)
+		_, err = repo.CreateEvent(ctx, newEvent(at(12, 0), at(13, 0)))
+		require.NoError(t, err)
+
+		tests := []struct {
+			name        string
+			start, stop time.Time
+			err         error
+		}{
+			{name: "overlaps end", start: at(10, 30), stop: at(11, 30), err: storage.ErrDateBusy},
+			{name: "overlaps start", start: at(9, 30), stop: at(10, 30), err: storage.ErrDateBusy},
+			{name: "inside", start: at(10, 15), stop: at(10, 45), err: storage.ErrDateBusy},
+			{name: "covers both", start: at(9, 0), stop: at(14, 0), err: storage.ErrDateBusy},
+			{name: "same interval", start: at(12, 0), stop: at(13, 0), err: storage.ErrDateBusy},
+			{name: "empty interval", start: at(10, 30), stop: at(10, 30)},
+			{name: "back to back", start: at(11, 0), stop: at(12, 0)},
+		}
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				_, err := repo.CreateEvent(ctx, newEvent(tc.start, tc.stop))
+				require.ErrorIs(t, err, tc.err)
+			})
+		}
+
+		// другой пользователь может занять то же время
+		event := newEvent(at(10, 0), at(11, 0))
+		event.UserID = 2
+		_, err = repo.CreateEvent(ctx, event)
+		require.NoError(t, err)
+
+		// перенос события на занятое время и сдвиг внутри своего интервала
+		event = newEvent(at(11, 30), at(12, 30))
+		event.ID = id
+		require.ErrorIs(t, repo.UpdateEvent(ctx, id, event), storage.ErrDateBusy)
+		event = newEvent(at(9, 30), at(10, 30))
+		event.ID = id
+		require.NoError(t, repo.UpdateEvent(ctx, id, event))
+		_, err = repo.CreateEvent(ctx, newEvent(at(10, 30), at(11, 0)))
+		require.NoError(t, err)
+
+		require.NoError(t, repo.DeleteEvent(ctx, id))
+		_, err = repo.CreateEvent(ctx, newEvent(at(9, 0), at(10, 30)))
+		require.NoError(t, err)
+	})
+
+	t.Run("overlaps recurring", func(t *testing.T) {
+		repo := New(false)
+		day := func(day, hour int) time.Time {
+			return time.Date(2025, 1, day, hour, 0, 0, 0, time.UTC)
+		}
+		newSeries := func(start, stop time.Time, rrule string) storage.Event {
+			return storage.Event{UserID: 1, StartTime: start, StopTime: stop, RRule: rrule}
+		}
+		// повторения 5, 6, 8 и 9 января, 7 января исключено
+		series := newSeries(day(5, 10), day(5, 11), "FREQ=DAILY;COUNT=5")
+		series.ExDates = []time.Time{day(7, 10)}
+		var err error
+		series.ID, err = repo.CreateEvent(ctx, series)
+		require.NoError(t, err)
+		single, err := repo.CreateEvent(ctx, newEvent(day(20, 10), day(20, 11)))
+		require.NoError(t, err)
+
+		tests := []struct {
+			name  string
+			event storage.Event
+			err   error
+		}{
+			{name: "later occurrence", event: newEvent(day(6, 10), day(6, 12)), err: storage.ErrDateBusy},
+			{name: "last occurrence", event: newEvent(day(9, 9), day(9, 12)), err: storage.ErrDateBusy},
+			{
+				name: "series hits single event", event: newSeries(day(15, 10), day(15, 11), "FREQ=DAILY;COUNT=10"),
+				err: storage.ErrDateBusy,
+			},
+			{name: "series hits series", event: newSeries(day(1, 10), day(1, 11), "FREQ=WEEKLY"), err: storage.ErrDateBusy},
+			{name: "between occurrences", event: newEvent(day(6, 11), day(6, 12))},
+			{name: "excluded occurrence", event: newEvent(day(7, 10), day(7, 11))},
+			{name: "after series", event: newEvent(day(10, 10), day(10, 11))},
+			{name: "series before single event", event: newSeries(day(15, 10), day(15, 11), "FREQ=DAILY;COUNT=5")},
+			{name: "series in free time", event: newSeries(day(1, 12), day(1, 13), "FREQ=WEEKLY")},
+		}
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				_, err := repo.CreateEvent(ctx, tc.event)
+				require.ErrorIs(t, err, tc.err)
+			})
+		}
+
+		// перенос события на повторение серии и серии на событие
+		event := newEvent(day(8, 10), day(8, 11))
+		event.ID = single
+		require.ErrorIs(t, repo.UpdateEvent(ctx, single, event), storage.ErrDateBusy)
+		series.RRule = "FREQ=DAILY;COUNT=20"
+		require.ErrorIs(t, repo.UpdateEvent(ctx, series.ID, series), storage.ErrDateBusy)
+		series.RRule = "FREQ=DAILY;COUNT=3"
+		require.NoError(t, repo.UpdateEvent(ctx, series.ID, series))
+	})
+
+	t.Run("overlaps allowed", func(t *testing.T) {
+		repo := New(true)
+		for i := 0; i < 3; i++ {
+			_, err := repo.CreateEvent(ctx, newEvent(at(10, 0), at(11, i)))
+			require.NoError(t, err)
+		}
+		events, err := repo.ListEventsDay(ctx, 1, at(0, 0))
+		require.NoError(t, err)
+		require.Equal(t, 3, len(events))
+	})
+}
+
+func TestStorageListEvents(t *testing.T) {
+	ctx := context.Background()
+	repo := New(false)
+	at := func(day, hour int) time.Time {
+		return time.Date(2025, 1, day, hour, 0, 0, 0, time.UTC)
+	}
+	create := func(title, description string, start, stop time.Time) string {
+		id, err := repo.CreateEvent(ctx, storage.Event{
+			Title: title, Description: description, UserID: 1, StartTime: start, StopTime: stop,
+		})
+		require.NoError(t, err)
+		return id
+	}
+	night := create("Night shift", "warehouse", at(1, 22), at(2, 6))
+	create("Planning", "quarter planning, warehouse budget", at(2, 10), at(2, 11))
+	create("Retro", "sprint retro", at(3, 10), at(3, 11))
+	titles := func(params storage.ListParams) []string {
+		params.UserID = 1
+		events, _, err := repo.ListEvents(ctx, params)
+		require.NoError(t, err)
+		result := make([]string, 0, len(events))
+		for _, event := range events {
+			result = append(result, event.Title)
+		}
+		return result
+	}
+
+	t.Run("range overlap", func(t *testing.T) {
+		require.Equal(t, []string{"Night shift", "Planning"},
+			titles(storage.ListParams{StartTime: at(2, 0), StopTime: at(3, 0)}))
+		require.Equal(t, []string{"Night shift"}, titles(storage.ListParams{StartTime: at(1, 0), StopTime: at(2, 0)}))
+		require.Empty(t, titles(storage.ListParams{StartTime: at(2, 6), StopTime: at(2, 10)}))
+		events, err := repo.ListEventsDay(ctx, 1, at(2, 0))
+		require.NoError(t, err)
+		require.Len(t, events, 2, "period lists use the same overlap semantics")
+	})
+
+	t.Run("search", func(t *testing.T) {
+		all := storage.ListParams{StartTime: at(1, 0), StopTime: at(4, 0)}
+		all.Query = "WAREHOUSE"
+		require.Equal(t, []string{"Night shift", "Planning"}, titles(all))
+		all.Query = "warehouse budget"
+		require.Equal(t, []string{"Planning"}, titles(all))
+		all.Query = "retro, sprint"
+		require.Equal(t, []string{"Retro"}, titles(all))
+		all.Query = "ware"
+		require.Empty(t, titles(all))
+		all.Query = "?!"
+		require.Empty(t, titles(all))
+	})
+
+	t.Run("search index follows updates", func(t *testing.T) {
+		event, err := repo.GetEvent(ctx, night)
+		require.NoError(t, err)
+		updated := *event
+		updated.Description = "office"
+		require.NoError(t, repo.UpdateEvent(ctx, night, updated))
+
+		all := storage.ListParams{StartTime: at(1, 0), StopTime: at(4, 0), Query: "warehouse"}
+		require.Equal(t, []string{"Planning"}, titles(all))
+		all.Query = "office"
+		require.Equal(t, []string{"Night shift"}, titles(all))
+
+		require.NoError(t, repo.DeleteEvent(ctx, night))
+		require.Empty(t, titles(all))
+		require.NotContains(t, repo.tokens, "office")
+	})
+}
+
+func TestStorageOutbox(t *testing.T) {
+	ctx := context.Background()
+	repo := New(false)
+	reminder := storage.Reminder{Before: time.Hour}
+	startTime := time.Now().Add(time.Minute * 30)
+	id, err := repo.CreateEvent(ctx, storage.Event{
+		Title: "event", UserID: 1, StartTime: startTime, StopTime: startTime.Add(time.Hour),
+		Reminders: []storage.Reminder{reminder},
+	})
+	require.NoError(t, err)
+	notification := storage.Notification{ID: id, Title: "event", StartTime: startTime, UserID: 1}
+
+	require.ErrorIs(t, repo.EnqueueNotification(ctx, badEventID, reminder, notification), storage.ErrEventNotFound)
+	require.Empty(t, repo.outbox, "nothing is enqueued when reminder is not cleared")
+
+	require.NoError(t, repo.EnqueueNotification(ctx, id, reminder, notification))
+	require.NoError(t, repo.EnqueueNotification(ctx, id, reminder, notification))
+	reminders, err := repo.ListDueReminders(ctx)
+	require.NoError(t, err)
+	require.Empty(t, reminders)
+
+	messages, err := repo.ListOutbox(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	require.Equal(t, notification, messages[0].Notification)
+
+	// после неудачной отправки уведомление ждет следующей попытки
+	require.NoError(t, repo.MarkOutboxFailed(ctx, id, time.Now().Add(time.Hour), "broker is down"))
+	messages, err = repo.ListOutbox(ctx, 10)
+	require.NoError(t, err)
+	require.Empty(t, messages)
+	require.Equal(t, 1, repo.outbox[id].Attempts)
+	require.Equal(t, "broker is down", repo.outbox[id].LastError)
+
+	require.NoError(t, repo.MarkOutboxFailed(ctx, id, time.Now(), "broker is down"))
+	messages, err = repo.ListOutbox(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	require.Equal(t, 2, messages[0].Attempts)
+
+	require.NoError(t, repo.MarkOutboxSent(ctx, id))
+	messages, err = repo.ListOutbox(ctx, 10)
+	require.NoError(t, err)
+	require.Empty(t, messages)
+	require.ErrorIs(t, repo.MarkOutboxSent(ctx, id), storage.ErrNotificationNotFound)
+}
+
 func TestStorageDeleteEventsBeforeDate(t *testing.T) {
-	repo := New()
+	repo := New(false)
 	ctx := context.Background()
 	_, err := repo.CreateEvent(ctx, storage.Event{
 		UserID: 1, StartTime: time.Now().Add(-time.Hour), StopTime: time.Now(),
@@ -187,7 +435,7 @@ func TestStorageConcurrency(t *testing.T) {
 	const threadCount = 10
 	const objectPerThread = 10000
 	ctx := context.Background()
-	repo := New()
+	repo := New(false)
 	afterCreate := make(chan string)
 	afterReadUpdate := make(chan string)
 
@@ -268,3 +516,69 @@ func TestStorageConcurrency(t *testing.T) {
 	require.Equal(t, uint64(threadCount*objectPerThread), readUpdateCount)
 	require.Equal(t, uint64(threadCount*objectPerThread), readDeleteCount)
 }
+
+func TestStorageRecurring(t *testing.T) {
+	ctx := context.Background()
+	repo := New(false)
+	reminder := storage.Reminder{Before: time.Hour}
+	startTime := time.Now().Truncate(time.Second).Add(-time.Hour * 24)
+	id, err := repo.CreateEvent(ctx, storage.Event{
+		Title: "stand-up", UserID: 1, StartTime: startTime, StopTime: startTime.Add(time.Minute * 15),
+		RRule: "FREQ=DAILY;COUNT=5", ExDates: []time.Time{startTime.AddDate(0, 0, 2)},
+		Reminders: []storage.Reminder{reminder},
+	})
+	require.NoError(t, err)
+
+	t.Run("invalid rrule", func(t *testing.T) {
+		_, err := repo.CreateEvent(ctx, storage.Event{
+			UserID: 2, StartTime: startTime, StopTime: startTime.Add(time.Hour), RRule: "FREQ=HOURLY",
+		})
+		require.ErrorIs(t, err, storage.ErrInvalidRRule)
+	})
+
+	t.Run("list occurrences", func(t *testing.T) {
+		events, err := repo.ListEventsWeek(ctx, 1, startTime)
+		require.NoError(t, err)
+		require.Equal(t, 4, len(events))
+		for _, event := range events {
+			require.Equal(t, id, event.ID)
+			require.Equal(t, time.Minute*15, event.StopTime.Sub(event.StartTime))
+		}
+
+		events, err = repo.ListEventsDay(ctx, 1, startTime.AddDate(0, 0, 1).Add(-time.Minute))
+		require.NoError(t, err)
+		require.Equal(t, 1, len(events))
+		require.Equal(t, startTime.AddDate(0, 0, 1), events[0].StartTime)
+	})
+
+	t.Run("reminder per occurrence", func(t *testing.T) {
+		// прошедшие повторения пропускаются, напоминание ждет ближайшее будущее
+		reminders, err := repo.ListDueReminders(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(reminders))
+
+		repo.reminderTime[id][reminder] = startTime.Add(-reminder.Before)
+		reminders, err = repo.ListDueReminders(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(reminders))
+		require.Equal(t, startTime, reminders[0].Event.StartTime)
+
+		require.NoError(t, repo.ClearReminderTime(ctx, id, reminder))
+		require.Equal(t, startTime.AddDate(0, 0, 1).Add(-reminder.Before), repo.reminderTime[id][reminder])
+		reminders, err = repo.ListDueReminders(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(reminders))
+		require.Equal(t, startTime.AddDate(0, 0, 1), reminders[0].Event.StartTime)
+
+		// исключенное повторение пропускается
+		require.NoError(t, repo.ClearReminderTime(ctx, id, reminder))
+		require.Equal(t, startTime.AddDate(0, 0, 3).Add(-reminder.Before), repo.reminderTime[id][reminder])
+	})
+
+	t.Run("delete old series", func(t *testing.T) {
+		require.NoError(t, repo.DeleteEventsBeforeDate(ctx, startTime.AddDate(0, 0, 1)))
+		require.Equal(t, 1, len(repo.all))
+		require.NoError(t, repo.DeleteEventsBeforeDate(ctx, startTime.AddDate(0, 0, 5)))
+		require.Equal(t, 0, len(repo.all))
+	})
+}
@@ -0,0 +1,73 @@
+// Package migrate применяет к БД хранилища встроенные миграции goose.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage" //nolint:depguard
+	"github.com/pressly/goose/v3"                                      //nolint:depguard
+	"github.com/pressly/goose/v3/lock"                                 //nolint:depguard
+)
+
+// Migrations миграции fsys для БД db.
+type Migrations struct {
+	provider *goose.Provider
+}
+
+// New создает миграции диалекта dialect. Если locker не nil, миграции выполняются под его блокировкой,
+// и одновременно запущенные процессы применяют их по очереди.
+func New(dialect goose.Dialect, db *sql.DB, fsys fs.FS, locker lock.SessionLocker) (*Migrations, error) {
+	var opts []goose.ProviderOption
+	if locker != nil {
+		opts = append(opts, goose.WithSessionLocker(locker))
+	}
+	provider, err := goose.NewProvider(dialect, db, fsys, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrMigration, err) //nolint:errorlint
+	}
+	return &Migrations{provider: provider}, nil
+}
+
+// Up применяет все новые миграции.
+func (m *Migrations) Up(ctx context.Context) ([]storage.Migration, error) {
+	results, err := m.provider.Up(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrMigration, err) //nolint:errorlint
+	}
+	migrations := make([]storage.Migration, 0, len(results))
+	for _, result := range results {
+		migrations = append(migrations, migration(result.Source, true))
+	}
+	return migrations, nil
+}
+
+// Down откатывает последнюю примененную миграцию.
+func (m *Migrations) Down(ctx context.Context) (storage.Migration, error) {
+	result, err := m.provider.Down(ctx)
+	if err != nil {
+		return storage.Migration{}, fmt.Errorf("%w: %v", storage.ErrMigration, err) //nolint:errorlint
+	}
+	return migration(result.Source, false), nil
+}
+
+// Status возвращает все миграции в порядке версий.
+func (m *Migrations) Status(ctx context.Context) ([]storage.Migration, error) {
+	statuses, err := m.provider.Status(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrMigration, err) //nolint:errorlint
+	}
+	migrations := make([]storage.Migration, 0, len(statuses))
+	for _, status := range statuses {
+		item := migration(status.Source, status.State == goose.StateApplied)
+		item.AppliedAt = status.AppliedAt
+		migrations = append(migrations, item)
+	}
+	return migrations, nil
+}
+
+func migration(source *goose.Source, applied bool) storage.Migration {
+	return storage.Migration{Version: source.Version, Source: source.Path, Applied: applied}
+}
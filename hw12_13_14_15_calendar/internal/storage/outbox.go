@@ -0,0 +1,15 @@
+package storage
+
+import "time"
+
+// OutboxMessage уведомление в outbox планировщика. Сохраняется в одной транзакции со сбросом времени напоминания
+// и отправляется в брокер отдельно, до успешной отправки.
+type OutboxMessage struct {
+	Notification
+	CreatedAt time.Time
+	// количество неудачных попыток отправки
+	Attempts int
+	// время следующей попытки отправки
+	NextAttemptTime time.Time
+	LastError       string
+}
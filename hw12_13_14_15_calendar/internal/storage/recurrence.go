@@ -0,0 +1,476 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid" //nolint:depguard
+)
+
+// Поддерживается подмножество RFC 5545 RRULE: FREQ=DAILY|WEEKLY|MONTHLY, INTERVAL, BYDAY, COUNT, UNTIL.
+// Исключения (EXDATE) хранятся в событии отдельно.
+
+type Frequency string
+
+const (
+	FreqDaily   Frequency = "DAILY"
+	FreqWeekly  Frequency = "WEEKLY"
+	FreqMonthly Frequency = "MONTHLY"
+)
+
+const (
+	rruleUntilLayout = "20060102T150405Z"
+	exDateLayout     = "20060102T150405Z"
+	// защита от бесконечного перебора для правил без COUNT и UNTIL.
+	maxIterations = 100000
+	// на сколько вперед сверяются повторения двух бесконечных серий.
+	overlapHorizon = 5 * 366 * 24 * time.Hour
+)
+
+// день недели правила, N - номер дня в месяце (1MO - первый понедельник, -1FR - последняя пятница), 0 - любой.
+type WeekdayNum struct {
+	Weekday time.Weekday
+	N       int
+}
+
+type RRule struct {
+	Freq     Frequency
+	Interval int
+	ByDay    []WeekdayNum
+	Count    int
+	// нулевое значение - без ограничения
+	Until time.Time
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+func ParseRRule(rule string) (*RRule, error) {
+	result := &RRule{Interval: 1}
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	for _, part := range strings.Split(rule, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRRule, part)
+		}
+		var err error
+		switch strings.ToUpper(name) {
+		case "FREQ":
+			result.Freq = Frequency(strings.ToUpper(value))
+		case "INTERVAL":
+			result.Interval, err = strconv.Atoi(value)
+			if err == nil && result.Interval < 1 {
+				err = fmt.Errorf("interval must be positive")
+			}
+		case "COUNT":
+			result.Count, err = strconv.Atoi(value)
+			if err == nil && result.Count < 1 {
+				err = fmt.Errorf("count must be positive")
+			}
+		case "UNTIL":
+			result.Until, err = parseRRuleTime(value)
+		case "BYDAY":
+			result.ByDay, err = parseByDay(value)
+		default:
+			err = fmt.Errorf("unsupported rule part")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v %v", ErrInvalidRRule, part, err) //nolint:errorlint
+		}
+	}
+	switch result.Freq {
+	case FreqDaily, FreqWeekly, FreqMonthly:
+	default:
+		return nil, fmt.Errorf("%w: unsupported FREQ %v", ErrInvalidRRule, result.Freq)
+	}
+	if result.Count > 0 && !result.Until.IsZero() {
+		return nil, fmt.Errorf("%w: COUNT and UNTIL must not occur together", ErrInvalidRRule)
+	}
+	return result, nil
+}
+
+func parseRRuleTime(value string) (time.Time, error) {
+	if t, err := time.Parse(rruleUntilLayout, value); err == nil {
+		return t, nil
+	}
+	// дата без времени - правило действует до конца этого дня
+	t, err := time.Parse("20060102", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.Add(24*time.Hour - time.Second), nil
+}
+
+func parseByDay(value string) ([]WeekdayNum, error) {
+	result := make([]WeekdayNum, 0)
+	for _, day := range strings.Split(value, ",") {
+		day = strings.ToUpper(strings.TrimSpace(day))
+		if len(day) < 2 {
+			return nil, fmt.Errorf("bad weekday %v", day)
+		}
+		weekday, ok := weekdayNames[day[len(day)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("bad weekday %v", day)
+		}
+		n := 0
+		if prefix := day[:len(day)-2]; prefix != "" {
+			var err error
+			n, err = strconv.Atoi(prefix)
+			if err != nil || n == 0 || n < -5 || n > 5 {
+				return nil, fmt.Errorf("bad weekday %v", day)
+			}
+		}
+		result = append(result, WeekdayNum{Weekday: weekday, N: n})
+	}
+	return result, nil
+}
+
+func (r *RRule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, d := range r.ByDay {
+			name := strings.ToUpper(d.Weekday.String()[:2])
+			if d.N != 0 {
+				name = strconv.Itoa(d.N) + name
+			}
+			days = append(days, name)
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(rruleUntilLayout))
+	}
+	return strings.Join(parts, ";")
+}
+
+// iterate перебирает начала повторений правила по возрастанию, начиная с dtstart (первое повторение всегда dtstart).
+// Перебор прекращается, когда fn возвращает false либо правило исчерпано.
+func (r *RRule) iterate(dtstart time.Time, fn func(t time.Time) bool) {
+	count := 0
+	emit := func(t time.Time) bool {
+		if !r.Until.IsZero() && t.After(r.Until) {
+			return false
+		}
+		count++
+		if !fn(t) {
+			return false
+		}
+		return r.Count == 0 || count < r.Count
+	}
+
+	if !emit(dtstart) || r.neverRepeats(dtstart) {
+		return
+	}
+	for period := 0; period < maxIterations; period++ {
+		candidates := r.periodCandidates(dtstart, period)
+		for _, t := range candidates {
+			if !t.After(dtstart) {
+				continue
+			}
+			if !emit(t) {
+				return
+			}
+		}
+	}
+}
+
+// periodCandidates возвращает отсортированные кандидаты на повторение в периоде с номером period (день, неделя, месяц).
+func (r *RRule) periodCandidates(dtstart time.Time, period int) []time.Time {
+	step := period * r.Interval
+	switch r.Freq {
+	case FreqDaily:
+		t := dtstart.AddDate(0, 0, step)
+		if len(r.ByDay) > 0 && !r.hasWeekday(t.Weekday()) {
+			return nil
+		}
+		return []time.Time{t}
+	case FreqWeekly:
+		// неделя начинается с понедельника (WKST=MO по умолчанию)
+		offset := (int(dtstart.Weekday()) + 6) % 7
+		weekStart := dtstart.AddDate(0, 0, step*7-offset)
+		if len(r.ByDay) == 0 {
+			return []time.Time{dtstart.AddDate(0, 0, step*7)}
+		}
+		result := make([]time.Time, 0, len(r.ByDay))
+		for _, d := range r.ByDay {
+			result = append(result, weekStart.AddDate(0, 0, (int(d.Weekday)+6)%7))
+		}
+		sortTimes(result)
+		return result
+	case FreqMonthly:
+		first := time.Date(dtstart.Year(), dtstart.Month()+time.Month(step), 1,
+			dtstart.Hour(), dtstart.Minute(), dtstart.Second(), dtstart.Nanosecond(), dtstart.Location())
+		if len(r.ByDay) == 0 {
+			t := first.AddDate(0, 0, dtstart.Day()-1)
+			if t.Month() != first.Month() {
+				// несуществующая дата (например 31 число) пропускается
+				return nil
+			}
+			return []time.Time{t}
+		}
+		return r.monthDays(first)
+	}
+	return nil
+}
+
+func (r *RRule) monthDays(first time.Time) []time.Time {
+	result := make([]time.Time, 0)
+	daysInMonth := first.AddDate(0, 1, -1).Day()
+	for _, d := range r.ByDay {
+		days := make([]time.Time, 0, 5)
+		for day := 0; day < daysInMonth; day++ {
+			t := first.AddDate(0, 0, day)
+			if t.Weekday() == d.Weekday {
+				days = append(days, t)
+			}
+		}
+		switch {
+		case d.N == 0:
+			result = append(result, days...)
+		case d.N > 0 && d.N <= len(days):
+			result = append(result, days[d.N-1])
+		case d.N < 0 && -d.N <= len(days):
+			result = append(result, days[len(days)+d.N])
+		}
+	}
+	sortTimes(result)
+	return result
+}
+
+// neverRepeats проверяет, что после dtstart правило не дает ни одного повторения: FREQ=DAILY с INTERVAL,
+// кратным 7, попадает только на день недели dtstart, а в BYDAY его нет.
+func (r *RRule) neverRepeats(dtstart time.Time) bool {
+	return r.Freq == FreqDaily && r.Interval%7 == 0 && len(r.ByDay) > 0 && !r.hasWeekday(dtstart.Weekday())
+}
+
+func (r *RRule) hasWeekday(weekday time.Weekday) bool {
+	for _, d := range r.ByDay {
+		if d.Weekday == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+func sortTimes(times []time.Time) {
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+}
+
+func isExDate(t time.Time, exDates []time.Time) bool {
+	for _, ex := range exDates {
+		if ex.Equal(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Event) IsRecurring() bool {
+	return e.RRule != ""
+}
+
+// Validate проверяет часовой пояс, границы события на весь день, напоминания и правило повторения события.
+func (e *Event) Validate() error {
+	if e.StopTime.Before(e.StartTime) {
+		return ErrInvalidStopTime
+	}
+	location, err := LoadLocation(e.TimeZone)
+	if err != nil {
+		return err
+	}
+	if e.AllDay && (!isMidnight(e.StartTime, location) || !isMidnight(e.StopTime, location) ||
+		!e.StopTime.After(e.StartTime)) {
+		return ErrInvalidAllDay
+	}
+	if err := ValidateReminders(e.Reminders); err != nil {
+		return err
+	}
+	if e.IsRecurring() {
+		rule, err := ParseRRule(e.RRule)
+		if err != nil {
+			return err
+		}
+		if rule.neverRepeats(e.localStartTime()) {
+			return fmt.Errorf("%w: %v has no occurrences after DTSTART", ErrInvalidRRule, e.RRule)
+		}
+	}
+	return nil
+}
+
+// Occurrences возвращает повторения события, начало которых попадает в интервал [from, to].
+// Для обычного события возвращается само событие, если оно попадает в интервал.
+func (e *Event) Occurrences(from, to time.Time) []*Event {
+	if !e.IsRecurring() {
+		if e.StartTime.Compare(from) >= 0 && e.StartTime.Compare(to) <= 0 {
+			return []*Event{e}
+		}
+		return nil
+	}
+	rule, err := ParseRRule(e.RRule)
+	if err != nil {
+		return nil
+	}
+	result := make([]*Event, 0)
+	rule.iterate(e.localStartTime(), func(t time.Time) bool {
+		if t.After(to) {
+			return false
+		}
+		if t.Compare(from) >= 0 && !isExDate(t, e.ExDates) {
+			result = append(result, e.occurrence(t))
+		}
+		return true
+	})
+	return result
+}
+
+// NextOccurrence возвращает ближайшее повторение события, начинающееся строго после after.
+func (e *Event) NextOccurrence(after time.Time) (time.Time, bool) {
+	if !e.IsRecurring() {
+		return e.StartTime, e.StartTime.After(after)
+	}
+	rule, err := ParseRRule(e.RRule)
+	if err != nil {
+		return time.Time{}, false
+	}
+	var result time.Time
+	found := false
+	rule.iterate(e.localStartTime(), func(t time.Time) bool {
+		if t.After(after) && !isExDate(t, e.ExDates) {
+			result = t
+			found = true
+			return false
+		}
+		return true
+	})
+	return result, found
+}
+
+// LastStopTime возвращает окончание последнего повторения события. Для бесконечной серии возвращается false.
+func (e *Event) LastStopTime() (time.Time, bool) {
+	if !e.IsRecurring() {
+		return e.StopTime, true
+	}
+	rule, err := ParseRRule(e.RRule)
+	if err != nil || (rule.Count == 0 && rule.Until.IsZero()) {
+		return time.Time{}, false
+	}
+	last := e.StartTime
+	rule.iterate(e.localStartTime(), func(t time.Time) bool {
+		last = t
+		return true
+	})
+	return e.occurrence(last).StopTime, true
+}
+
+// OverlapsEvent проверяет, пересекается ли какое-нибудь повторение события с повторением события other.
+// Как и tstzrange в ограничении xex0_event_UserID_period, пустые интервалы ни с чем не пересекаются.
+// Две бесконечные серии сверяются на overlapHorizon вперед от начала более поздней из них.
+func (e *Event) OverlapsEvent(other *Event) bool {
+	// повторения раньше from заканчиваются до начала первого повторения другого события
+	duration := max(e.StopTime.Sub(e.StartTime), other.StopTime.Sub(other.StartTime))
+	from := maxTime(e.StartTime, other.StartTime).Add(-duration)
+	to, ok := e.LastStopTime()
+	if otherStop, otherOK := other.LastStopTime(); !ok || (otherOK && otherStop.Before(to)) {
+		to, ok = otherStop, otherOK
+	}
+	if !ok {
+		to = maxTime(e.StartTime, other.StartTime).Add(overlapHorizon)
+	}
+	occurrences, otherOccurrences := e.Occurrences(from, to), other.Occurrences(from, to)
+	// повторения упорядочены по началу, first - первое повторение other, которое еще не закончилось
+	first := 0
+	for _, occurrence := range occurrences {
+		if !occurrence.StartTime.Before(occurrence.StopTime) {
+			continue
+		}
+		for first < len(otherOccurrences) && !otherOccurrences[first].StopTime.After(occurrence.StartTime) {
+			first++
+		}
+		for _, current := range otherOccurrences[first:] {
+			if !current.StartTime.Before(occurrence.StopTime) {
+				break
+			}
+			if current.StartTime.Before(current.StopTime) && current.StopTime.After(occurrence.StartTime) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// NextReminderTime возвращает время отправки напоминания reminder о ближайшем повторении, начинающемся
+// не раньше now. Для обычного события - время напоминания о нем.
+func (e *Event) NextReminderTime(reminder Reminder, now time.Time) *time.Time {
+	start := e.StartTime
+	if e.IsRecurring() {
+		var ok bool
+		start, ok = e.NextOccurrence(now.Add(-time.Nanosecond))
+		if !ok {
+			return nil
+		}
+	}
+	result := start.Add(-reminder.Before)
+	return &result
+}
+
+// NotificationID возвращает идентификатор уведомления по напоминанию о событии, у каждого напоминания
+// и каждого повторения серии он свой.
+func (e *Event) NotificationID(reminder Reminder) string {
+	name := e.ID + "/" + e.StartTime.UTC().Format(exDateLayout) + "/" + reminder.String()
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(name)).String()
+}
+
+func (e *Event) occurrence(startTime time.Time) *Event {
+	result := *e
+	if e.AllDay {
+		// событие на весь день длится целое число дней, их продолжительность меняется при переходе на летнее время
+		result.StopTime = startTime.AddDate(0, 0, e.Days())
+	} else {
+		result.StopTime = startTime.Add(e.StopTime.Sub(e.StartTime))
+	}
+	result.StartTime = startTime
+	return &result
+}
+
+// FormatExDates сериализует исключения в формате значения EXDATE.
+func FormatExDates(exDates []time.Time) string {
+	parts := make([]string, 0, len(exDates))
+	for _, t := range exDates {
+		parts = append(parts, t.UTC().Format(exDateLayout))
+	}
+	return strings.Join(parts, ",")
+}
+
+func ParseExDates(value string) ([]time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	result := make([]time.Time, 0)
+	for _, part := range strings.Split(value, ",") {
+		t, err := time.Parse(exDateLayout, part)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v %v", ErrInvalidRRule, part, err) //nolint:errorlint
+		}
+		result = append(result, t)
+	}
+	return result, nil
+}
@@ -0,0 +1,212 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require" //nolint:depguard
+)
+
+func date(month time.Month, day, hour int) time.Time {
+	return time.Date(2025, month, day, hour, 0, 0, 0, time.UTC)
+}
+
+func startTimes(events []*Event) []time.Time {
+	result := make([]time.Time, 0, len(events))
+	for _, event := range events {
+		result = append(result, event.StartTime)
+	}
+	return result
+}
+
+func TestParseRRule(t *testing.T) {
+	rule, err := ParseRRule("RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,-1FR;UNTIL=20250301T000000Z")
+	require.NoError(t, err)
+	require.Equal(t, FreqWeekly, rule.Freq)
+	require.Equal(t, 2, rule.Interval)
+	require.Equal(t, []WeekdayNum{{Weekday: time.Monday}, {Weekday: time.Friday, N: -1}}, rule.ByDay)
+	require.Equal(t, date(3, 1, 0), rule.Until)
+	require.Equal(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,-1FR;UNTIL=20250301T000000Z", rule.String())
+
+	for _, bad := range []string{
+		"", "FREQ=YEARLY", "FREQ=DAILY;COUNT=0", "FREQ=DAILY;INTERVAL=-1", "FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=DAILY;COUNT=2;UNTIL=20250301T000000Z", "FREQ=DAILY;BYMONTH=1", "FREQ",
+	} {
+		_, err := ParseRRule(bad)
+		require.ErrorIs(t, err, ErrInvalidRRule, bad)
+	}
+}
+
+func TestOccurrences(t *testing.T) {
+	tests := []struct {
+		name     string
+		start    time.Time
+		rrule    string
+		exDates  []time.Time
+		from, to time.Time
+		expected []time.Time
+	}{
+		{
+			name: "daily count", rrule: "FREQ=DAILY;COUNT=3",
+			from: date(1, 1, 0), to: date(2, 1, 0),
+			expected: []time.Time{date(1, 1, 10), date(1, 2, 10), date(1, 3, 10)},
+		},
+		{
+			name: "daily interval window", start: date(1, 1, 10), rrule: "FREQ=DAILY;INTERVAL=2",
+			from: date(1, 4, 0), to: date(1, 8, 0),
+			expected: []time.Time{date(1, 5, 10), date(1, 7, 10)},
+		},
+		{
+			// 6 января 2025 - понедельник
+			name: "daily byday every two weeks", rrule: "FREQ=DAILY;INTERVAL=14;BYDAY=MO;COUNT=3",
+			from: date(1, 1, 0), to: date(3, 1, 0),
+			expected: []time.Time{date(1, 6, 10), date(1, 20, 10), date(2, 3, 10)},
+		},
+		{
+			name: "daily byday never matches", rrule: "FREQ=DAILY;INTERVAL=7;BYDAY=TU",
+			from: date(1, 1, 0), to: date(12, 1, 0),
+			expected: []time.Time{date(1, 6, 10)},
+		},
+		{
+			name: "weekly byday", rrule: "FREQ=WEEKLY;BYDAY=WE,MO;COUNT=4",
+			from: date(1, 1, 0), to: date(2, 1, 0),
+			// 1 января 2025 - среда
+			expected: []time.Time{date(1, 1, 10), date(1, 6, 10), date(1, 8, 10), date(1, 13, 10)},
+		},
+		{
+			name: "weekly until exdate", rrule: "FREQ=WEEKLY;UNTIL=20250122T100000Z",
+			exDates: []time.Time{date(1, 8, 10)},
+			from:    date(1, 1, 0), to: date(2, 1, 0),
+			expected: []time.Time{date(1, 1, 10), date(1, 15, 10), date(1, 22, 10)},
+		},
+		{
+			name: "monthly skips missing day", rrule: "FREQ=MONTHLY;COUNT=3",
+			from: date(1, 1, 0), to: date(12, 1, 0),
+			expected: []time.Time{date(1, 31, 10), date(3, 31, 10), date(5, 31, 10)},
+		},
+		{
+			name: "monthly last friday", rrule: "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3",
+			from: date(1, 1, 0), to: date(12, 1, 0),
+			expected: []time.Time{date(1, 31, 10), date(2, 28, 10), date(3, 28, 10)},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			start := tc.start
+			if start.IsZero() {
+				start = tc.expected[0]
+			}
+			event := &Event{
+				ID: "id", StartTime: start, StopTime: start.Add(time.Hour), RRule: tc.rrule, ExDates: tc.exDates,
+			}
+			occurrences := event.Occurrences(tc.from, tc.to)
+			require.Equal(t, tc.expected, startTimes(occurrences))
+			for _, o := range occurrences {
+				require.Equal(t, time.Hour, o.StopTime.Sub(o.StartTime))
+				require.Equal(t, "id", o.ID)
+			}
+		})
+	}
+
+	t.Run("single event", func(t *testing.T) {
+		event := &Event{StartTime: date(1, 1, 10), StopTime: date(1, 1, 11)}
+		require.Len(t, event.Occurrences(date(1, 1, 0), date(1, 2, 0)), 1)
+		require.Empty(t, event.Occurrences(date(1, 2, 0), date(1, 3, 0)))
+	})
+}
+
+func TestValidateRRule(t *testing.T) {
+	// 6 января 2025 - понедельник
+	event := &Event{StartTime: date(1, 6, 10), StopTime: date(1, 6, 11), RRule: "FREQ=DAILY;INTERVAL=7;BYDAY=MO,TU"}
+	require.NoError(t, event.Validate())
+
+	for _, bad := range []string{
+		"FREQ=DAILY;INTERVAL=7;BYDAY=TU", "FREQ=DAILY;INTERVAL=14;BYDAY=WE,FR", "FREQ=DAILY;BYDAY=XX",
+	} {
+		event.RRule = bad
+		require.ErrorIs(t, event.Validate(), ErrInvalidRRule, bad)
+	}
+
+	// день недели DTSTART берется в часовом поясе события: в Москве это уже вторник
+	event = &Event{
+		StartTime: time.Date(2025, 1, 6, 22, 0, 0, 0, time.UTC), StopTime: time.Date(2025, 1, 6, 23, 0, 0, 0, time.UTC),
+		TimeZone: "Europe/Moscow", RRule: "FREQ=DAILY;INTERVAL=7;BYDAY=TU",
+	}
+	require.NoError(t, event.Validate())
+}
+
+func TestNextOccurrenceAndReminder(t *testing.T) {
+	reminder := Reminder{Before: time.Hour}
+	event := &Event{
+		ID: "6f0c3f6e-3a35-4b8e-a9f3-9d1f0c4a7b11", StartTime: date(1, 1, 10), StopTime: date(1, 1, 11),
+		RRule: "FREQ=DAILY;COUNT=3", ExDates: []time.Time{date(1, 2, 10)}, Reminders: []Reminder{reminder},
+	}
+
+	next, ok := event.NextOccurrence(date(1, 1, 10))
+	require.True(t, ok)
+	require.Equal(t, date(1, 3, 10), next)
+	_, ok = event.NextOccurrence(date(1, 3, 10))
+	require.False(t, ok)
+
+	require.Equal(t, date(1, 3, 9), *event.NextReminderTime(reminder, date(1, 1, 12)))
+	require.Nil(t, event.NextReminderTime(reminder, date(1, 4, 0)))
+
+	last, ok := event.LastStopTime()
+	require.True(t, ok)
+	require.Equal(t, date(1, 3, 11), last)
+	_, ok = (&Event{StartTime: date(1, 1, 10), RRule: "FREQ=DAILY"}).LastStopTime()
+	require.False(t, ok)
+
+	occurrences := event.Occurrences(date(1, 1, 0), date(2, 1, 0))
+	require.NotEqual(t, occurrences[0].NotificationID(reminder), occurrences[1].NotificationID(reminder))
+	require.NotEqual(t, occurrences[0].NotificationID(reminder),
+		occurrences[0].NotificationID(Reminder{Before: time.Hour, Channel: "email"}))
+	require.Len(t, occurrences[0].NotificationID(reminder), 36)
+}
+
+func TestOverlapsEvent(t *testing.T) {
+	event := func(start, stop time.Time, rrule string) *Event {
+		return &Event{StartTime: start, StopTime: stop, RRule: rrule}
+	}
+	daily := event(date(1, 1, 10), date(1, 1, 11), "FREQ=DAILY;COUNT=5")
+	tests := []struct {
+		name     string
+		a, b     *Event
+		overlaps bool
+	}{
+		{
+			name: "single events", a: event(date(1, 1, 10), date(1, 1, 11), ""), b: event(date(1, 1, 10), date(1, 1, 12), ""),
+			overlaps: true,
+		},
+		{name: "back to back", a: event(date(1, 1, 10), date(1, 1, 11), ""), b: event(date(1, 1, 11), date(1, 1, 12), "")},
+		{name: "later occurrence", a: daily, b: event(date(1, 3, 10), date(1, 3, 11), ""), overlaps: true},
+		{name: "after series", a: daily, b: event(date(1, 6, 10), date(1, 6, 11), "")},
+		{name: "empty interval", a: daily, b: event(date(1, 3, 10), date(1, 3, 10), "")},
+		{
+			name: "infinite series", a: event(date(1, 1, 9), date(1, 1, 12), "FREQ=MONTHLY"),
+			b: event(date(1, 6, 8), date(1, 6, 10), "FREQ=WEEKLY"), overlaps: true,
+		},
+		{
+			name: "infinite series apart", a: event(date(1, 1, 9), date(1, 1, 10), "FREQ=DAILY"),
+			b: event(date(1, 6, 10), date(1, 6, 11), "FREQ=WEEKLY"),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.overlaps, tc.a.OverlapsEvent(tc.b))
+			require.Equal(t, tc.overlaps, tc.b.OverlapsEvent(tc.a))
+		})
+	}
+}
+
+func TestExDates(t *testing.T) {
+	exDates := []time.Time{date(1, 2, 10), date(1, 9, 10)}
+	value := FormatExDates(exDates)
+	require.Equal(t, "20250102T100000Z,20250109T100000Z", value)
+	parsed, err := ParseExDates(value)
+	require.NoError(t, err)
+	require.Equal(t, exDates, parsed)
+
+	_, err = ParseExDates("bad")
+	require.ErrorIs(t, err, ErrInvalidRRule)
+}
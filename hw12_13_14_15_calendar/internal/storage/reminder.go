@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Reminder напоминание о начале события.
+type Reminder struct {
+	// за сколько до начала события отправляется напоминание
+	Before time.Duration
+	// канал доставки, пустой - канал пользователя по умолчанию
+	Channel string
+}
+
+// DueReminder напоминание, время отправки которого наступило.
+type DueReminder struct {
+	Reminder
+	// событие, для серии - повторение, о котором напоминание
+	Event *Event
+}
+
+func (r Reminder) String() string {
+	return r.Before.String() + ":" + r.Channel
+}
+
+// ValidateReminders проверяет напоминания события: время до начала неотрицательное, повторов нет.
+func ValidateReminders(reminders []Reminder) error {
+	seen := make(map[Reminder]bool, len(reminders))
+	for _, reminder := range reminders {
+		if reminder.Before < 0 {
+			return fmt.Errorf("%w: negative %v", ErrInvalidReminder, reminder)
+		}
+		if strings.ContainsAny(reminder.Channel, ":,") {
+			return fmt.Errorf("%w: channel %q", ErrInvalidReminder, reminder.Channel)
+		}
+		if seen[reminder] {
+			return fmt.Errorf("%w: duplicate %v", ErrInvalidReminder, reminder)
+		}
+		seen[reminder] = true
+	}
+	return nil
+}
+
+// SortReminders упорядочивает напоминания по времени отправки: сначала самые ранние, затем по каналу.
+func SortReminders(reminders []Reminder) {
+	sort.Slice(reminders, func(i, j int) bool {
+		if reminders[i].Before != reminders[j].Before {
+			return reminders[i].Before > reminders[j].Before
+		}
+		return reminders[i].Channel < reminders[j].Channel
+	})
+}
+
+// FormatReminders сериализует напоминания строкой вида "24h0m0s:email,15m0s:".
+func FormatReminders(reminders []Reminder) string {
+	parts := make([]string, 0, len(reminders))
+	for _, reminder := range reminders {
+		parts = append(parts, reminder.String())
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseReminders разбирает строку FormatReminders, время до начала - в любых единицах time.ParseDuration.
+// Напоминания упорядочиваются SortReminders.
+func ParseReminders(value string) ([]Reminder, error) {
+	if value == "" {
+		return nil, nil
+	}
+	result := make([]Reminder, 0)
+	for _, part := range strings.Split(value, ",") {
+		before, channel, _ := strings.Cut(part, ":")
+		duration, err := time.ParseDuration(before)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidReminder, part)
+		}
+		result = append(result, Reminder{Before: duration, Channel: channel})
+	}
+	SortReminders(result)
+	return result, nil
+}
+
+// DueOccurrence возвращает событие или повторение серии, о котором напоминание reminder,
+// отправляемое в reminderTime. Для удаленного из серии повторения возвращается false.
+func (e *Event) DueOccurrence(reminder Reminder, reminderTime time.Time) (*Event, bool) {
+	if !e.IsRecurring() {
+		return e, true
+	}
+	start := reminderTime.Add(reminder.Before)
+	occurrences := e.Occurrences(start, start)
+	if len(occurrences) == 0 {
+		return nil, false
+	}
+	return occurrences[0], true
+}
+
+// FollowingReminderTime возвращает время напоминания о повторении серии, следующем за тем,
+// о котором напоминание отправлено в reminderTime. Для обычного события и последнего повторения - nil.
+func (e *Event) FollowingReminderTime(reminder Reminder, reminderTime time.Time) *time.Time {
+	if !e.IsRecurring() {
+		return nil
+	}
+	next, ok := e.NextOccurrence(reminderTime.Add(reminder.Before))
+	if !ok {
+		return nil
+	}
+	result := next.Add(-reminder.Before)
+	return &result
+}
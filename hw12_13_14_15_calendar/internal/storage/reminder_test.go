@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require" //nolint:depguard
+)
+
+func TestReminders(t *testing.T) {
+	reminders := []Reminder{{Before: 15 * time.Minute}, {Before: 24 * time.Hour, Channel: "email"}}
+	value := FormatReminders(reminders)
+	require.Equal(t, "15m0s:,24h0m0s:email", value)
+
+	parsed, err := ParseReminders(value)
+	require.NoError(t, err)
+	require.Equal(t, []Reminder{{Before: 24 * time.Hour, Channel: "email"}, {Before: 15 * time.Minute}}, parsed,
+		"reminders are sorted, earliest first")
+
+	parsed, err = ParseReminders("900000000000ns:webhook,3600000000us:")
+	require.NoError(t, err)
+	require.Equal(t, []Reminder{{Before: time.Hour}, {Before: 15 * time.Minute, Channel: "webhook"}}, parsed)
+
+	parsed, err = ParseReminders("")
+	require.NoError(t, err)
+	require.Nil(t, parsed)
+
+	_, err = ParseReminders("soon:email")
+	require.ErrorIs(t, err, ErrInvalidReminder)
+}
+
+func TestValidateReminders(t *testing.T) {
+	tests := []struct {
+		name      string
+		reminders []Reminder
+		err       error
+	}{
+		{name: "empty"},
+		{name: "same time different channels", reminders: []Reminder{{Channel: "email"}, {Channel: "webhook"}}},
+		{name: "negative", reminders: []Reminder{{Before: -time.Minute}}, err: ErrInvalidReminder},
+		{name: "duplicate", reminders: []Reminder{{Before: time.Hour}, {Before: time.Hour}}, err: ErrInvalidReminder},
+		{name: "bad channel", reminders: []Reminder{{Channel: "email,sms"}}, err: ErrInvalidReminder},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorIs(t, ValidateReminders(tt.reminders), tt.err)
+		})
+	}
+}
+
+func TestDueOccurrence(t *testing.T) {
+	reminder := Reminder{Before: time.Hour}
+	event := &Event{
+		StartTime: date(1, 1, 10), StopTime: date(1, 1, 11),
+		RRule: "FREQ=DAILY;COUNT=3", ExDates: []time.Time{date(1, 2, 10)},
+	}
+
+	occurrence, ok := event.DueOccurrence(reminder, date(1, 3, 9))
+	require.True(t, ok)
+	require.Equal(t, date(1, 3, 10), occurrence.StartTime)
+	_, ok = event.DueOccurrence(reminder, date(1, 2, 9))
+	require.False(t, ok, "excluded occurrence")
+
+	require.Equal(t, date(1, 3, 9), *event.FollowingReminderTime(reminder, date(1, 1, 9)))
+	require.Nil(t, event.FollowingReminderTime(reminder, date(1, 3, 9)), "last occurrence")
+
+	single := &Event{StartTime: date(1, 1, 10), StopTime: date(1, 1, 11)}
+	occurrence, ok = single.DueOccurrence(reminder, date(1, 1, 9))
+	require.True(t, ok)
+	require.Same(t, single, occurrence)
+	require.Nil(t, single.FollowingReminderTime(reminder, date(1, 1, 9)))
+}
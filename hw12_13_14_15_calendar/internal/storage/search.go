@@ -0,0 +1,19 @@
+package storage
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Tokenize разбивает текст на слова в нижнем регистре, как парсер конфигурации simple полнотекстового поиска
+// Postgres: словом считается последовательность букв и цифр.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// SearchText текст события, по которому идет поиск.
+func (e *Event) SearchText() string {
+	return e.Title + " " + e.Description
+}
@@ -0,0 +1,13 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require" //nolint:depguard
+)
+
+func TestTokenize(t *testing.T) {
+	require.Equal(t, []string{"planning", "q1", "встреча", "в", "офисе"},
+		Tokenize("Planning: Q1 — Встреча в офисе!"))
+	require.Empty(t, Tokenize(" ,.!"))
+}
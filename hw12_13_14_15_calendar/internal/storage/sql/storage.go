@@ -5,30 +5,119 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
-	_ "github.com/jackc/pgx/v5/stdlib"                                 //nolint:depguard
-	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage" //nolint:depguard
+	"github.com/google/uuid"                                                   //nolint:depguard
+	"github.com/jackc/pgx/v5/pgconn"                                           //nolint:depguard
+	_ "github.com/jackc/pgx/v5/stdlib"                                         //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage"         //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage/migrate" //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/migrations"               //nolint:depguard
+	"github.com/pressly/goose/v3"                                              //nolint:depguard
+	"github.com/pressly/goose/v3/lock"                                         //nolint:depguard
 )
 
+// участники события и напоминания собираются в строки storage.FormatAttendees и storage.FormatReminders,
+// время до начала напоминания - в микросекундах.
+const eventColumns = `id, title, starttime, stoptime, description, userid, rrule, exdate, tracecontext, 
+	timezone, allday,
+	(select string_agg(a.userid || ':' || a.status, ',' order by a.userid) from attendee a where a.eventid = event.id),
+	(select string_agg(` + beforeStartMicroseconds + ` || 'us:' || r.channel, ',' order by r.beforeStart desc, r.channel)
+	from reminder r where r.eventid = event.id)`
+
+// время до начала напоминания r в микросекундах.
+const beforeStartMicroseconds = `(extract(epoch from r.beforeStart) * 1000000)::bigint`
+
+// условие выборки не удаленных событий пользователя $1: своих и тех, на которые он приглашен.
+const userCondition = `deletedat is null and (userid = $1 or id in (select eventid from attendee where userid = $1))`
+
+// условие пересечения события с интервалом [$2, $3), как в storage.Event.Overlaps.
+const overlapCondition = `(stoptime > $2 or starttime >= $2)`
+
+// searchCondition полнотекстовый поиск по индексу xie2_event_search, пустой запрос не ограничивает выборку.
+func searchCondition(param string) string {
+	return ` and (` + param + `::text = '' or search @@ plainto_tsquery('simple', ` + param + `::text))`
+}
+
+// код ошибки нарушения ограничения исключения xex0_event_UserID_period.
+const exclusionViolation = "23P01"
+
 type Storage struct {
 	driver, dsn string
-	db          *sql.DB
+	db          tracedDB
+	migrations  *migrate.Migrations
+	// события с признаком overlap не участвуют в проверке пересечений
+	allowOverlap bool
+	// применять новые миграции в Connect
+	autoMigrate bool
 }
 
-func New(driver, dsn string) *Storage {
+// драйвер database/sql по умолчанию.
+const defaultDriver = "pgx"
+
+func init() {
+	storage.Register("sql", func(ctx context.Context, params storage.Params) (storage.Storage, error) {
+		driver := params.Driver
+		if driver == "" {
+			driver = defaultDriver
+		}
+		dbStorage := New(driver, params.DSN, params.AllowOverlap, params.AutoMigrate)
+		if err := dbStorage.Connect(ctx); err != nil {
+			return nil, fmt.Errorf("failed to connect to db: %w", err)
+		}
+		return dbStorage, nil
+	})
+}
+
+func New(driver, dsn string, allowOverlap, autoMigrate bool) *Storage {
 	return &Storage{
-		driver: driver,
-		dsn:    dsn,
+		driver:       driver,
+		dsn:          dsn,
+		allowOverlap: allowOverlap,
+		autoMigrate:  autoMigrate,
 	}
 }
 
+// Connect подключается к БД и, если задан autoMigrate, применяет новые миграции.
 func (s *Storage) Connect(ctx context.Context) error {
 	var err error
-	s.db, err = sql.Open(s.driver, s.dsn)
+	s.db.DB, err = sql.Open(s.driver, s.dsn)
 	if err != nil {
 		return fmt.Errorf("%w: error while connecting to dsn %v using driver %v", err, s.dsn, s.driver)
 	}
+	if err := s.db.PingContext(ctx); err != nil {
+		return err
+	}
+	// advisory lock Postgres: реплики, запущенные одновременно, применяют миграции по очереди,
+	// остальные ждут и находят схему уже обновленной
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrMigration, err) //nolint:errorlint
+	}
+	s.migrations, err = migrate.New(goose.DialectPostgres, s.db.DB, migrations.FS, locker)
+	if err != nil {
+		return err
+	}
+	if s.autoMigrate {
+		_, err = s.migrations.Up(ctx)
+	}
+	return err
+}
+
+func (s *Storage) MigrateUp(ctx context.Context) ([]storage.Migration, error) {
+	return s.migrations.Up(ctx)
+}
+
+func (s *Storage) MigrateDown(ctx context.Context) (storage.Migration, error) {
+	return s.migrations.Down(ctx)
+}
+
+func (s *Storage) MigrationStatus(ctx context.Context) ([]storage.Migration, error) {
+	return s.migrations.Status(ctx)
+}
+
+func (s *Storage) Ping(ctx context.Context) error {
 	return s.db.PingContext(ctx)
 }
 
@@ -37,143 +126,424 @@ func (s *Storage) Close(_ context.Context) error {
 }
 
 func (s *Storage) CreateEvent(ctx context.Context, event storage.Event) (string, error) {
-	if event.StopTime.Before(event.StartTime) {
-		return "", storage.ErrInvalidStopTime
+	if err := event.Validate(); err != nil {
+		return "", err
 	}
 
-	var reminderTime *time.Time
-	if event.Reminder != nil {
-		tempTime := event.StartTime.Add(-*event.Reminder)
-		reminderTime = &tempTime
-	}
-	row := s.db.QueryRowContext(ctx, `insert into event (id, title, startTime, stopTime, description, userID, reminder, 
-	reminderTime) values (gen_random_uuid(),$1, $2, $3, $4, $5, $6, $7) 
-	on conflict (starttime, userid) do nothing
-	returning id`,
-		event.Title, event.StartTime, event.StopTime, event.Description, event.UserID, event.Reminder, reminderTime)
-
-	var id string
-	err := row.Scan(&id)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return "", fmt.Errorf("%w: %v", storage.ErrDateBusy, event)
+	err := s.inTx(ctx, func(tx tracedTx) error {
+		if err := s.checkSeriesOverlap(ctx, tx, &event); err != nil {
+			return err
+		}
+		// пересечение с другими событиями пользователя отсекает ограничение xex0_event_UserID_period
+		row := tx.QueryRowContext(ctx, `insert into event (id, title, startTime, stopTime, description, userID, 
+		rrule, exdate, overlap, traceContext, timeZone, allDay) 
+		values (gen_random_uuid(),$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) 
+		on conflict do nothing
+		returning id`,
+			event.Title, event.StartTime, event.StopTime, event.Description, event.UserID,
+			nullString(event.RRule), nullString(storage.FormatExDates(event.ExDates)),
+			s.allowOverlap, nullString(event.TraceContext), nullString(event.TimeZone), event.AllDay)
+		err := row.Scan(&event.ID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: %v", storage.ErrDateBusy, event)
+			}
+			return fmt.Errorf("%w: %v %v", storage.ErrCreateEvent, event, err) //nolint:errorlint
+		}
+		if err := insertReminders(ctx, tx, &event, storage.ErrCreateEvent); err != nil {
+			return err
 		}
-		return "", fmt.Errorf("%w: %v %v", storage.ErrCreateEvent, event, err) //nolint:errorlint
+		after, err := getEvent(ctx, tx, event.ID)
+		if err != nil {
+			return err
+		}
+		return insertAudit(ctx, tx, storage.NewAuditRecord(ctx, storage.AuditCreate, nil, after))
+	})
+	if err != nil {
+		return "", err
 	}
-	return id, nil
+	return event.ID, nil
 }
 
 func (s *Storage) UpdateEvent(ctx context.Context, id string, event storage.Event) error {
 	if event.ID != id {
 		return fmt.Errorf("%w: id=%v event.ID=%v", storage.ErrInvalidArgiments, id, event.ID)
 	}
-	var reminderTime *time.Time
-	if event.Reminder != nil {
-		tempTime := event.StartTime.Add(-*event.Reminder)
-		reminderTime = &tempTime
+	if !isUUID(id) {
+		return storage.ErrEventNotFound
 	}
-	result, err := s.db.ExecContext(ctx, `update event 
-	SET title = $1, startTime = $2, stopTime = $3, description = $4, reminder = $5, reminderTime = $6 
-	WHERE id = $7 and userID = $8`,
-		event.Title, event.StartTime, event.StopTime, event.Description, event.Reminder, reminderTime,
-		event.ID, event.UserID)
+	if err := event.Validate(); err != nil {
+		return err
+	}
+	return s.inTx(ctx, func(tx tracedTx) error {
+		before, err := getEvent(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if before.UserID != event.UserID {
+			return storage.ErrUpdateUserID
+		}
+		if err := s.checkSeriesOverlap(ctx, tx, &event); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `update event 
+		SET title = $1, startTime = $2, stopTime = $3, description = $4, rrule = $5, exdate = $6, overlap = $7, 
+		traceContext = $10, timeZone = $11, allDay = $12 
+		WHERE id = $8 and userID = $9`,
+			event.Title, event.StartTime, event.StopTime, event.Description,
+			nullString(event.RRule), nullString(storage.FormatExDates(event.ExDates)), s.allowOverlap,
+			event.ID, event.UserID, nullString(event.TraceContext), nullString(event.TimeZone), event.AllDay)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == exclusionViolation {
+				return fmt.Errorf("%w: %v", storage.ErrDateBusy, event)
+			}
+			return fmt.Errorf("%w: %v %v", storage.ErrUpdateEvent, event, err) //nolint:errorlint
+		}
+		if err := replaceReminders(ctx, tx, &event, storage.ErrUpdateEvent); err != nil {
+			return err
+		}
+		after, err := getEvent(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		return insertAudit(ctx, tx, storage.NewAuditRecord(ctx, storage.AuditUpdate, before, after))
+	})
+}
+
+// replaceReminders заново сохраняет напоминания события, время отправки всех напоминаний считается заново.
+func replaceReminders(ctx context.Context, tx tracedTx, event *storage.Event, errKind error) error {
+	_, err := tx.ExecContext(ctx, `delete from reminder where eventID = $1`, event.ID)
 	if err != nil {
-		return fmt.Errorf("%w: %v %v", storage.ErrUpdateEvent, event, err) //nolint:errorlint
+		return fmt.Errorf("%w: %v %v", errKind, event, err) //nolint:errorlint
 	}
+	return insertReminders(ctx, tx, event, errKind)
+}
 
-	rowCount, err := result.RowsAffected()
+// checkSeriesOverlap проверяет пересечение повторений серий, которое не видит ограничение
+// xex0_event_UserID_period: серия сверяется со всеми событиями пользователя, обычное событие - с сериями.
+// Блокировка пользователя до конца транзакции не дает параллельно сохранить пересекающиеся события.
+func (s *Storage) checkSeriesOverlap(ctx context.Context, tx tracedTx, event *storage.Event) error {
+	if s.allowOverlap || !event.StartTime.Before(event.StopTime) {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, `select pg_advisory_xact_lock(hashtextextended('event.userid:' || $1::text, 0))`,
+		event.UserID)
 	if err != nil {
-		return fmt.Errorf("%w: %v %v", storage.ErrUpdateEvent, event, err) //nolint:errorlint
+		return fmt.Errorf("%w: %v", storage.ErrTransaction, err) //nolint:errorlint
 	}
-	if rowCount != 1 {
-		return storage.ErrEventNotFound
+
+	query := `select ` + eventColumns + ` from event where userid = $1 and id::text <> $2 and deletedat is null 
+	and not overlap and starttime < stoptime and (rrule is not null or $3::boolean)`
+	args := []any{event.UserID, event.ID, event.IsRecurring()}
+	if lastStopTime, ok := event.LastStopTime(); ok {
+		query += ` and starttime < $4`
+		args = append(args, lastStopTime)
+	}
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrReadEvent, err) //nolint:errorlint
+	}
+	defer rows.Close()
+	events, err := makeEventsFromRows(rows)
+	if err != nil {
+		return err
+	}
+	for _, current := range events {
+		if current.OverlapsEvent(event) {
+			return fmt.Errorf("%w: %v", storage.ErrDateBusy, *event)
+		}
+	}
+	return nil
+}
+
+// insertReminders сохраняет напоминания события со временем их отправки, errKind - ошибка вызывающего метода.
+func insertReminders(ctx context.Context, tx tracedTx, event *storage.Event, errKind error) error {
+	now := time.Now()
+	for _, reminder := range event.Reminders {
+		_, err := tx.ExecContext(ctx, `insert into reminder (eventID, beforeStart, channel, reminderTime)
+		values ($1, $2, $3, $4)`, event.ID, reminder.Before, reminder.Channel, event.NextReminderTime(reminder, now))
+		if err != nil {
+			return fmt.Errorf("%w: %v %v", errKind, event, err) //nolint:errorlint
+		}
 	}
 	return nil
 }
 
 func (s *Storage) DeleteEvent(ctx context.Context, id string) error {
-	result, err := s.db.ExecContext(ctx, `delete from event where id=$1;`, id)
-	if err != nil {
-		return fmt.Errorf("%w: %v %v", storage.ErrDeleteEvent, id, err) //nolint:errorlint
+	return s.inTx(ctx, func(tx tracedTx) error {
+		before, err := getEvent(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `update event set deletedAt = CURRENT_TIMESTAMP where id = $1`, id)
+		if err != nil {
+			return fmt.Errorf("%w: %v %v", storage.ErrDeleteEvent, id, err) //nolint:errorlint
+		}
+		return insertAudit(ctx, tx, storage.NewAuditRecord(ctx, storage.AuditDelete, before, nil))
+	})
+}
+
+func (s *Storage) RestoreEvent(ctx context.Context, id string) error {
+	if !isUUID(id) {
+		return storage.ErrEventNotFound
 	}
+	return s.inTx(ctx, func(tx tracedTx) error {
+		row := tx.QueryRowContext(ctx, `select `+eventColumns+` from event 
+		where id = $1 and deletedAt is not null for update`, id)
+		event, err := scanEvent(row)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				if err := lockEvent(ctx, tx, id); err != nil {
+					return err
+				}
+				return storage.ErrEventNotDeleted
+			}
+			return fmt.Errorf("%w: %v %v", storage.ErrReadEvent, id, err) //nolint:errorlint
+		}
+		if err := s.checkSeriesOverlap(ctx, tx, event); err != nil {
+			return err
+		}
+		// пересечение с другими событиями пользователя отсекает ограничение xex0_event_UserID_period
+		_, err = tx.ExecContext(ctx, `update event set deletedAt = null where id = $1`, id)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == exclusionViolation {
+				return fmt.Errorf("%w: %v", storage.ErrDateBusy, event)
+			}
+			return fmt.Errorf("%w: %v %v", storage.ErrUpdateEvent, id, err) //nolint:errorlint
+		}
+		if err := replaceReminders(ctx, tx, event, storage.ErrUpdateEvent); err != nil {
+			return err
+		}
+		return insertAudit(ctx, tx, storage.NewAuditRecord(ctx, storage.AuditRestore, nil, event))
+	})
+}
 
-	rows, err := result.RowsAffected()
+// insertAudit дописывает запись в журнал изменений событий.
+func insertAudit(ctx context.Context, tx tracedTx, record *storage.AuditRecord) error {
+	before, err := storage.FormatSnapshot(record.Before)
 	if err != nil {
-		return fmt.Errorf("%w: %v %v", storage.ErrDeleteEvent, id, err) //nolint:errorlint
+		return err
 	}
-	if rows != 1 {
-		return storage.ErrEventNotFound
+	after, err := storage.FormatSnapshot(record.After)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `insert into event_audit (eventID, action, actor, createdAt, oldEvent, newEvent)
+	values ($1, $2, $3, $4, $5, $6)`, record.EventID, record.Action, record.Actor, record.Time,
+		nullString(before), nullString(after))
+	if err != nil {
+		return fmt.Errorf("%w: %v %v", storage.ErrWriteAudit, record.EventID, err) //nolint:errorlint
 	}
 	return nil
 }
 
+func (s *Storage) ListEventHistory(ctx context.Context, id string) ([]*storage.AuditRecord, error) {
+	result := make([]*storage.AuditRecord, 0)
+	if !isUUID(id) {
+		return result, nil
+	}
+	rows, err := s.db.QueryContext(ctx, `select eventID, action, actor, createdAt, oldEvent, newEvent
+	from event_audit where eventID = $1 order by id`, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v %v", storage.ErrReadAudit, id, err) //nolint:errorlint
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		record := &storage.AuditRecord{}
+		var before, after sql.NullString
+		err := rows.Scan(&record.EventID, &record.Action, &record.Actor, &record.Time, &before, &after)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v %v", storage.ErrReadAudit, id, err) //nolint:errorlint
+		}
+		if record.Before, err = storage.ParseSnapshot(before.String); err != nil {
+			return nil, fmt.Errorf("%w: %v %v", storage.ErrReadAudit, id, err) //nolint:errorlint
+		}
+		if record.After, err = storage.ParseSnapshot(after.String); err != nil {
+			return nil, fmt.Errorf("%w: %v %v", storage.ErrReadAudit, id, err) //nolint:errorlint
+		}
+		result = append(result, record)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("%w: %v %v", storage.ErrReadAudit, id, rows.Err()) //nolint:errorlint
+	}
+	return result, nil
+}
+
 func (s *Storage) GetEvent(ctx context.Context, id string) (*storage.Event, error) {
-	row := s.db.QueryRowContext(ctx, `select id, title, starttime, stoptime, description, userid, reminder from "event" 
-	where id = $1`, id)
-	event := storage.Event{}
-	err := row.Scan(&event.ID, &event.Title, &event.StartTime, &event.StopTime, &event.Description, &event.UserID,
-		&event.Reminder)
+	if !isUUID(id) {
+		return nil, storage.ErrEventNotFound
+	}
+	row := s.db.QueryRowContext(ctx, `select `+eventColumns+` from "event" where id = $1 and deletedAt is null`, id)
+	event, err := scanEvent(row)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, storage.ErrEventNotFound
 		}
 		return nil, fmt.Errorf("%w: %v %v", storage.ErrReadEvent, id, err) //nolint:errorlint
 	}
-	return &event, nil
+	return event, nil
 }
 
-func (s *Storage) ListEventsDay(ctx context.Context, startTime time.Time) ([]*storage.Event, error) {
-	return s.listEventsInt(ctx, startTime, startTime.Add(time.Hour*24))
+func (s *Storage) ListEventsDay(ctx context.Context, userID int64, startTime time.Time) ([]*storage.Event, error) {
+	return s.listEventsInt(ctx, userID, startTime, startTime.AddDate(0, 0, 1))
 }
 
-func (s *Storage) ListEventsWeek(ctx context.Context, startTime time.Time) ([]*storage.Event, error) {
-	return s.listEventsInt(ctx, startTime, startTime.Add(time.Hour*24*7))
+func (s *Storage) ListEventsWeek(ctx context.Context, userID int64, startTime time.Time) ([]*storage.Event, error) {
+	return s.listEventsInt(ctx, userID, startTime, startTime.AddDate(0, 0, 7))
 }
 
-func (s *Storage) ListEventsMonth(ctx context.Context, startTime time.Time) ([]*storage.Event, error) {
-	return s.listEventsInt(ctx, startTime, startTime.AddDate(0, 1, 0))
+func (s *Storage) ListEventsMonth(ctx context.Context, userID int64, startTime time.Time) ([]*storage.Event, error) {
+	return s.listEventsInt(ctx, userID, startTime, startTime.AddDate(0, 1, 0))
 }
 
-func (s *Storage) listEventsInt(ctx context.Context, startTime time.Time, stopTime time.Time) (
+func (s *Storage) listEventsInt(ctx context.Context, userID int64, startTime time.Time, stopTime time.Time) (
 	[]*storage.Event, error,
 ) {
-	rows, err := s.db.QueryContext(ctx, `select id, title, starttime, stoptime, description, userid, reminder 
-	from event where starttime >= $1 and stoptime <= $2`, startTime, stopTime)
+	// повторяющиеся события выбираются целиком и разворачиваются в повторения внутри интервала
+	// выборка по пользователю идет по индексам xie1_event_UserID_startTime и xie0_attendee_userID
+	rows, err := s.db.QueryContext(ctx, `select `+eventColumns+` 
+	from event where `+userCondition+` and starttime < $3 and (rrule is not null or `+overlapCondition+`)`,
+		userID, startTime, stopTime)
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		return nil, fmt.Errorf("%w: %v", storage.ErrReadEvent, err) //nolint:errorlint
 	}
 	defer rows.Close()
-	return makeEventsFromRows(rows)
+	events, err := makeEventsFromRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*storage.Event, 0, len(events))
+	for _, event := range events {
+		result = append(result, event.Overlapping(startTime, stopTime)...)
+	}
+	return result, nil
+}
+
+func (s *Storage) ListEvents(ctx context.Context, params storage.ListParams) (
+	[]*storage.Event, *storage.Cursor, error,
+) {
+	// одиночные события выбираются страницей по индексу, курсор сравнивается как ключ (starttime, id)
+	order, compare := "asc", ">"
+	if params.Desc {
+		order, compare = "desc", "<"
+	}
+	// запрос собирается только из констант, значения передаются параметрами
+	query := `select ` + eventColumns + ` from event where ` + userCondition + ` and rrule is null ` + //nolint:gosec
+		`and starttime < $3 and ` + overlapCondition + searchCondition("$4")
+	args := []any{params.UserID, params.StartTime, params.StopTime, params.Query}
+	if params.Cursor != nil {
+		query += ` and (starttime, id) ` + compare + ` ($5, $6::uuid)`
+		args = append(args, params.Cursor.StartTime, params.Cursor.ID)
+	}
+	query += ` order by starttime ` + order + `, id ` + order
+	if params.Limit > 0 {
+		// лишняя запись показывает, что есть следующая страница
+		query += ` limit ` + strconv.Itoa(params.Limit+1)
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", storage.ErrReadEvent, err) //nolint:errorlint
+	}
+	defer rows.Close()
+	events, err := makeEventsFromRows(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// серии разворачиваются в повторения и объединяются с одиночными событиями
+	rows, err = s.db.QueryContext(ctx, `select `+eventColumns+` from event `+ //nolint:gosec
+		`where `+userCondition+` and rrule is not null and starttime < $2`+searchCondition("$3"),
+		params.UserID, params.StopTime, params.Query)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", storage.ErrReadEvent, err) //nolint:errorlint
+	}
+	defer rows.Close()
+	series, err := makeEventsFromRows(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, event := range series {
+		events = append(events, event.Overlapping(params.StartTime, params.StopTime)...)
+	}
+	result, next := params.Page(events)
+	return result, next, nil
 }
 
-func (s *Storage) ListEventsReminder(ctx context.Context) ([]*storage.Event, error) {
-	rows, err := s.db.QueryContext(ctx, `select id, title, starttime, stoptime, description, userid, reminder 
-	from event where ReminderTime < CURRENT_TIMESTAMP`)
+func (s *Storage) ListDueReminders(ctx context.Context) ([]*storage.DueReminder, error) {
+	rows, err := s.db.QueryContext(ctx, `select `+eventColumns+`, `+beforeStartMicroseconds+`, r.channel, r.reminderTime 
+	from reminder r join event on event.id = r.eventID 
+	where r.reminderTime < CURRENT_TIMESTAMP and event.deletedAt is null`)
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		return nil, fmt.Errorf("%w: %v", storage.ErrReadEvent, err) //nolint:errorlint
 	}
 	defer rows.Close()
-	return makeEventsFromRows(rows)
+
+	result := make([]*storage.DueReminder, 0)
+	for rows.Next() {
+		var before int64
+		var reminder storage.Reminder
+		var reminderTime time.Time
+		event, err := scanEvent(rows, &before, &reminder.Channel, &reminderTime)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", storage.ErrReadEvent, err) //nolint:errorlint
+		}
+		reminder.Before = time.Duration(before) * time.Microsecond
+		// для серии - напоминание о конкретном повторении
+		occurrence, ok := event.DueOccurrence(reminder, reminderTime)
+		if !ok {
+			continue
+		}
+		result = append(result, &storage.DueReminder{Reminder: reminder, Event: occurrence})
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrReadEvent, rows.Err()) //nolint:errorlint
+	}
+	return result, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanEvent(row scanner, extra ...any) (*storage.Event, error) {
+	event := &storage.Event{}
+	var rrule, exdate, traceContext, timeZone, attendees, reminders sql.NullString
+	dest := []any{
+		&event.ID, &event.Title, &event.StartTime, &event.StopTime, &event.Description, &event.UserID,
+		&rrule, &exdate, &traceContext, &timeZone, &event.AllDay, &attendees, &reminders,
+	}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+	event.RRule = rrule.String
+	event.TraceContext = traceContext.String
+	event.TimeZone = timeZone.String
+	event.ExDates, err = storage.ParseExDates(exdate.String)
+	if err != nil {
+		return nil, err
+	}
+	event.Attendees, err = storage.ParseAttendees(attendees.String)
+	if err != nil {
+		return nil, err
+	}
+	event.Reminders, err = storage.ParseReminders(reminders.String)
+	if err != nil {
+		return nil, err
+	}
+	return event, nil
 }
 
 func makeEventsFromRows(rows *sql.Rows) ([]*storage.Event, error) {
 	result := make([]*storage.Event, 0)
 	for rows.Next() {
-		event := &storage.Event{}
-		var reminderStr string
-		err := rows.Scan(&event.ID, &event.Title, &event.StartTime, &event.StopTime, &event.Description, &event.UserID,
-			&reminderStr)
+		event, err := scanEvent(rows)
 		if err != nil {
 			return nil, fmt.Errorf("%w: %v", storage.ErrReadEvent, err) //nolint:errorlint
 		}
-		if reminderStr != "" {
-			reminderTime, err := time.Parse(time.TimeOnly, reminderStr)
-			if err != nil {
-				return nil, fmt.Errorf("%w: %v", storage.ErrReadEvent, err) //nolint:errorlint
-			}
-			reminder := time.Duration(reminderTime.Hour()*60*60+reminderTime.Minute()*60+reminderTime.Second()) * 1000000000
-			event.Reminder = &reminder
-		}
 		result = append(result, event)
 	}
 
@@ -183,27 +553,95 @@ func makeEventsFromRows(rows *sql.Rows) ([]*storage.Event, error) {
 	return result, nil
 }
 
-func (s *Storage) ClearReminderTime(ctx context.Context, id string) error {
-	result, err := s.db.ExecContext(ctx, `update event set reminderTime = null where id=$1;`, id)
+// isUUID проверяет формат id: колонки id в БД имеют тип uuid, и событий с id другого формата нет.
+func isUUID(id string) bool {
+	_, err := uuid.Parse(id)
+	return err == nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func (s *Storage) ClearReminderTime(ctx context.Context, id string, reminder storage.Reminder) error {
+	return s.inTx(ctx, func(tx tracedTx) error {
+		return clearReminderTime(ctx, tx, id, reminder)
+	})
+}
+
+func clearReminderTime(ctx context.Context, tx tracedTx, id string, reminder storage.Reminder) error {
+	event, err := getEvent(ctx, tx, id)
 	if err != nil {
-		return fmt.Errorf("%w: %v %v", storage.ErrUpdateEvent, id, err) //nolint:errorlint
+		return err
+	}
+	var reminderTime sql.NullTime
+	err = tx.QueryRowContext(ctx, `select reminderTime from reminder 
+	where eventID = $1 and beforeStart = $2 and channel = $3`, id, reminder.Before, reminder.Channel).Scan(&reminderTime)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// напоминание удалено при изменении события
+			return nil
+		}
+		return fmt.Errorf("%w: %v %v", storage.ErrReadEvent, id, err) //nolint:errorlint
+	}
+	// для серии напоминание переносится на следующее повторение
+	var nextReminderTime *time.Time
+	if reminderTime.Valid {
+		nextReminderTime = event.FollowingReminderTime(reminder, reminderTime.Time)
 	}
 
-	rows, err := result.RowsAffected()
+	_, err = tx.ExecContext(ctx, `update reminder set reminderTime = $4 
+	where eventID = $1 and beforeStart = $2 and channel = $3`, id, reminder.Before, reminder.Channel, nextReminderTime)
 	if err != nil {
 		return fmt.Errorf("%w: %v %v", storage.ErrUpdateEvent, id, err) //nolint:errorlint
 	}
-	if rows != 1 {
-		return storage.ErrEventNotFound
+	return nil
+}
+
+func (s *Storage) inTx(ctx context.Context, fn func(tx tracedTx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrTransaction, err) //nolint:errorlint
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrTransaction, err) //nolint:errorlint
 	}
 	return nil
 }
 
 func (s *Storage) DeleteEventsBeforeDate(ctx context.Context, time time.Time) error {
-	_, err := s.db.ExecContext(ctx, `delete from event where starttime < $1;`, time)
+	_, err := s.db.ExecContext(ctx, `delete from event where starttime < $1 and rrule is null;`, time)
 	if err != nil {
 		return fmt.Errorf("%w: %v %v", storage.ErrDeleteEvent, time, err) //nolint:errorlint
 	}
+	// вместе со старыми событиями удаляются давно отправленные уведомления outbox
+	_, err = s.db.ExecContext(ctx, `delete from notification_outbox where sentTime < $1;`, time)
+	if err != nil {
+		return fmt.Errorf("%w: %v %v", storage.ErrDeleteEvent, time, err) //nolint:errorlint
+	}
+
+	// серия удаляется только после окончания последнего повторения
+	rows, err := s.db.QueryContext(ctx, `select `+eventColumns+` from event where starttime < $1 and rrule is not null`,
+		time)
+	if err != nil {
+		return fmt.Errorf("%w: %v %v", storage.ErrDeleteEvent, time, err) //nolint:errorlint
+	}
+	defer rows.Close()
+	events, err := makeEventsFromRows(rows)
+	if err != nil {
+		return fmt.Errorf("%w: %v %v", storage.ErrDeleteEvent, time, err) //nolint:errorlint
+	}
+	for _, event := range events {
+		if lastStopTime, ok := event.LastStopTime(); ok && lastStopTime.Before(time) {
+			if _, err := s.db.ExecContext(ctx, `delete from event where id = $1;`, event.ID); err != nil {
+				return fmt.Errorf("%w: %v %v", storage.ErrDeleteEvent, event.ID, err) //nolint:errorlint
+			}
+		}
+	}
 	return nil
 }
 
@@ -217,3 +655,255 @@ func (s *Storage) SaveNotification(ctx context.Context, notification storage.Not
 	}
 	return nil
 }
+
+func (s *Storage) GetNotificationDelivery(ctx context.Context, id string) (*storage.NotificationDelivery, error) {
+	if !isUUID(id) {
+		return nil, storage.ErrNotificationNotFound
+	}
+	delivery := &storage.NotificationDelivery{}
+	var channel, lastError sql.NullString
+	err := s.db.QueryRowContext(ctx, `select status, channel, attempts, lastError from notification where id = $1`, id).
+		Scan(&delivery.Status, &channel, &delivery.Attempts, &lastError)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotificationNotFound
+		}
+		return nil, fmt.Errorf("%w: %v %v", storage.ErrReadNotification, id, err) //nolint:errorlint
+	}
+	delivery.Channel = channel.String
+	delivery.LastError = lastError.String
+	return delivery, nil
+}
+
+func (s *Storage) UpdateNotificationDelivery(ctx context.Context, id string, status storage.DeliveryStatus,
+	channel string, lastError string,
+) error {
+	if !isUUID(id) {
+		return storage.ErrNotificationNotFound
+	}
+	result, err := s.db.ExecContext(ctx, `update notification 
+	set status = $2, channel = $3, attempts = attempts + 1, lastError = $4, updatedAt = CURRENT_TIMESTAMP 
+	where id = $1`, id, status, nullString(channel), nullString(lastError))
+	if err != nil {
+		return fmt.Errorf("%w: %v %v", storage.ErrUpdateNotification, id, err) //nolint:errorlint
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v %v", storage.ErrUpdateNotification, id, err) //nolint:errorlint
+	}
+	if rows != 1 {
+		return storage.ErrNotificationNotFound
+	}
+	return nil
+}
+
+func (s *Storage) EnqueueNotification(ctx context.Context, eventID string, reminder storage.Reminder,
+	notification storage.Notification,
+) error {
+	return s.inTx(ctx, func(tx tracedTx) error {
+		if err := clearReminderTime(ctx, tx, eventID, reminder); err != nil {
+			return err
+		}
+		return enqueue(ctx, tx, notification)
+	})
+}
+
+func enqueue(ctx context.Context, tx tracedTx, notification storage.Notification) error {
+	_, err := tx.ExecContext(ctx, `insert into notification_outbox (id, title, startTime, userID, traceContext, 
+	kind, attendee, channel) values ($1, $2, $3, $4, $5, $6, $7, $8) on conflict (id) do nothing`,
+		notification.ID, notification.Title, notification.StartTime, notification.UserID,
+		nullString(notification.TraceContext), nullString(string(notification.Kind)), nullAttendee(notification.Attendee),
+		nullString(notification.Channel))
+	if err != nil {
+		return fmt.Errorf("%w: %v %v", storage.ErrCreateNotification, notification, err) //nolint:errorlint
+	}
+	return nil
+}
+
+func nullAttendee(attendee *storage.Attendee) sql.NullString {
+	if attendee == nil {
+		return sql.NullString{}
+	}
+	return nullString(storage.FormatAttendees([]storage.Attendee{*attendee}))
+}
+
+func parseAttendee(value sql.NullString) (*storage.Attendee, error) {
+	attendees, err := storage.ParseAttendees(value.String)
+	if err != nil || len(attendees) == 0 {
+		return nil, err
+	}
+	return &attendees[0], nil
+}
+
+// getEvent возвращает не удаленное событие и блокирует его строку до конца транзакции.
+func getEvent(ctx context.Context, tx tracedTx, id string) (*storage.Event, error) {
+	if !isUUID(id) {
+		return nil, storage.ErrEventNotFound
+	}
+	row := tx.QueryRowContext(ctx, `select `+eventColumns+` from event where id = $1 and deletedAt is null for update`, id)
+	event, err := scanEvent(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrEventNotFound
+		}
+		return nil, fmt.Errorf("%w: %v %v", storage.ErrReadEvent, id, err) //nolint:errorlint
+	}
+	return event, nil
+}
+
+// lockEvent проверяет наличие не удаленного события и блокирует его строку до конца транзакции.
+func lockEvent(ctx context.Context, tx tracedTx, id string) error {
+	if !isUUID(id) {
+		return storage.ErrEventNotFound
+	}
+	var found int
+	err := tx.QueryRowContext(ctx, `select 1 from event where id = $1 and deletedAt is null for update`, id).
+		Scan(&found)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.ErrEventNotFound
+		}
+		return fmt.Errorf("%w: %v %v", storage.ErrReadEvent, id, err) //nolint:errorlint
+	}
+	return nil
+}
+
+func (s *Storage) InviteAttendee(ctx context.Context, eventID string, userID int64,
+	notification storage.Notification,
+) error {
+	return s.inTx(ctx, func(tx tracedTx) error {
+		if err := lockEvent(ctx, tx, eventID); err != nil {
+			return err
+		}
+		result, err := tx.ExecContext(ctx, `insert into attendee (eventID, userID, status) values ($1, $2, $3) 
+		on conflict (eventID, userID) do nothing`, eventID, userID, storage.RSVPNeedsAction)
+		if err != nil {
+			return fmt.Errorf("%w: %v %v", storage.ErrUpdateAttendee, eventID, err) //nolint:errorlint
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("%w: %v %v", storage.ErrUpdateAttendee, eventID, err) //nolint:errorlint
+		}
+		if rows == 0 {
+			// пользователь уже приглашен
+			return nil
+		}
+		return enqueue(ctx, tx, notification)
+	})
+}
+
+func (s *Storage) UpdateAttendee(ctx context.Context, eventID string, attendee storage.Attendee,
+	notification storage.Notification,
+) error {
+	if err := attendee.Status.Validate(); err != nil {
+		return err
+	}
+	return s.inTx(ctx, func(tx tracedTx) error {
+		if err := lockEvent(ctx, tx, eventID); err != nil {
+			return err
+		}
+		var status storage.RSVP
+		err := tx.QueryRowContext(ctx, `select status from attendee where eventID = $1 and userID = $2`,
+			eventID, attendee.UserID).Scan(&status)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return storage.ErrAttendeeNotFound
+			}
+			return fmt.Errorf("%w: %v %v", storage.ErrReadEvent, eventID, err) //nolint:errorlint
+		}
+		if status == attendee.Status {
+			return nil
+		}
+		_, err = tx.ExecContext(ctx, `update attendee set status = $3, updatedAt = CURRENT_TIMESTAMP 
+		where eventID = $1 and userID = $2`, eventID, attendee.UserID, attendee.Status)
+		if err != nil {
+			return fmt.Errorf("%w: %v %v", storage.ErrUpdateAttendee, eventID, err) //nolint:errorlint
+		}
+		return enqueue(ctx, tx, notification)
+	})
+}
+
+func (s *Storage) RemoveAttendee(ctx context.Context, eventID string, userID int64,
+	notification storage.Notification,
+) error {
+	return s.inTx(ctx, func(tx tracedTx) error {
+		if err := lockEvent(ctx, tx, eventID); err != nil {
+			return err
+		}
+		result, err := tx.ExecContext(ctx, `delete from attendee where eventID = $1 and userID = $2`, eventID, userID)
+		if err != nil {
+			return fmt.Errorf("%w: %v %v", storage.ErrUpdateAttendee, eventID, err) //nolint:errorlint
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("%w: %v %v", storage.ErrUpdateAttendee, eventID, err) //nolint:errorlint
+		}
+		if rows == 0 {
+			return storage.ErrAttendeeNotFound
+		}
+		return enqueue(ctx, tx, notification)
+	})
+}
+
+func (s *Storage) ListOutbox(ctx context.Context, limit int) ([]*storage.OutboxMessage, error) {
+	rows, err := s.db.QueryContext(ctx, `select id, title, startTime, userID, createdAt, attempts, nextAttemptTime, 
+	lastError, traceContext, kind, attendee, channel from notification_outbox 
+	where sentTime is null and nextAttemptTime <= CURRENT_TIMESTAMP 
+	order by createdAt limit $1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrReadNotification, err) //nolint:errorlint
+	}
+	defer rows.Close()
+
+	result := make([]*storage.OutboxMessage, 0)
+	for rows.Next() {
+		message := &storage.OutboxMessage{}
+		var lastError, traceContext, kind, attendee, channel sql.NullString
+		err := rows.Scan(&message.ID, &message.Title, &message.StartTime, &message.UserID, &message.CreatedAt,
+			&message.Attempts, &message.NextAttemptTime, &lastError, &traceContext, &kind, &attendee, &channel)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", storage.ErrReadNotification, err) //nolint:errorlint
+		}
+		message.LastError = lastError.String
+		message.TraceContext = traceContext.String
+		message.Kind = storage.NotificationKind(kind.String)
+		message.Channel = channel.String
+		if message.Attendee, err = parseAttendee(attendee); err != nil {
+			return nil, fmt.Errorf("%w: %v", storage.ErrReadNotification, err) //nolint:errorlint
+		}
+		result = append(result, message)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrReadNotification, rows.Err()) //nolint:errorlint
+	}
+	return result, nil
+}
+
+func (s *Storage) MarkOutboxSent(ctx context.Context, id string) error {
+	return s.updateOutbox(ctx, id, `update notification_outbox set sentTime = CURRENT_TIMESTAMP 
+	where id = $1 and sentTime is null`, id)
+}
+
+func (s *Storage) MarkOutboxFailed(ctx context.Context, id string, nextAttemptTime time.Time, reason string) error {
+	return s.updateOutbox(ctx, id, `update notification_outbox 
+	set attempts = attempts + 1, nextAttemptTime = $2, lastError = $3 
+	where id = $1 and sentTime is null`, id, nextAttemptTime, reason)
+}
+
+func (s *Storage) updateOutbox(ctx context.Context, id string, query string, args ...any) error {
+	if !isUUID(id) {
+		return storage.ErrNotificationNotFound
+	}
+	result, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%w: %v %v", storage.ErrUpdateNotification, id, err) //nolint:errorlint
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v %v", storage.ErrUpdateNotification, id, err) //nolint:errorlint
+	}
+	if rows != 1 {
+		return storage.ErrNotificationNotFound
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/tracing" //nolint:depguard
+)
+
+// значение db.system спанов запросов.
+const dbSystem = "postgresql"
+
+// querier общие методы *sql.DB и *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// tracedDB *sql.DB, каждый запрос которого выполняется в отдельном спане.
+type tracedDB struct {
+	*sql.DB
+}
+
+func (db tracedDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return execTraced(ctx, db.DB, query, args)
+}
+
+func (db tracedDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return queryTraced(ctx, db.DB, query, args)
+}
+
+func (db tracedDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return queryRowTraced(ctx, db.DB, query, args)
+}
+
+func (db tracedDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (tracedTx, error) {
+	tx, err := db.DB.BeginTx(ctx, opts)
+	return tracedTx{tx}, err
+}
+
+// tracedTx *sql.Tx, каждый запрос которого выполняется в отдельном спане.
+type tracedTx struct {
+	*sql.Tx
+}
+
+func (tx tracedTx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return execTraced(ctx, tx.Tx, query, args)
+}
+
+func (tx tracedTx) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return queryTraced(ctx, tx.Tx, query, args)
+}
+
+func (tx tracedTx) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return queryRowTraced(ctx, tx.Tx, query, args)
+}
+
+func execTraced(ctx context.Context, q querier, query string, args []any) (result sql.Result, err error) {
+	ctx, span := tracing.StartQuery(ctx, dbSystem, query)
+	defer func() { tracing.End(span, err) }()
+	return q.ExecContext(ctx, query, args...)
+}
+
+// queryTraced спан заканчивается до чтения строк результата.
+func queryTraced(ctx context.Context, q querier, query string, args []any) (rows *sql.Rows, err error) {
+	ctx, span := tracing.StartQuery(ctx, dbSystem, query)
+	defer func() { tracing.End(span, err) }()
+	return q.QueryContext(ctx, query, args...)
+}
+
+func queryRowTraced(ctx context.Context, q querier, query string, args []any) *sql.Row {
+	ctx, span := tracing.StartQuery(ctx, dbSystem, query)
+	row := q.QueryRowContext(ctx, query, args...)
+	tracing.End(span, row.Err())
+	return row
+}
@@ -0,0 +1,59 @@
+-- +goose Up
+-- +goose StatementBegin
+-- время хранится текстом фиксированной длины в UTC, поэтому строки сравниваются и сортируются как время
+create table event(
+  id text not null primary key,
+  title text not null,
+  startTime text not null,
+  stopTime text not null,
+  description text not null default '',
+  userID integer not null,
+  -- напоминание за reminder наносекунд до начала
+  reminder integer null,
+  -- время отправки напоминания
+  reminderTime text null,
+  -- правило повторения RFC 5545 RRULE и исключенные повторения EXDATE
+  rrule text null,
+  exdate text null,
+  -- событие может пересекаться по времени с другими событиями пользователя
+  overlap integer not null default 0,
+  -- слова названия и описания через пробел, с пробелами по краям
+  search text not null default ''
+);
+create index xie0_event_reminderTime on event (reminderTime);
+create index xie1_event_userID_startTime on event (userID, startTime);
+
+create table notification(
+  id text not null primary key,
+  title text,
+  startTime text,
+  userID integer,
+  -- состояние доставки: pending, sent, failed
+  status text not null default 'pending',
+  channel text null,
+  attempts integer not null default 0,
+  lastError text null,
+  updatedAt text null
+);
+
+create table notification_outbox(
+  id text not null primary key,
+  title text,
+  startTime text,
+  userID integer,
+  createdAt text not null,
+  attempts integer not null default 0,
+  nextAttemptTime text not null,
+  lastError text null,
+  -- время успешной отправки, null - не отправлено
+  sentTime text null
+);
+create index xie0_notification_outbox_nextAttemptTime on notification_outbox (nextAttemptTime) where sentTime is null;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table notification_outbox;
+drop table notification;
+drop table event;
+-- +goose StatementEnd
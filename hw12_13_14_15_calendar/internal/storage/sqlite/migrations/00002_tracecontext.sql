@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- контекст трассировки W3C traceparent запроса, изменившего событие, и события уведомления
+alter table event add column traceContext text null;
+alter table notification_outbox add column traceContext text null;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table notification_outbox drop column traceContext;
+alter table event drop column traceContext;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+-- приглашенные на событие пользователи, status - ответ на приглашение
+create table attendee(
+  eventID text not null,
+  userID integer not null,
+  status text not null default 'needs-action',
+  updatedAt text not null,
+  primary key (eventID, userID)
+);
+create index xie0_attendee_userID on attendee (userID);
+-- внешние ключи SQLite включаются только прагмой соединения, поэтому участники удаляются триггером
+create trigger event_delete_attendee after delete on event
+begin
+  delete from attendee where eventID = old.id;
+end;
+-- повод уведомления, null - напоминание, и участник в виде userID:status
+alter table notification_outbox add column kind text null;
+alter table notification_outbox add column attendee text null;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table notification_outbox drop column attendee;
+alter table notification_outbox drop column kind;
+drop trigger event_delete_attendee;
+drop table attendee;
+-- +goose StatementEnd
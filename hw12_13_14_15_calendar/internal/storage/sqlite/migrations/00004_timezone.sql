@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- часовой пояс IANA события, null - UTC, и признак события на весь день
+alter table event add column timeZone text null;
+alter table event add column allDay integer not null default 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table event drop column allDay;
+alter table event drop column timeZone;
+-- +goose StatementEnd
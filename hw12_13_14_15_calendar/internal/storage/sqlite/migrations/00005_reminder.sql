@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+-- напоминания о событиях: за beforeStart наносекунд до начала, channel - канал доставки,
+-- пустой - канал пользователя по умолчанию, reminderTime - время отправки, null - отправлено
+create table reminder(
+  eventID text not null,
+  beforeStart integer not null,
+  channel text not null default '',
+  reminderTime text null,
+  primary key (eventID, beforeStart, channel)
+);
+create index xie0_reminder_reminderTime on reminder (reminderTime);
+insert into reminder (eventID, beforeStart, reminderTime)
+  select id, reminder, reminderTime from event where reminder is not null;
+create trigger event_delete_reminder after delete on event
+begin
+  delete from reminder where eventID = old.id;
+end;
+drop index xie0_event_reminderTime;
+alter table event drop column reminderTime;
+alter table event drop column reminder;
+-- канал доставки напоминания, null - канал пользователя по умолчанию
+alter table notification_outbox add column channel text null;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table notification_outbox drop column channel;
+alter table event add column reminder integer null;
+alter table event add column reminderTime text null;
+-- у события остается только самое раннее напоминание
+update event set
+  reminder = (select beforeStart from reminder where eventID = event.id order by beforeStart desc limit 1),
+  reminderTime = (select reminderTime from reminder where eventID = event.id order by beforeStart desc limit 1);
+create index xie0_event_reminderTime on event (reminderTime);
+drop trigger event_delete_reminder;
+drop table reminder;
+-- +goose StatementEnd
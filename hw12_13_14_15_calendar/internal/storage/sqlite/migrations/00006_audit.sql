@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+-- время пометки события удаленным, null - не удалено
+alter table event add column deletedAt text null;
+-- журнал изменений событий: action - create, update, delete, restore, actor - изменивший событие пользователь,
+-- 0 - сам сервис, oldEvent и newEvent - событие до и после изменения в JSON
+create table event_audit(
+  id integer primary key autoincrement,
+  eventID text not null,
+  action text not null,
+  actor integer not null,
+  createdAt text not null,
+  oldEvent text null,
+  newEvent text null
+);
+create index xie0_event_audit_eventID on event_audit (eventID, id);
+-- журнал только дополняется
+create trigger event_audit_no_update before update on event_audit
+begin
+  select raise(abort, 'event_audit is append-only');
+end;
+create trigger event_audit_no_delete before delete on event_audit
+begin
+  select raise(abort, 'event_audit is append-only');
+end;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop trigger event_audit_no_delete;
+drop trigger event_audit_no_update;
+drop table event_audit;
+-- без признака удаления помеченные удаленными события удаляются окончательно
+delete from event where deletedAt is not null;
+alter table event drop column deletedAt;
+-- +goose StatementEnd
@@ -0,0 +1,935 @@
+// Package sqlitestorage хранилище в файле SQLite для небольших установок без сервера БД.
+// Схема создается и обновляется встроенными миграциями при подключении.
+package sqlitestorage
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"                                                   //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage"         //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage/migrate" //nolint:depguard
+	"github.com/pressly/goose/v3"                                              //nolint:depguard
+	_ "modernc.org/sqlite"                                                     //nolint:depguard
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// драйвер database/sql modernc.org/sqlite, не требует cgo.
+const driver = "sqlite"
+
+// время хранится текстом фиксированной длины в UTC, поэтому строки сравниваются и сортируются как время.
+const timeLayout = "2006-01-02 15:04:05.000000000"
+
+// участники события и напоминания собираются в строки storage.FormatAttendees и storage.FormatReminders.
+const eventColumns = `id, title, startTime, stopTime, description, userID, rrule, exdate, traceContext,
+	timeZone, allDay, (select group_concat(userID || ':' || status, ',') from
+	(select userID, status from attendee where eventID = event.id order by userID)),
+	(select group_concat(beforeStart || 'ns:' || channel, ',') from
+	(select beforeStart, channel from reminder where eventID = event.id order by beforeStart desc, channel))`
+
+// условие выборки не удаленных событий пользователя ?1: своих и тех, на которые он приглашен.
+const userCondition = `deletedAt is null and (userID = ?1 or id in (select eventID from attendee where userID = ?1))`
+
+// условие пересечения события с интервалом [?2, ?3), как в storage.Event.Overlaps.
+const overlapCondition = `(stopTime > ?2 or startTime >= ?2)`
+
+func init() {
+	storage.Register("sqlite", func(ctx context.Context, params storage.Params) (storage.Storage, error) {
+		dbStorage := New(params.DSN, params.AllowOverlap)
+		if err := dbStorage.Connect(ctx); err != nil {
+			return nil, fmt.Errorf("failed to open sqlite db: %w", err)
+		}
+		return dbStorage, nil
+	})
+}
+
+type Storage struct {
+	dsn        string
+	db         *sql.DB
+	migrations *migrate.Migrations
+	// события с признаком overlap не участвуют в проверке пересечений
+	allowOverlap bool
+}
+
+// New создает хранилище в файле dsn, например calendar.db или file:calendar.db?_pragma=busy_timeout(5000).
+func New(dsn string, allowOverlap bool) *Storage {
+	return &Storage{dsn: dsn, allowOverlap: allowOverlap}
+}
+
+// Connect открывает БД и применяет к ней миграции.
+func (s *Storage) Connect(ctx context.Context) error {
+	db, err := sql.Open(driver, s.dsn)
+	if err != nil {
+		return fmt.Errorf("%w: error while opening %v", err, s.dsn)
+	}
+	// SQLite допускает одного писателя: запросы процесса выполняются по очереди в одном соединении,
+	// заодно между запросами сохраняется база :memory:
+	db.SetMaxOpenConns(1)
+	fsys, err := fs.Sub(migrations, "migrations")
+	if err != nil {
+		_ = db.Close()
+		return fmt.Errorf("%w: %v", storage.ErrMigration, err) //nolint:errorlint
+	}
+	// блокировка не нужна: единственное соединение и так выполняет миграции по очереди
+	s.migrations, err = migrate.New(goose.DialectSQLite3, db, fsys, nil)
+	if err != nil {
+		_ = db.Close()
+		return err
+	}
+	if _, err := s.migrations.Up(ctx); err != nil {
+		_ = db.Close()
+		return err
+	}
+	s.db = db
+	return nil
+}
+
+func (s *Storage) MigrateUp(ctx context.Context) ([]storage.Migration, error) {
+	return s.migrations.Up(ctx)
+}
+
+func (s *Storage) MigrateDown(ctx context.Context) (storage.Migration, error) {
+	return s.migrations.Down(ctx)
+}
+
+func (s *Storage) MigrationStatus(ctx context.Context) ([]storage.Migration, error) {
+	return s.migrations.Status(ctx)
+}
+
+func (s *Storage) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func (s *Storage) Close(_ context.Context) error {
+	return s.db.Close()
+}
+
+func (s *Storage) CreateEvent(ctx context.Context, event storage.Event) (string, error) {
+	if err := event.Validate(); err != nil {
+		return "", err
+	}
+	event.ID = uuid.New().String()
+
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := s.checkOverlap(ctx, tx, &event); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `insert into event (id, title, startTime, stopTime, description, userID,
+		rrule, exdate, overlap, search, traceContext, timeZone, allDay)
+		values (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11, ?12, ?13)`,
+			event.ID, event.Title, formatTime(event.StartTime), formatTime(event.StopTime), event.Description,
+			event.UserID, nullString(event.RRule), nullString(storage.FormatExDates(event.ExDates)), s.allowOverlap,
+			searchText(&event), nullString(event.TraceContext), nullString(event.TimeZone), event.AllDay)
+		if err != nil {
+			return fmt.Errorf("%w: %v %v", storage.ErrCreateEvent, event, err) //nolint:errorlint
+		}
+		if err := insertReminders(ctx, tx, &event, storage.ErrCreateEvent); err != nil {
+			return err
+		}
+		after, err := getEvent(ctx, tx, event.ID)
+		if err != nil {
+			return err
+		}
+		return insertAudit(ctx, tx, storage.NewAuditRecord(ctx, storage.AuditCreate, nil, after))
+	})
+	if err != nil {
+		return "", err
+	}
+	return event.ID, nil
+}
+
+func (s *Storage) UpdateEvent(ctx context.Context, id string, event storage.Event) error {
+	if event.ID != id {
+		return fmt.Errorf("%w: id=%v event.ID=%v", storage.ErrInvalidArgiments, id, event.ID)
+	}
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		before, err := getEvent(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if before.UserID != event.UserID {
+			return storage.ErrUpdateUserID
+		}
+		if err := event.Validate(); err != nil {
+			return err
+		}
+		if err := s.checkOverlap(ctx, tx, &event); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `update event
+		set title = ?1, startTime = ?2, stopTime = ?3, description = ?4, rrule = ?5, exdate = ?6, overlap = ?7,
+		search = ?8, traceContext = ?10, timeZone = ?11, allDay = ?12
+		where id = ?9`,
+			event.Title, formatTime(event.StartTime), formatTime(event.StopTime), event.Description,
+			nullString(event.RRule), nullString(storage.FormatExDates(event.ExDates)), s.allowOverlap,
+			searchText(&event), id, nullString(event.TraceContext), nullString(event.TimeZone), event.AllDay)
+		if err != nil {
+			return fmt.Errorf("%w: %v %v", storage.ErrUpdateEvent, event, err) //nolint:errorlint
+		}
+		if err := replaceReminders(ctx, tx, &event, storage.ErrUpdateEvent); err != nil {
+			return err
+		}
+		after, err := getEvent(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		return insertAudit(ctx, tx, storage.NewAuditRecord(ctx, storage.AuditUpdate, before, after))
+	})
+}
+
+// replaceReminders заново сохраняет напоминания события, время отправки всех напоминаний считается заново.
+func replaceReminders(ctx context.Context, tx *sql.Tx, event *storage.Event, errKind error) error {
+	_, err := tx.ExecContext(ctx, `delete from reminder where eventID = ?1`, event.ID)
+	if err != nil {
+		return fmt.Errorf("%w: %v %v", errKind, event, err) //nolint:errorlint
+	}
+	return insertReminders(ctx, tx, event, errKind)
+}
+
+// insertReminders сохраняет напоминания события со временем их отправки, errKind - ошибка вызывающего метода.
+func insertReminders(ctx context.Context, tx *sql.Tx, event *storage.Event, errKind error) error {
+	now := time.Now()
+	for _, reminder := range event.Reminders {
+		_, err := tx.ExecContext(ctx, `insert into reminder (eventID, beforeStart, channel, reminderTime)
+		values (?1, ?2, ?3, ?4)`, event.ID, int64(reminder.Before), reminder.Channel,
+			nullTime(event.NextReminderTime(reminder, now)))
+		if err != nil {
+			return fmt.Errorf("%w: %v %v", errKind, event, err) //nolint:errorlint
+		}
+	}
+	return nil
+}
+
+// checkOverlap проверяет пересечение интервала события с другими событиями пользователя так же, как
+// ограничение xex0_event_UserID_period в Postgres: пустые интервалы ни с чем не пересекаются, события
+// с признаком overlap не проверяются. Повторения серий сверяются в Go: серия - со всеми событиями
+// пользователя, обычное событие - с сериями.
+func (s *Storage) checkOverlap(ctx context.Context, tx *sql.Tx, event *storage.Event) error {
+	if s.allowOverlap || !event.StartTime.Before(event.StopTime) {
+		return nil
+	}
+	var busy bool
+	err := tx.QueryRowContext(ctx, `select exists(select 1 from event where userID = ?1 and id <> ?2
+	and deletedAt is null and not overlap and startTime < stopTime and startTime < ?4 and stopTime > ?3)`,
+		event.UserID, event.ID, formatTime(event.StartTime), formatTime(event.StopTime)).Scan(&busy)
+	if err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrReadEvent, err) //nolint:errorlint
+	}
+	if busy {
+		return fmt.Errorf("%w: %v", storage.ErrDateBusy, *event)
+	}
+
+	query := `select ` + eventColumns + ` from event where userID = ?1 and id <> ?2 and deletedAt is null
+	and not overlap and startTime < stopTime and (rrule is not null or ?3)`
+	args := []any{event.UserID, event.ID, event.IsRecurring()}
+	if lastStopTime, ok := event.LastStopTime(); ok {
+		query += ` and startTime < ?4`
+		args = append(args, formatTime(lastStopTime))
+	}
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrReadEvent, err) //nolint:errorlint
+	}
+	defer rows.Close()
+	events, err := makeEventsFromRows(rows)
+	if err != nil {
+		return err
+	}
+	for _, current := range events {
+		if current.OverlapsEvent(event) {
+			return fmt.Errorf("%w: %v", storage.ErrDateBusy, *event)
+		}
+	}
+	return nil
+}
+
+func (s *Storage) DeleteEvent(ctx context.Context, id string) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		before, err := getEvent(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `update event set deletedAt = ?2 where id = ?1`, id, formatTime(time.Now()))
+		if err != nil {
+			return fmt.Errorf("%w: %v %v", storage.ErrDeleteEvent, id, err) //nolint:errorlint
+		}
+		return insertAudit(ctx, tx, storage.NewAuditRecord(ctx, storage.AuditDelete, before, nil))
+	})
+}
+
+func (s *Storage) RestoreEvent(ctx context.Context, id string) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		row := tx.QueryRowContext(ctx, `select `+eventColumns+` from event where id = ?1 and deletedAt is not null`, id)
+		event, err := scanEvent(row)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				if err := checkEvent(ctx, tx, id); err != nil {
+					return err
+				}
+				return storage.ErrEventNotDeleted
+			}
+			return fmt.Errorf("%w: %v %v", storage.ErrReadEvent, id, err) //nolint:errorlint
+		}
+		if err := s.checkOverlap(ctx, tx, event); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `update event set deletedAt = null where id = ?1`, id)
+		if err != nil {
+			return fmt.Errorf("%w: %v %v", storage.ErrUpdateEvent, id, err) //nolint:errorlint
+		}
+		if err := replaceReminders(ctx, tx, event, storage.ErrUpdateEvent); err != nil {
+			return err
+		}
+		return insertAudit(ctx, tx, storage.NewAuditRecord(ctx, storage.AuditRestore, nil, event))
+	})
+}
+
+// insertAudit дописывает запись в журнал изменений событий.
+func insertAudit(ctx context.Context, tx *sql.Tx, record *storage.AuditRecord) error {
+	before, err := storage.FormatSnapshot(record.Before)
+	if err != nil {
+		return err
+	}
+	after, err := storage.FormatSnapshot(record.After)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `insert into event_audit (eventID, action, actor, createdAt, oldEvent, newEvent)
+	values (?1, ?2, ?3, ?4, ?5, ?6)`, record.EventID, record.Action, record.Actor, formatTime(record.Time),
+		nullString(before), nullString(after))
+	if err != nil {
+		return fmt.Errorf("%w: %v %v", storage.ErrWriteAudit, record.EventID, err) //nolint:errorlint
+	}
+	return nil
+}
+
+func (s *Storage) ListEventHistory(ctx context.Context, id string) ([]*storage.AuditRecord, error) {
+	rows, err := s.db.QueryContext(ctx, `select eventID, action, actor, createdAt, oldEvent, newEvent
+	from event_audit where eventID = ?1 order by id`, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v %v", storage.ErrReadAudit, id, err) //nolint:errorlint
+	}
+	defer rows.Close()
+
+	result := make([]*storage.AuditRecord, 0)
+	for rows.Next() {
+		record := &storage.AuditRecord{}
+		var createdAt string
+		var before, after sql.NullString
+		err := rows.Scan(&record.EventID, &record.Action, &record.Actor, &createdAt, &before, &after)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v %v", storage.ErrReadAudit, id, err) //nolint:errorlint
+		}
+		if record.Time, err = parseTime(createdAt); err != nil {
+			return nil, fmt.Errorf("%w: %v %v", storage.ErrReadAudit, id, err) //nolint:errorlint
+		}
+		if record.Before, err = storage.ParseSnapshot(before.String); err != nil {
+			return nil, fmt.Errorf("%w: %v %v", storage.ErrReadAudit, id, err) //nolint:errorlint
+		}
+		if record.After, err = storage.ParseSnapshot(after.String); err != nil {
+			return nil, fmt.Errorf("%w: %v %v", storage.ErrReadAudit, id, err) //nolint:errorlint
+		}
+		result = append(result, record)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("%w: %v %v", storage.ErrReadAudit, id, rows.Err()) //nolint:errorlint
+	}
+	return result, nil
+}
+
+func (s *Storage) GetEvent(ctx context.Context, id string) (*storage.Event, error) {
+	row := s.db.QueryRowContext(ctx, `select `+eventColumns+` from event where id = ?1 and deletedAt is null`, id)
+	event, err := scanEvent(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrEventNotFound
+		}
+		return nil, fmt.Errorf("%w: %v %v", storage.ErrReadEvent, id, err) //nolint:errorlint
+	}
+	return event, nil
+}
+
+func (s *Storage) ListEventsDay(ctx context.Context, userID int64, startTime time.Time) ([]*storage.Event, error) {
+	return s.listEventsInt(ctx, userID, startTime, startTime.AddDate(0, 0, 1))
+}
+
+func (s *Storage) ListEventsWeek(ctx context.Context, userID int64, startTime time.Time) ([]*storage.Event, error) {
+	return s.listEventsInt(ctx, userID, startTime, startTime.AddDate(0, 0, 7))
+}
+
+func (s *Storage) ListEventsMonth(ctx context.Context, userID int64, startTime time.Time) ([]*storage.Event, error) {
+	return s.listEventsInt(ctx, userID, startTime, startTime.AddDate(0, 1, 0))
+}
+
+func (s *Storage) listEventsInt(ctx context.Context, userID int64, startTime time.Time, stopTime time.Time) (
+	[]*storage.Event, error,
+) {
+	// повторяющиеся события выбираются целиком и разворачиваются в повторения внутри интервала
+	rows, err := s.db.QueryContext(ctx, `select `+eventColumns+`
+	from event where `+userCondition+` and startTime < ?3 and (rrule is not null or `+overlapCondition+`)
+	order by startTime, id`,
+		userID, formatTime(startTime), formatTime(stopTime))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrReadEvent, err) //nolint:errorlint
+	}
+	defer rows.Close()
+	events, err := makeEventsFromRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*storage.Event, 0, len(events))
+	for _, event := range events {
+		result = append(result, event.Overlapping(startTime, stopTime)...)
+	}
+	return result, nil
+}
+
+func (s *Storage) ListEvents(ctx context.Context, params storage.ListParams) (
+	[]*storage.Event, *storage.Cursor, error,
+) {
+	// одиночные события выбираются страницей по индексу, курсор сравнивается как ключ (startTime, id)
+	order, compare := "asc", ">"
+	if params.Desc {
+		order, compare = "desc", "<"
+	}
+	search, searchArgs := searchCondition(params.Query, 4)
+	// запрос собирается только из констант, значения передаются параметрами
+	query := `select ` + eventColumns + ` from event where ` + userCondition + ` and rrule is null ` + //nolint:gosec
+		`and startTime < ?3 and ` + overlapCondition + search
+	args := append([]any{params.UserID, formatTime(params.StartTime), formatTime(params.StopTime)}, searchArgs...)
+	if params.Cursor != nil {
+		first := len(args) + 1
+		query += ` and (startTime, id) ` + compare + ` (?` + strconv.Itoa(first) + `, ?` + strconv.Itoa(first+1) + `)`
+		args = append(args, formatTime(params.Cursor.StartTime), params.Cursor.ID)
+	}
+	query += ` order by startTime ` + order + `, id ` + order
+	if params.Limit > 0 {
+		// лишняя запись показывает, что есть следующая страница
+		query += ` limit ` + strconv.Itoa(params.Limit+1)
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", storage.ErrReadEvent, err) //nolint:errorlint
+	}
+	defer rows.Close()
+	events, err := makeEventsFromRows(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// серии разворачиваются в повторения и объединяются с одиночными событиями
+	search, searchArgs = searchCondition(params.Query, 3)
+	rows, err = s.db.QueryContext(ctx, `select `+eventColumns+` from event `+ //nolint:gosec
+		`where `+userCondition+` and rrule is not null and startTime < ?2`+search,
+		append([]any{params.UserID, formatTime(params.StopTime)}, searchArgs...)...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", storage.ErrReadEvent, err) //nolint:errorlint
+	}
+	defer rows.Close()
+	series, err := makeEventsFromRows(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, event := range series {
+		events = append(events, event.Overlapping(params.StartTime, params.StopTime)...)
+	}
+	result, next := params.Page(events)
+	return result, next, nil
+}
+
+// searchCondition условие поиска всех слов запроса в колонке search, параметры нумеруются с first.
+// Слова состоят только из букв и цифр, поэтому не содержат спецсимволов like.
+func searchCondition(query string, first int) (string, []any) {
+	tokens := storage.Tokenize(query)
+	if len(tokens) == 0 {
+		if query != "" {
+			// в запросе нет ни одного слова, как и у plainto_tsquery ничего не найдено
+			return ` and 0`, nil
+		}
+		return "", nil
+	}
+	var condition strings.Builder
+	args := make([]any, 0, len(tokens))
+	for i, token := range tokens {
+		condition.WriteString(` and search like ?` + strconv.Itoa(first+i))
+		args = append(args, "% "+token+" %")
+	}
+	return condition.String(), args
+}
+
+// searchText слова названия и описания события через пробел, с пробелами по краям.
+func searchText(event *storage.Event) string {
+	tokens := storage.Tokenize(event.SearchText())
+	if len(tokens) == 0 {
+		return ""
+	}
+	return " " + strings.Join(tokens, " ") + " "
+}
+
+func (s *Storage) ListDueReminders(ctx context.Context) ([]*storage.DueReminder, error) {
+	rows, err := s.db.QueryContext(ctx, `select `+eventColumns+`, r.beforeStart, r.channel, r.reminderTime
+	from reminder r join event on event.id = r.eventID
+	where r.reminderTime < ?1 and event.deletedAt is null`, formatTime(time.Now()))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrReadEvent, err) //nolint:errorlint
+	}
+	defer rows.Close()
+
+	result := make([]*storage.DueReminder, 0)
+	for rows.Next() {
+		var before int64
+		var channel, reminderTimeStr string
+		event, err := scanEvent(rows, &before, &channel, &reminderTimeStr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", storage.ErrReadEvent, err) //nolint:errorlint
+		}
+		reminderTime, err := parseTime(reminderTimeStr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", storage.ErrReadEvent, err) //nolint:errorlint
+		}
+		reminder := storage.Reminder{Before: time.Duration(before), Channel: channel}
+		// для серии - напоминание о конкретном повторении
+		occurrence, ok := event.DueOccurrence(reminder, reminderTime)
+		if !ok {
+			continue
+		}
+		result = append(result, &storage.DueReminder{Reminder: reminder, Event: occurrence})
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrReadEvent, rows.Err()) //nolint:errorlint
+	}
+	return result, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanEvent(row scanner, extra ...any) (*storage.Event, error) {
+	event := &storage.Event{}
+	var startTime, stopTime string
+	var rrule, exdate, traceContext, timeZone, attendees, reminders sql.NullString
+	dest := []any{
+		&event.ID, &event.Title, &startTime, &stopTime, &event.Description, &event.UserID,
+		&rrule, &exdate, &traceContext, &timeZone, &event.AllDay, &attendees, &reminders,
+	}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+	if event.StartTime, err = parseTime(startTime); err != nil {
+		return nil, err
+	}
+	if event.StopTime, err = parseTime(stopTime); err != nil {
+		return nil, err
+	}
+	event.RRule = rrule.String
+	event.TraceContext = traceContext.String
+	event.TimeZone = timeZone.String
+	event.ExDates, err = storage.ParseExDates(exdate.String)
+	if err != nil {
+		return nil, err
+	}
+	event.Attendees, err = storage.ParseAttendees(attendees.String)
+	if err != nil {
+		return nil, err
+	}
+	event.Reminders, err = storage.ParseReminders(reminders.String)
+	if err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+func makeEventsFromRows(rows *sql.Rows) ([]*storage.Event, error) {
+	result := make([]*storage.Event, 0)
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", storage.ErrReadEvent, err) //nolint:errorlint
+		}
+		result = append(result, event)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrReadEvent, rows.Err()) //nolint:errorlint
+	}
+	return result, nil
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+func parseTime(value string) (time.Time, error) {
+	return time.Parse(timeLayout, value)
+}
+
+func nullTime(t *time.Time) sql.NullString {
+	if t == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: formatTime(*t), Valid: true}
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func (s *Storage) ClearReminderTime(ctx context.Context, id string, reminder storage.Reminder) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		return clearReminderTime(ctx, tx, id, reminder)
+	})
+}
+
+func clearReminderTime(ctx context.Context, tx *sql.Tx, id string, reminder storage.Reminder) error {
+	event, err := getEvent(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	var reminderTimeStr sql.NullString
+	err = tx.QueryRowContext(ctx, `select reminderTime from reminder
+	where eventID = ?1 and beforeStart = ?2 and channel = ?3`, id, int64(reminder.Before), reminder.Channel).
+		Scan(&reminderTimeStr)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// напоминание удалено при изменении события
+			return nil
+		}
+		return fmt.Errorf("%w: %v %v", storage.ErrReadEvent, id, err) //nolint:errorlint
+	}
+	// для серии напоминание переносится на следующее повторение
+	var nextReminderTime *time.Time
+	if reminderTimeStr.Valid {
+		reminderTime, err := parseTime(reminderTimeStr.String)
+		if err != nil {
+			return fmt.Errorf("%w: %v %v", storage.ErrReadEvent, id, err) //nolint:errorlint
+		}
+		nextReminderTime = event.FollowingReminderTime(reminder, reminderTime)
+	}
+
+	_, err = tx.ExecContext(ctx, `update reminder set reminderTime = ?4
+	where eventID = ?1 and beforeStart = ?2 and channel = ?3`,
+		id, int64(reminder.Before), reminder.Channel, nullTime(nextReminderTime))
+	if err != nil {
+		return fmt.Errorf("%w: %v %v", storage.ErrUpdateEvent, id, err) //nolint:errorlint
+	}
+	return nil
+}
+
+// inTx выполняет fn в транзакции. Соединение одно, поэтому внутри fn запросы идут только через tx.
+func (s *Storage) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrTransaction, err) //nolint:errorlint
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", storage.ErrTransaction, err) //nolint:errorlint
+	}
+	return nil
+}
+
+func (s *Storage) DeleteEventsBeforeDate(ctx context.Context, time time.Time) error {
+	before := formatTime(time)
+	_, err := s.db.ExecContext(ctx, `delete from event where startTime < ?1 and rrule is null`, before)
+	if err != nil {
+		return fmt.Errorf("%w: %v %v", storage.ErrDeleteEvent, time, err) //nolint:errorlint
+	}
+	// вместе со старыми событиями удаляются давно отправленные уведомления outbox
+	_, err = s.db.ExecContext(ctx, `delete from notification_outbox where sentTime < ?1`, before)
+	if err != nil {
+		return fmt.Errorf("%w: %v %v", storage.ErrDeleteEvent, time, err) //nolint:errorlint
+	}
+
+	// серия удаляется только после окончания последнего повторения
+	rows, err := s.db.QueryContext(ctx, `select `+eventColumns+` from event where startTime < ?1 and rrule is not null`,
+		before)
+	if err != nil {
+		return fmt.Errorf("%w: %v %v", storage.ErrDeleteEvent, time, err) //nolint:errorlint
+	}
+	events, err := makeEventsFromRows(rows)
+	// соединение одно, выборка закрывается до следующих запросов
+	rows.Close()
+	if err != nil {
+		return fmt.Errorf("%w: %v %v", storage.ErrDeleteEvent, time, err) //nolint:errorlint
+	}
+	for _, event := range events {
+		if lastStopTime, ok := event.LastStopTime(); ok && lastStopTime.Before(time) {
+			if _, err := s.db.ExecContext(ctx, `delete from event where id = ?1`, event.ID); err != nil {
+				return fmt.Errorf("%w: %v %v", storage.ErrDeleteEvent, event.ID, err) //nolint:errorlint
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Storage) SaveNotification(ctx context.Context, notification storage.Notification) error {
+	_, err := s.db.ExecContext(ctx, `insert into notification (id, title, startTime, userID)
+	values (?1, ?2, ?3, ?4) on conflict (id) do nothing`,
+		notification.ID, notification.Title, formatTime(notification.StartTime), notification.UserID)
+	if err != nil {
+		return fmt.Errorf("%w: %v %v", storage.ErrCreateNotification, notification, err) //nolint:errorlint
+	}
+	return nil
+}
+
+func (s *Storage) GetNotificationDelivery(ctx context.Context, id string) (*storage.NotificationDelivery, error) {
+	delivery := &storage.NotificationDelivery{}
+	var channel, lastError sql.NullString
+	err := s.db.QueryRowContext(ctx, `select status, channel, attempts, lastError from notification where id = ?1`, id).
+		Scan(&delivery.Status, &channel, &delivery.Attempts, &lastError)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotificationNotFound
+		}
+		return nil, fmt.Errorf("%w: %v %v", storage.ErrReadNotification, id, err) //nolint:errorlint
+	}
+	delivery.Channel = channel.String
+	delivery.LastError = lastError.String
+	return delivery, nil
+}
+
+func (s *Storage) UpdateNotificationDelivery(ctx context.Context, id string, status storage.DeliveryStatus,
+	channel string, lastError string,
+) error {
+	return s.updateNotification(ctx, id, `update notification
+	set status = ?2, channel = ?3, attempts = attempts + 1, lastError = ?4, updatedAt = ?5
+	where id = ?1`, id, status, nullString(channel), nullString(lastError), formatTime(time.Now()))
+}
+
+func (s *Storage) EnqueueNotification(ctx context.Context, eventID string, reminder storage.Reminder,
+	notification storage.Notification,
+) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := clearReminderTime(ctx, tx, eventID, reminder); err != nil {
+			return err
+		}
+		return enqueue(ctx, tx, notification)
+	})
+}
+
+func enqueue(ctx context.Context, tx *sql.Tx, notification storage.Notification) error {
+	now := formatTime(time.Now())
+	_, err := tx.ExecContext(ctx, `insert into notification_outbox (id, title, startTime, userID, createdAt,
+	nextAttemptTime, traceContext, kind, attendee, channel) values (?1, ?2, ?3, ?4, ?5, ?5, ?6, ?7, ?8, ?9)
+	on conflict (id) do nothing`,
+		notification.ID, notification.Title, formatTime(notification.StartTime), notification.UserID, now,
+		nullString(notification.TraceContext), nullString(string(notification.Kind)), nullAttendee(notification.Attendee),
+		nullString(notification.Channel))
+	if err != nil {
+		return fmt.Errorf("%w: %v %v", storage.ErrCreateNotification, notification, err) //nolint:errorlint
+	}
+	return nil
+}
+
+func nullAttendee(attendee *storage.Attendee) sql.NullString {
+	if attendee == nil {
+		return sql.NullString{}
+	}
+	return nullString(storage.FormatAttendees([]storage.Attendee{*attendee}))
+}
+
+func parseAttendee(value sql.NullString) (*storage.Attendee, error) {
+	attendees, err := storage.ParseAttendees(value.String)
+	if err != nil || len(attendees) == 0 {
+		return nil, err
+	}
+	return &attendees[0], nil
+}
+
+// getEvent возвращает не удаленное событие в транзакции tx.
+func getEvent(ctx context.Context, tx *sql.Tx, id string) (*storage.Event, error) {
+	row := tx.QueryRowContext(ctx, `select `+eventColumns+` from event where id = ?1 and deletedAt is null`, id)
+	event, err := scanEvent(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrEventNotFound
+		}
+		return nil, fmt.Errorf("%w: %v %v", storage.ErrReadEvent, id, err) //nolint:errorlint
+	}
+	return event, nil
+}
+
+// checkEvent проверяет наличие не удаленного события, транзакция SQLite и так выполняется единственным
+// соединением.
+func checkEvent(ctx context.Context, tx *sql.Tx, id string) error {
+	var exists bool
+	err := tx.QueryRowContext(ctx, `select exists(select 1 from event where id = ?1 and deletedAt is null)`, id).
+		Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("%w: %v %v", storage.ErrReadEvent, id, err) //nolint:errorlint
+	}
+	if !exists {
+		return storage.ErrEventNotFound
+	}
+	return nil
+}
+
+func (s *Storage) InviteAttendee(ctx context.Context, eventID string, userID int64,
+	notification storage.Notification,
+) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := checkEvent(ctx, tx, eventID); err != nil {
+			return err
+		}
+		result, err := tx.ExecContext(ctx, `insert into attendee (eventID, userID, status, updatedAt)
+		values (?1, ?2, ?3, ?4) on conflict (eventID, userID) do nothing`,
+			eventID, userID, storage.RSVPNeedsAction, formatTime(time.Now()))
+		if err != nil {
+			return fmt.Errorf("%w: %v %v", storage.ErrUpdateAttendee, eventID, err) //nolint:errorlint
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("%w: %v %v", storage.ErrUpdateAttendee, eventID, err) //nolint:errorlint
+		}
+		if rows == 0 {
+			// пользователь уже приглашен
+			return nil
+		}
+		return enqueue(ctx, tx, notification)
+	})
+}
+
+func (s *Storage) UpdateAttendee(ctx context.Context, eventID string, attendee storage.Attendee,
+	notification storage.Notification,
+) error {
+	if err := attendee.Status.Validate(); err != nil {
+		return err
+	}
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := checkEvent(ctx, tx, eventID); err != nil {
+			return err
+		}
+		var status storage.RSVP
+		err := tx.QueryRowContext(ctx, `select status from attendee where eventID = ?1 and userID = ?2`,
+			eventID, attendee.UserID).Scan(&status)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return storage.ErrAttendeeNotFound
+			}
+			return fmt.Errorf("%w: %v %v", storage.ErrReadEvent, eventID, err) //nolint:errorlint
+		}
+		if status == attendee.Status {
+			return nil
+		}
+		_, err = tx.ExecContext(ctx, `update attendee set status = ?3, updatedAt = ?4
+		where eventID = ?1 and userID = ?2`, eventID, attendee.UserID, attendee.Status, formatTime(time.Now()))
+		if err != nil {
+			return fmt.Errorf("%w: %v %v", storage.ErrUpdateAttendee, eventID, err) //nolint:errorlint
+		}
+		return enqueue(ctx, tx, notification)
+	})
+}
+
+func (s *Storage) RemoveAttendee(ctx context.Context, eventID string, userID int64,
+	notification storage.Notification,
+) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := checkEvent(ctx, tx, eventID); err != nil {
+			return err
+		}
+		result, err := tx.ExecContext(ctx, `delete from attendee where eventID = ?1 and userID = ?2`, eventID, userID)
+		if err != nil {
+			return fmt.Errorf("%w: %v %v", storage.ErrUpdateAttendee, eventID, err) //nolint:errorlint
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("%w: %v %v", storage.ErrUpdateAttendee, eventID, err) //nolint:errorlint
+		}
+		if rows == 0 {
+			return storage.ErrAttendeeNotFound
+		}
+		return enqueue(ctx, tx, notification)
+	})
+}
+
+func (s *Storage) ListOutbox(ctx context.Context, limit int) ([]*storage.OutboxMessage, error) {
+	rows, err := s.db.QueryContext(ctx, `select id, title, startTime, userID, createdAt, attempts, nextAttemptTime,
+	lastError, traceContext, kind, attendee, channel from notification_outbox
+	where sentTime is null and nextAttemptTime <= ?1
+	order by createdAt, rowid limit ?2`, formatTime(time.Now()), limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrReadNotification, err) //nolint:errorlint
+	}
+	defer rows.Close()
+
+	result := make([]*storage.OutboxMessage, 0)
+	for rows.Next() {
+		message, err := scanOutboxMessage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", storage.ErrReadNotification, err) //nolint:errorlint
+		}
+		result = append(result, message)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrReadNotification, rows.Err()) //nolint:errorlint
+	}
+	return result, nil
+}
+
+func scanOutboxMessage(row scanner) (*storage.OutboxMessage, error) {
+	message := &storage.OutboxMessage{}
+	var startTime, createdAt, nextAttemptTime string
+	var lastError, traceContext, kind, attendee, channel sql.NullString
+	err := row.Scan(&message.ID, &message.Title, &startTime, &message.UserID, &createdAt,
+		&message.Attempts, &nextAttemptTime, &lastError, &traceContext, &kind, &attendee, &channel)
+	if err != nil {
+		return nil, err
+	}
+	if message.StartTime, err = parseTime(startTime); err != nil {
+		return nil, err
+	}
+	if message.CreatedAt, err = parseTime(createdAt); err != nil {
+		return nil, err
+	}
+	if message.NextAttemptTime, err = parseTime(nextAttemptTime); err != nil {
+		return nil, err
+	}
+	message.LastError = lastError.String
+	message.TraceContext = traceContext.String
+	message.Kind = storage.NotificationKind(kind.String)
+	message.Channel = channel.String
+	if message.Attendee, err = parseAttendee(attendee); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+func (s *Storage) MarkOutboxSent(ctx context.Context, id string) error {
+	return s.updateNotification(ctx, id,
+		`update notification_outbox set sentTime = ?2 where id = ?1 and sentTime is null`, id, formatTime(time.Now()))
+}
+
+func (s *Storage) MarkOutboxFailed(ctx context.Context, id string, nextAttemptTime time.Time, reason string) error {
+	return s.updateNotification(ctx, id, `update notification_outbox
+	set attempts = attempts + 1, nextAttemptTime = ?2, lastError = ?3
+	where id = ?1 and sentTime is null`, id, formatTime(nextAttemptTime), reason)
+}
+
+func (s *Storage) updateNotification(ctx context.Context, id string, query string, args ...any) error {
+	result, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%w: %v %v", storage.ErrUpdateNotification, id, err) //nolint:errorlint
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v %v", storage.ErrUpdateNotification, id, err) //nolint:errorlint
+	}
+	if rows != 1 {
+		return storage.ErrNotificationNotFound
+	}
+	return nil
+}
@@ -0,0 +1,638 @@
+package sqlitestorage
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage"             //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage/storagetest" //nolint:depguard
+	"github.com/stretchr/testify/require"                                          //nolint:depguard
+)
+
+const (
+	badEventID = "bad_event_id"
+	badUserID  = 777
+)
+
+func newTestStorage(t *testing.T, allowOverlap bool) *Storage {
+	t.Helper()
+	repo := New(filepath.Join(t.TempDir(), "calendar.db"), allowOverlap)
+	require.NoError(t, repo.Connect(context.Background()))
+	t.Cleanup(func() { _ = repo.Close(context.Background()) })
+	return repo
+}
+
+// count возвращает количество строк таблицы, удовлетворяющих условию.
+func count(t *testing.T, repo *Storage, table string, where string, args ...any) int {
+	t.Helper()
+	var result int
+	query := `select count(*) from ` + table
+	if where != "" {
+		query += ` where ` + where
+	}
+	require.NoError(t, repo.db.QueryRowContext(context.Background(), query, args...).Scan(&result))
+	return result
+}
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T, allowOverlap bool) storage.Storage {
+		t.Helper()
+		return newTestStorage(t, allowOverlap)
+	})
+}
+
+func TestStorage(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestStorage(t, false)
+	event1 := storage.Event{
+		Title:       "title 1",
+		StartTime:   time.Date(2025, 1, 1, 11, 0, 0, 0, time.UTC),
+		StopTime:    time.Date(2025, 1, 1, 11, 30, 0, 0, time.UTC),
+		Description: "description 1",
+		UserID:      1,
+	}
+	event2 := storage.Event{
+		Title:       "title 2",
+		StartTime:   time.Date(2025, 1, 2, 11, 10, 0, 0, time.UTC),
+		StopTime:    time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC),
+		Description: "description 2",
+		UserID:      1,
+	}
+
+	t.Run("add event 1", func(t *testing.T) {
+		id, err := repo.CreateEvent(ctx, event1)
+		event1.ID = id
+
+		require.Equal(t, len(id), 36, "generated id must be 36 symbols")
+		require.NoError(t, err)
+		require.Equal(t, 1, count(t, repo, "event", ""))
+	})
+
+	t.Run("add event ErrDateBusy", func(t *testing.T) {
+		_, err := repo.CreateEvent(ctx, event1)
+		require.ErrorIs(t, err, storage.ErrDateBusy)
+	})
+
+	t.Run("add event ErrInvalidStopTime", func(t *testing.T) {
+		savedStopTime := event1.StopTime
+		event1.StopTime = time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+		_, err := repo.CreateEvent(ctx, event1)
+		event1.StopTime = savedStopTime
+		require.ErrorIs(t, err, storage.ErrInvalidStopTime)
+	})
+
+	t.Run("add event 2", func(t *testing.T) {
+		id, err := repo.CreateEvent(ctx, event2)
+		event2.ID = id
+
+		require.NoError(t, err)
+		require.Equal(t, 2, count(t, repo, "event", ""))
+		require.Equal(t, 2, count(t, repo, "event", "userID = ?1", event2.UserID))
+	})
+
+	t.Run("get event 1", func(t *testing.T) {
+		event, err := repo.GetEvent(ctx, event1.ID)
+		require.NoError(t, err)
+		require.Equal(t, event, &event1)
+	})
+	t.Run("get event 2", func(t *testing.T) {
+		event, err := repo.GetEvent(ctx, event2.ID)
+		require.NoError(t, err)
+		require.Equal(t, event, &event2)
+	})
+	t.Run("get event ErrEventNotFound", func(t *testing.T) {
+		_, err := repo.GetEvent(ctx, badEventID)
+		require.ErrorIs(t, err, storage.ErrEventNotFound)
+	})
+	t.Run("update ErrEventNotFound", func(t *testing.T) {
+		savedID := event1.ID
+		event1.ID = badEventID
+		err := repo.UpdateEvent(ctx, badEventID, event1)
+		event1.ID = savedID
+		require.ErrorIs(t, err, storage.ErrEventNotFound)
+	})
+	t.Run("update ErrUpdateUserID", func(t *testing.T) {
+		savedUserID := event1.UserID
+		event1.UserID = badUserID
+		err := repo.UpdateEvent(ctx, event1.ID, event1)
+		event1.UserID = savedUserID
+		require.ErrorIs(t, err, storage.ErrUpdateUserID)
+	})
+	t.Run("update ErrInvalidStopTime", func(t *testing.T) {
+		savedStopTime := event1.StopTime
+		event1.StopTime = time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+		err := repo.UpdateEvent(ctx, event1.ID, event1)
+		event1.StopTime = savedStopTime
+		require.ErrorIs(t, err, storage.ErrInvalidStopTime)
+	})
+	t.Run("update ErrDateBusy", func(t *testing.T) {
+		savedID := event1.ID
+		event1.ID = event2.ID
+		err := repo.UpdateEvent(ctx, event1.ID, event1)
+		event1.ID = savedID
+		require.ErrorIs(t, err, storage.ErrDateBusy)
+	})
+	t.Run("update event ok", func(t *testing.T) {
+		event1.Description = "updated description"
+		err := repo.UpdateEvent(ctx, event1.ID, event1)
+		require.NoError(t, err)
+		require.Equal(t, 2, count(t, repo, "event", ""))
+		event, err := repo.GetEvent(ctx, event1.ID)
+		require.NoError(t, err)
+		require.Equal(t, event, &event1)
+	})
+
+	t.Run("list events day", func(t *testing.T) {
+		events, err := repo.ListEventsDay(ctx, 1, event1.StartTime)
+		require.NoError(t, err)
+		require.Equal(t, len(events), 1)
+		require.Equal(t, *events[0], event1)
+	})
+	t.Run("list events week", func(t *testing.T) {
+		events, err := repo.ListEventsWeek(ctx, 1, event1.StartTime)
+		require.NoError(t, err)
+		require.Equal(t, len(events), 2)
+	})
+	t.Run("list events month", func(t *testing.T) {
+		events, err := repo.ListEventsMonth(ctx, 1, event1.StartTime)
+		require.NoError(t, err)
+		require.Equal(t, len(events), 2)
+		// чужие события не видны
+		events, err = repo.ListEventsMonth(ctx, badUserID, event1.StartTime)
+		require.NoError(t, err)
+		require.Equal(t, len(events), 0)
+	})
+	t.Run("list due reminders", func(t *testing.T) {
+		reminders, err := repo.ListDueReminders(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(reminders))
+	})
+	t.Run("clear reminder", func(t *testing.T) {
+		reminder := storage.Reminder{Before: time.Hour}
+		err := repo.ClearReminderTime(ctx, event1.ID, reminder)
+		require.NoError(t, err)
+		require.ErrorIs(t, repo.ClearReminderTime(ctx, badEventID, reminder), storage.ErrEventNotFound)
+	})
+
+	t.Run("delete event ErrEventNotFound", func(t *testing.T) {
+		err := repo.DeleteEvent(ctx, badEventID)
+		require.ErrorIs(t, err, storage.ErrEventNotFound)
+	})
+	t.Run("delete event ok", func(t *testing.T) {
+		err := repo.DeleteEvent(ctx, event1.ID)
+		require.NoError(t, err)
+		// событие помечается удаленным, журнал хранит его последнее состояние
+		require.Equal(t, 1, count(t, repo, "event", "deletedAt is null"))
+		require.Equal(t, 1, count(t, repo, "event", "id = ?1 and deletedAt is not null", event1.ID))
+		require.Equal(t, 1, count(t, repo, "event_audit", "eventID = ?1 and action = 'delete'", event1.ID))
+		require.ErrorIs(t, repo.DeleteEvent(ctx, event1.ID), storage.ErrEventNotFound)
+		event, err := repo.GetEvent(ctx, event2.ID)
+		require.NoError(t, err)
+		require.Equal(t, event, &event2)
+	})
+}
+
+func TestStorageAuditAppendOnly(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestStorage(t, false)
+	startTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	id, err := repo.CreateEvent(ctx, storage.Event{
+		Title: "event", UserID: 1, StartTime: startTime, StopTime: startTime.Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, count(t, repo, "event_audit", "eventID = ?1", id))
+
+	_, err = repo.db.ExecContext(ctx, `update event_audit set actor = 2`)
+	require.ErrorContains(t, err, "append-only")
+	_, err = repo.db.ExecContext(ctx, `delete from event_audit`)
+	require.ErrorContains(t, err, "append-only")
+	require.Equal(t, 1, count(t, repo, "event_audit", "eventID = ?1 and actor = 0", id))
+}
+
+func TestStorageReopen(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestStorage(t, false)
+	id, err := repo.CreateEvent(ctx, storage.Event{
+		Title: "title", UserID: 1, StartTime: time.Date(2025, 1, 1, 11, 0, 0, 0, time.UTC),
+		StopTime: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+
+	reopened := New(repo.dsn, false)
+	require.NoError(t, reopened.Connect(ctx), "migrations are applied once")
+	defer reopened.Close(ctx)
+	event, err := reopened.GetEvent(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "title", event.Title)
+}
+
+func TestStorageOverlap(t *testing.T) {
+	ctx := context.Background()
+	at := func(hour, minute int) time.Time {
+		return time.Date(2025, 1, 1, hour, minute, 0, 0, time.UTC)
+	}
+	newEvent := func(start, stop time.Time) storage.Event {
+		return storage.Event{UserID: 1, StartTime: start, StopTime: stop}
+	}
+
+	t.Run("overlaps forbidden", func(t *testing.T) {
+		repo := newTestStorage(t, false)
+		id, err := repo.CreateEvent(ctx, newEvent(at(10, 0), at(11, 0)))
+		require.NoError(t, err)
+		_, err = repo.CreateEvent(ctx, newEvent(at(12, 0), at(13, 0)))
+		require.NoError(t, err)
+
+		tests := []struct {
+			name        string
+			start, stop time.Time
+			err         error
+		}{
+			{name: "overlaps end", start: at(10, 30), stop: at(11, 30), err: storage.ErrDateBusy},
+			{name: "overlaps start", start: at(9, 30), stop: at(10, 30), err: storage.ErrDateBusy},
+			{name: "inside", start: at(10, 15), stop: at(10, 45), err: storage.ErrDateBusy},
+			{name: "covers both", start: at(9, 0), stop: at(14, 0), err: storage.ErrDateBusy},
+			{name: "same interval", start: at(12, 0), stop: at(13, 0), err: storage.ErrDateBusy},
+			{name: "empty interval", start: at(10, 30), stop: at(10, 30)},
+			{name: "back to back", start: at(11, 0), stop: at(12, 0)},
+		}
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				_, err := repo.CreateEvent(ctx, newEvent(tc.start, tc.stop))
+				require.ErrorIs(t, err, tc.err)
+			})
+		}
+
+		// другой пользователь может занять то же время
+		event := newEvent(at(10, 0), at(11, 0))
+		event.UserID = 2
+		_, err = repo.CreateEvent(ctx, event)
+		require.NoError(t, err)
+
+		// перенос события на занятое время и сдвиг внутри своего интервала
+		event = newEvent(at(11, 30), at(12, 30))
+		event.ID = id
+		require.ErrorIs(t, repo.UpdateEvent(ctx, id, event), storage.ErrDateBusy)
+		event = newEvent(at(9, 30), at(10, 30))
+		event.ID = id
+		require.NoError(t, repo.UpdateEvent(ctx, id, event))
+		_, err = repo.CreateEvent(ctx, newEvent(at(10, 30), at(11, 0)))
+		require.NoError(t, err)
+
+		require.NoError(t, repo.DeleteEvent(ctx, id))
+		_, err = repo.CreateEvent(ctx, newEvent(at(9, 0), at(10, 30)))
+		require.NoError(t, err)
+	})
+
+	t.Run("overlaps allowed", func(t *testing.T) {
+		repo := newTestStorage(t, true)
+		for i := 0; i < 3; i++ {
+			_, err := repo.CreateEvent(ctx, newEvent(at(10, 0), at(11, i)))
+			require.NoError(t, err)
+		}
+		events, err := repo.ListEventsDay(ctx, 1, at(0, 0))
+		require.NoError(t, err)
+		require.Equal(t, 3, len(events))
+	})
+}
+
+func TestStorageListEvents(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestStorage(t, false)
+	at := func(day, hour int) time.Time {
+		return time.Date(2025, 1, day, hour, 0, 0, 0, time.UTC)
+	}
+	create := func(title, description string, start, stop time.Time) string {
+		id, err := repo.CreateEvent(ctx, storage.Event{
+			Title: title, Description: description, UserID: 1, StartTime: start, StopTime: stop,
+		})
+		require.NoError(t, err)
+		return id
+	}
+	night := create("Night shift", "warehouse", at(1, 22), at(2, 6))
+	create("Planning", "quarter planning, warehouse budget", at(2, 10), at(2, 11))
+	create("Retro", "sprint retro", at(3, 10), at(3, 11))
+	titles := func(params storage.ListParams) []string {
+		params.UserID = 1
+		events, _, err := repo.ListEvents(ctx, params)
+		require.NoError(t, err)
+		result := make([]string, 0, len(events))
+		for _, event := range events {
+			result = append(result, event.Title)
+		}
+		return result
+	}
+
+	t.Run("range overlap", func(t *testing.T) {
+		require.Equal(t, []string{"Night shift", "Planning"},
+			titles(storage.ListParams{StartTime: at(2, 0), StopTime: at(3, 0)}))
+		require.Equal(t, []string{"Night shift"}, titles(storage.ListParams{StartTime: at(1, 0), StopTime: at(2, 0)}))
+		require.Empty(t, titles(storage.ListParams{StartTime: at(2, 6), StopTime: at(2, 10)}))
+		events, err := repo.ListEventsDay(ctx, 1, at(2, 0))
+		require.NoError(t, err)
+		require.Len(t, events, 2, "period lists use the same overlap semantics")
+	})
+
+	t.Run("pages", func(t *testing.T) {
+		params := storage.ListParams{UserID: 1, StartTime: at(1, 0), StopTime: at(4, 0), Limit: 2, Desc: true}
+		events, next, err := repo.ListEvents(ctx, params)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		require.Equal(t, "Retro", events[0].Title)
+		require.NotNil(t, next)
+
+		params.Cursor = next
+		events, next, err = repo.ListEvents(ctx, params)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		require.Equal(t, "Night shift", events[0].Title)
+		require.Nil(t, next)
+	})
+
+	t.Run("search", func(t *testing.T) {
+		all := storage.ListParams{StartTime: at(1, 0), StopTime: at(4, 0)}
+		all.Query = "WAREHOUSE"
+		require.Equal(t, []string{"Night shift", "Planning"}, titles(all))
+		all.Query = "warehouse budget"
+		require.Equal(t, []string{"Planning"}, titles(all))
+		all.Query = "retro, sprint"
+		require.Equal(t, []string{"Retro"}, titles(all))
+		all.Query = "ware"
+		require.Empty(t, titles(all))
+		all.Query = "?!"
+		require.Empty(t, titles(all))
+	})
+
+	t.Run("search index follows updates", func(t *testing.T) {
+		event, err := repo.GetEvent(ctx, night)
+		require.NoError(t, err)
+		updated := *event
+		updated.Description = "office"
+		require.NoError(t, repo.UpdateEvent(ctx, night, updated))
+
+		all := storage.ListParams{StartTime: at(1, 0), StopTime: at(4, 0), Query: "warehouse"}
+		require.Equal(t, []string{"Planning"}, titles(all))
+		all.Query = "office"
+		require.Equal(t, []string{"Night shift"}, titles(all))
+
+		require.NoError(t, repo.DeleteEvent(ctx, night))
+		require.Empty(t, titles(all))
+	})
+}
+
+func TestStorageOutbox(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestStorage(t, false)
+	reminder := storage.Reminder{Before: time.Hour}
+	startTime := time.Now().UTC().Truncate(time.Second).Add(time.Minute * 30)
+	id, err := repo.CreateEvent(ctx, storage.Event{
+		Title: "event", UserID: 1, StartTime: startTime, StopTime: startTime.Add(time.Hour),
+		Reminders: []storage.Reminder{reminder},
+	})
+	require.NoError(t, err)
+	notification := storage.Notification{ID: id, Title: "event", StartTime: startTime, UserID: 1}
+
+	require.ErrorIs(t, repo.EnqueueNotification(ctx, badEventID, reminder, notification), storage.ErrEventNotFound)
+	require.Equal(t, 0, count(t, repo, "notification_outbox", ""), "nothing is enqueued when reminder is not cleared")
+
+	require.NoError(t, repo.EnqueueNotification(ctx, id, reminder, notification))
+	require.NoError(t, repo.EnqueueNotification(ctx, id, reminder, notification))
+	reminders, err := repo.ListDueReminders(ctx)
+	require.NoError(t, err)
+	require.Empty(t, reminders)
+
+	messages, err := repo.ListOutbox(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	require.Equal(t, notification, messages[0].Notification)
+
+	// после неудачной отправки уведомление ждет следующей попытки
+	require.NoError(t, repo.MarkOutboxFailed(ctx, id, time.Now().Add(time.Hour), "broker is down"))
+	messages, err = repo.ListOutbox(ctx, 10)
+	require.NoError(t, err)
+	require.Empty(t, messages)
+	require.Equal(t, 1, count(t, repo, "notification_outbox", "attempts = 1 and lastError = 'broker is down'"))
+
+	require.NoError(t, repo.MarkOutboxFailed(ctx, id, time.Now(), "broker is down"))
+	messages, err = repo.ListOutbox(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	require.Equal(t, 2, messages[0].Attempts)
+
+	require.NoError(t, repo.MarkOutboxSent(ctx, id))
+	messages, err = repo.ListOutbox(ctx, 10)
+	require.NoError(t, err)
+	require.Empty(t, messages)
+	require.ErrorIs(t, repo.MarkOutboxSent(ctx, id), storage.ErrNotificationNotFound)
+}
+
+func TestStorageNotificationDelivery(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestStorage(t, false)
+	notification := storage.Notification{
+		ID: "3f333df6-90a4-4fda-8dd3-9485d27cee36", Title: "event", StartTime: time.Now(), UserID: 1,
+	}
+
+	_, err := repo.GetNotificationDelivery(ctx, notification.ID)
+	require.ErrorIs(t, err, storage.ErrNotificationNotFound)
+	require.ErrorIs(t, repo.UpdateNotificationDelivery(ctx, notification.ID, storage.DeliverySent, "stdout", ""),
+		storage.ErrNotificationNotFound)
+
+	require.NoError(t, repo.SaveNotification(ctx, notification))
+	require.NoError(t, repo.SaveNotification(ctx, notification), "repeated notification is ignored")
+	delivery, err := repo.GetNotificationDelivery(ctx, notification.ID)
+	require.NoError(t, err)
+	require.Equal(t, storage.NotificationDelivery{Status: storage.DeliveryPending}, *delivery)
+
+	require.NoError(t, repo.UpdateNotificationDelivery(ctx, notification.ID, storage.DeliveryPending, "email",
+		"connection refused"))
+	require.NoError(t, repo.UpdateNotificationDelivery(ctx, notification.ID, storage.DeliverySent, "stdout", ""))
+	delivery, err = repo.GetNotificationDelivery(ctx, notification.ID)
+	require.NoError(t, err)
+	require.Equal(t, storage.NotificationDelivery{Status: storage.DeliverySent, Channel: "stdout", Attempts: 2},
+		*delivery)
+}
+
+func TestStorageDeleteEventsBeforeDate(t *testing.T) {
+	repo := newTestStorage(t, false)
+	ctx := context.Background()
+	_, err := repo.CreateEvent(ctx, storage.Event{
+		UserID: 1, StartTime: time.Now().Add(-time.Hour), StopTime: time.Now(),
+	})
+	require.NoError(t, err)
+	_, err = repo.CreateEvent(ctx, storage.Event{
+		UserID: 2, StartTime: time.Now().Add(time.Hour), StopTime: time.Now().Add(time.Hour * 2),
+	})
+	require.NoError(t, err)
+
+	err = repo.DeleteEventsBeforeDate(ctx, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, 1, count(t, repo, "event", ""))
+	require.Equal(t, 1, count(t, repo, "event", "userID = 2"))
+}
+
+func TestStorageConcurrency(t *testing.T) {
+	const threadCount = 10
+	const objectPerThread = 100
+	ctx := context.Background()
+	repo := newTestStorage(t, false)
+	afterCreate := make(chan string)
+	afterReadUpdate := make(chan string)
+
+	var wgCreate sync.WaitGroup
+	// создаем объекты в разных потоках
+	for i := 0; i < threadCount; i++ {
+		wgCreate.Add(1)
+		go func(userID int64) {
+			defer wgCreate.Done()
+			startTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+			stopTime := startTime.Add(time.Hour)
+			for i := 0; i < objectPerThread; i++ {
+				id, err := repo.CreateEvent(ctx, storage.Event{UserID: userID, StartTime: startTime, StopTime: stopTime})
+				require.NoError(t, err)
+				require.NotEmpty(t, id)
+				afterCreate <- id
+				startTime = stopTime
+				stopTime = startTime.Add(time.Hour)
+			}
+		}(int64(i))
+	}
+
+	go func() {
+		wgCreate.Wait()
+		close(afterCreate)
+	}()
+
+	var readUpdateCount uint64
+	var wgReadUpdate sync.WaitGroup
+	// читаем/меняем объекты в разных потоках
+	for i := 0; i < threadCount; i++ {
+		wgReadUpdate.Add(1)
+		go func() {
+			defer wgReadUpdate.Done()
+			for id := range afterCreate {
+				event, err := repo.GetEvent(ctx, id)
+				require.NoError(t, err)
+				require.NotNil(t, event)
+				require.Equal(t, id, event.ID)
+				atomic.AddUint64(&readUpdateCount, 1)
+
+				event.Description = event.ID
+				err = repo.UpdateEvent(ctx, id, *event)
+				require.NoError(t, err)
+				afterReadUpdate <- id
+			}
+		}()
+	}
+
+	go func() {
+		wgReadUpdate.Wait()
+		close(afterReadUpdate)
+	}()
+
+	var readDeleteCount uint64
+	var wgReadDelete sync.WaitGroup
+	// читаем и удаляем объекты в разных потоках
+	for i := 0; i < threadCount; i++ {
+		wgReadDelete.Add(1)
+		go func() {
+			defer wgReadDelete.Done()
+			for id := range afterReadUpdate {
+				event, err := repo.GetEvent(ctx, id)
+				require.NoError(t, err)
+				require.NotNil(t, event)
+				require.Equal(t, id, event.ID)
+				// проверяем что update работает
+				require.Equal(t, id, event.Description)
+
+				err2 := repo.DeleteEvent(ctx, id)
+				require.NoError(t, err2)
+				atomic.AddUint64(&readDeleteCount, 1)
+			}
+		}()
+	}
+	wgReadDelete.Wait()
+
+	require.Equal(t, uint64(threadCount*objectPerThread), readUpdateCount)
+	require.Equal(t, uint64(threadCount*objectPerThread), readDeleteCount)
+	require.Equal(t, 0, count(t, repo, "event", "deletedAt is null"))
+}
+
+func TestStorageRecurring(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestStorage(t, false)
+	reminder := storage.Reminder{Before: time.Hour}
+	startTime := time.Now().UTC().Truncate(time.Second).Add(-time.Hour * 24)
+	id, err := repo.CreateEvent(ctx, storage.Event{
+		Title: "stand-up", UserID: 1, StartTime: startTime, StopTime: startTime.Add(time.Minute * 15),
+		RRule: "FREQ=DAILY;COUNT=5", ExDates: []time.Time{startTime.AddDate(0, 0, 2)},
+		Reminders: []storage.Reminder{reminder},
+	})
+	require.NoError(t, err)
+	reminderTime := func() time.Time {
+		var value string
+		require.NoError(t, repo.db.QueryRowContext(ctx,
+			`select reminderTime from reminder where eventID = ?1`, id).Scan(&value))
+		result, err := parseTime(value)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("invalid rrule", func(t *testing.T) {
+		_, err := repo.CreateEvent(ctx, storage.Event{
+			UserID: 2, StartTime: startTime, StopTime: startTime.Add(time.Hour), RRule: "FREQ=HOURLY",
+		})
+		require.ErrorIs(t, err, storage.ErrInvalidRRule)
+	})
+
+	t.Run("list occurrences", func(t *testing.T) {
+		events, err := repo.ListEventsWeek(ctx, 1, startTime)
+		require.NoError(t, err)
+		require.Equal(t, 4, len(events))
+		for _, event := range events {
+			require.Equal(t, id, event.ID)
+			require.Equal(t, time.Minute*15, event.StopTime.Sub(event.StartTime))
+		}
+
+		events, err = repo.ListEventsDay(ctx, 1, startTime.AddDate(0, 0, 1).Add(-time.Minute))
+		require.NoError(t, err)
+		require.Equal(t, 1, len(events))
+		require.Equal(t, startTime.AddDate(0, 0, 1), events[0].StartTime)
+	})
+
+	t.Run("reminder per occurrence", func(t *testing.T) {
+		// прошедшие повторения пропускаются, напоминание ждет ближайшее будущее
+		reminders, err := repo.ListDueReminders(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(reminders))
+
+		_, err = repo.db.ExecContext(ctx, `update reminder set reminderTime = ?2 where eventID = ?1`,
+			id, formatTime(startTime.Add(-reminder.Before)))
+		require.NoError(t, err)
+		reminders, err = repo.ListDueReminders(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(reminders))
+		require.Equal(t, startTime, reminders[0].Event.StartTime)
+
+		require.NoError(t, repo.ClearReminderTime(ctx, id, reminder))
+		require.Equal(t, startTime.AddDate(0, 0, 1).Add(-reminder.Before), reminderTime())
+		reminders, err = repo.ListDueReminders(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(reminders))
+		require.Equal(t, startTime.AddDate(0, 0, 1), reminders[0].Event.StartTime)
+
+		// исключенное повторение пропускается
+		require.NoError(t, repo.ClearReminderTime(ctx, id, reminder))
+		require.Equal(t, startTime.AddDate(0, 0, 3).Add(-reminder.Before), reminderTime())
+	})
+
+	t.Run("delete old series", func(t *testing.T) {
+		require.NoError(t, repo.DeleteEventsBeforeDate(ctx, startTime.AddDate(0, 0, 1)))
+		require.Equal(t, 1, count(t, repo, "event", ""))
+		require.NoError(t, repo.DeleteEventsBeforeDate(ctx, startTime.AddDate(0, 0, 5)))
+		require.Equal(t, 0, count(t, repo, "event", ""))
+	})
+}
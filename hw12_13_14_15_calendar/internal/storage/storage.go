@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Storage хранилище событий, уведомлений хранителя и outbox планировщика.
+type Storage interface {
+	// CreateEvent, UpdateEvent, DeleteEvent и RestoreEvent в той же транзакции пишут запись журнала изменений
+	// события, изменивший событие пользователь берется из контекста WithActor.
+	CreateEvent(ctx context.Context, event Event) (string, error)
+	UpdateEvent(ctx context.Context, id string, event Event) error
+	// DeleteEvent помечает событие удаленным: оно больше не видно и не занимает время, но его можно восстановить.
+	DeleteEvent(ctx context.Context, id string) error
+	// RestoreEvent восстанавливает удаленное событие, время напоминаний считается заново.
+	RestoreEvent(ctx context.Context, id string) error
+	// ListEventHistory возвращает журнал изменений события, в том числе удаленного, от старых записей к новым.
+	ListEventHistory(ctx context.Context, id string) ([]*AuditRecord, error)
+	GetEvent(ctx context.Context, id string) (*Event, error)
+	// границы дня, недели и месяца считаются по календарю часового пояса startTime
+	ListEventsDay(ctx context.Context, userID int64, startTime time.Time) ([]*Event, error)
+	ListEventsWeek(ctx context.Context, userID int64, startTime time.Time) ([]*Event, error)
+	ListEventsMonth(ctx context.Context, userID int64, startTime time.Time) ([]*Event, error)
+	ListEvents(ctx context.Context, params ListParams) ([]*Event, *Cursor, error)
+	// ListDueReminders возвращает напоминания, время отправки которых наступило.
+	ListDueReminders(ctx context.Context) ([]*DueReminder, error)
+	// ClearReminderTime отмечает напоминание события отправленным: для серии оно переносится на следующее
+	// повторение. Напоминание, которого у события уже нет, пропускается.
+	ClearReminderTime(ctx context.Context, id string, reminder Reminder) error
+	// DeleteEventsBeforeDate окончательно удаляет старые события, в том числе помеченные удаленными.
+	// Журнал изменений остается.
+	DeleteEventsBeforeDate(ctx context.Context, time time.Time) error
+	SaveNotification(ctx context.Context, notification Notification) error
+	GetNotificationDelivery(ctx context.Context, id string) (*NotificationDelivery, error)
+	UpdateNotificationDelivery(ctx context.Context, id string, status DeliveryStatus,
+		channel string, lastError string) error
+	// EnqueueNotification в одной транзакции сохраняет уведомление в outbox и отмечает напоминание события
+	// отправленным, как ClearReminderTime.
+	EnqueueNotification(ctx context.Context, eventID string, reminder Reminder, notification Notification) error
+	// InviteAttendee добавляет участника события со статусом needs-action и в той же транзакции сохраняет
+	// уведомление в outbox. Повторное приглашение участника ничего не меняет.
+	InviteAttendee(ctx context.Context, eventID string, userID int64, notification Notification) error
+	// UpdateAttendee меняет статус участника события, уведомление сохраняется только при изменении статуса.
+	UpdateAttendee(ctx context.Context, eventID string, attendee Attendee, notification Notification) error
+	// RemoveAttendee удаляет участника события и сохраняет уведомление в outbox.
+	RemoveAttendee(ctx context.Context, eventID string, userID int64, notification Notification) error
+	ListOutbox(ctx context.Context, limit int) ([]*OutboxMessage, error)
+	MarkOutboxSent(ctx context.Context, id string) error
+	MarkOutboxFailed(ctx context.Context, id string, nextAttemptTime time.Time, reason string) error
+	// Ping проверяет доступность хранилища.
+	Ping(ctx context.Context) error
+	Close(ctx context.Context) error
+}
+
+// Migration миграция схемы БД хранилища.
+type Migration struct {
+	Version int64
+	// имя файла миграции
+	Source    string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrator реализуют хранилища, схема БД которых управляется встроенными миграциями.
+type Migrator interface {
+	// MigrateUp применяет все новые миграции и возвращает примененные.
+	MigrateUp(ctx context.Context) ([]Migration, error)
+	// MigrateDown откатывает последнюю примененную миграцию и возвращает ее.
+	MigrateDown(ctx context.Context) (Migration, error)
+	// MigrationStatus возвращает все миграции с признаком применения.
+	MigrationStatus(ctx context.Context) ([]Migration, error)
+}
+
+// Params параметры создания хранилища, каждая реализация использует нужные ей.
+type Params struct {
+	// драйвер database/sql, пустой - драйвер реализации по умолчанию
+	Driver string
+	DSN    string
+	// разрешены ли пользователю пересекающиеся по времени события
+	AllowOverlap bool
+	// применить при подключении новые миграции схемы БД
+	AutoMigrate bool
+}
+
+// Factory создает хранилище и подключается к нему.
+type Factory func(ctx context.Context, params Params) (Storage, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]Factory)
+)
+
+// Register регистрирует реализацию хранилища под именем, которое указывается в параметре storage конфигурации.
+// Реализации регистрируются в init своих пакетов, повторная регистрация имени - ошибка программы.
+func Register(name string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	if _, ok := factories[name]; ok {
+		panic("storage: Register called twice for " + name)
+	}
+	factories[name] = factory
+}
+
+// Open создает хранилище зарегистрированной реализации name.
+func Open(ctx context.Context, name string, params Params) (Storage, error) {
+	factoriesMu.RLock()
+	factory, ok := factories[name]
+	factoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q, available %v", ErrUnknownStorage, name, Backends())
+	}
+	return factory(ctx, params)
+}
+
+// Backends возвращает отсортированные имена зарегистрированных реализаций.
+func Backends() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	result := make([]string, 0, len(factories))
+	for name := range factories {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,26 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require" //nolint:depguard
+)
+
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+	var opened Params
+	Register("test", func(_ context.Context, params Params) (Storage, error) {
+		opened = params
+		return nil, nil
+	})
+	require.Contains(t, Backends(), "test")
+	require.Panics(t, func() { Register("test", nil) }, "name is registered twice")
+
+	_, err := Open(ctx, "test", Params{DSN: "calendar.db", AllowOverlap: true})
+	require.NoError(t, err)
+	require.Equal(t, Params{DSN: "calendar.db", AllowOverlap: true}, opened)
+
+	_, err = Open(ctx, "unknown", Params{})
+	require.ErrorIs(t, err, ErrUnknownStorage)
+}
@@ -0,0 +1,1023 @@
+// Package storagetest общий набор проверок реализаций storage.Storage. Каждая реализация запускает его
+// в своих тестах, поэтому поведение хранилищ не расходится. Проверки используют только интерфейс хранилища.
+package storagetest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"                                           //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage" //nolint:depguard
+	"github.com/stretchr/testify/require"                              //nolint:depguard
+)
+
+const (
+	// несуществующий id в формате uuid и id в неверном формате, оба для хранилища не существуют.
+	missingID   = "00000000-0000-0000-0000-000000000000"
+	malformedID = "bad_event_id"
+	badUserID   = 777
+	// контекст трассировки W3C traceparent, хранилище сохраняет его без разбора.
+	traceContext = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+)
+
+// Factory создает пустое хранилище для одной проверки и закрывает его по окончании теста.
+type Factory func(t *testing.T, allowOverlap bool) storage.Storage
+
+// Run проверяет все методы хранилища и возвращаемые ими ошибки.
+func Run(t *testing.T, newStorage Factory) {
+	t.Helper()
+	s := suite{newStorage: newStorage}
+	t.Run("events", s.testEvents)
+	t.Run("overlap forbidden", s.testOverlapForbidden)
+	t.Run("overlap recurring", s.testOverlapRecurring)
+	t.Run("overlap allowed", s.testOverlapAllowed)
+	t.Run("list periods", s.testListPeriods)
+	t.Run("list pages", s.testListPages)
+	t.Run("time zone", s.testTimeZone)
+	t.Run("search", s.testSearch)
+	t.Run("recurring", s.testRecurring)
+	t.Run("reminder", s.testReminder)
+	t.Run("reminders", s.testReminders)
+	t.Run("reminder recurring", s.testReminderRecurring)
+	t.Run("delete before date", s.testDeleteEventsBeforeDate)
+	t.Run("notification delivery", s.testNotificationDelivery)
+	t.Run("outbox", s.testOutbox)
+	t.Run("attendees", s.testAttendees)
+	t.Run("history", s.testHistory)
+	t.Run("concurrency", s.testConcurrency)
+}
+
+// suite проверки хранилища, каждая получает новое пустое хранилище.
+type suite struct {
+	newStorage Factory
+}
+
+func at(day, hour, minute int) time.Time {
+	return time.Date(2025, 1, day, hour, minute, 0, 0, time.UTC)
+}
+
+// now текущее время с точностью до секунды, которую хранят все реализации.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
+
+// normalize приводит время события к UTC: реализации возвращают время в разных часовых поясах.
+func normalize(event *storage.Event) storage.Event {
+	result := *event
+	result.StartTime = result.StartTime.UTC()
+	result.StopTime = result.StopTime.UTC()
+	if result.ExDates != nil {
+		result.ExDates = make([]time.Time, 0, len(event.ExDates))
+		for _, t := range event.ExDates {
+			result.ExDates = append(result.ExDates, t.UTC())
+		}
+	}
+	return result
+}
+
+func requireEvent(t *testing.T, expected storage.Event, actual *storage.Event) {
+	t.Helper()
+	require.NotNil(t, actual)
+	require.Equal(t, normalize(&expected), normalize(actual))
+}
+
+func create(t *testing.T, repo storage.Storage, event storage.Event) string {
+	t.Helper()
+	id, err := repo.CreateEvent(context.Background(), event)
+	require.NoError(t, err)
+	return id
+}
+
+func titles(events []*storage.Event) []string {
+	result := make([]string, 0, len(events))
+	for _, event := range events {
+		result = append(result, event.Title)
+	}
+	return result
+}
+
+func startTimes(events []*storage.Event) []time.Time {
+	result := make([]time.Time, 0, len(events))
+	for _, event := range events {
+		result = append(result, event.StartTime.UTC())
+	}
+	return result
+}
+
+func (s suite) testEvents(t *testing.T) {
+	repo := s.newStorage(t, false)
+	ctx := context.Background()
+	require.NoError(t, repo.Ping(ctx))
+	event1 := storage.Event{
+		Title: "title 1", StartTime: at(1, 11, 0), StopTime: at(1, 11, 30), Description: "description 1",
+		UserID: 1, Reminders: []storage.Reminder{{Before: 24 * time.Hour, Channel: "email"}, {Before: time.Hour}},
+		TraceContext: traceContext,
+	}
+	event2 := storage.Event{
+		Title: "title 2", StartTime: at(2, 11, 10), StopTime: at(2, 12, 0), Description: "description 2", UserID: 1,
+	}
+
+	id, err := repo.CreateEvent(ctx, event1)
+	require.NoError(t, err)
+	require.Len(t, id, 36, "generated id must be uuid")
+	event1.ID = id
+	event2.ID = create(t, repo, event2)
+	require.NotEqual(t, event1.ID, event2.ID)
+
+	t.Run("create errors", func(t *testing.T) {
+		_, err := repo.CreateEvent(ctx, event1)
+		require.ErrorIs(t, err, storage.ErrDateBusy)
+
+		invalid := event1
+		invalid.StopTime = at(1, 10, 0)
+		_, err = repo.CreateEvent(ctx, invalid)
+		require.ErrorIs(t, err, storage.ErrInvalidStopTime)
+
+		invalid = event1
+		invalid.UserID = 2
+		invalid.RRule = "FREQ=HOURLY"
+		_, err = repo.CreateEvent(ctx, invalid)
+		require.ErrorIs(t, err, storage.ErrInvalidRRule)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		event, err := repo.GetEvent(ctx, event1.ID)
+		require.NoError(t, err)
+		requireEvent(t, event1, event)
+		event, err = repo.GetEvent(ctx, event2.ID)
+		require.NoError(t, err)
+		requireEvent(t, event2, event)
+
+		for _, id := range []string{missingID, malformedID} {
+			_, err = repo.GetEvent(ctx, id)
+			require.ErrorIs(t, err, storage.ErrEventNotFound, id)
+		}
+	})
+
+	t.Run("update errors", func(t *testing.T) {
+		require.ErrorIs(t, repo.UpdateEvent(ctx, event2.ID, event1), storage.ErrInvalidArgiments)
+
+		for _, id := range []string{missingID, malformedID} {
+			missing := event1
+			missing.ID = id
+			require.ErrorIs(t, repo.UpdateEvent(ctx, id, missing), storage.ErrEventNotFound, id)
+		}
+
+		invalid := event1
+		invalid.UserID = badUserID
+		require.ErrorIs(t, repo.UpdateEvent(ctx, event1.ID, invalid), storage.ErrUpdateUserID)
+
+		invalid = event1
+		invalid.StopTime = at(1, 10, 0)
+		require.ErrorIs(t, repo.UpdateEvent(ctx, event1.ID, invalid), storage.ErrInvalidStopTime)
+
+		invalid = event1
+		invalid.RRule = "FREQ=HOURLY"
+		require.ErrorIs(t, repo.UpdateEvent(ctx, event1.ID, invalid), storage.ErrInvalidRRule)
+
+		invalid = event1
+		invalid.StartTime, invalid.StopTime = event2.StartTime, event2.StopTime
+		require.ErrorIs(t, repo.UpdateEvent(ctx, event1.ID, invalid), storage.ErrDateBusy)
+
+		event, err := repo.GetEvent(ctx, event1.ID)
+		require.NoError(t, err)
+		requireEvent(t, event1, event)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		event1.Title = "new title"
+		event1.Description = "new description"
+		event1.StartTime, event1.StopTime = at(1, 12, 0), at(1, 13, 0)
+		event1.Reminders = nil
+		event1.TraceContext = ""
+		require.NoError(t, repo.UpdateEvent(ctx, event1.ID, event1))
+		event, err := repo.GetEvent(ctx, event1.ID)
+		require.NoError(t, err)
+		requireEvent(t, event1, event)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		for _, id := range []string{missingID, malformedID} {
+			require.ErrorIs(t, repo.DeleteEvent(ctx, id), storage.ErrEventNotFound, id)
+		}
+		require.NoError(t, repo.DeleteEvent(ctx, event1.ID))
+		_, err := repo.GetEvent(ctx, event1.ID)
+		require.ErrorIs(t, err, storage.ErrEventNotFound)
+		require.ErrorIs(t, repo.DeleteEvent(ctx, event1.ID), storage.ErrEventNotFound)
+
+		event, err := repo.GetEvent(ctx, event2.ID)
+		require.NoError(t, err)
+		requireEvent(t, event2, event)
+	})
+}
+
+func (s suite) testOverlapForbidden(t *testing.T) {
+	repo := s.newStorage(t, false)
+	ctx := context.Background()
+	newEvent := func(start, stop time.Time) storage.Event {
+		return storage.Event{Title: "event", UserID: 1, StartTime: start, StopTime: stop}
+	}
+	id := create(t, repo, newEvent(at(1, 10, 0), at(1, 11, 0)))
+	create(t, repo, newEvent(at(1, 12, 0), at(1, 13, 0)))
+
+	tests := []struct {
+		name        string
+		start, stop time.Time
+		err         error
+	}{
+		{name: "overlaps end", start: at(1, 10, 30), stop: at(1, 11, 30), err: storage.ErrDateBusy},
+		{name: "overlaps start", start: at(1, 9, 30), stop: at(1, 10, 30), err: storage.ErrDateBusy},
+		{name: "inside", start: at(1, 10, 15), stop: at(1, 10, 45), err: storage.ErrDateBusy},
+		{name: "covers both", start: at(1, 9, 0), stop: at(1, 14, 0), err: storage.ErrDateBusy},
+		{name: "same interval", start: at(1, 12, 0), stop: at(1, 13, 0), err: storage.ErrDateBusy},
+		{name: "empty interval", start: at(1, 10, 30), stop: at(1, 10, 30)},
+		{name: "back to back", start: at(1, 11, 0), stop: at(1, 12, 0)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := repo.CreateEvent(ctx, newEvent(tc.start, tc.stop))
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+
+	// другой пользователь может занять то же время
+	event := newEvent(at(1, 10, 0), at(1, 11, 0))
+	event.UserID = 2
+	create(t, repo, event)
+
+	// перенос события на занятое время и сдвиг внутри своего интервала
+	event = newEvent(at(1, 11, 30), at(1, 12, 30))
+	event.ID = id
+	require.ErrorIs(t, repo.UpdateEvent(ctx, id, event), storage.ErrDateBusy)
+	event = newEvent(at(1, 9, 30), at(1, 10, 30))
+	event.ID = id
+	require.NoError(t, repo.UpdateEvent(ctx, id, event))
+	create(t, repo, newEvent(at(1, 10, 30), at(1, 11, 0)))
+
+	// удаленное событие время не занимает
+	require.NoError(t, repo.DeleteEvent(ctx, id))
+	create(t, repo, newEvent(at(1, 9, 0), at(1, 10, 30)))
+}
+
+func (s suite) testOverlapRecurring(t *testing.T) {
+	repo := s.newStorage(t, false)
+	ctx := context.Background()
+	newEvent := func(start, stop time.Time, rrule string) storage.Event {
+		return storage.Event{Title: "event", UserID: 1, StartTime: start, StopTime: stop, RRule: rrule}
+	}
+	// повторения 5, 6, 8 и 9 января, 7 января исключено
+	series := newEvent(at(5, 10, 0), at(5, 11, 0), "FREQ=DAILY;COUNT=5")
+	series.ExDates = []time.Time{at(7, 10, 0)}
+	series.ID = create(t, repo, series)
+	single := create(t, repo, newEvent(at(20, 10, 0), at(20, 11, 0), ""))
+
+	tests := []struct {
+		name  string
+		event storage.Event
+		err   error
+	}{
+		{name: "later occurrence", event: newEvent(at(6, 10, 30), at(6, 11, 30), ""), err: storage.ErrDateBusy},
+		{name: "last occurrence", event: newEvent(at(9, 9, 0), at(9, 12, 0), ""), err: storage.ErrDateBusy},
+		{
+			name: "series hits single event", event: newEvent(at(15, 10, 0), at(15, 10, 30), "FREQ=DAILY;COUNT=10"),
+			err: storage.ErrDateBusy,
+		},
+		{name: "series hits series", event: newEvent(at(1, 10, 0), at(1, 11, 0), "FREQ=WEEKLY"), err: storage.ErrDateBusy},
+		{name: "between occurrences", event: newEvent(at(6, 11, 0), at(6, 12, 0), "")},
+		{name: "excluded occurrence", event: newEvent(at(7, 10, 0), at(7, 11, 0), "")},
+		{name: "after series", event: newEvent(at(10, 10, 0), at(10, 11, 0), "")},
+		{name: "series before single event", event: newEvent(at(15, 10, 0), at(15, 10, 30), "FREQ=DAILY;COUNT=5")},
+		{name: "series in free time", event: newEvent(at(1, 12, 0), at(1, 13, 0), "FREQ=WEEKLY")},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := repo.CreateEvent(ctx, tc.event)
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+
+	// перенос события на повторение серии и серии на событие
+	event := newEvent(at(8, 10, 0), at(8, 11, 0), "")
+	event.ID = single
+	require.ErrorIs(t, repo.UpdateEvent(ctx, single, event), storage.ErrDateBusy)
+	series.RRule = "FREQ=DAILY;COUNT=20"
+	require.ErrorIs(t, repo.UpdateEvent(ctx, series.ID, series), storage.ErrDateBusy)
+	series.RRule = "FREQ=DAILY;COUNT=3"
+	require.NoError(t, repo.UpdateEvent(ctx, series.ID, series))
+}
+
+func (s suite) testOverlapAllowed(t *testing.T) {
+	repo := s.newStorage(t, true)
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		create(t, repo, storage.Event{Title: "event", UserID: 1, StartTime: at(1, 10, 0), StopTime: at(1, 11, i)})
+	}
+	events, err := repo.ListEventsDay(ctx, 1, at(1, 0, 0))
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+}
+
+func (s suite) testListPeriods(t *testing.T) {
+	repo := s.newStorage(t, false)
+	ctx := context.Background()
+	// события, закончившиеся до начала периода или начавшиеся после его окончания, в период не попадают;
+	// событие, начавшееся раньше периода и закончившееся в нем, попадает
+	create(t, repo, storage.Event{Title: "night", UserID: 1, StartTime: at(1, 22, 0), StopTime: at(2, 6, 0)})
+	create(t, repo, storage.Event{Title: "day", UserID: 1, StartTime: at(2, 10, 0), StopTime: at(2, 11, 0)})
+	create(t, repo, storage.Event{Title: "week", UserID: 1, StartTime: at(8, 10, 0), StopTime: at(8, 11, 0)})
+	create(t, repo, storage.Event{Title: "month", UserID: 1, StartTime: at(31, 10, 0), StopTime: at(31, 11, 0)})
+	create(t, repo, storage.Event{Title: "next month", UserID: 1, StartTime: at(33, 0, 0), StopTime: at(33, 1, 0)})
+	create(t, repo, storage.Event{Title: "other user", UserID: 2, StartTime: at(2, 10, 0), StopTime: at(2, 11, 0)})
+
+	tests := []struct {
+		name   string
+		list   func(ctx context.Context, userID int64, startTime time.Time) ([]*storage.Event, error)
+		start  time.Time
+		titles []string
+	}{
+		{name: "day", list: repo.ListEventsDay, start: at(2, 0, 0), titles: []string{"night", "day"}},
+		{name: "day ends before", list: repo.ListEventsDay, start: at(2, 6, 0), titles: []string{"day"}},
+		{name: "empty day", list: repo.ListEventsDay, start: at(3, 0, 0), titles: []string{}},
+		{name: "week", list: repo.ListEventsWeek, start: at(2, 0, 0), titles: []string{"night", "day", "week"}},
+		{
+			name: "month", list: repo.ListEventsMonth, start: at(1, 0, 0),
+			titles: []string{"night", "day", "week", "month"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			events, err := tc.list(ctx, 1, tc.start)
+			require.NoError(t, err)
+			require.ElementsMatch(t, tc.titles, titles(events))
+		})
+	}
+
+	t.Run("list range", func(t *testing.T) {
+		params := storage.ListParams{UserID: 1, StartTime: at(2, 0, 0), StopTime: at(3, 0, 0)}
+		events, next, err := repo.ListEvents(ctx, params)
+		require.NoError(t, err)
+		require.Nil(t, next)
+		require.Equal(t, []string{"night", "day"}, titles(events))
+
+		params.StartTime, params.StopTime = at(2, 6, 0), at(2, 10, 0)
+		events, _, err = repo.ListEvents(ctx, params)
+		require.NoError(t, err)
+		require.Empty(t, events, "range is half-open")
+
+		params.UserID = badUserID
+		params.StartTime, params.StopTime = at(1, 0, 0), at(40, 0, 0)
+		events, _, err = repo.ListEvents(ctx, params)
+		require.NoError(t, err)
+		require.Empty(t, events)
+	})
+}
+
+func (s suite) testTimeZone(t *testing.T) {
+	repo := s.newStorage(t, false)
+	ctx := context.Background()
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	// 30 марта 2025 в Берлине переход на летнее время, день длится 23 часа
+	day := time.Date(2025, 3, 30, 0, 0, 0, 0, berlin)
+	allDay := storage.Event{
+		Title: "all day", UserID: 1, StartTime: day, StopTime: day.AddDate(0, 0, 1),
+		TimeZone: "Europe/Berlin", AllDay: true,
+	}
+	allDay.ID = create(t, repo, allDay)
+	create(t, repo, storage.Event{
+		Title: "next day", UserID: 1, StartTime: day.AddDate(0, 0, 1).Add(30 * time.Minute),
+		StopTime: day.AddDate(0, 0, 1).Add(time.Hour), TimeZone: "Europe/Berlin",
+	})
+
+	event, err := repo.GetEvent(ctx, allDay.ID)
+	require.NoError(t, err)
+	requireEvent(t, allDay, event)
+
+	events, err := repo.ListEventsDay(ctx, 1, day)
+	require.NoError(t, err)
+	require.Equal(t, []string{"all day"}, titles(events), "day boundary is local midnight, not 24 hours")
+	events, err = repo.ListEventsWeek(ctx, 1, day.AddDate(0, 0, -6))
+	require.NoError(t, err)
+	require.Equal(t, []string{"all day"}, titles(events))
+
+	update := allDay
+	update.TimeZone, update.AllDay = "", false
+	require.NoError(t, repo.UpdateEvent(ctx, allDay.ID, update))
+	event, err = repo.GetEvent(ctx, allDay.ID)
+	require.NoError(t, err)
+	requireEvent(t, update, event)
+
+	update.TimeZone = "Mars/Olympus"
+	require.ErrorIs(t, repo.UpdateEvent(ctx, allDay.ID, update), storage.ErrInvalidTimeZone)
+	_, err = repo.CreateEvent(ctx, storage.Event{
+		Title: "bad", UserID: 1, StartTime: at(1, 10, 0), StopTime: at(1, 11, 0), AllDay: true,
+	})
+	require.ErrorIs(t, err, storage.ErrInvalidAllDay)
+}
+
+func (s suite) testListPages(t *testing.T) {
+	repo := s.newStorage(t, false)
+	ctx := context.Background()
+	create(t, repo, storage.Event{Title: "first", UserID: 1, StartTime: at(1, 10, 0), StopTime: at(1, 11, 0)})
+	create(t, repo, storage.Event{
+		Title: "series", UserID: 1, StartTime: at(1, 12, 0), StopTime: at(1, 13, 0), RRule: "FREQ=DAILY;COUNT=3",
+	})
+	create(t, repo, storage.Event{Title: "last", UserID: 1, StartTime: at(3, 10, 0), StopTime: at(3, 11, 0)})
+	expected := []time.Time{at(1, 10, 0), at(1, 12, 0), at(2, 12, 0), at(3, 10, 0), at(3, 12, 0)}
+
+	for _, desc := range []bool{false, true} {
+		params := storage.ListParams{UserID: 1, StartTime: at(1, 0, 0), StopTime: at(4, 0, 0), Limit: 2, Desc: desc}
+		result := make([]time.Time, 0)
+		for pages := 0; ; pages++ {
+			require.Less(t, pages, 3, "pages must end")
+			events, next, err := repo.ListEvents(ctx, params)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(events), 2)
+			result = append(result, startTimes(events)...)
+			if next == nil {
+				break
+			}
+			// курсор передается клиенту строкой
+			params.Cursor, err = storage.ParseCursor(next.String())
+			require.NoError(t, err)
+		}
+		if desc {
+			for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+				result[i], result[j] = result[j], result[i]
+			}
+		}
+		require.Equal(t, expected, result, "desc=%v", desc)
+	}
+}
+
+func (s suite) testSearch(t *testing.T) {
+	repo := s.newStorage(t, false)
+	ctx := context.Background()
+	night := create(t, repo, storage.Event{
+		Title: "Night shift", Description: "warehouse", UserID: 1, StartTime: at(1, 22, 0), StopTime: at(2, 6, 0),
+	})
+	create(t, repo, storage.Event{
+		Title: "Planning", Description: "quarter planning, warehouse budget", UserID: 1,
+		StartTime: at(2, 10, 0), StopTime: at(2, 11, 0),
+	})
+	create(t, repo, storage.Event{
+		Title: "Retro", Description: "sprint retro", UserID: 1, StartTime: at(3, 10, 0), StopTime: at(3, 11, 0),
+		RRule: "FREQ=WEEKLY;COUNT=2",
+	})
+	search := func(query string) []string {
+		events, _, err := repo.ListEvents(ctx, storage.ListParams{
+			UserID: 1, StartTime: at(1, 0, 0), StopTime: at(4, 0, 0), Query: query,
+		})
+		require.NoError(t, err)
+		return titles(events)
+	}
+
+	require.Equal(t, []string{"Night shift", "Planning", "Retro"}, search(""))
+	require.Equal(t, []string{"Night shift", "Planning"}, search("WAREHOUSE"))
+	require.Equal(t, []string{"Planning"}, search("warehouse budget"))
+	require.Equal(t, []string{"Retro"}, search("retro, sprint"))
+	require.Empty(t, search("ware"))
+	require.Empty(t, search("?!"))
+
+	// поиск следует за изменением и удалением события
+	event, err := repo.GetEvent(ctx, night)
+	require.NoError(t, err)
+	event.Description = "office"
+	require.NoError(t, repo.UpdateEvent(ctx, night, *event))
+	require.Equal(t, []string{"Planning"}, search("warehouse"))
+	require.Equal(t, []string{"Night shift"}, search("office"))
+	require.NoError(t, repo.DeleteEvent(ctx, night))
+	require.Empty(t, search("office"))
+}
+
+func (s suite) testRecurring(t *testing.T) {
+	repo := s.newStorage(t, false)
+	ctx := context.Background()
+	series := storage.Event{
+		Title: "stand-up", UserID: 1, StartTime: at(1, 10, 0), StopTime: at(1, 10, 15),
+		RRule: "FREQ=DAILY;COUNT=5", ExDates: []time.Time{at(3, 10, 0)},
+	}
+	series.ID = create(t, repo, series)
+
+	event, err := repo.GetEvent(ctx, series.ID)
+	require.NoError(t, err)
+	requireEvent(t, series, event)
+
+	events, err := repo.ListEventsWeek(ctx, 1, at(1, 0, 0))
+	require.NoError(t, err)
+	require.ElementsMatch(t, []time.Time{at(1, 10, 0), at(2, 10, 0), at(4, 10, 0), at(5, 10, 0)}, startTimes(events))
+	for _, event := range events {
+		require.Equal(t, series.ID, event.ID)
+		require.Equal(t, time.Minute*15, event.StopTime.Sub(event.StartTime))
+	}
+
+	events, err = repo.ListEventsDay(ctx, 1, at(2, 9, 0))
+	require.NoError(t, err)
+	require.Equal(t, []time.Time{at(2, 10, 0)}, startTimes(events))
+
+	// исключение повторения освобождает его время
+	series.ExDates = append(series.ExDates, at(4, 10, 0))
+	require.NoError(t, repo.UpdateEvent(ctx, series.ID, series))
+	events, err = repo.ListEventsDay(ctx, 1, at(4, 0, 0))
+	require.NoError(t, err)
+	require.Empty(t, events)
+}
+
+func (s suite) testReminder(t *testing.T) {
+	repo := s.newStorage(t, false)
+	ctx := context.Background()
+	reminder := storage.Reminder{Before: time.Hour}
+	startTime := now().Add(time.Minute * 30)
+	due := storage.Event{
+		Title: "due", UserID: 1, StartTime: startTime, StopTime: startTime.Add(time.Minute),
+		Reminders: []storage.Reminder{reminder},
+	}
+	due.ID = create(t, repo, due)
+	create(t, repo, storage.Event{
+		Title: "later", UserID: 1, StartTime: startTime.Add(time.Hour * 2), StopTime: startTime.Add(time.Hour * 3),
+		Reminders: []storage.Reminder{reminder},
+	})
+	create(t, repo, storage.Event{
+		Title: "no reminder", UserID: 2, StartTime: startTime, StopTime: startTime.Add(time.Minute),
+	})
+
+	reminders, err := repo.ListDueReminders(ctx)
+	require.NoError(t, err)
+	require.Len(t, reminders, 1)
+	require.Equal(t, reminder, reminders[0].Reminder)
+	requireEvent(t, due, reminders[0].Event)
+
+	require.NoError(t, repo.ClearReminderTime(ctx, due.ID, reminder))
+	reminders, err = repo.ListDueReminders(ctx)
+	require.NoError(t, err)
+	require.Empty(t, reminders, "reminder is sent once")
+	require.NoError(t, repo.ClearReminderTime(ctx, due.ID, reminder), "cleared reminder is ignored")
+
+	// изменение события заново рассчитывает время напоминания
+	require.NoError(t, repo.UpdateEvent(ctx, due.ID, due))
+	reminders, err = repo.ListDueReminders(ctx)
+	require.NoError(t, err)
+	require.Len(t, reminders, 1)
+
+	for _, id := range []string{missingID, malformedID} {
+		require.ErrorIs(t, repo.ClearReminderTime(ctx, id, reminder), storage.ErrEventNotFound, id)
+	}
+}
+
+func (s suite) testReminders(t *testing.T) {
+	repo := s.newStorage(t, false)
+	ctx := context.Background()
+	email := storage.Reminder{Before: time.Hour, Channel: "email"}
+	webhook := storage.Reminder{Before: time.Minute * 45, Channel: "webhook"}
+	later := storage.Reminder{Before: time.Minute * 10}
+	startTime := now().Add(time.Minute * 30)
+	event := storage.Event{
+		Title: "meeting", UserID: 1, StartTime: startTime, StopTime: startTime.Add(time.Hour),
+		Reminders: []storage.Reminder{later, email, webhook},
+	}
+	event.ID = create(t, repo, event)
+
+	stored, err := repo.GetEvent(ctx, event.ID)
+	require.NoError(t, err)
+	require.Equal(t, []storage.Reminder{email, webhook, later}, stored.Reminders, "reminders are sorted")
+
+	dueReminders := func() []storage.Reminder {
+		t.Helper()
+		due, err := repo.ListDueReminders(ctx)
+		require.NoError(t, err)
+		result := make([]storage.Reminder, 0, len(due))
+		for _, reminder := range due {
+			require.Equal(t, event.ID, reminder.Event.ID)
+			result = append(result, reminder.Reminder)
+		}
+		return result
+	}
+	require.ElementsMatch(t, []storage.Reminder{email, webhook}, dueReminders(), "reminders fire independently")
+
+	require.NoError(t, repo.ClearReminderTime(ctx, event.ID, email))
+	require.Equal(t, []storage.Reminder{webhook}, dueReminders(), "other reminder stays due")
+
+	// напоминание можно убрать из события, не трогая остальные
+	event.Reminders = []storage.Reminder{email}
+	require.NoError(t, repo.UpdateEvent(ctx, event.ID, event))
+	require.Equal(t, []storage.Reminder{email}, dueReminders())
+
+	invalid := [][]storage.Reminder{
+		{{Before: -time.Minute}},
+		{email, email},
+		{{Before: time.Minute, Channel: "a:b"}},
+	}
+	for _, reminders := range invalid {
+		_, err := repo.CreateEvent(ctx, storage.Event{
+			Title: "invalid", UserID: 2, StartTime: startTime, StopTime: startTime.Add(time.Hour), Reminders: reminders,
+		})
+		require.ErrorIs(t, err, storage.ErrInvalidReminder, reminders)
+	}
+}
+
+func (s suite) testReminderRecurring(t *testing.T) {
+	repo := s.newStorage(t, false)
+	ctx := context.Background()
+	reminder := storage.Reminder{Before: time.Hour}
+	// первое повторение уже прошло, напоминание о втором пора отправлять, третье исключено
+	startTime := now().Add(time.Minute*30 - time.Hour*24)
+	id := create(t, repo, storage.Event{
+		Title: "stand-up", UserID: 1, StartTime: startTime, StopTime: startTime.Add(time.Minute * 15),
+		RRule: "FREQ=DAILY;COUNT=5", ExDates: []time.Time{startTime.AddDate(0, 0, 2)},
+		Reminders: []storage.Reminder{reminder},
+	})
+
+	reminders, err := repo.ListDueReminders(ctx)
+	require.NoError(t, err)
+	require.Len(t, reminders, 1)
+	require.Equal(t, id, reminders[0].Event.ID)
+	require.Equal(t, startTime.AddDate(0, 0, 1), reminders[0].Event.StartTime.UTC(), "reminder is about the occurrence")
+
+	// напоминание переходит к следующему повторению, до него еще далеко
+	require.NoError(t, repo.ClearReminderTime(ctx, id, reminder))
+	reminders, err = repo.ListDueReminders(ctx)
+	require.NoError(t, err)
+	require.Empty(t, reminders)
+}
+
+func (s suite) testDeleteEventsBeforeDate(t *testing.T) {
+	repo := s.newStorage(t, false)
+	ctx := context.Background()
+	old := create(t, repo, storage.Event{Title: "old", UserID: 1, StartTime: at(1, 10, 0), StopTime: at(1, 11, 0)})
+	future := create(t, repo, storage.Event{
+		Title: "future", UserID: 2, StartTime: at(20, 10, 0), StopTime: at(20, 11, 0),
+	})
+	finished := create(t, repo, storage.Event{
+		Title: "finished series", UserID: 1, StartTime: at(2, 10, 0), StopTime: at(2, 11, 0),
+		RRule: "FREQ=DAILY;COUNT=3",
+	})
+	active := create(t, repo, storage.Event{
+		Title: "active series", UserID: 3, StartTime: at(2, 10, 0), StopTime: at(2, 11, 0),
+		RRule: "FREQ=DAILY;COUNT=30",
+	})
+	endless := create(t, repo, storage.Event{
+		Title: "endless series", UserID: 4, StartTime: at(2, 10, 0), StopTime: at(2, 11, 0), RRule: "FREQ=WEEKLY",
+	})
+
+	require.NoError(t, repo.DeleteEventsBeforeDate(ctx, at(10, 0, 0)))
+	for _, id := range []string{old, finished} {
+		_, err := repo.GetEvent(ctx, id)
+		require.ErrorIs(t, err, storage.ErrEventNotFound)
+	}
+	for _, id := range []string{future, active, endless} {
+		_, err := repo.GetEvent(ctx, id)
+		require.NoError(t, err)
+	}
+}
+
+func (s suite) testNotificationDelivery(t *testing.T) {
+	repo := s.newStorage(t, false)
+	ctx := context.Background()
+	notification := storage.Notification{
+		ID: "3f333df6-90a4-4fda-8dd3-9485d27cee36", Title: "event", StartTime: now(), UserID: 1,
+	}
+
+	for _, id := range []string{notification.ID, malformedID} {
+		_, err := repo.GetNotificationDelivery(ctx, id)
+		require.ErrorIs(t, err, storage.ErrNotificationNotFound, id)
+		require.ErrorIs(t, repo.UpdateNotificationDelivery(ctx, id, storage.DeliverySent, "stdout", ""),
+			storage.ErrNotificationNotFound, id)
+	}
+
+	require.NoError(t, repo.SaveNotification(ctx, notification))
+	require.NoError(t, repo.SaveNotification(ctx, notification), "repeated notification is ignored")
+	delivery, err := repo.GetNotificationDelivery(ctx, notification.ID)
+	require.NoError(t, err)
+	require.Equal(t, storage.NotificationDelivery{Status: storage.DeliveryPending}, *delivery)
+
+	require.NoError(t, repo.UpdateNotificationDelivery(ctx, notification.ID, storage.DeliveryPending, "email",
+		"connection refused"))
+	delivery, err = repo.GetNotificationDelivery(ctx, notification.ID)
+	require.NoError(t, err)
+	require.Equal(t, storage.NotificationDelivery{
+		Status: storage.DeliveryPending, Channel: "email", Attempts: 1, LastError: "connection refused",
+	}, *delivery)
+
+	require.NoError(t, repo.UpdateNotificationDelivery(ctx, notification.ID, storage.DeliverySent, "stdout", ""))
+	require.NoError(t, repo.SaveNotification(ctx, notification), "saving again keeps delivery state")
+	delivery, err = repo.GetNotificationDelivery(ctx, notification.ID)
+	require.NoError(t, err)
+	require.Equal(t, storage.NotificationDelivery{Status: storage.DeliverySent, Channel: "stdout", Attempts: 2},
+		*delivery)
+}
+
+func (s suite) testOutbox(t *testing.T) {
+	repo := s.newStorage(t, false)
+	ctx := context.Background()
+	reminder := storage.Reminder{Before: time.Hour, Channel: "email"}
+	startTime := now().Add(time.Minute * 30)
+	enqueue := func(title string) storage.Notification {
+		id := create(t, repo, storage.Event{
+			Title: title, UserID: 1, StartTime: startTime, StopTime: startTime, Reminders: []storage.Reminder{reminder},
+		})
+		notification := storage.Notification{
+			ID: id, Title: title, StartTime: startTime, UserID: 1, Channel: reminder.Channel, TraceContext: traceContext,
+		}
+		require.NoError(t, repo.EnqueueNotification(ctx, id, reminder, notification))
+		return notification
+	}
+	notifications := func(messages []*storage.OutboxMessage) []storage.Notification {
+		result := make([]storage.Notification, 0, len(messages))
+		for _, message := range messages {
+			notification := message.Notification
+			notification.StartTime = notification.StartTime.UTC()
+			result = append(result, notification)
+		}
+		return result
+	}
+
+	for _, id := range []string{missingID, malformedID} {
+		require.ErrorIs(t, repo.EnqueueNotification(ctx, id, reminder, storage.Notification{ID: missingID}),
+			storage.ErrEventNotFound, id)
+	}
+	messages, err := repo.ListOutbox(ctx, 10)
+	require.NoError(t, err)
+	require.Empty(t, messages, "nothing is enqueued when reminder is not cleared")
+
+	first := enqueue("first")
+	second := enqueue("second")
+	require.NoError(t, repo.EnqueueNotification(ctx, first.ID, reminder, first), "repeated notification is ignored")
+	reminders, err := repo.ListDueReminders(ctx)
+	require.NoError(t, err)
+	require.Empty(t, reminders, "enqueue clears reminder")
+
+	messages, err = repo.ListOutbox(ctx, 10)
+	require.NoError(t, err)
+	require.Equal(t, []storage.Notification{first, second}, notifications(messages), "outbox keeps order")
+	require.Zero(t, messages[0].Attempts)
+	messages, err = repo.ListOutbox(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, []storage.Notification{first}, notifications(messages))
+
+	// после неудачной отправки уведомление ждет следующей попытки
+	require.NoError(t, repo.MarkOutboxFailed(ctx, first.ID, time.Now().Add(time.Hour), "broker is down"))
+	messages, err = repo.ListOutbox(ctx, 10)
+	require.NoError(t, err)
+	require.Equal(t, []storage.Notification{second}, notifications(messages))
+
+	require.NoError(t, repo.MarkOutboxFailed(ctx, first.ID, time.Now().Add(-time.Second), "broker is down"))
+	messages, err = repo.ListOutbox(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	require.Equal(t, first.ID, messages[0].ID)
+	require.Equal(t, 2, messages[0].Attempts)
+	require.Equal(t, "broker is down", messages[0].LastError)
+
+	require.NoError(t, repo.MarkOutboxSent(ctx, first.ID))
+	require.NoError(t, repo.MarkOutboxSent(ctx, second.ID))
+	messages, err = repo.ListOutbox(ctx, 10)
+	require.NoError(t, err)
+	require.Empty(t, messages)
+
+	for _, id := range []string{first.ID, missingID, malformedID} {
+		require.ErrorIs(t, repo.MarkOutboxSent(ctx, id), storage.ErrNotificationNotFound, id)
+		require.ErrorIs(t, repo.MarkOutboxFailed(ctx, id, time.Now(), "error"), storage.ErrNotificationNotFound, id)
+	}
+}
+
+func (s suite) testAttendees(t *testing.T) {
+	repo := s.newStorage(t, false)
+	ctx := context.Background()
+	event := storage.Event{
+		Title: "meeting", UserID: 1, StartTime: at(3, 10, 0), StopTime: at(3, 11, 0),
+		Attendees: []storage.Attendee{{UserID: 5, Status: storage.RSVPAccepted}},
+	}
+	event.ID = create(t, repo, event)
+	notification := func(kind storage.NotificationKind, userID int64, attendee storage.Attendee) storage.Notification {
+		return storage.Notification{
+			ID: uuid.New().String(), Kind: kind, Attendee: &attendee, Title: event.Title, StartTime: event.StartTime,
+			UserID: userID,
+		}
+	}
+	attendees := func() []storage.Attendee {
+		current, err := repo.GetEvent(ctx, event.ID)
+		require.NoError(t, err)
+		return current.Attendees
+	}
+	require.Empty(t, attendees(), "create ignores attendees")
+
+	needsAction := storage.Attendee{UserID: 2, Status: storage.RSVPNeedsAction}
+	invitation := notification(storage.NotificationInvitation, 2, needsAction)
+	for _, id := range []string{missingID, malformedID} {
+		require.ErrorIs(t, repo.InviteAttendee(ctx, id, 2, invitation), storage.ErrEventNotFound, id)
+		require.ErrorIs(t, repo.UpdateAttendee(ctx, id, needsAction, invitation), storage.ErrEventNotFound, id)
+		require.ErrorIs(t, repo.RemoveAttendee(ctx, id, 2, invitation), storage.ErrEventNotFound, id)
+	}
+	require.NoError(t, repo.InviteAttendee(ctx, event.ID, 2, invitation))
+	require.NoError(t, repo.InviteAttendee(ctx, event.ID, 2, notification(storage.NotificationInvitation, 2, needsAction)),
+		"repeated invitation is ignored")
+	require.Equal(t, []storage.Attendee{needsAction}, attendees())
+
+	// приглашенный видит событие в своих выборках
+	events, err := repo.ListEventsDay(ctx, 2, at(3, 0, 0))
+	require.NoError(t, err)
+	require.Equal(t, []string{"meeting"}, titles(events))
+	require.Equal(t, []storage.Attendee{needsAction}, events[0].Attendees)
+	events, _, err = repo.ListEvents(ctx, storage.ListParams{UserID: 2, StartTime: at(1, 0, 0), StopTime: at(10, 0, 0)})
+	require.NoError(t, err)
+	require.Equal(t, []string{"meeting"}, titles(events))
+	events, err = repo.ListEventsDay(ctx, 3, at(3, 0, 0))
+	require.NoError(t, err)
+	require.Empty(t, events)
+
+	event.Title = "meeting updated"
+	event.Attendees = nil
+	require.NoError(t, repo.UpdateEvent(ctx, event.ID, event))
+	require.Equal(t, []storage.Attendee{needsAction}, attendees(), "update keeps attendees")
+
+	accepted := storage.Attendee{UserID: 2, Status: storage.RSVPAccepted}
+	rsvp := notification(storage.NotificationRSVP, 1, accepted)
+	require.NoError(t, repo.UpdateAttendee(ctx, event.ID, accepted, rsvp))
+	require.NoError(t, repo.UpdateAttendee(ctx, event.ID, accepted, notification(storage.NotificationRSVP, 1, accepted)),
+		"same status is ignored")
+	require.ErrorIs(t, repo.UpdateAttendee(ctx, event.ID, storage.Attendee{UserID: 2, Status: "maybe"}, rsvp),
+		storage.ErrInvalidRSVP)
+	require.ErrorIs(t, repo.UpdateAttendee(ctx, event.ID, storage.Attendee{UserID: 3, Status: storage.RSVPDeclined},
+		rsvp), storage.ErrAttendeeNotFound)
+	require.Equal(t, []storage.Attendee{accepted}, attendees())
+
+	invited := storage.Attendee{UserID: 3, Status: storage.RSVPNeedsAction}
+	invitation3 := notification(storage.NotificationInvitation, 3, invited)
+	require.NoError(t, repo.InviteAttendee(ctx, event.ID, 3, invitation3))
+	require.Equal(t, []storage.Attendee{accepted, invited}, attendees())
+	uninvited := notification(storage.NotificationUninvited, 3, invited)
+	require.NoError(t, repo.RemoveAttendee(ctx, event.ID, 3, uninvited))
+	require.ErrorIs(t, repo.RemoveAttendee(ctx, event.ID, 3, uninvited), storage.ErrAttendeeNotFound)
+	require.Equal(t, []storage.Attendee{accepted}, attendees())
+
+	// уведомления сохраняются только при изменении участников
+	messages, err := repo.ListOutbox(ctx, 10)
+	require.NoError(t, err)
+	result := make([]storage.Notification, 0, len(messages))
+	for _, message := range messages {
+		message.StartTime = message.StartTime.UTC()
+		result = append(result, message.Notification)
+	}
+	require.Equal(t, []storage.Notification{invitation, rsvp, invitation3, uninvited}, result)
+
+	require.NoError(t, repo.DeleteEvent(ctx, event.ID))
+	events, err = repo.ListEventsDay(ctx, 2, at(3, 0, 0))
+	require.NoError(t, err)
+	require.Empty(t, events)
+}
+
+func (s suite) testHistory(t *testing.T) {
+	repo := s.newStorage(t, false)
+	ctx := storage.WithActor(context.Background(), 1)
+	startTime := now().Add(time.Minute * 30)
+	event := storage.Event{
+		Title: "meeting", UserID: 1, StartTime: startTime, StopTime: startTime.Add(time.Hour),
+		Reminders: []storage.Reminder{{Before: time.Hour}},
+	}
+	started := time.Now()
+	id, err := repo.CreateEvent(ctx, event)
+	require.NoError(t, err)
+	event.ID = id
+	created, err := repo.GetEvent(ctx, id)
+	require.NoError(t, err)
+	event.Title = "updated meeting"
+	require.NoError(t, repo.UpdateEvent(ctx, id, event))
+	updated, err := repo.GetEvent(ctx, id)
+	require.NoError(t, err)
+	// удаляет событие сам сервис
+	require.NoError(t, repo.DeleteEvent(context.Background(), id))
+
+	t.Run("deleted event is hidden", func(t *testing.T) {
+		_, err := repo.GetEvent(ctx, id)
+		require.ErrorIs(t, err, storage.ErrEventNotFound)
+		require.ErrorIs(t, repo.UpdateEvent(ctx, id, event), storage.ErrEventNotFound)
+		require.ErrorIs(t, repo.DeleteEvent(ctx, id), storage.ErrEventNotFound)
+		events, err := repo.ListEventsDay(ctx, 1, startTime.Truncate(24*time.Hour))
+		require.NoError(t, err)
+		require.Empty(t, events)
+		reminders, err := repo.ListDueReminders(ctx)
+		require.NoError(t, err)
+		require.Empty(t, reminders)
+	})
+
+	t.Run("history", func(t *testing.T) {
+		history, err := repo.ListEventHistory(ctx, id)
+		require.NoError(t, err)
+		require.Len(t, history, 3)
+		expected := []struct {
+			action        storage.AuditAction
+			actor         int64
+			before, after *storage.Event
+		}{
+			{action: storage.AuditCreate, actor: 1, after: created},
+			{action: storage.AuditUpdate, actor: 1, before: created, after: updated},
+			{action: storage.AuditDelete, before: updated},
+		}
+		for i, record := range history {
+			require.Equal(t, id, record.EventID)
+			require.Equal(t, expected[i].action, record.Action)
+			require.Equal(t, expected[i].actor, record.Actor)
+			require.WithinRange(t, record.Time, started.Truncate(time.Second), time.Now())
+			for _, snapshot := range []struct{ expected, actual *storage.Event }{
+				{expected[i].before, record.Before}, {expected[i].after, record.After},
+			} {
+				if snapshot.expected == nil {
+					require.Nil(t, snapshot.actual)
+					continue
+				}
+				requireEvent(t, *snapshot.expected, snapshot.actual)
+			}
+		}
+		for _, id := range []string{missingID, malformedID} {
+			history, err := repo.ListEventHistory(ctx, id)
+			require.NoError(t, err)
+			require.Empty(t, history, id)
+		}
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		// удаленное событие время не занимает, но восстановить его на занятое время нельзя
+		blocker := create(t, repo, storage.Event{
+			Title: "blocker", UserID: 1, StartTime: startTime, StopTime: startTime.Add(time.Hour),
+		})
+		require.ErrorIs(t, repo.RestoreEvent(ctx, id), storage.ErrDateBusy)
+		require.NoError(t, repo.DeleteEvent(ctx, blocker))
+
+		require.NoError(t, repo.RestoreEvent(ctx, id))
+		restored, err := repo.GetEvent(ctx, id)
+		require.NoError(t, err)
+		requireEvent(t, *updated, restored)
+		reminders, err := repo.ListDueReminders(ctx)
+		require.NoError(t, err)
+		require.Len(t, reminders, 1)
+		require.Equal(t, id, reminders[0].Event.ID)
+
+		history, err := repo.ListEventHistory(ctx, id)
+		require.NoError(t, err)
+		require.Len(t, history, 4)
+		require.Equal(t, storage.AuditRestore, history[3].Action)
+		require.Nil(t, history[3].Before)
+		requireEvent(t, *updated, history[3].After)
+
+		require.ErrorIs(t, repo.RestoreEvent(ctx, id), storage.ErrEventNotDeleted)
+		for _, id := range []string{missingID, malformedID} {
+			require.ErrorIs(t, repo.RestoreEvent(ctx, id), storage.ErrEventNotFound, id)
+		}
+	})
+}
+
+func (s suite) testConcurrency(t *testing.T) {
+	repo := s.newStorage(t, false)
+	const threadCount = 5
+	const objectPerThread = 20
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, threadCount)
+	// каждый поток создает, читает, меняет и удаляет события своего пользователя
+	for i := 0; i < threadCount; i++ {
+		wg.Add(1)
+		go func(userID int64) {
+			defer wg.Done()
+			errs <- func() error {
+				startTime := at(1, 0, 0)
+				for i := 0; i < objectPerThread; i++ {
+					event := storage.Event{Title: "event", UserID: userID, StartTime: startTime, StopTime: startTime}
+					id, err := repo.CreateEvent(ctx, event)
+					if err != nil {
+						return err
+					}
+					event.ID = id
+					event.StopTime = startTime.Add(time.Hour)
+					if err := repo.UpdateEvent(ctx, id, event); err != nil {
+						return err
+					}
+					startTime = event.StopTime
+				}
+				events, err := repo.ListEventsMonth(ctx, userID, at(1, 0, 0))
+				if err != nil {
+					return err
+				}
+				for _, event := range events {
+					if err := repo.DeleteEvent(ctx, event.ID); err != nil {
+						return err
+					}
+				}
+				return nil
+			}()
+		}(int64(i))
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	for i := 0; i < threadCount; i++ {
+		events, err := repo.ListEventsMonth(ctx, int64(i), at(1, 0, 0))
+		require.NoError(t, err)
+		require.Empty(t, events)
+	}
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// LoadLocation возвращает часовой пояс IANA по имени, пустое имя - UTC.
+// Local не принимается: его смысл зависит от настроек сервера.
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	if name == "Local" {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTimeZone, name)
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTimeZone, err) //nolint:errorlint
+	}
+	return location, nil
+}
+
+// Location возвращает часовой пояс события, для некорректного имени - UTC.
+func (e *Event) Location() *time.Location {
+	location, err := LoadLocation(e.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// Days возвращает число дней события на весь день.
+func (e *Event) Days() int {
+	location := e.Location()
+	start, stop := e.StartTime.In(location), e.StopTime.In(location)
+	startDate := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	stopDate := time.Date(stop.Year(), stop.Month(), stop.Day(), 0, 0, 0, 0, time.UTC)
+	return int(stopDate.Sub(startDate).Hours() / 24)
+}
+
+// NormalizeAllDay приводит границы события на весь день к полуночи в часовом поясе события.
+// Дни берутся из StartTime и StopTime в том виде, как их передал клиент: StopTime не в полночь
+// округляется до конца дня, событие без продолжительности длится один день.
+func (e *Event) NormalizeAllDay() {
+	if !e.AllDay {
+		return
+	}
+	location := e.Location()
+	start := midnight(e.StartTime, location)
+	stop := midnight(e.StopTime, location)
+	if !isMidnight(e.StopTime, e.StopTime.Location()) {
+		stop = stop.AddDate(0, 0, 1)
+	}
+	if !stop.After(start) {
+		stop = start.AddDate(0, 0, 1)
+	}
+	e.StartTime, e.StopTime = start, stop
+}
+
+// localStartTime возвращает начало события в его часовом поясе, без пояса - как есть.
+func (e *Event) localStartTime() time.Time {
+	if e.TimeZone == "" {
+		return e.StartTime
+	}
+	return e.StartTime.In(e.Location())
+}
+
+// midnight возвращает полночь в location даты, которую показывает t в собственном часовом поясе.
+func midnight(t time.Time, location *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, location)
+}
+
+func isMidnight(t time.Time, location *time.Location) bool {
+	t = t.In(location)
+	return t.Equal(midnight(t, location))
+}
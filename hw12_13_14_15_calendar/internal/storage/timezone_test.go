@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require" //nolint:depguard
+)
+
+func TestLoadLocation(t *testing.T) {
+	location, err := LoadLocation("")
+	require.NoError(t, err)
+	require.Equal(t, time.UTC, location)
+
+	location, err = LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	require.Equal(t, "Europe/Berlin", location.String())
+
+	for _, bad := range []string{"Local", "Mars/Olympus", "../etc"} {
+		_, err := LoadLocation(bad)
+		require.ErrorIs(t, err, ErrInvalidTimeZone, bad)
+	}
+}
+
+func TestNormalizeAllDay(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	moscow := time.FixedZone("MSK", 3*60*60)
+
+	tests := []struct {
+		name        string
+		timeZone    string
+		start, stop time.Time
+		expStart    time.Time
+		expStop     time.Time
+	}{
+		{
+			name:  "one day without zone",
+			start: date(1, 2, 10), stop: date(1, 2, 11),
+			expStart: date(1, 2, 0), expStop: date(1, 3, 0),
+		},
+		{
+			name:  "stop at midnight is exclusive",
+			start: date(1, 2, 0), stop: date(1, 4, 0),
+			expStart: date(1, 2, 0), expStop: date(1, 4, 0),
+		},
+		{
+			name:  "empty event lasts one day",
+			start: date(1, 2, 0), stop: date(1, 2, 0),
+			expStart: date(1, 2, 0), expStop: date(1, 3, 0),
+		},
+		{
+			name: "dates are taken as sent by client", timeZone: "Europe/Berlin",
+			start:    time.Date(2025, 3, 30, 1, 0, 0, 0, moscow),
+			stop:     time.Date(2025, 3, 30, 1, 0, 0, 0, moscow),
+			expStart: time.Date(2025, 3, 30, 0, 0, 0, 0, berlin),
+			expStop:  time.Date(2025, 3, 31, 0, 0, 0, 0, berlin),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := Event{StartTime: tt.start, StopTime: tt.stop, TimeZone: tt.timeZone, AllDay: true}
+			event.NormalizeAllDay()
+			require.True(t, tt.expStart.Equal(event.StartTime), event.StartTime)
+			require.True(t, tt.expStop.Equal(event.StopTime), event.StopTime)
+			require.NoError(t, event.Validate())
+		})
+	}
+
+	event := Event{StartTime: date(1, 2, 10), StopTime: date(1, 2, 11)}
+	event.NormalizeAllDay()
+	require.Equal(t, date(1, 2, 10), event.StartTime, "not all-day event is unchanged")
+}
+
+func TestValidateTimeZone(t *testing.T) {
+	event := Event{StartTime: date(1, 2, 0), StopTime: date(1, 3, 0), TimeZone: "Mars/Olympus"}
+	require.ErrorIs(t, event.Validate(), ErrInvalidTimeZone)
+
+	event = Event{StartTime: date(1, 2, 0), StopTime: date(1, 3, 0), TimeZone: "Europe/Berlin", AllDay: true}
+	require.ErrorIs(t, event.Validate(), ErrInvalidAllDay, "UTC midnight is not Berlin midnight")
+
+	event = Event{StartTime: date(1, 2, 0), StopTime: date(1, 2, 0), AllDay: true}
+	require.ErrorIs(t, event.Validate(), ErrInvalidAllDay)
+}
+
+func TestOccurrencesTimeZone(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	// 30 марта 2025 в Берлине переход на летнее время: встреча в 9:00 по местному времени
+	// сдвигается с 8:00 на 7:00 UTC
+	event := Event{
+		StartTime: time.Date(2025, 3, 28, 8, 0, 0, 0, time.UTC),
+		StopTime:  time.Date(2025, 3, 28, 9, 0, 0, 0, time.UTC),
+		TimeZone:  "Europe/Berlin",
+		RRule:     "FREQ=DAILY;COUNT=4",
+	}
+	occurrences := event.Occurrences(date(1, 1, 0), date(12, 31, 0))
+	require.Len(t, occurrences, 4)
+	for i, occurrence := range occurrences {
+		require.True(t, time.Date(2025, 3, 28+i, 9, 0, 0, 0, berlin).Equal(occurrence.StartTime), occurrence.StartTime)
+		require.Equal(t, time.Hour, occurrence.StopTime.Sub(occurrence.StartTime))
+	}
+
+	// событие на весь день в день перехода длится 23 часа
+	allDay := Event{
+		StartTime: time.Date(2025, 3, 29, 0, 0, 0, 0, berlin),
+		StopTime:  time.Date(2025, 3, 30, 0, 0, 0, 0, berlin),
+		TimeZone:  "Europe/Berlin",
+		AllDay:    true,
+		RRule:     "FREQ=DAILY;COUNT=2",
+	}
+	occurrences = allDay.Occurrences(date(1, 1, 0), date(12, 31, 0))
+	require.Len(t, occurrences, 2)
+	require.Equal(t, 24*time.Hour, occurrences[0].StopTime.Sub(occurrences[0].StartTime))
+	require.Equal(t, 23*time.Hour, occurrences[1].StopTime.Sub(occurrences[1].StartTime))
+	require.Equal(t, 1, occurrences[1].Days())
+
+	stop, ok := allDay.LastStopTime()
+	require.True(t, ok)
+	require.True(t, time.Date(2025, 3, 31, 0, 0, 0, 0, berlin).Equal(stop), stop)
+}
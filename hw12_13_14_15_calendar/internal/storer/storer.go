@@ -0,0 +1,60 @@
+// Package storer хранитель: сохраняет полученные из брокера уведомления и доставляет их пользователям.
+package storer
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/app"      //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/client"   //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/delivery" //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/metrics"  //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage"  //nolint:depguard
+)
+
+type Storer struct {
+	app       *app.App
+	deliverer *delivery.Dispatcher
+}
+
+func New(app *app.App, deliverer *delivery.Dispatcher) *Storer {
+	return &Storer{app: app, deliverer: deliverer}
+}
+
+// Handler возвращает обработчик сообщений топика уведомлений.
+func (s *Storer) Handler() client.SubscriberHandlerFunc {
+	return s.processNotification
+}
+
+func (s *Storer) processNotification(ctx context.Context, raw *[]byte) error {
+	notification := storage.Notification{}
+	err := json.Unmarshal(*raw, &notification)
+	if err != nil {
+		// When a handler returns an error, the default behavior is to send a Nack (negative-acknowledgement).
+		// The message will be processed again.
+		// если не смогли разобрать что прилетело, то нет смысла получать это снова
+		s.app.Logger.Error("unmarshal " + err.Error())
+		metrics.StorerHandlerErrors.WithLabelValues("unmarshal").Inc()
+		return nil //nolint:nilerr
+	}
+	if !notification.PublishedAt.IsZero() {
+		metrics.StorerConsumeLag.Observe(time.Since(notification.PublishedAt).Seconds())
+	}
+
+	s.app.Logger.Info("received event " + notification.ID)
+	err = s.app.Storage.SaveNotification(ctx, notification)
+	if err != nil {
+		s.app.Logger.Error("failed to save notification: " + err.Error())
+		metrics.StorerHandlerErrors.WithLabelValues("save").Inc()
+		return err
+	}
+	// сохранение идемпотентно, поэтому при ошибке доставки сообщение получаем повторно
+	err = s.deliverer.Deliver(ctx, notification)
+	if err != nil {
+		s.app.Logger.Error("failed to deliver notification: " + err.Error())
+		metrics.StorerHandlerErrors.WithLabelValues("deliver").Inc()
+		return err
+	}
+	return nil
+}
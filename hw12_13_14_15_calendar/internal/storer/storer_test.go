@@ -0,0 +1,213 @@
+package storer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/app"                          //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/client"                       //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/client/memory"                //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/delivery"                     //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/logger"                       //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/metrics"                      //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/scheduler"                    //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage"                      //nolint:depguard
+	memorystorage "github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/storage/memory" //nolint:depguard
+	"github.com/msa16/otus-hw/hw12_13_14_15_calendar/internal/tracing"                      //nolint:depguard
+	"github.com/prometheus/client_golang/prometheus/testutil"                               //nolint:depguard
+	"github.com/stretchr/testify/require"                                                   //nolint:depguard
+	"go.opentelemetry.io/otel"                                                              //nolint:depguard
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"                                           //nolint:depguard
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"                                          //nolint:depguard
+	"go.opentelemetry.io/otel/trace/noop"                                                   //nolint:depguard
+)
+
+const topic = "events"
+
+var (
+	errSMTPDown  = errors.New("smtp server is down")
+	testReminder = storage.Reminder{Before: time.Hour}
+)
+
+// flakySender отправка уведомления, которая не удается, пока fail true.
+type flakySender struct {
+	mu   sync.Mutex
+	fail bool
+	sent []string
+}
+
+func (s *flakySender) Send(_ context.Context, _ string, notification storage.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail {
+		return errSMTPDown
+	}
+	s.sent = append(s.sent, notification.ID)
+	return nil
+}
+
+func (s *flakySender) setFail(fail bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fail = fail
+}
+
+// testCalendar планировщик и хранитель одного процесса с общим хранилищем и брокером в памяти.
+type testCalendar struct {
+	calendar  *app.App
+	repo      *memorystorage.Storage
+	broker    *memory.Broker
+	scheduler *scheduler.Scheduler
+	email     *flakySender
+	stdout    *bytes.Buffer
+}
+
+func newTestCalendar(t *testing.T) *testCalendar {
+	t.Helper()
+	logg := logger.New("INFO", "/tmp/calendar-storer-test.log")
+	repo := memorystorage.New(false)
+	broker := memory.New(client.RetryPolicy{MaxRetries: 2, InitialInterval: time.Millisecond, Multiplier: 2}, logg)
+	calendar := app.New(logg, repo, broker)
+
+	tc := &testCalendar{
+		calendar: calendar, repo: repo, broker: broker, scheduler: scheduler.New(calendar, topic, scheduler.Intervals{}),
+		email: &flakySender{}, stdout: &bytes.Buffer{},
+	}
+	dispatcher := delivery.NewDispatcher(repo, delivery.ChannelStdout,
+		map[int64]delivery.Recipient{2: {Channel: delivery.ChannelEmail, Address: "user2@example.com"}}, 0)
+	dispatcher.Register(delivery.ChannelStdout, delivery.NewStdoutSender(tc.stdout))
+	dispatcher.Register(delivery.ChannelEmail, tc.email)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	broker.Subscribe(topic, New(calendar, dispatcher).Handler())
+	done := make(chan error)
+	go func() { done <- broker.Connect(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+		require.NoError(t, broker.Disconnect())
+	})
+	require.Eventually(t, func() bool { return broker.Publish(ctx, "ping", nil) == nil }, time.Second, time.Millisecond*10)
+	return tc
+}
+
+// createEvent создает событие, напоминание о котором уже пора отправить, и возвращает id уведомления.
+func (tc *testCalendar) createEvent(t *testing.T, userID int64) string {
+	t.Helper()
+	startTime := time.Now().Add(time.Minute * 30)
+	id, err := tc.repo.CreateEvent(context.Background(), storage.Event{
+		Title: "event", UserID: userID, StartTime: startTime, StopTime: startTime.Add(time.Hour),
+		Reminders: []storage.Reminder{testReminder},
+	})
+	require.NoError(t, err)
+	return tc.notificationID(t, id)
+}
+
+func (tc *testCalendar) notificationID(t *testing.T, eventID string) string {
+	t.Helper()
+	event, err := tc.repo.GetEvent(context.Background(), eventID)
+	require.NoError(t, err)
+	return event.NotificationID(testReminder)
+}
+
+func (tc *testCalendar) deliveryStatus(id string) storage.DeliveryStatus {
+	delivery, err := tc.repo.GetNotificationDelivery(context.Background(), id)
+	if err != nil {
+		return ""
+	}
+	return delivery.Status
+}
+
+func TestSchedulerToStorer(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("delivered", func(t *testing.T) {
+		tc := newTestCalendar(t)
+		id := tc.createEvent(t, 1)
+
+		tc.scheduler.ProcessEvents(ctx)
+		tc.scheduler.RelayOutbox(ctx)
+		require.Eventually(t, func() bool {
+			return tc.deliveryStatus(id) == storage.DeliverySent
+		}, time.Second, time.Millisecond*10)
+		require.Contains(t, tc.stdout.String(), "notification "+id+" for user 1")
+
+		messages, err := tc.repo.ListOutbox(ctx, 10)
+		require.NoError(t, err)
+		require.Empty(t, messages)
+	})
+
+	t.Run("dead letter replay", func(t *testing.T) {
+		tc := newTestCalendar(t)
+		tc.email.setFail(true)
+		id := tc.createEvent(t, 2)
+		deliverErrors := metrics.StorerHandlerErrors.WithLabelValues("deliver")
+		deliverErrorsBefore := testutil.ToFloat64(deliverErrors)
+
+		tc.scheduler.ProcessEvents(ctx)
+		tc.scheduler.RelayOutbox(ctx)
+		var letters []client.DeadLetter
+		require.Eventually(t, func() bool {
+			letters, _ = tc.broker.ListDeadLetters(ctx, topic, 0)
+			return len(letters) == 1
+		}, time.Second, time.Millisecond*10)
+		require.Contains(t, letters[0].Reason, errSMTPDown.Error())
+		// первая попытка и два повтора
+		state, err := tc.repo.GetNotificationDelivery(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, storage.DeliveryPending, state.Status)
+		require.Equal(t, delivery.ChannelEmail, state.Channel)
+		require.Equal(t, 3, state.Attempts)
+		require.Equal(t, deliverErrorsBefore+3, testutil.ToFloat64(deliverErrors))
+
+		// почтовый сервер снова доступен
+		tc.email.setFail(false)
+		replayed, err := tc.broker.ReplayDeadLetters(ctx, topic, 0)
+		require.NoError(t, err)
+		require.Len(t, replayed, 1)
+		require.Eventually(t, func() bool {
+			return tc.deliveryStatus(id) == storage.DeliverySent
+		}, time.Second, time.Millisecond*10)
+		require.Equal(t, []string{id}, tc.email.sent)
+	})
+	t.Run("one trace", func(t *testing.T) {
+		recorder := tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+		t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+		tc := newTestCalendar(t)
+
+		// запрос API создает событие, планировщик и хранитель продолжают его трейс
+		requestCtx, request := tracing.Tracer().Start(ctx, "POST /events")
+		startTime := time.Now().Add(time.Minute * 30)
+		eventID, err := tc.calendar.CreateUserEvent(requestCtx, 1, storage.Event{
+			Title: "event", UserID: 1, StartTime: startTime, StopTime: startTime.Add(time.Hour),
+			Reminders: []storage.Reminder{testReminder},
+		})
+		require.NoError(t, err)
+		request.End()
+		id := tc.notificationID(t, eventID)
+
+		tc.scheduler.ProcessEvents(ctx)
+		tc.scheduler.RelayOutbox(ctx)
+		require.Eventually(t, func() bool {
+			return tc.deliveryStatus(id) == storage.DeliverySent
+		}, time.Second, time.Millisecond*10)
+
+		var names []string
+		require.Eventually(t, func() bool {
+			names = names[:0]
+			for _, span := range recorder.Ended() {
+				if span.SpanContext().TraceID() == request.SpanContext().TraceID() {
+					names = append(names, span.Name())
+				}
+			}
+			return len(names) == 4
+		}, time.Second, time.Millisecond*10)
+		require.ElementsMatch(t, []string{"POST /events", "scheduler enqueue", topic + " publish", topic + " process"},
+			names)
+	})
+}
@@ -0,0 +1,52 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel"                         //nolint:depguard
+	"go.opentelemetry.io/otel/codes"                   //nolint:depguard
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0" //nolint:depguard
+	"go.opentelemetry.io/otel/trace"                   //nolint:depguard
+)
+
+const instrumentationName = "github.com/msa16/otus-hw/hw12_13_14_15_calendar"
+
+// Tracer возвращает трассировщик календаря глобального TracerProvider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// StartPublish начинает спан публикации сообщения в topic и записывает его контекст в metadata сообщения.
+func StartPublish(ctx context.Context, topic string, metadata map[string]string) (context.Context, trace.Span) {
+	ctx, span := Tracer().Start(ctx, topic+" publish", trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(semconv.MessagingDestinationName(topic), semconv.MessagingOperationTypePublish))
+	Inject(ctx, metadata)
+	return ctx, span
+}
+
+// StartProcess начинает спан обработки сообщения topic, продолжающий трейс из metadata сообщения.
+func StartProcess(ctx context.Context, topic string, metadata map[string]string) (context.Context, trace.Span) {
+	return Tracer().Start(Extract(ctx, metadata), topic+" process", trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(semconv.MessagingDestinationName(topic), semconv.MessagingOperationTypeDeliver))
+}
+
+// End завершает спан, ошибка err отмечается в нем.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// StartQuery начинает клиентский спан запроса query к БД system.
+func StartQuery(ctx context.Context, system, query string) (context.Context, trace.Span) {
+	operation := "query"
+	if fields := strings.Fields(query); len(fields) > 0 {
+		operation = strings.ToLower(fields[0])
+	}
+	return Tracer().Start(ctx, operation, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemKey.String(system), semconv.DBOperationName(operation),
+			semconv.DBQueryText(query)))
+}
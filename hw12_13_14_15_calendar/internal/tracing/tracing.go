@@ -0,0 +1,179 @@
+// Package tracing трассировка OpenTelemetry: экспорт спанов и передача контекста трассировки
+// через хранилище и сообщения брокера, чтобы один трейс охватывал создание события в API,
+// публикацию уведомления планировщиком и его сохранение хранителем.
+package tracing
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"go.opentelemetry.io/otel"                                     //nolint:depguard
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"            //nolint:depguard
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"        //nolint:depguard
+	"go.opentelemetry.io/otel/propagation"                         //nolint:depguard
+	"go.opentelemetry.io/otel/sdk/resource"                        //nolint:depguard
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"                  //nolint:depguard
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"             //nolint:depguard
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1" //nolint:depguard
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"              //nolint:depguard
+	"google.golang.org/protobuf/encoding/protojson"                //nolint:depguard
+)
+
+// экспорт спанов tracing.exporter.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	// строки JSON в формате OTLP, как у file exporter OpenTelemetry Collector.
+	ExporterOTLPFile = "otlp-file"
+)
+
+var ErrUnknownExporter = errors.New("unknown trace exporter")
+
+// контекст трассировки передается в заголовке traceparent W3C независимо от настроек экспорта.
+var propagator = propagation.TraceContext{}
+
+// Setup настраивает глобальный TracerProvider процесса service. Спаны пишутся в file, пустой - в stdout.
+// Возвращает функцию, которая выгружает накопленные спаны и закрывает файл.
+func Setup(ctx context.Context, service, exporter, file string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagator)
+	if exporter == "" || exporter == ExporterNone {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	var w io.WriteCloser = nopCloser{os.Stdout}
+	if file != "" {
+		f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644) //nolint:gosec
+		if err != nil {
+			return nil, err
+		}
+		w = f
+	}
+	var spanExporter sdktrace.SpanExporter
+	var err error
+	switch exporter {
+	case ExporterStdout:
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(w))
+	case ExporterOTLPFile:
+		spanExporter, err = otlptrace.New(ctx, &fileClient{w: w})
+	default:
+		err = fmt.Errorf("%w: %q", ErrUnknownExporter, exporter)
+	}
+	if err != nil {
+		_ = w.Close()
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(service)))
+	if err != nil {
+		_ = w.Close()
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(spanExporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	return func(ctx context.Context) error {
+		return errors.Join(provider.Shutdown(ctx), w.Close())
+	}, nil
+}
+
+// Inject записывает контекст трассировки ctx в carrier, например в метаданные сообщения.
+func Inject(ctx context.Context, carrier map[string]string) {
+	propagator.Inject(ctx, propagation.MapCarrier(carrier))
+}
+
+// Extract возвращает ctx с контекстом трассировки из carrier.
+func Extract(ctx context.Context, carrier map[string]string) context.Context {
+	return propagator.Extract(ctx, propagation.MapCarrier(carrier))
+}
+
+// TraceParent возвращает контекст трассировки ctx в формате traceparent для сохранения в хранилище,
+// пустую строку - если трассировки нет.
+func TraceParent(ctx context.Context) string {
+	carrier := make(map[string]string)
+	Inject(ctx, carrier)
+	return carrier["traceparent"]
+}
+
+// WithTraceParent возвращает ctx, продолжающий трейс сохраненного TraceParent.
+func WithTraceParent(ctx context.Context, traceParent string) context.Context {
+	if traceParent == "" {
+		return ctx
+	}
+	return Extract(ctx, map[string]string{"traceparent": traceParent})
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// fileClient пишет спаны в w строками ExportTraceServiceRequest в JSON OTLP: перечисления - числами,
+// идентификаторы трейсов и спанов - в hex, а не в base64, как у protojson.
+type fileClient struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (c *fileClient) Start(context.Context) error { return nil }
+
+func (c *fileClient) Stop(context.Context) error { return nil }
+
+func (c *fileClient) UploadTraces(_ context.Context, spans []*tracepb.ResourceSpans) error {
+	data, err := protojson.MarshalOptions{UseEnumNumbers: true}.Marshal(
+		&coltracepb.ExportTraceServiceRequest{ResourceSpans: spans})
+	if err != nil {
+		return err
+	}
+	var request any
+	if err := json.Unmarshal(data, &request); err != nil {
+		return err
+	}
+	if err := hexIDs(request); err != nil {
+		return err
+	}
+	if data, err = json.Marshal(request); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err = c.w.Write(append(data, '\n'))
+	return err
+}
+
+// поля идентификаторов, которые OTLP JSON кодирует в hex.
+var idFields = map[string]bool{"traceId": true, "spanId": true, "parentSpanId": true}
+
+// hexIDs перекодирует идентификаторы value, разобранного из JSON protojson, из base64 в hex.
+func hexIDs(value any) error {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if s, ok := field.(string); ok && idFields[key] {
+				id, err := base64.StdEncoding.DecodeString(s)
+				if err != nil {
+					return err
+				}
+				v[key] = hex.EncodeToString(id)
+				continue
+			}
+			if err := hexIDs(field); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if err := hexIDs(item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
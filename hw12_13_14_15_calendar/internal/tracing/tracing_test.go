@@ -0,0 +1,67 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require" //nolint:depguard
+	"go.opentelemetry.io/otel"            //nolint:depguard
+	"go.opentelemetry.io/otel/trace"      //nolint:depguard
+	"go.opentelemetry.io/otel/trace/noop" //nolint:depguard
+)
+
+const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestTraceParent(t *testing.T) {
+	ctx := context.Background()
+	require.Empty(t, TraceParent(ctx))
+	require.Equal(t, ctx, WithTraceParent(ctx, ""))
+
+	ctx = WithTraceParent(ctx, traceParent)
+	require.Equal(t, traceParent, TraceParent(ctx))
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", trace.SpanContextFromContext(ctx).TraceID().String())
+}
+
+func TestSetup(t *testing.T) {
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+	ctx := context.Background()
+
+	_, err := Setup(ctx, "test", "jaeger", "")
+	require.ErrorIs(t, err, ErrUnknownExporter)
+
+	shutdown, err := Setup(ctx, "test", ExporterNone, "")
+	require.NoError(t, err)
+	require.NoError(t, shutdown(ctx))
+
+	for _, exporter := range []string{ExporterStdout, ExporterOTLPFile} {
+		t.Run(exporter, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "spans.json")
+			shutdown, err := Setup(ctx, "calendar", exporter, file)
+			require.NoError(t, err)
+
+			metadata := make(map[string]string)
+			publishCtx, publish := StartPublish(WithTraceParent(ctx, traceParent), "events", metadata)
+			require.NotEmpty(t, metadata["traceparent"])
+			_, process := StartProcess(ctx, "events", metadata)
+			require.Equal(t, trace.SpanContextFromContext(publishCtx).TraceID(), process.SpanContext().TraceID())
+			End(process, nil)
+			End(publish, nil)
+			require.NoError(t, shutdown(ctx))
+
+			data, err := os.ReadFile(file)
+			require.NoError(t, err)
+			require.Contains(t, string(data), "4bf92f3577b34da6a3ce929d0e0e4736")
+			require.Contains(t, string(data), "events process")
+			if exporter == ExporterOTLPFile {
+				var request map[string]any
+				require.NoError(t, json.Unmarshal(data, &request), "one request per line")
+				require.Contains(t, request, "resourceSpans")
+				require.Contains(t, string(data), `"stringValue":"calendar"`)
+				require.Contains(t, string(data), `"kind":5`, "enums are numbers")
+			}
+		})
+	}
+}
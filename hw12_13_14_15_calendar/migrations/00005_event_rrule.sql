@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table event add column if not exists rrule text null;
+alter table event add column if not exists exdate text null;
+comment on column event.rrule is 'Правило повторения RFC 5545 RRULE';
+comment on column event.exdate is 'Исключенные повторения, значение EXDATE';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table event drop column if exists exdate;
+alter table event drop column if exists rrule;
+-- +goose StatementEnd
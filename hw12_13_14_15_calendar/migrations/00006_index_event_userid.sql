@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+create index if not exists xie1_event_UserID_startTime on event (UserID, startTime);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index if exists xie1_event_UserID_startTime;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+create extension if not exists btree_gist;
+alter table event add column if not exists overlap boolean not null default false;
+comment on column event.overlap is 'Событие может пересекаться по времени с другими событиями пользователя';
+-- уже пересекающиеся события исключаем из проверки, иначе ограничение не создать
+update event e set overlap = true where exists (
+  select 1 from event o
+  where o.userID = e.userID and o.id <> e.id
+    and tstzrange(o.startTime, o.stopTime) && tstzrange(e.startTime, e.stopTime)
+);
+alter table event add constraint xex0_event_UserID_period
+  exclude using gist (userID with =, tstzrange(startTime, stopTime) with &&) where (not overlap);
+drop index if exists xak0_event_startTime_UserID;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table event drop constraint if exists xex0_event_UserID_period;
+alter table event drop column if exists overlap;
+create unique index xak0_event_startTime_UserID on event (startTime, UserID);
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table event add column if not exists search tsvector
+  generated always as (to_tsvector('simple', coalesce(title, '') || ' ' || coalesce(description, ''))) stored;
+comment on column event.search is 'Слова названия и описания для полнотекстового поиска';
+create index if not exists xie2_event_search on event using gin (search);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index if exists xie2_event_search;
+alter table event drop column if exists search;
+-- +goose StatementEnd
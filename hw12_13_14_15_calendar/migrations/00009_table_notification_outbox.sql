@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+create table notification_outbox(
+  id uuid,
+  title text,
+  startTime timestamp with time zone,
+  userID bigint,
+  createdAt timestamp with time zone not null default CURRENT_TIMESTAMP,
+  attempts int not null default 0,
+  nextAttemptTime timestamp with time zone not null default CURRENT_TIMESTAMP,
+  lastError text null,
+  sentTime timestamp with time zone null
+);
+comment on table notification_outbox is 'Уведомления планировщика, ожидающие отправки в брокер';
+comment on column notification_outbox.nextAttemptTime is 'Время следующей попытки отправки';
+comment on column notification_outbox.sentTime is 'Время успешной отправки, null - не отправлено';
+create unique index xpk_notification_outbox_id on notification_outbox (id);
+create index xie0_notification_outbox_nextAttemptTime on notification_outbox (nextAttemptTime) where sentTime is null;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table notification_outbox;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table notification add column if not exists status text not null default 'pending';
+alter table notification add column if not exists channel text null;
+alter table notification add column if not exists attempts int not null default 0;
+alter table notification add column if not exists lastError text null;
+alter table notification add column if not exists updatedAt timestamp with time zone null;
+comment on column notification.status is 'Состояние доставки: pending, sent, failed';
+comment on column notification.channel is 'Канал последней попытки доставки';
+comment on column notification.attempts is 'Количество попыток доставки';
+comment on column notification.lastError is 'Ошибка последней попытки доставки';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table notification drop column if exists updatedAt;
+alter table notification drop column if exists lastError;
+alter table notification drop column if exists attempts;
+alter table notification drop column if exists channel;
+alter table notification drop column if exists status;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table event add column if not exists traceContext text null;
+alter table notification_outbox add column if not exists traceContext text null;
+comment on column event.traceContext is 'Контекст трассировки W3C traceparent запроса, изменившего событие';
+comment on column notification_outbox.traceContext is 'Контекст трассировки события уведомления';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table notification_outbox drop column if exists traceContext;
+alter table event drop column if exists traceContext;
+-- +goose StatementEnd
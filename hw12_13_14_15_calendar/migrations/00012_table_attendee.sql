@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+create table attendee(
+  eventID uuid not null references event (id) on delete cascade,
+  userID bigint not null,
+  status text not null default 'needs-action',
+  updatedAt timestamp with time zone not null default CURRENT_TIMESTAMP
+);
+comment on table attendee is 'Приглашенные на событие пользователи';
+comment on column attendee.status is 'Ответ на приглашение: needs-action, accepted, declined, tentative';
+create unique index xpk_attendee_eventID_userID on attendee (eventID, userID);
+create index xie0_attendee_userID on attendee (userID);
+alter table notification_outbox add column if not exists kind text null;
+alter table notification_outbox add column if not exists attendee text null;
+comment on column notification_outbox.kind is 'Повод уведомления, null - напоминание';
+comment on column notification_outbox.attendee is 'Участник уведомления о приглашении в виде userID:status';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table notification_outbox drop column if exists attendee;
+alter table notification_outbox drop column if exists kind;
+drop table attendee;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table event add column if not exists timeZone text null;
+alter table event add column if not exists allDay boolean not null default false;
+comment on column event.timeZone is 'Часовой пояс IANA события, null - UTC';
+comment on column event.allDay is 'Событие на весь день, границы - полночь в часовом поясе события';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table event drop column if exists allDay;
+alter table event drop column if exists timeZone;
+-- +goose StatementEnd
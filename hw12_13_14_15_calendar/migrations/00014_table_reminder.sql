@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+create table reminder(
+  eventID uuid not null references event (id) on delete cascade,
+  beforeStart interval not null,
+  channel text not null default '',
+  reminderTime timestamp with time zone null
+);
+comment on table reminder is 'Напоминания о событиях';
+comment on column reminder.beforeStart is 'За сколько до начала события отправляется напоминание';
+comment on column reminder.channel is 'Канал доставки, пустой - канал пользователя по умолчанию';
+comment on column reminder.reminderTime is 'Время отправки напоминания, null - отправлено';
+create unique index xpk_reminder_eventID_beforeStart_channel on reminder (eventID, beforeStart, channel);
+create index xie0_reminder_reminderTime on reminder (reminderTime);
+insert into reminder (eventID, beforeStart, reminderTime)
+  select id, reminder, ReminderTime from event where reminder is not null;
+drop index if exists xie0_event_ReminderTime;
+alter table event drop column if exists ReminderTime;
+alter table event drop column if exists reminder;
+alter table notification_outbox add column if not exists channel text null;
+comment on column notification_outbox.channel is 'Канал доставки напоминания, null - канал пользователя по умолчанию';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table notification_outbox drop column if exists channel;
+alter table event add column if not exists reminder interval null;
+alter table event add column if not exists ReminderTime timestamp with time zone null;
+comment on column event.ReminderTime is 'Время отправки напоминания';
+-- у события остается только самое раннее напоминание
+update event set reminder = r.beforeStart, ReminderTime = r.reminderTime
+  from (select distinct on (eventID) eventID, beforeStart, reminderTime from reminder
+    order by eventID, beforeStart desc) r
+  where r.eventID = event.id;
+create index if not exists xie0_event_ReminderTime on event (ReminderTime);
+drop table reminder;
+-- +goose StatementEnd
@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table event add column if not exists deletedAt timestamp with time zone null;
+comment on column event.deletedAt is 'Время пометки события удаленным, null - не удалено';
+-- удаленные события не занимают время пользователя
+alter table event drop constraint if exists xex0_event_UserID_period;
+alter table event add constraint xex0_event_UserID_period
+  exclude using gist (userID with =, tstzrange(startTime, stopTime) with &&) where (not overlap and deletedAt is null);
+create table event_audit(
+  id bigserial primary key,
+  eventID uuid not null,
+  action text not null,
+  actor bigint not null,
+  createdAt timestamp with time zone not null,
+  oldEvent jsonb null,
+  newEvent jsonb null
+);
+comment on table event_audit is 'Журнал изменений событий, только дополняется';
+comment on column event_audit.action is 'Изменение: create, update, delete, restore';
+comment on column event_audit.actor is 'Пользователь, изменивший событие, 0 - сам сервис';
+comment on column event_audit.oldEvent is 'Событие до изменения';
+comment on column event_audit.newEvent is 'Событие после изменения';
+create index xie0_event_audit_eventID on event_audit (eventID, id);
+create function event_audit_append_only() returns trigger language plpgsql as $$
+begin
+  raise exception 'event_audit is append-only';
+end;
+$$;
+create trigger event_audit_append_only before update or delete or truncate on event_audit
+  for each statement execute function event_audit_append_only();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table event_audit;
+drop function if exists event_audit_append_only();
+-- без признака удаления помеченные удаленными события удаляются окончательно
+delete from event where deletedAt is not null;
+alter table event drop constraint if exists xex0_event_UserID_period;
+alter table event add constraint xex0_event_UserID_period
+  exclude using gist (userID with =, tstzrange(startTime, stopTime) with &&) where (not overlap);
+alter table event drop column if exists deletedAt;
+-- +goose StatementEnd
@@ -0,0 +1,9 @@
+// Package migrations содержит миграции схемы Postgres хранилища sql, встроенные в исполняемые файлы.
+package migrations
+
+import "embed"
+
+// FS файлы миграций goose.
+//
+//go:embed *.sql
+var FS embed.FS